@@ -0,0 +1,272 @@
+package bench
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/publishers"
+	gzap "github.com/alexnobleburn/glogger/glog/zap"
+	"github.com/rs/zerolog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+const message = "request handled"
+
+type order struct {
+	ID    string   `json:"id"`
+	Items []string `json:"items"`
+	Total float64  `json:"total"`
+}
+
+var sampleOrder = order{ID: "o-42", Items: []string{"book", "pen"}, Total: 12.5}
+
+func gloggerService() *glog.LoggerService {
+	ls := glog.NewLoggerService(glog.WithBlockingSend(), glog.WithErrorHandler(func(error) {}))
+	ls.AddLogger("zap", gzap.NewZapLoggerWithWriter("bench", "test", io.Discard))
+	ls.Start()
+	return ls
+}
+
+func tenFieldOptions() []models.Option {
+	return []models.Option{
+		models.WithComponent("http"),
+		models.WithStringField("method", "GET"),
+		models.WithStringField("path", "/api/orders"),
+		models.WithIntField("status", 200),
+		models.WithFloatField("duration_ms", 12.5),
+		models.WithStringField("user", "u-1"),
+		models.WithStringField("tenant", "acme"),
+		models.WithBoolField("cached", false),
+		models.WithIntField("bytes", 512),
+		models.WithStringField("trace_id", "4bf92f3577b34da6"),
+	}
+}
+
+func newZap() *zap.Logger {
+	enc := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	return zap.New(zapcore.NewCore(enc, zapcore.AddSync(io.Discard), zapcore.DebugLevel))
+}
+
+func newZerolog() zerolog.Logger {
+	return zerolog.New(io.Discard).With().Timestamp().Logger()
+}
+
+func newSlog() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func BenchmarkNoFields(b *testing.B) {
+	ctx := context.Background()
+	b.Run("glogger", func(b *testing.B) {
+		ls := gloggerService()
+		logger := ls.NewLogger()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.Info(ctx, message)
+		}
+		ls.Stop()
+	})
+	b.Run("zap", func(b *testing.B) {
+		logger := newZap()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.Info(message)
+		}
+	})
+	b.Run("zerolog", func(b *testing.B) {
+		logger := newZerolog()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.Info().Msg(message)
+		}
+	})
+	b.Run("slog", func(b *testing.B) {
+		logger := newSlog()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.InfoContext(ctx, message)
+		}
+	})
+}
+
+func BenchmarkTenFields(b *testing.B) {
+	ctx := context.Background()
+	b.Run("glogger", func(b *testing.B) {
+		ls := gloggerService()
+		logger := ls.NewLogger()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.Info(ctx, message, tenFieldOptions()...)
+		}
+		ls.Stop()
+	})
+	b.Run("zap", func(b *testing.B) {
+		logger := newZap()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.Info(message,
+				zap.String("component", "http"),
+				zap.String("method", "GET"),
+				zap.String("path", "/api/orders"),
+				zap.Int("status", 200),
+				zap.Float64("duration_ms", 12.5),
+				zap.String("user", "u-1"),
+				zap.String("tenant", "acme"),
+				zap.Bool("cached", false),
+				zap.Int("bytes", 512),
+				zap.String("trace_id", "4bf92f3577b34da6"))
+		}
+	})
+	b.Run("zerolog", func(b *testing.B) {
+		logger := newZerolog()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.Info().
+				Str("component", "http").
+				Str("method", "GET").
+				Str("path", "/api/orders").
+				Int("status", 200).
+				Float64("duration_ms", 12.5).
+				Str("user", "u-1").
+				Str("tenant", "acme").
+				Bool("cached", false).
+				Int("bytes", 512).
+				Str("trace_id", "4bf92f3577b34da6").
+				Msg(message)
+		}
+	})
+	b.Run("slog", func(b *testing.B) {
+		logger := newSlog()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.LogAttrs(ctx, slog.LevelInfo, message,
+				slog.String("component", "http"),
+				slog.String("method", "GET"),
+				slog.String("path", "/api/orders"),
+				slog.Int("status", 200),
+				slog.Float64("duration_ms", 12.5),
+				slog.String("user", "u-1"),
+				slog.String("tenant", "acme"),
+				slog.Bool("cached", false),
+				slog.Int("bytes", 512),
+				slog.String("trace_id", "4bf92f3577b34da6"))
+		}
+	})
+}
+
+func BenchmarkObjectField(b *testing.B) {
+	ctx := context.Background()
+	b.Run("glogger", func(b *testing.B) {
+		ls := gloggerService()
+		logger := ls.NewLogger()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.Info(ctx, message, models.WithObjectField("order", sampleOrder))
+		}
+		ls.Stop()
+	})
+	b.Run("zap", func(b *testing.B) {
+		logger := newZap()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.Info(message, zap.Any("order", sampleOrder))
+		}
+	})
+	b.Run("zerolog", func(b *testing.B) {
+		logger := newZerolog()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.Info().Interface("order", sampleOrder).Msg(message)
+		}
+	})
+	b.Run("slog", func(b *testing.B) {
+		logger := newSlog()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			logger.LogAttrs(ctx, slog.LevelInfo, message, slog.Any("order", sampleOrder))
+		}
+	})
+}
+
+func BenchmarkContention(b *testing.B) {
+	ctx := context.Background()
+	b.Run("glogger", func(b *testing.B) {
+		ls := gloggerService()
+		logger := ls.NewLogger()
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Info(ctx, message, models.WithComponent("http"), models.WithIntField("status", 200))
+			}
+		})
+		ls.Stop()
+	})
+	b.Run("zap", func(b *testing.B) {
+		logger := newZap()
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Info(message, zap.String("component", "http"), zap.Int("status", 200))
+			}
+		})
+	})
+	b.Run("zerolog", func(b *testing.B) {
+		logger := newZerolog()
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.Info().Str("component", "http").Int("status", 200).Msg(message)
+			}
+		})
+	})
+	b.Run("slog", func(b *testing.B) {
+		logger := newSlog()
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				logger.LogAttrs(ctx, slog.LevelInfo, message, slog.String("component", "http"), slog.Int("status", 200))
+			}
+		})
+	})
+}
+
+// BenchmarkPipeline measures the service alone: records go through the
+// channels and workers to a publisher that only counts them.
+func BenchmarkPipeline(b *testing.B) {
+	ctx := context.Background()
+	ls := glog.NewLoggerService(glog.WithBlockingSend(), glog.WithErrorHandler(func(error) {}))
+	null := publishers.NewNull()
+	ls.AddLogger("null", null)
+	ls.Start()
+	logger := ls.NewLogger()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info(ctx, message, tenFieldOptions()...)
+	}
+	ls.Stop()
+	b.StopTimer()
+	if got := null.Total(); got != int64(b.N) {
+		b.Fatalf("expected %d records delivered, got %d", b.N, got)
+	}
+}
@@ -0,0 +1,14 @@
+// Package bench compares glogger end to end with direct use of zap, zerolog
+// and log/slog, writing JSON to io.Discard in every case.
+//
+// The glogger benchmarks include the asynchronous pipeline: each run ends
+// with Stop, so every record has reached the publisher before the timer
+// stops and the numbers are not flattered by records still queued or
+// dropped.
+//
+// bench is a separate module that replaces glogger with the parent
+// directory, so the comparison libraries never become dependencies of
+// glogger itself. Run it from this directory with
+//
+//	go test . -run '^$' -bench . -benchmem
+package bench
@@ -0,0 +1,19 @@
+module github.com/alexnobleburn/glogger/bench
+
+go 1.21
+
+require (
+	github.com/alexnobleburn/glogger v0.0.0
+	github.com/rs/zerolog v1.33.0
+	go.uber.org/zap v1.26.0
+)
+
+require (
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.19 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+)
+
+replace github.com/alexnobleburn/glogger => ../
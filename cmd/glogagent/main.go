@@ -0,0 +1,75 @@
+// Command glogagent runs glog/agent as a standalone host agent: it accepts
+// record streams from glog/remote publishers and writes them as JSON to
+// stdout, where the host's log shipper picks them up.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/agent"
+	"github.com/alexnobleburn/glogger/glog/zap"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	var (
+		network = flag.String("network", "unix", "listen network: tcp or unix")
+		address = flag.String("listen", "/run/glog/agent.sock", "listen address or socket path")
+		workers = flag.Int("workers", 4, "service worker count")
+		buffer  = flag.Int("buffer", 10000, "service input buffer size")
+		grace   = flag.Duration("grace", 5*time.Second, "time to let clients finish on shutdown")
+	)
+	flag.Parse()
+	os.Exit(run(*network, *address, *workers, *buffer, *grace))
+}
+
+func run(network, address string, workers, buffer int, grace time.Duration) int {
+	if network == "unix" {
+		_ = os.Remove(address)
+	}
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "glogagent:", err)
+		return 1
+	}
+
+	// Stdout carries the records; diagnostics go to stderr.
+	logErr := func(err error) { fmt.Fprintln(os.Stderr, "glogagent:", err) }
+	ls := glog.NewLoggerService(
+		glog.WithNumWorkers(workers),
+		glog.WithInputBufferSize(buffer),
+		glog.WithBlockingSend(),
+		glog.WithErrorHandler(logErr),
+	)
+	ls.AddLogger("stdout", zap.NewZapLogger("", ""))
+	ls.Start()
+
+	a := agent.New(ls, agent.WithErrorHandler(logErr))
+	served := make(chan error, 1)
+	go func() { served <- a.Serve(ln) }()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	code := 0
+	select {
+	case <-ctx.Done():
+	case err := <-served:
+		logErr(err)
+		code = 1
+	}
+
+	shutdownCtx, stop := context.WithTimeout(context.Background(), grace)
+	defer stop()
+	if err := a.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		logErr(err)
+	}
+	ls.Stop()
+	return code
+}
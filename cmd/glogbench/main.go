@@ -0,0 +1,119 @@
+// Command glogbench measures glogger pipeline throughput and, with -soak,
+// runs a long soak test that fails on goroutine, heap or file descriptor
+// leaks.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/glogtest"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+func main() {
+	var (
+		soak        = flag.Bool("soak", false, "run the soak test instead of the throughput benchmark")
+		duration    = flag.Duration("duration", 10*time.Second, "load duration")
+		interval    = flag.Duration("interval", 10*time.Second, "soak sample interval")
+		rate        = flag.Int("rate", 0, "soak records per second (0 = unthrottled)")
+		workers     = flag.Int("workers", 4, "service worker count")
+		producers   = flag.Int("producers", 8, "benchmark producer goroutines")
+		sendTimeout = flag.Duration("send-timeout", 100*time.Millisecond, "publisher send timeout")
+	)
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	setup := func(collector interfaces.LogPublisher) (interfaces.Logger, func()) {
+		ls := glog.NewLoggerService(
+			glog.WithNumWorkers(*workers),
+			glog.WithSendTimeout(*sendTimeout),
+			glog.WithErrorHandler(func(error) {}),
+		)
+		ls.AddLogger("discard", collector)
+		ls.Start()
+		return ls.NewLogger(), ls.Stop
+	}
+
+	if *soak {
+		os.Exit(runSoak(ctx, *duration, *interval, *rate, setup))
+	}
+	runThroughput(*duration, *producers, setup)
+}
+
+func runSoak(ctx context.Context, duration, interval time.Duration, rate int, setup glogtest.PipelineSetup) int {
+	report, err := glogtest.Soak(ctx, glogtest.SoakConfig{
+		Duration:       duration,
+		SampleInterval: interval,
+		Rate:           rate,
+		OnSample: func(s glogtest.SoakSample) {
+			fmt.Printf("%8s goroutines=%d heap=%dKiB fds=%d sent=%d\n",
+				s.Elapsed.Truncate(time.Second), s.Goroutines, s.HeapAlloc>>10, s.OpenFDs, s.Sent)
+		},
+	}, setup)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "soak interrupted:", err)
+		return 2
+	}
+	if report.Failed() {
+		for _, leak := range report.Leaks {
+			fmt.Fprintln(os.Stderr, "LEAK:", leak)
+		}
+		return 1
+	}
+	fmt.Println("soak passed")
+	return 0
+}
+
+type countingPublisher struct {
+	mu sync.Mutex
+	n  int64
+}
+
+func (c *countingPublisher) SendMsg(*models.LogData) {
+	c.mu.Lock()
+	c.n++
+	c.mu.Unlock()
+}
+
+func runThroughput(duration time.Duration, producers int, setup glogtest.PipelineSetup) {
+	counter := &countingPublisher{}
+	logger, stop := setup(counter)
+
+	var (
+		wg   sync.WaitGroup
+		sent = make([]int64, producers)
+	)
+	deadline := time.Now().Add(duration)
+	ctx := context.Background()
+	wg.Add(producers)
+	for p := 0; p < producers; p++ {
+		go func(id int) {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				logger.Info(ctx, "bench", models.WithIntField("producer", id))
+				sent[id]++
+			}
+		}(p)
+	}
+	wg.Wait()
+	stop()
+
+	var total int64
+	for _, n := range sent {
+		total += n
+	}
+	counter.mu.Lock()
+	delivered := counter.n
+	counter.mu.Unlock()
+	fmt.Printf("sent=%d delivered=%d dropped=%d throughput=%.0f logs/sec\n",
+		total, delivered, total-delivered, float64(total)/duration.Seconds())
+}
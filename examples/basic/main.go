@@ -1,3 +1,5 @@
+// Command basic shows the everyday Logger API: levels, structured fields,
+// components, stack traces and context metadata.
 package main
 
 import (
@@ -6,21 +8,25 @@ import (
 	"github.com/alexnobleburn/glogger/glog"
 	"github.com/alexnobleburn/glogger/glog/models"
 	"github.com/alexnobleburn/glogger/glog/zap"
+	"io"
+	"os"
+	"time"
 )
 
 func main() {
-	// Initialize and configure the service
+	run(os.Stdout)
+}
+
+func run(w io.Writer) {
+	// Construct, register publishers, start; Stop drains and flushes.
 	service := glog.NewLoggerService(
 		glog.WithNumWorkers(4),
-		glog.WithSendTimeout(200),
+		glog.WithSendTimeout(200*time.Millisecond),
 	)
-	defer service.Stop()
-
-	// Add Zap publisher
-	service.AddLogger("zap", zap.NewZapLogger("example-app", "development"))
+	service.AddLogger("zap", zap.NewZapLoggerWithWriter("example-app", "development", w))
 	service.Start()
+	defer service.Stop()
 
-	// Create logger
 	log := service.NewLogger()
 	ctx := context.Background()
 
@@ -62,5 +68,5 @@ func main() {
 		models.WithObjectField("request", map[string]any{
 			"method": "POST", "path": "/api/users", "status": 201,
 		}),
-		models.WithIntField("duration_ms", 45))
+		models.WithFloatField("duration_ms", 45.2))
 }
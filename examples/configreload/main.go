@@ -0,0 +1,77 @@
+// Command configreload builds its service from a YAML file with glog/config
+// and applies edits to the file on SIGHUP, without a restart.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/config"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	path := flag.String("config", "examples/configreload/glog.yaml", "config file")
+	check := flag.Bool("check", false, "validate the config file and exit")
+	flag.Parse()
+
+	if *check {
+		// Load parses and validates the file without building anything,
+		// e.g. before a deploy.
+		c, err := config.Load(*path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s: %d publishers\n", *path, len(c.Publishers))
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	onReload := func(err error) {
+		if err != nil {
+			// The running configuration is kept.
+			fmt.Fprintln(os.Stderr, "reload:", err)
+			return
+		}
+		fmt.Fprintln(os.Stderr, "reloaded", *path)
+	}
+	if err := run(ctx, *path, time.Second, onReload); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run logs a debug and an info record every interval until ctx is done.
+// SIGHUP re-reads path; onReload gets the result.
+func run(ctx context.Context, path string, interval time.Duration, onReload func(error)) error {
+	// The reloader loads the file, builds the service and keeps the file's
+	// path to re-read it.
+	r, err := config.NewReloader(path)
+	if err != nil {
+		return err
+	}
+	service := r.Service()
+	service.Start()
+	defer service.Stop()
+	stopReload := r.ReloadOnSignal(onReload, syscall.SIGHUP)
+	defer stopReload()
+
+	log := service.NewLogger()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for n := 1; ; n++ {
+		log.Debug(ctx, "tick", models.WithIntField("n", n))
+		log.Info(ctx, "tick", models.WithIntField("n", n))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
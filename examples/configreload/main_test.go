@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+const testConfig = `
+app_id: config-demo
+level: LEVEL
+publishers:
+  - type: file
+    path: PATH
+    format: logfmt
+`
+
+func writeConfig(t *testing.T, path, level, logPath string) {
+	t.Helper()
+	doc := strings.NewReplacer("LEVEL", level, "PATH", logPath).Replace(testConfig)
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitFor polls the log file until it contains want.
+func waitFor(t *testing.T, logPath, want string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		b, _ := os.ReadFile(logPath)
+		if strings.Contains(string(b), want) || time.Now().After(deadline) {
+			return string(b)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRun_ReloadsOnSIGHUP(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "glog.yaml")
+	logPath := filepath.Join(dir, "app.log")
+	writeConfig(t, cfgPath, "info", logPath)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reloaded := make(chan error, 1)
+	done := make(chan error, 1)
+	go func() { done <- run(ctx, cfgPath, 5*time.Millisecond, func(err error) { reloaded <- err }) }()
+
+	if out := waitFor(t, logPath, "level=info"); strings.Contains(out, "level=debug") || !strings.Contains(out, "level=info") {
+		t.Fatalf("expected only info records before the reload, got %q", out)
+	}
+
+	writeConfig(t, cfgPath, "debug", logPath)
+	proc, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proc.Signal(syscall.SIGHUP); err != nil {
+		t.Skip(err)
+	}
+	select {
+	case err := <-reloaded:
+		if err != nil {
+			t.Fatalf("unexpected reload error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a reload on SIGHUP")
+	}
+	if out := waitFor(t, logPath, "level=debug"); !strings.Contains(out, "level=debug") {
+		t.Errorf("expected debug records after the reload, got %q", out)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRun_InvalidConfig(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "glog.yaml")
+	if err := os.WriteFile(cfgPath, []byte("levle: info\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(context.Background(), cfgPath, time.Millisecond, nil); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+}
@@ -0,0 +1,109 @@
+// Command filerotation writes JSON lines to a file that is rotated by size,
+// keeping a fixed number of old files, using the generic Writer publisher.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/publishers"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// rotatingFile renames app.log to app.log.1 (app.log.1 to app.log.2, ...)
+// once it would exceed maxBytes. publishers.Writer calls Write once per
+// line, so lines are never split across files.
+type rotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	backups  int
+	f        *os.File
+	size     int64
+}
+
+func openRotating(path string, maxBytes int64, backups int) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxBytes: maxBytes, backups: backups}
+	return r, r.open()
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.size > 0 && r.size+int64(len(p)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	for i := r.backups - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.f.Close()
+}
+
+func main() {
+	dir := flag.String("dir", os.TempDir(), "log directory")
+	flag.Parse()
+	if err := run(*dir, 4096, 3, 500); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, maxBytes int64, backups, records int) error {
+	file, err := openRotating(filepath.Join(dir, "app.log"), maxBytes, backups)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	pub := publishers.NewWriter(file, encoder.NewJSON("rotation-demo", "development"))
+
+	service := glog.NewLoggerService(glog.WithBlockingSend())
+	service.AddLogger("file", pub)
+	service.Start()
+
+	log := service.NewLogger()
+	for i := 0; i < records; i++ {
+		log.Info(context.Background(), "tick", models.WithIntField("n", i))
+	}
+	// Stop before closing the file so every record reaches it.
+	service.Stop()
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	if err := run(dir, 2048, 3, 100); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	names := []string{"app.log", "app.log.1", "app.log.2", "app.log.3"}
+	for _, name := range names {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+		if info.Size() > 2048 {
+			t.Errorf("%s exceeds the size limit: %d bytes", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app.log.4")); err == nil {
+		t.Error("expected at most 3 backups")
+	}
+
+	f, err := os.Open(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	lines := 0
+	for sc := bufio.NewScanner(f); sc.Scan(); {
+		lines++
+	}
+	if lines == 0 {
+		t.Error("expected the current file to hold the latest records")
+	}
+}
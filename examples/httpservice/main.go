@@ -0,0 +1,83 @@
+// Command httpservice runs an HTTP server that writes one canonical log line
+// per request and shuts down cleanly on SIGINT/SIGTERM.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/zap"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8080", "listen address")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := run(ctx, ln, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, ln net.Listener, w io.Writer) error {
+	service := glog.NewLoggerService()
+	service.AddLogger("zap", zap.NewZapLoggerWithWriter("orders-api", "development", w))
+	service.Start()
+	// Runs after the server has shut down, so records from the last
+	// requests are still written.
+	defer service.Stop()
+	log := service.NewLogger()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		glog.AddToCanonical(r.Context(), models.WithStringField("order_id", id))
+		log.Debug(r.Context(), "loading order", models.WithComponent("orders"))
+		if id == "" {
+			http.Error(w, "missing id", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, "order %s\n", id)
+	})
+
+	srv := &http.Server{
+		Handler:           glog.CanonicalMiddleware(log, mux, glog.WithSuppressInfo()),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ln) }()
+	log.Info(ctx, "listening", models.WithStringField("addr", ln.Addr().String()))
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	log.Info(context.Background(), "server stopped")
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type lockedBuffer struct {
+	mu sync.Mutex
+	bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.Buffer.Write(p)
+}
+
+func TestRun(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var out lockedBuffer
+	done := make(chan error, 1)
+	go func() { done <- run(ctx, ln, &out) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/orders?id=42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	logs := out.String()
+	if !strings.Contains(logs, `"msg":"canonical-log-line"`) || !strings.Contains(logs, `"order_id":"42"`) {
+		t.Errorf("expected a canonical line with the order id, got:\n%s", logs)
+	}
+	if strings.Contains(logs, "loading order") {
+		t.Error("expected debug records inside the request to be suppressed")
+	}
+	if !strings.Contains(logs, "server stopped") {
+		t.Error("expected records written during shutdown to be flushed")
+	}
+}
@@ -0,0 +1,69 @@
+// Command kafkasink sends records to Kafka through the batching publisher.
+// A real program wraps its Kafka client in kafka.Producer; this one prints
+// the batches so it runs without a broker.
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/kafka"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// printProducer stands in for e.g. a kafka-go Writer:
+//
+//	func (p writerProducer) Produce(ctx context.Context, topic string, msgs []kafka.Message) error {
+//		out := make([]kafkago.Message, len(msgs))
+//		for i, m := range msgs {
+//			out[i] = kafkago.Message{Topic: topic, Key: m.Key, Value: m.Value}
+//		}
+//		return p.w.WriteMessages(ctx, out...)
+//	}
+type printProducer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (p *printProducer) Produce(_ context.Context, topic string, msgs []kafka.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, "batch of %d to %s\n", len(msgs), topic)
+	for _, m := range msgs {
+		fmt.Fprintf(p.w, "  key=%s %s\n", m.Key, m.Value)
+	}
+	return nil
+}
+
+func main() {
+	run(os.Stdout)
+}
+
+func run(w io.Writer) {
+	pub := kafka.New(&printProducer{w: w}, kafka.Config{
+		Topic:         "app-logs",
+		BatchSize:     50,
+		FlushInterval: time.Second,
+		KeyField:      "order_id",
+		Encoder:       encoder.NewJSON("checkout", "development"),
+	})
+	defer pub.Close()
+
+	service := glog.NewLoggerService(glog.WithBlockingSend())
+	service.AddLogger("kafka", pub)
+	service.Start()
+	// Stop drains the pipeline and flushes the Kafka publisher.
+	defer service.Stop()
+
+	log := service.NewLogger()
+	for i := 1; i <= 3; i++ {
+		log.Info(context.Background(), "order placed",
+			models.WithComponent("checkout"),
+			models.WithStringField("order_id", fmt.Sprintf("o-%d", i)))
+	}
+}
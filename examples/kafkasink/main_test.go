@@ -0,0 +1,21 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var out bytes.Buffer
+	run(&out)
+
+	if !strings.HasPrefix(out.String(), "batch of 3 to app-logs\n") {
+		t.Fatalf("expected one flushed batch of 3, got:\n%s", out.String())
+	}
+	for _, key := range []string{"key=o-1 ", "key=o-2 ", "key=o-3 "} {
+		if !strings.Contains(out.String(), key) {
+			t.Errorf("expected message keyed %q", key)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Command shutdown stops cleanly on SIGINT/SIGTERM without sleeping: the
+// producers stop first, then Stop drains the pipeline and flushes the
+// publishers, so no record logged before the signal is lost.
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/zap"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	n := run(ctx, os.Stdout, 4)
+	fmt.Fprintf(os.Stderr, "logged %d records\n", n)
+}
+
+// run logs from producers until ctx is done and returns how many records
+// were logged.
+func run(ctx context.Context, w io.Writer, producers int) int64 {
+	// Blocking send: under load producers slow down instead of dropping,
+	// so the count below is exact.
+	service := glog.NewLoggerService(glog.WithBlockingSend())
+	service.AddLogger("zap", zap.NewZapLoggerWithWriter("shutdown-demo", "development", w))
+	service.Start()
+	log := service.NewLogger()
+
+	var logged atomic.Int64
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				log.Info(ctx, "working", models.WithIntField("producer", p))
+				logged.Add(1)
+			}
+		}(p)
+	}
+
+	<-ctx.Done()
+	// 1. Stop producing.
+	wg.Wait()
+	// 2. Drain the pipeline and flush publishers.
+	service.Stop()
+	return logged.Load()
+}
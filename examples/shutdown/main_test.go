@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// countingWriter cancels the run after limit lines, standing in for a
+// signal arriving mid-load.
+type countingWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	lines  int
+	limit  int
+	cancel context.CancelFunc
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lines += bytes.Count(p, []byte("\n"))
+	if w.lines >= w.limit {
+		w.cancel()
+	}
+	return w.buf.Write(p)
+}
+
+func TestRun_NoRecordLost(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &countingWriter{limit: 200, cancel: cancel}
+	logged := run(ctx, out, 4)
+
+	written := int64(strings.Count(out.buf.String(), "\n"))
+	if logged < 200 || written != logged {
+		t.Errorf("logged %d records but %d were written", logged, written)
+	}
+}
@@ -0,0 +1,35 @@
+// Package testingpatterns shows how to test code that logs: depend on
+// interfaces.Logger, and in tests capture records with a recording
+// publisher behind a real service (see orders_test.go).
+package testingpatterns
+
+import (
+	"context"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+)
+
+var ErrOutOfStock = errors.New("out of stock")
+
+// Orders depends on interfaces.Logger rather than *glog.Logger, so tests
+// may pass any implementation.
+type Orders struct {
+	Log   interfaces.Logger
+	Stock map[string]int
+}
+
+func (o *Orders) Place(ctx context.Context, sku string, qty int) error {
+	if o.Stock[sku] < qty {
+		o.Log.Error(ctx, ErrOutOfStock,
+			models.WithComponent("orders"),
+			models.WithStringField("sku", sku),
+			models.WithIntField("requested", qty))
+		return ErrOutOfStock
+	}
+	o.Stock[sku] -= qty
+	o.Log.Info(ctx, "order placed",
+		models.WithComponent("orders"),
+		models.WithStringField("sku", sku))
+	return nil
+}
@@ -0,0 +1,80 @@
+package testingpatterns
+
+import (
+	"context"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync"
+	"testing"
+)
+
+// recorder keeps every record it receives.
+type recorder struct {
+	mu      sync.Mutex
+	records []*models.LogData
+}
+
+func (r *recorder) SendMsg(data *models.LogData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, data)
+}
+
+// find returns the first record with msg. Workers deliver in parallel, so
+// tests look records up instead of relying on their order.
+func (r *recorder) find(msg string) *models.LogData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, data := range r.records {
+		if data.Msg == msg {
+			return data
+		}
+	}
+	return nil
+}
+
+func field(data *models.LogData, key string) *models.LogField {
+	for _, f := range data.Fields {
+		if f.Key == key {
+			return f
+		}
+	}
+	return nil
+}
+
+// newTestLogger wires a recorder behind a real service. WithBlockingSend
+// makes sure nothing is dropped and Stop (run by Cleanup or explicitly)
+// waits until every record is delivered, so tests never need to sleep.
+func newTestLogger(t *testing.T) (*glog.Logger, *recorder, func()) {
+	rec := &recorder{}
+	service := glog.NewLoggerService(glog.WithBlockingSend())
+	service.AddLogger("recorder", rec)
+	service.Start()
+	t.Cleanup(service.Stop)
+	return service.NewLogger(), rec, service.Stop
+}
+
+func TestPlace_LogsOutOfStock(t *testing.T) {
+	log, rec, stop := newTestLogger(t)
+	orders := &Orders{Log: log, Stock: map[string]int{"sku-1": 1}}
+
+	if err := orders.Place(context.Background(), "sku-1", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := orders.Place(context.Background(), "sku-1", 2); !errors.Is(err, ErrOutOfStock) {
+		t.Fatalf("expected ErrOutOfStock, got %v", err)
+	}
+	stop()
+
+	failure := rec.find(ErrOutOfStock.Error())
+	if failure == nil || failure.Level != models.ErrorLevel {
+		t.Fatalf("expected an error record, got %+v", failure)
+	}
+	if f := field(failure, "requested"); f == nil || f.Integer != 2 {
+		t.Errorf("expected requested=2, got %+v", f)
+	}
+	if rec.find("order placed") == nil {
+		t.Error("expected the successful order to be logged")
+	}
+}
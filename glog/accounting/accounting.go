@@ -0,0 +1,148 @@
+// Package accounting attributes log volume to components, levels and tenants
+// so log costs can be charged back to the teams producing them.
+//
+// An Accountant is a regular publisher: register it next to the real sinks
+// and it sees every record the service dispatches.
+package accounting
+
+import (
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const defaultTenantKey = "tenant"
+
+// Key identifies one accounting bucket.
+type Key struct {
+	Component string
+	Level     models.LogLevel
+	Tenant    string
+}
+
+type Stat struct {
+	Key
+	Records int64
+	Bytes   int64
+}
+
+type Option func(*Accountant)
+
+// WithTenantKey sets the field key the tenant is read from (default "tenant").
+func WithTenantKey(key string) Option {
+	return func(a *Accountant) {
+		if key != "" {
+			a.tenantKey = key
+		}
+	}
+}
+
+// Compile-time check that Accountant implements interfaces.LogPublisher.
+var _ interfaces.LogPublisher = (*Accountant)(nil)
+
+type Accountant struct {
+	tenantKey string
+	mu        sync.Mutex
+	stats     map[Key]*Stat
+}
+
+func New(opts ...Option) *Accountant {
+	a := &Accountant{
+		tenantKey: defaultTenantKey,
+		stats:     make(map[Key]*Stat),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+func (a *Accountant) SendMsg(data *models.LogData) {
+	key := Key{Level: data.Level}
+	for _, f := range data.Fields {
+		if f == nil || f.Type != models.FieldTypeString {
+			continue
+		}
+		switch f.Key {
+		case models.FieldComponentKey:
+			key.Component = f.String
+		case a.tenantKey:
+			key.Tenant = f.String
+		}
+	}
+	size := int64(data.Size())
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s, ok := a.stats[key]
+	if !ok {
+		s = &Stat{Key: key}
+		a.stats[key] = s
+	}
+	s.Records++
+	s.Bytes += size
+}
+
+// Stats returns a snapshot sorted by component, tenant and level.
+func (a *Accountant) Stats() []Stat {
+	a.mu.Lock()
+	res := make([]Stat, 0, len(a.stats))
+	for _, s := range a.stats {
+		res = append(res, *s)
+	}
+	a.mu.Unlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Component != res[j].Component {
+			return res[i].Component < res[j].Component
+		}
+		if res[i].Tenant != res[j].Tenant {
+			return res[i].Tenant < res[j].Tenant
+		}
+		return res[i].Level < res[j].Level
+	})
+	return res
+}
+
+func (a *Accountant) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stats = make(map[Key]*Stat)
+}
+
+// WriteMetrics writes the counters in the Prometheus text exposition format.
+func (a *Accountant) WriteMetrics(w io.Writer) error {
+	stats := a.Stats()
+	var b strings.Builder
+	b.WriteString("# HELP glogger_log_records_total Log records by component, level and tenant.\n")
+	b.WriteString("# TYPE glogger_log_records_total counter\n")
+	for _, s := range stats {
+		fmt.Fprintf(&b, "glogger_log_records_total{%s} %d\n", labels(s.Key), s.Records)
+	}
+	b.WriteString("# HELP glogger_log_bytes_total Estimated log bytes by component, level and tenant.\n")
+	b.WriteString("# TYPE glogger_log_bytes_total counter\n")
+	for _, s := range stats {
+		fmt.Fprintf(&b, "glogger_log_bytes_total{%s} %d\n", labels(s.Key), s.Bytes)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ServeHTTP exposes WriteMetrics so the Accountant can be mounted as a
+// scrape endpoint.
+func (a *Accountant) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_ = a.WriteMetrics(w)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labels(k Key) string {
+	return fmt.Sprintf(`component="%s",level="%s",tenant="%s"`,
+		labelEscaper.Replace(k.Component), k.Level.String(), labelEscaper.Replace(k.Tenant))
+}
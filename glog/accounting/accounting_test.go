@@ -0,0 +1,84 @@
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"github.com/alexnobleburn/glogger/glog/glogtest"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+	"testing"
+)
+
+func newRecord(level models.LogLevel, component, tenant string) *models.LogData {
+	data := &models.LogData{Ctx: context.Background(), Msg: "message", Level: level}
+	if component != "" {
+		data.Fields = append(data.Fields,
+			&models.LogField{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: component})
+	}
+	if tenant != "" {
+		data.Fields = append(data.Fields,
+			&models.LogField{Key: "customer", Type: models.FieldTypeString, String: tenant})
+	}
+	return data
+}
+
+func TestAccountant_Stats(t *testing.T) {
+	a := New(WithTenantKey("customer"))
+
+	a.SendMsg(newRecord(models.InfoLevel, "billing", "acme"))
+	a.SendMsg(newRecord(models.InfoLevel, "billing", "acme"))
+	a.SendMsg(newRecord(models.ErrorLevel, "billing", "acme"))
+	a.SendMsg(newRecord(models.InfoLevel, "auth", ""))
+
+	stats := a.Stats()
+	if len(stats) != 3 {
+		t.Fatalf("expected 3 buckets, got %d", len(stats))
+	}
+
+	if stats[0].Component != "auth" || stats[0].Records != 1 {
+		t.Errorf("unexpected first bucket: %+v", stats[0])
+	}
+	billingInfo := stats[1]
+	if billingInfo.Component != "billing" || billingInfo.Tenant != "acme" ||
+		billingInfo.Level != models.InfoLevel || billingInfo.Records != 2 {
+		t.Errorf("unexpected billing info bucket: %+v", billingInfo)
+	}
+	if billingInfo.Bytes <= 0 {
+		t.Error("expected byte estimate to be positive")
+	}
+}
+
+func TestAccountant_WriteMetrics(t *testing.T) {
+	a := New()
+	a.SendMsg(newRecord(models.WarnLevel, "db\"x", ""))
+
+	var buf bytes.Buffer
+	if err := a.WriteMetrics(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `glogger_log_records_total{component="db\"x",level="warn",tenant=""} 1`) {
+		t.Errorf("missing records metric in output:\n%s", out)
+	}
+	if !strings.Contains(out, "# TYPE glogger_log_bytes_total counter") {
+		t.Errorf("missing bytes metric in output:\n%s", out)
+	}
+}
+
+func TestAccountant_Reset(t *testing.T) {
+	a := New()
+	a.SendMsg(newRecord(models.InfoLevel, "", ""))
+	a.Reset()
+
+	if len(a.Stats()) != 0 {
+		t.Error("expected stats to be empty after reset")
+	}
+}
+
+func TestConformance(t *testing.T) {
+	glogtest.RunPublisherConformance(t, func(t testing.TB) interfaces.LogPublisher {
+		return New()
+	})
+}
@@ -0,0 +1,273 @@
+// Package admin serves an HTTP endpoint to view and change the routing of a
+// running glog.LoggerService: the service, named and component levels, and
+// whether and how often each publisher receives records.
+//
+//	mux.Handle("/admin/log/", http.StripPrefix("/admin/log",
+//		admin.Handler(service, logger, audit.TokenAuth(tokens))))
+//
+// Routes, relative to where the handler is mounted:
+//
+//	GET /                  the current State
+//	PUT /levels            {"level": "info", "named": {"http": "warn"}, "components": {"database": null}}
+//	PUT /publishers/{id}   {"enabled": false, "sample_rate": 10}
+//
+// A null level removes the override. Every PUT is validated as a whole
+// before anything is changed and answers with the new State. Every
+// request is written as an audit record.
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/audit"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net/http"
+	"strings"
+)
+
+// maxBodySize bounds request bodies; a reconfiguration is a few hundred
+// bytes.
+const maxBodySize = 64 << 10
+
+// State is what GET / returns.
+type State struct {
+	Level      string                         `json:"level"`
+	Named      map[string]string              `json:"named"`
+	Components map[string]string              `json:"components"`
+	Publishers map[string]glog.PublisherState `json:"publishers"`
+}
+
+// LevelsUpdate is the body of PUT /levels. Absent keys are left unchanged
+// and a null level removes the override.
+type LevelsUpdate struct {
+	Level      string             `json:"level,omitempty"`
+	Named      map[string]*string `json:"named,omitempty"`
+	Components map[string]*string `json:"components,omitempty"`
+}
+
+// PublisherUpdate is the body of PUT /publishers/{id}. Absent keys are left
+// unchanged.
+type PublisherUpdate struct {
+	Enabled    *bool `json:"enabled,omitempty"`
+	SampleRate *int  `json:"sample_rate,omitempty"`
+}
+
+// Handler returns the admin endpoint of svc. auth decides who may use it,
+// e.g. audit.TokenAuth or audit.MTLSAuth; requests it rejects get 401. A
+// nil auth lets every request through, which should only be used behind
+// another authenticating proxy.
+//
+// Every request, accepted or not, is recorded with audit.Middleware on
+// logger, with the decoded change and the values it replaced. A glog
+// logger is used through its Internal logger, so a request that raises the
+// level or disables a publisher cannot hide its own record. Handler panics
+// if logger is nil.
+func Handler(svc *glog.LoggerService, logger interfaces.Logger, auth audit.Authenticator) http.Handler {
+	if logger == nil {
+		panic("admin: Handler needs an audit logger")
+	}
+	if l, ok := logger.(*glog.Logger); ok {
+		logger = l.Internal()
+	}
+	return audit.Middleware(logger, auth, &handler{svc: svc})
+}
+
+type handler struct {
+	svc *glog.LoggerService
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	switch {
+	case path == "":
+		if !allow(w, r, http.MethodGet) {
+			return
+		}
+	case path == "levels":
+		if !allow(w, r, http.MethodPut) {
+			return
+		}
+		var body LevelsUpdate
+		if !decode(w, r, &body) {
+			return
+		}
+		audit.AddDetails(r.Context(),
+			models.WithObjectField("change", body),
+			models.WithObjectField("previous", h.previousLevels(body)))
+		if err := h.updateLevels(body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case strings.HasPrefix(path, "publishers/"):
+		if !allow(w, r, http.MethodPut) {
+			return
+		}
+		id := strings.TrimPrefix(path, "publishers/")
+		if _, ok := h.svc.PublisherStates()[id]; !ok {
+			http.Error(w, fmt.Sprintf("unknown publisher %q", id), http.StatusNotFound)
+			return
+		}
+		var body PublisherUpdate
+		if !decode(w, r, &body) {
+			return
+		}
+		audit.AddDetails(r.Context(),
+			models.WithStringField("publisher", id),
+			models.WithObjectField("change", body),
+			models.WithObjectField("previous", h.svc.PublisherStates()[id]))
+		if err := h.updatePublisher(id, body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(h.state())
+}
+
+func (h *handler) state() State {
+	s := State{
+		Level:      h.svc.Level().String(),
+		Named:      make(map[string]string),
+		Components: make(map[string]string),
+		Publishers: h.svc.PublisherStates(),
+	}
+	for name, level := range h.svc.NamedLevels() {
+		if name != "" {
+			s.Named[name] = level.String()
+		}
+	}
+	for component, level := range h.svc.ComponentLevels() {
+		s.Components[component] = level.String()
+	}
+	return s
+}
+
+// previousLevels returns the values the levels in body replace, null for
+// overrides that are not set.
+func (h *handler) previousLevels(body LevelsUpdate) LevelsUpdate {
+	prev := LevelsUpdate{}
+	if body.Level != "" {
+		prev.Level = h.svc.Level().String()
+	}
+	prev.Named = previous(body.Named, h.svc.NamedLevels())
+	prev.Components = previous(body.Components, h.svc.ComponentLevels())
+	return prev
+}
+
+func previous(keys map[string]*string, current map[string]models.LogLevel) map[string]*string {
+	if len(keys) == 0 {
+		return nil
+	}
+	res := make(map[string]*string, len(keys))
+	for k := range keys {
+		res[k] = nil
+		if level, ok := current[k]; ok {
+			s := level.String()
+			res[k] = &s
+		}
+	}
+	return res
+}
+
+// updateLevels parses every level before setting any, so a bad request
+// changes nothing.
+func (h *handler) updateLevels(body LevelsUpdate) error {
+	var level *models.LogLevel
+	if body.Level != "" {
+		l, err := models.ParseLevel(body.Level)
+		if err != nil {
+			return err
+		}
+		level = &l
+	}
+	named, err := parseLevels(body.Named)
+	if err != nil {
+		return err
+	}
+	components, err := parseLevels(body.Components)
+	if err != nil {
+		return err
+	}
+	if _, ok := components[""]; ok {
+		return fmt.Errorf("empty component; set level instead")
+	}
+
+	if level != nil {
+		h.svc.SetLevel(*level)
+	}
+	for name, l := range named {
+		if l == nil {
+			h.svc.ClearNamedLevel(name)
+		} else {
+			h.svc.SetNamedLevel(name, *l)
+		}
+	}
+	for component, l := range components {
+		if l == nil {
+			h.svc.ClearComponentLevel(component)
+		} else {
+			h.svc.SetComponentLevel(component, *l)
+		}
+	}
+	return nil
+}
+
+func parseLevels(levels map[string]*string) (map[string]*models.LogLevel, error) {
+	res := make(map[string]*models.LogLevel, len(levels))
+	for name, s := range levels {
+		if s == nil {
+			res[name] = nil
+			continue
+		}
+		l, err := models.ParseLevel(*s)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", name, err)
+		}
+		res[name] = &l
+	}
+	return res, nil
+}
+
+// updatePublisher validates both fields before setting either, so a bad
+// request changes nothing.
+func (h *handler) updatePublisher(id string, body PublisherUpdate) error {
+	if body.SampleRate != nil && *body.SampleRate < 0 {
+		return fmt.Errorf("invalid sample rate %d", *body.SampleRate)
+	}
+	if _, ok := h.svc.PublisherStates()[id]; !ok {
+		return fmt.Errorf("unknown publisher %q", id)
+	}
+	if body.SampleRate != nil {
+		if err := h.svc.SetSampleRate(id, *body.SampleRate); err != nil {
+			return err
+		}
+	}
+	if body.Enabled != nil {
+		return h.svc.SetPublisherEnabled(id, *body.Enabled)
+	}
+	return nil
+}
+
+func allow(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	return false
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
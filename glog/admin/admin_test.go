@@ -0,0 +1,197 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/audit"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/publishers"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// auditPublisher keeps the audit records of the admin endpoint.
+type auditPublisher struct {
+	mu      sync.Mutex
+	records []*models.LogData
+}
+
+func (p *auditPublisher) SendMsg(data *models.LogData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.records = append(p.records, data)
+}
+
+// fields returns the fields of the record for path, since workers may
+// deliver records in any order.
+func (p *auditPublisher) fields(path string) map[string]any {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, r := range p.records {
+		res := recordFields(r)
+		if res["path"] == path {
+			return res
+		}
+	}
+	return nil
+}
+
+func recordFields(r *models.LogData) map[string]any {
+	res := make(map[string]any)
+	for _, f := range r.Fields {
+		switch f.Type {
+		case models.FieldTypeString:
+			res[f.Key] = f.String
+		case models.FieldTypeInt:
+			res[f.Key] = f.Integer
+		default:
+			res[f.Key] = f.Object
+		}
+	}
+	return res
+}
+
+func newHandler(t *testing.T) (*glog.LoggerService, http.Handler) {
+	t.Helper()
+	ls, h, _ := newAuditedHandler(t)
+	return ls, h
+}
+
+func newAuditedHandler(t *testing.T) (*glog.LoggerService, http.Handler, *auditPublisher) {
+	t.Helper()
+	ls := glog.NewLoggerService(glog.WithNamedLevels(map[string]models.LogLevel{"http": models.WarnLevel}))
+	ls.AddLogger("kafka", publishers.NewNull())
+	records := &auditPublisher{}
+	ls.AddLogger("audit", records, glog.WithFilter(glog.HasComponent("glogger.admin")))
+	ls.Start()
+	t.Cleanup(ls.Stop)
+	return ls, Handler(ls, ls.NewLogger(), audit.TokenAuth(map[string]string{"secret": "ops"})), records
+}
+
+func do(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler_GetState(t *testing.T) {
+	_, h := newHandler(t)
+	rec := do(h, http.MethodGet, "/", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var s State
+	if err := json.Unmarshal(rec.Body.Bytes(), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Level != "debug" || s.Named["http"] != "warn" || !s.Publishers["kafka"].Enabled || len(s.Publishers) != 2 {
+		t.Errorf("unexpected state %+v", s)
+	}
+}
+
+func TestHandler_Unauthorized(t *testing.T) {
+	_, h := newHandler(t)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestHandler_PutLevels(t *testing.T) {
+	ls, h := newHandler(t)
+	rec := do(h, http.MethodPut, "/levels", `{"level":"info","named":{"http":null,"db":"error"},"components":{"payments":"debug"}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if ls.Level() != models.InfoLevel {
+		t.Errorf("expected info, got %v", ls.Level())
+	}
+	named := ls.NamedLevels()
+	if _, ok := named["http"]; ok || named["db"] != models.ErrorLevel {
+		t.Errorf("unexpected named levels %v", named)
+	}
+	if ls.ComponentLevels()["payments"] != models.DebugLevel {
+		t.Errorf("unexpected component levels %v", ls.ComponentLevels())
+	}
+}
+
+func TestHandler_InvalidLevelsChangeNothing(t *testing.T) {
+	ls, h := newHandler(t)
+	for name, body := range map[string]string{
+		"bad level":       `{"level":"info","named":{"db":"loud"}}`,
+		"empty component": `{"level":"info","components":{"":"debug"}}`,
+		"unknown key":     `{"levle":"info"}`,
+	} {
+		if rec := do(h, http.MethodPut, "/levels", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, rec.Code)
+		}
+	}
+	if ls.Level() != models.DebugLevel {
+		t.Errorf("expected the level unchanged, got %v", ls.Level())
+	}
+}
+
+func TestHandler_PutPublisher(t *testing.T) {
+	ls, h := newHandler(t)
+	rec := do(h, http.MethodPut, "/publishers/kafka", `{"enabled":false,"sample_rate":10}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if s := ls.PublisherStates()["kafka"]; s.Enabled || s.SampleRate != 10 {
+		t.Errorf("unexpected publisher state %+v", s)
+	}
+
+	if rec := do(h, http.MethodPut, "/publishers/loki", `{"enabled":false}`); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown publisher, got %d", rec.Code)
+	}
+	if rec := do(h, http.MethodPut, "/publishers/kafka", `{"enabled":true,"sample_rate":-1}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a negative rate, got %d", rec.Code)
+	}
+	if s := ls.PublisherStates()["kafka"]; s.Enabled {
+		t.Error("expected a rejected update to change nothing")
+	}
+	if rec := do(h, http.MethodGet, "/publishers/kafka", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestHandler_AuditsChangesPastTheirEffect(t *testing.T) {
+	ls, h, records := newAuditedHandler(t)
+	ls.SetSampleRate("audit", 100)
+	rec := do(h, http.MethodPut, "/levels", `{"level":"error","named":{"http":null}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	do(h, http.MethodPut, "/publishers/kafka", `{"enabled":false}`)
+	if err := ls.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	records.mu.Lock()
+	n := len(records.records)
+	records.mu.Unlock()
+	if n != 2 {
+		t.Fatalf("expected both changes audited despite the new level, got %d records", n)
+	}
+	f := records.fields("/levels")
+	if f["actor"] != "ops" || f["status"] != http.StatusOK {
+		t.Errorf("unexpected audit record %v", f)
+	}
+	change, _ := f["change"].(LevelsUpdate)
+	prev, _ := f["previous"].(LevelsUpdate)
+	if change.Level != "error" || prev.Level != "debug" || prev.Named["http"] == nil || *prev.Named["http"] != "warn" {
+		t.Errorf("expected the change and the replaced values, got %+v and %+v", change, prev)
+	}
+	f = records.fields("/publishers/kafka")
+	if prev, ok := f["previous"].(glog.PublisherState); f["publisher"] != "kafka" || !ok || !prev.Enabled {
+		t.Errorf("unexpected publisher audit record %v", f)
+	}
+}
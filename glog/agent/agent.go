@@ -0,0 +1,234 @@
+// Package agent receives records streamed by glog/remote publishers and
+// feeds them into a local LoggerService, so that many processes on a host
+// share one delivery pipeline and one set of backend credentials.
+//
+// The agent is a gRPC server for the LogCollector service of
+// glog/remote/log.proto. Serve accepts streams of LogData messages on a TCP
+// or unix listener and acknowledges records as they are handed to the
+// service. Receive decodes messages from any other transport the caller
+// runs.
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/remote"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrClosed is returned by Serve after Shutdown.
+var ErrClosed = errors.New("agent: closed")
+
+type Option func(*Agent)
+
+// WithErrorHandler receives stream and decoding errors. Defaults to
+// fmt.Println.
+func WithErrorHandler(handler func(error)) Option {
+	return func(a *Agent) {
+		if handler != nil {
+			a.errorHandler = handler
+		}
+	}
+}
+
+// WithServerOptions passes options such as grpc.Creds for TLS to the gRPC
+// server.
+func WithServerOptions(opts ...grpc.ServerOption) Option {
+	return func(a *Agent) {
+		a.serverOpts = append(a.serverOpts, opts...)
+	}
+}
+
+// Agent decodes LogData streams and hands each record to the service it
+// was created with. The service's processors, pauses, quotas and publishers
+// apply as for records logged locally.
+type Agent struct {
+	sink         interfaces.LogPublisher
+	errorHandler func(error)
+	serverOpts   []grpc.ServerOption
+	server       *grpc.Server
+	received     atomic.Int64
+	rejected     atomic.Int64
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// New returns an Agent feeding ls. ls must be started before records
+// arrive and stopped after Shutdown.
+func New(ls *glog.LoggerService, opts ...Option) *Agent {
+	a := &Agent{
+		sink:         ls.AsPublisher(),
+		errorHandler: func(err error) { fmt.Println(err) },
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.server = grpc.NewServer(append([]grpc.ServerOption{
+		grpc.ForceServerCodec(remote.Codec{}),
+		grpc.MaxRecvMsgSize(remote.MaxRecordSize),
+	}, a.serverOpts...)...)
+	desc := remote.StreamDesc
+	desc.Handler = a.stream
+	a.server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "glogger.remote.v1.LogCollector",
+		Streams:     []grpc.StreamDesc{desc},
+	}, nil)
+	return a
+}
+
+// Serve accepts LogCollector streams from remote publishers on ln until
+// Shutdown. It may be called for several listeners.
+func (a *Agent) Serve(ln net.Listener) error {
+	if a.isClosed() {
+		_ = ln.Close()
+		return ErrClosed
+	}
+	err := a.server.Serve(ln)
+	if a.isClosed() {
+		return ErrClosed
+	}
+	return err
+}
+
+// stream serves one LogCollector.Stream call. Acknowledgements go out from
+// a second goroutine, so a client that reads them only after sending a
+// whole batch cannot stall the receive loop.
+func (a *Agent) stream(_ any, ss grpc.ServerStream) error {
+	var taken atomic.Int64
+	kick := make(chan struct{}, 1)
+	acked := make(chan error, 1)
+	go func() { acked <- sendAcks(ss, &taken, kick) }()
+
+	err := a.recvStream(ss, &taken, kick)
+	close(kick)
+	if ackErr := <-acked; err == nil {
+		err = ackErr
+	}
+	if err != nil && status.Code(err) != codes.Canceled {
+		addr := "unknown"
+		if p, ok := peer.FromContext(ss.Context()); ok {
+			addr = p.Addr.String()
+		}
+		a.errorHandler(fmt.Errorf("agent: %s: %w", addr, err))
+	}
+	return err
+}
+
+func (a *Agent) recvStream(ss grpc.ServerStream, taken *atomic.Int64, kick chan<- struct{}) error {
+	for {
+		var msg []byte
+		if err := ss.RecvMsg(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		// Undecodable records are acknowledged too; resending them would
+		// not help.
+		a.accept(msg)
+		taken.Add(1)
+		select {
+		case kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// sendAcks acknowledges the records taken so far each time kick fires,
+// until kick is closed.
+func sendAcks(ss grpc.ServerStream, taken *atomic.Int64, kick <-chan struct{}) error {
+	var sent int64
+	for range kick {
+		n := taken.Load()
+		if n == sent {
+			continue
+		}
+		ack := remote.MarshalAck(n)
+		if err := ss.SendMsg(&ack); err != nil {
+			return err
+		}
+		sent = n
+	}
+	return nil
+}
+
+// Receive feeds records from recv until it returns io.EOF and returns how
+// many were accepted. It is the entry point for other transports: recv
+// returns the next encoded LogData message, however it arrived.
+func (a *Agent) Receive(recv func() ([]byte, error)) (int64, error) {
+	var n int64
+	for {
+		msg, err := recv()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if a.accept(msg) {
+			n++
+		}
+	}
+}
+
+// accept decodes msg and hands the record to the service.
+func (a *Agent) accept(msg []byte) bool {
+	data, err := remote.Unmarshal(msg)
+	if err != nil {
+		a.rejected.Add(1)
+		a.errorHandler(fmt.Errorf("agent: %w", err))
+		return false
+	}
+	a.sink.SendMsg(data)
+	a.received.Add(1)
+	return true
+}
+
+// Received returns how many records were handed to the service.
+func (a *Agent) Received() int64 {
+	return a.received.Load()
+}
+
+// Rejected returns how many messages could not be decoded.
+func (a *Agent) Rejected() int64 {
+	return a.rejected.Load()
+}
+
+// Shutdown stops accepting streams and waits for open ones to end. When ctx
+// is done first, the remaining streams are cancelled.
+func (a *Agent) Shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	a.closed = true
+	a.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		a.server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+	a.server.Stop()
+	<-done
+	return ctx.Err()
+}
+
+func (a *Agent) isClosed() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.closed
+}
@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/remote"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	mu   sync.Mutex
+	logs []*models.LogData
+}
+
+func (r *recorder) SendMsg(data *models.LogData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, data)
+}
+
+func (r *recorder) snapshot() []*models.LogData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*models.LogData(nil), r.logs...)
+}
+
+func TestAgent_FansOutRemoteRecords(t *testing.T) {
+	sink := &recorder{}
+	ls := glog.NewLoggerService(glog.WithBlockingSend())
+	ls.AddLogger("sink", sink, glog.WithProcessors(glog.RedactFields("token")))
+	ls.Start()
+
+	a := New(ls, WithErrorHandler(func(error) {}))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- a.Serve(ln) }()
+
+	ts := time.Unix(1700000000, 0)
+	for _, app := range []string{"billing", "search"} {
+		conn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		pub := remote.New(remote.Collector(conn), remote.Config{AppID: app, Env: "prod"})
+		pub.SendMsg(&models.LogData{
+			Ctx:    context.Background(),
+			Msg:    "from " + app,
+			Level:  models.WarnLevel,
+			Time:   ts,
+			Fields: []*models.LogField{{Key: "token", Type: models.FieldTypeString, String: "secret"}},
+		})
+		// Flush returns once the agent has acknowledged the record.
+		if err := pub.Flush(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := pub.Close(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		conn.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := a.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+	if err := <-served; err != ErrClosed {
+		t.Errorf("expected ErrClosed from Serve, got %v", err)
+	}
+	ls.Stop()
+
+	logs := sink.snapshot()
+	if len(logs) != 2 || a.Received() != 2 {
+		t.Fatalf("expected 2 records, got %d (received %d)", len(logs), a.Received())
+	}
+	apps := map[any]bool{}
+	for _, l := range logs {
+		apps[l.Ctx.Value(models.AppID)] = true
+		if l.Level != models.WarnLevel || !l.Time.Equal(ts) {
+			t.Errorf("expected level and time preserved, got %v %v", l.Level, l.Time)
+		}
+		if len(l.Fields) != 1 || l.Fields[0].String == "secret" {
+			t.Errorf("expected agent processors to apply, got %+v", l.Fields)
+		}
+	}
+	if !apps["billing"] || !apps["search"] {
+		t.Errorf("expected records of both processes, got %v", apps)
+	}
+}
+
+func TestAgent_ReceiveSkipsUndecodable(t *testing.T) {
+	sink := &recorder{}
+	ls := glog.NewLoggerService(glog.WithBlockingSend())
+	ls.AddLogger("sink", sink)
+	ls.Start()
+
+	var errs int
+	a := New(ls, WithErrorHandler(func(error) { errs++ }))
+	msgs := [][]byte{
+		remote.Marshal(&models.LogData{Msg: "ok"}, "app", "dev"),
+		{0x0a, 0xff},
+	}
+	n, err := a.Receive(func() ([]byte, error) {
+		if len(msgs) == 0 {
+			return nil, io.EOF
+		}
+		m := msgs[0]
+		msgs = msgs[1:]
+		return m, nil
+	})
+	ls.Stop()
+	if err != nil || n != 1 || a.Rejected() != 1 || errs != 1 {
+		t.Errorf("expected 1 accepted and 1 rejected, got n=%d rejected=%d errs=%d err=%v", n, a.Rejected(), errs, err)
+	}
+	if len(sink.snapshot()) != 1 {
+		t.Errorf("expected the valid record delivered, got %d", len(sink.snapshot()))
+	}
+}
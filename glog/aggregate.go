@@ -0,0 +1,76 @@
+package glog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+)
+
+var ErrPublisherCycle = errors.New("glogger: record forwarded in a cycle between services")
+
+type forwardedKey struct{}
+
+// Compile-time check that servicePublisher implements interfaces.LogPublisher.
+var _ interfaces.LogPublisher = (*servicePublisher)(nil)
+
+// servicePublisher feeds records into another service's pipeline.
+type servicePublisher struct {
+	target *LoggerService
+	// source is the service the publisher was added to; AddLogger registers
+	// a copy with source set.
+	source *LoggerService
+}
+
+// AsPublisher returns a publisher that feeds records into ls, so that a
+// library running its own service can be funneled into the host's sinks and
+// policies:
+//
+//	libService.AddLogger("host", hostService.AsPublisher())
+//
+// Records keep their context and fields and go through ls's processors,
+// pauses and send policy. A record that would reach a service it already
+// passed through is dropped and reported as ErrPublisherCycle. Stop the
+// embedded service before ls, or its last records are reported as logged
+// after Stop.
+func (ls *LoggerService) AsPublisher() interfaces.LogPublisher {
+	return &servicePublisher{target: ls}
+}
+
+func (p *servicePublisher) SendMsg(data *models.LogData) {
+	ctx := data.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	visited, _ := ctx.Value(forwardedKey{}).([]*LoggerService)
+	for _, svc := range visited {
+		if svc == p.target {
+			p.reportCycle(data)
+			return
+		}
+	}
+	if p.target == p.source {
+		p.reportCycle(data)
+		return
+	}
+
+	path := make([]*LoggerService, 0, len(visited)+2)
+	path = append(path, visited...)
+	if p.source != nil && len(visited) == 0 {
+		path = append(path, p.source)
+	}
+	path = append(path, p.target)
+
+	forwarded := data.Clone()
+	forwarded.Ctx = context.WithValue(ctx, forwardedKey{}, path)
+	(&Logger{logChan: p.target.inputCh, svc: p.target}).sendData(forwarded)
+}
+
+func (p *servicePublisher) reportCycle(data *models.LogData) {
+	handler := p.target.errorHandler
+	if p.source != nil {
+		handler = p.source.errorHandler
+	}
+	handler(fmt.Errorf("%w: %q", ErrPublisherCycle, data.Msg))
+}
@@ -0,0 +1,72 @@
+package glog
+
+import (
+	"context"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync"
+	"testing"
+)
+
+func TestAsPublisher_FunnelsIntoHost(t *testing.T) {
+	host := NewLoggerService(WithBlockingSend())
+	sink := &mockPublisher{logs: make([]*models.LogData, 0)}
+	host.AddLogger("sink", sink, WithProcessors(RedactFields("token")))
+	host.Start()
+
+	lib := NewLoggerService(WithBlockingSend())
+	lib.AddLogger("host", host.AsPublisher())
+	lib.Start()
+
+	lib.NewLogger().Info(context.Background(), "from library",
+		models.WithComponent("lib"),
+		models.WithStringField("token", "secret"))
+	lib.Stop()
+	host.Stop()
+
+	logs := sink.GetLogs()
+	if len(logs) != 1 || logs[0].Msg != "from library" {
+		t.Fatalf("expected the library record in the host sink, got %d", len(logs))
+	}
+	if f := fieldByKey(logs[0], "token"); f == nil || f.String != redactedValue {
+		t.Error("expected host processors to apply to forwarded records")
+	}
+}
+
+func TestAsPublisher_BreaksCycles(t *testing.T) {
+	var mu sync.Mutex
+	var cycles int
+	onError := func(err error) {
+		if errors.Is(err, ErrPublisherCycle) {
+			mu.Lock()
+			cycles++
+			mu.Unlock()
+		}
+	}
+	a := NewLoggerService(WithBlockingSend(), WithErrorHandler(onError))
+	b := NewLoggerService(WithBlockingSend(), WithErrorHandler(onError))
+	sinkA := &mockPublisher{logs: make([]*models.LogData, 0)}
+	sinkB := &mockPublisher{logs: make([]*models.LogData, 0)}
+	a.AddLogger("sink", sinkA)
+	b.AddLogger("sink", sinkB)
+	a.AddLogger("b", b.AsPublisher())
+	b.AddLogger("a", a.AsPublisher())
+	a.AddLogger("self", a.AsPublisher())
+	a.Start()
+	b.Start()
+
+	a.NewLogger().Info(context.Background(), "ping")
+	// Wait for the record to travel a -> b -> (a dropped) before stopping.
+	waitForLogs(sinkB, 1, defaultFlushTimeout)
+	a.Stop()
+	b.Stop()
+
+	if len(sinkA.GetLogs()) != 1 || len(sinkB.GetLogs()) != 1 {
+		t.Errorf("expected each sink to see the record once, got %d/%d", len(sinkA.GetLogs()), len(sinkB.GetLogs()))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if cycles != 2 {
+		t.Errorf("expected the self loop and the a->b->a loop reported, got %d", cycles)
+	}
+}
@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const accessComponent = "glogger.admin"
+
+var ErrUnauthenticated = errors.New("audit: unauthenticated")
+
+// Authenticator resolves the principal behind a request. Returning an error
+// rejects the request with 401 and emits a denied access record.
+type Authenticator func(r *http.Request) (principal string, err error)
+
+// TokenAuth accepts "Authorization: Bearer <token>" headers. tokens maps each
+// accepted token to the principal name recorded in the audit trail.
+func TokenAuth(tokens map[string]string) Authenticator {
+	return func(r *http.Request) (string, error) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			return "", ErrUnauthenticated
+		}
+		for candidate, principal := range tokens {
+			if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+				return principal, nil
+			}
+		}
+		return "", ErrUnauthenticated
+	}
+}
+
+// MTLSAuth accepts requests whose verified client certificate has one of the
+// given common names. With no names, any verified client certificate passes.
+func MTLSAuth(commonNames ...string) Authenticator {
+	return func(r *http.Request) (string, error) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.PeerCertificates) == 0 {
+			return "", ErrUnauthenticated
+		}
+		cn := r.TLS.PeerCertificates[0].Subject.CommonName
+		if len(commonNames) == 0 {
+			return cn, nil
+		}
+		for _, allowed := range commonNames {
+			if cn == allowed {
+				return cn, nil
+			}
+		}
+		return "", ErrUnauthenticated
+	}
+}
+
+type detailsKey struct{}
+
+// details collects what a handler behind Middleware reports with
+// AddDetails.
+type details struct {
+	mu   sync.Mutex
+	opts []models.Option
+}
+
+// AddDetails adds options, usually fields describing what a request
+// changed and the values it replaced, to the access record Middleware
+// writes for the request of ctx. Outside Middleware it does nothing.
+func AddDetails(ctx context.Context, opts ...models.Option) {
+	d, ok := ctx.Value(detailsKey{}).(*details)
+	if !ok {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.opts = append(d.opts, opts...)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Middleware authenticates every request to a control endpoint and emits an
+// audit record describing who did what, when and from where. Rejected
+// requests are recorded at WarnLevel. A nil auth lets every request through
+// as an anonymous principal, which should only be used behind another
+// authenticating proxy. Handlers add what they changed with AddDetails.
+// Pass a glog logger's Internal() so the records bypass the levels and
+// filters the audited requests may change.
+func Middleware(logger interfaces.Logger, auth Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		principal := "anonymous"
+		if auth != nil {
+			p, err := auth(r)
+			if err != nil {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				logger.Warning(r.Context(), "admin access denied",
+					accessFields(r, "", http.StatusUnauthorized, started, err)...)
+				return
+			}
+			principal = p
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		d := &details{}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), detailsKey{}, d)))
+
+		opts := accessFields(r, principal, rec.status, started, nil)
+		d.mu.Lock()
+		opts = append(opts, d.opts...)
+		d.mu.Unlock()
+		logger.Info(r.Context(), "admin access", opts...)
+	})
+}
+
+func accessFields(r *http.Request, principal string, status int, started time.Time, err error) []models.Option {
+	opts := []models.Option{
+		models.WithComponent(accessComponent),
+		models.WithStringField("actor", principal),
+		models.WithStringField("method", r.Method),
+		models.WithStringField("path", r.URL.RequestURI()),
+		models.WithStringField("remote_addr", r.RemoteAddr),
+		models.WithStringField("occurred_at", started.UTC().Format(time.RFC3339Nano)),
+		models.WithIntField("status", status),
+	}
+	if err != nil {
+		opts = append(opts, models.WithStringField(models.FieldErrKey, err.Error()))
+	}
+	return opts
+}
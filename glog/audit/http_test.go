@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type entry struct {
+	level models.LogLevel
+	msg   string
+	opts  *models.Options
+}
+
+// captureLogger implements interfaces.Logger and keeps resolved options.
+type captureLogger struct {
+	mu      sync.Mutex
+	entries []entry
+}
+
+func (c *captureLogger) add(level models.LogLevel, msg string, options []models.Option) {
+	opts := &models.Options{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, entry{level: level, msg: msg, opts: opts})
+}
+
+func (c *captureLogger) Error(_ context.Context, err error, options ...models.Option) {
+	c.add(models.ErrorLevel, err.Error(), options)
+}
+
+func (c *captureLogger) Errors(ctx context.Context, errs []error, options ...models.Option) {
+	for _, err := range errs {
+		c.Error(ctx, err, options...)
+	}
+}
+
+func (c *captureLogger) Info(_ context.Context, message string, options ...models.Option) {
+	c.add(models.InfoLevel, message, options)
+}
+
+func (c *captureLogger) Warning(_ context.Context, message string, options ...models.Option) {
+	c.add(models.WarnLevel, message, options)
+}
+
+func (c *captureLogger) Debug(_ context.Context, message string, options ...models.Option) {
+	c.add(models.DebugLevel, message, options)
+}
+
+func (c *captureLogger) Log(_ context.Context, level models.LogLevel, message string, options ...models.Option) {
+	c.add(level, message, options)
+}
+
+func fieldString(opts *models.Options, key string) string {
+	for _, f := range opts.GetFields() {
+		if f.Key == key {
+			return f.String
+		}
+	}
+	return ""
+}
+
+func TestMiddleware_TokenAuth(t *testing.T) {
+	logger := &captureLogger{}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	h := Middleware(logger, TokenAuth(map[string]string{"s3cret": "ops-bot"}), next)
+
+	req := httptest.NewRequest(http.MethodPut, "/level?value=debug", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if len(logger.entries) != 1 {
+		t.Fatalf("expected 1 audit record, got %d", len(logger.entries))
+	}
+	e := logger.entries[0]
+	if e.level != models.InfoLevel || fieldString(e.opts, "actor") != "ops-bot" {
+		t.Errorf("unexpected audit record: %+v", e)
+	}
+	if fieldString(e.opts, "path") != "/level?value=debug" || fieldString(e.opts, "method") != http.MethodPut {
+		t.Error("expected method and path in audit record")
+	}
+	if e.opts.GetComponent() != accessComponent {
+		t.Errorf("expected component %q, got %q", accessComponent, e.opts.GetComponent())
+	}
+}
+
+func TestMiddleware_Denied(t *testing.T) {
+	logger := &captureLogger{}
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+	h := Middleware(logger, TokenAuth(map[string]string{"s3cret": "ops-bot"}), next)
+
+	req := httptest.NewRequest(http.MethodPost, "/flush", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if called {
+		t.Error("handler must not run for rejected requests")
+	}
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rec.Code)
+	}
+	if len(logger.entries) != 1 || logger.entries[0].level != models.WarnLevel {
+		t.Fatalf("expected one denied audit record, got %+v", logger.entries)
+	}
+}
+
+func TestMTLSAuth_NoCertificate(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if _, err := MTLSAuth()(req); err == nil {
+		t.Error("expected plain HTTP request to be rejected")
+	}
+}
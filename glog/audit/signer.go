@@ -0,0 +1,332 @@
+// Package audit hardens audit records so consumers can trust them after they
+// pass through untrusted intermediaries (brokers, collectors, shared storage).
+package audit
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FieldSignatureKey = "audit_signature"
+	FieldKeyIDKey     = "audit_key_id"
+	// FieldTimeKey and FieldSeqKey carry the signed event time and sequence
+	// number. Sinks keep the record time at their own precision, if at all,
+	// so verifiers read these fields instead.
+	FieldTimeKey = "audit_time"
+	FieldSeqKey  = "audit_seq"
+
+	encryptedPrefix = "enc:v1:"
+)
+
+var (
+	ErrMissingSignature = errors.New("audit: record has no signature")
+	ErrInvalidSignature = errors.New("audit: signature does not match record")
+	ErrNotEncrypted     = errors.New("audit: field is not encrypted")
+)
+
+type Option func(*Signer)
+
+// WithKeyID attaches an identifier of the signing key to every record so
+// verifiers can pick the right public key after rotation.
+func WithKeyID(id string) Option {
+	return func(s *Signer) {
+		s.keyID = id
+	}
+}
+
+// WithEncryptedFields encrypts the values of the given field keys with
+// AES-GCM before signing. key must be 16, 24 or 32 bytes long.
+func WithEncryptedFields(key []byte, fieldKeys ...string) Option {
+	return func(s *Signer) {
+		s.encKey = key
+		for _, k := range fieldKeys {
+			s.encFields[k] = struct{}{}
+		}
+	}
+}
+
+// Compile-time check that Signer implements interfaces.LogPublisher.
+var _ interfaces.LogPublisher = (*Signer)(nil)
+
+// Signer wraps a publisher and adds a detached ed25519 signature to every
+// record, optionally encrypting designated fields first. The record handed to
+// the wrapped publisher is a copy, other publishers still see the original.
+type Signer struct {
+	next      interfaces.LogPublisher
+	key       ed25519.PrivateKey
+	keyID     string
+	encKey    []byte
+	encFields map[string]struct{}
+	aead      cipher.AEAD
+}
+
+func NewSigner(next interfaces.LogPublisher, key ed25519.PrivateKey, opts ...Option) (*Signer, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("audit: invalid ed25519 private key size %d", len(key))
+	}
+	s := &Signer{
+		next:      next,
+		key:       key,
+		encFields: make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if len(s.encFields) > 0 {
+		aead, err := newAEAD(s.encKey)
+		if err != nil {
+			return nil, err
+		}
+		s.aead = aead
+	}
+	return s, nil
+}
+
+func (s *Signer) SendMsg(data *models.LogData) {
+	record := data.Clone()
+
+	if s.aead != nil {
+		for _, f := range record.Fields {
+			if f == nil {
+				continue
+			}
+			if _, ok := s.encFields[f.Key]; ok {
+				s.encrypt(f)
+			}
+		}
+	}
+	if !record.Time.IsZero() {
+		record.Fields = append(record.Fields,
+			&models.LogField{Key: FieldTimeKey, Type: models.FieldTypeTime, Time: record.Time})
+	}
+	if record.Seq != 0 {
+		record.Fields = append(record.Fields,
+			&models.LogField{Key: FieldSeqKey, Type: models.FieldTypeUint64, Uint64: record.Seq})
+	}
+	if s.keyID != "" {
+		record.Fields = append(record.Fields,
+			&models.LogField{Key: FieldKeyIDKey, Type: models.FieldTypeString, String: s.keyID})
+	}
+
+	sig := ed25519.Sign(s.key, Canonical(record))
+	record.Fields = append(record.Fields, &models.LogField{
+		Key:    FieldSignatureKey,
+		Type:   models.FieldTypeString,
+		String: base64.StdEncoding.EncodeToString(sig),
+	})
+
+	s.next.SendMsg(record)
+}
+
+func (s *Signer) encrypt(f *models.LogField) {
+	plain := []byte(f.String)
+	if f.Type != models.FieldTypeString {
+		plain = fieldJSON(f)
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		// Never leak the plaintext when encryption is impossible.
+		*f = models.LogField{Key: f.Key, Type: models.FieldTypeString, String: encryptedPrefix + "unavailable"}
+		return
+	}
+	sealed := s.aead.Seal(nonce, nonce, plain, []byte(f.Key))
+	*f = models.LogField{
+		Key:    f.Key,
+		Type:   models.FieldTypeString,
+		String: encryptedPrefix + base64.StdEncoding.EncodeToString(sealed),
+	}
+}
+
+// Verify checks the signature of a record produced by Signer.
+func Verify(data *models.LogData, pub ed25519.PublicKey) error {
+	var sig string
+	fields := make([]element, 0, len(data.Fields))
+	for _, f := range data.Fields {
+		if f == nil {
+			continue
+		}
+		if f.Key == FieldSignatureKey {
+			sig = f.String
+			continue
+		}
+		fields = append(fields, element{key: f.Key, value: normalize(fieldJSON(f))})
+	}
+	return verify(canonical(data.Level.String(), data.Msg, fields), sig, pub)
+}
+
+// VerifyJSON checks the signature of a record read back from a JSON sink,
+// written by encoder.NewJSON with the default output keys and payload.
+func VerifyJSON(record []byte, pub ed25519.PublicKey) error {
+	var line struct {
+		Level   string                     `json:"level"`
+		Msg     string                     `json:"msg"`
+		Payload map[string]json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(record, &line); err != nil {
+		return fmt.Errorf("audit: decode record: %w", err)
+	}
+	var sig string
+	fields := make([]element, 0, len(line.Payload))
+	for k, v := range line.Payload {
+		if k == FieldSignatureKey {
+			if err := json.Unmarshal(v, &sig); err != nil {
+				return fmt.Errorf("audit: decode signature: %w", err)
+			}
+			continue
+		}
+		fields = append(fields, element{key: k, value: normalize(v)})
+	}
+	return verify(canonical(line.Level, line.Msg, fields), sig, pub)
+}
+
+func verify(msg []byte, sig string, pub ed25519.PublicKey) error {
+	if sig == "" {
+		return ErrMissingSignature
+	}
+	raw, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("audit: decode signature: %w", err)
+	}
+	if !ed25519.Verify(pub, msg, raw) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Decrypt returns the plaintext of a field encrypted by Signer. Non-string
+// values come back in their JSON representation.
+func Decrypt(f *models.LogField, key []byte) (string, error) {
+	if f == nil || f.Type != models.FieldTypeString || !strings.HasPrefix(f.String, encryptedPrefix) {
+		return "", ErrNotEncrypted
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(f.String, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("audit: decode field %q: %w", f.Key, err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("audit: field %q is truncated", f.Key)
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(f.Key))
+	if err != nil {
+		return "", fmt.Errorf("audit: decrypt field %q: %w", f.Key, err)
+	}
+	return string(plain), nil
+}
+
+// Canonical returns the byte representation that is signed: level, message
+// and fields sorted by key, each element length-prefixed so that no two
+// different records share an encoding. Field values are signed as the JSON
+// the JSON encoder writes, with object keys sorted, so a record keeps its
+// signature after a round trip through a JSON sink. The event time and
+// sequence number are signed through the FieldTimeKey and FieldSeqKey fields
+// that Signer adds.
+func Canonical(data *models.LogData) []byte {
+	fields := make([]element, 0, len(data.Fields))
+	for _, f := range data.Fields {
+		if f != nil {
+			fields = append(fields, element{key: f.Key, value: normalize(fieldJSON(f))})
+		}
+	}
+	return canonical(data.Level.String(), data.Msg, fields)
+}
+
+type element struct {
+	key   string
+	value []byte
+}
+
+func canonical(level, msg string, fields []element) []byte {
+	var buf bytes.Buffer
+	writeElem(&buf, []byte(level))
+	writeElem(&buf, []byte(msg))
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].key < fields[j].key })
+	for _, f := range fields {
+		writeElem(&buf, []byte(f.key))
+		writeElem(&buf, f.value)
+	}
+	return buf.Bytes()
+}
+
+func writeElem(buf *bytes.Buffer, b []byte) {
+	var n [binary.MaxVarintLen64]byte
+	buf.Write(n[:binary.PutUvarint(n[:], uint64(len(b)))])
+	buf.Write(b)
+}
+
+// fieldJSON encodes a field value as encoder.JSON does.
+func fieldJSON(f *models.LogField) []byte {
+	var v any
+	switch f.Type {
+	case models.FieldTypeString:
+		v = f.String
+	case models.FieldTypeInt:
+		return strconv.AppendInt(nil, int64(f.Integer), 10)
+	case models.FieldTypeFloat:
+		v = f.Float
+	case models.FieldTypeBool:
+		return strconv.AppendBool(nil, f.Bool)
+	case models.FieldTypeInt64:
+		return strconv.AppendInt(nil, f.Int64, 10)
+	case models.FieldTypeUint64:
+		return strconv.AppendUint(nil, f.Uint64, 10)
+	case models.FieldTypeBinary:
+		v = base64.StdEncoding.EncodeToString(f.Binary)
+	case models.FieldTypeDuration:
+		v = f.Duration.String()
+	case models.FieldTypeTime:
+		v = f.Time.Format(time.RFC3339Nano)
+	default:
+		// safejson is deterministic and replaces cycles and excessive
+		// depth with placeholders, so any object can be signed.
+		v = f.Object
+	}
+	b, _ := safejson.Marshal(v)
+	return b
+}
+
+// normalize re-encodes JSON compactly with object keys sorted, without HTML
+// escaping and with numbers kept as written, so that the same value read
+// back from a sink compares equal.
+func normalize(raw []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return raw
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return raw
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("audit: invalid encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
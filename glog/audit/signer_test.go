@@ -0,0 +1,229 @@
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+	"time"
+)
+
+type capturePublisher struct {
+	last *models.LogData
+}
+
+func (c *capturePublisher) SendMsg(data *models.LogData) {
+	c.last = data
+}
+
+func newAuditRecord() *models.LogData {
+	return &models.LogData{
+		Ctx:   context.Background(),
+		Msg:   "user role changed",
+		Level: models.InfoLevel,
+		Seq:   42,
+		Time:  time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Fields: []*models.LogField{
+			{Key: "actor", Type: models.FieldTypeString, String: "alice"},
+			{Key: "ssn", Type: models.FieldTypeString, String: "123-45-6789"},
+			{Key: "role_id", Type: models.FieldTypeInt, Integer: 7},
+		},
+	}
+}
+
+func findField(data *models.LogData, key string) *models.LogField {
+	for _, f := range data.Fields {
+		if f.Key == key {
+			return f
+		}
+	}
+	return nil
+}
+
+func TestSigner_SignAndVerify(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	capture := &capturePublisher{}
+	signer, err := NewSigner(capture, priv, WithKeyID("k1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	original := newAuditRecord()
+	signer.SendMsg(original)
+
+	if findField(original, FieldSignatureKey) != nil {
+		t.Error("signer must not mutate the shared record")
+	}
+	if err := Verify(capture.last, pub); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+	if f := findField(capture.last, FieldKeyIDKey); f == nil || f.String != "k1" {
+		t.Error("expected key id field")
+	}
+
+	findField(capture.last, "actor").String = "mallory"
+	if err := Verify(capture.last, pub); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected tampering to be detected, got %v", err)
+	}
+}
+
+func TestSigner_EncryptedFields(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	encKey := make([]byte, 32)
+	capture := &capturePublisher{}
+	signer, err := NewSigner(capture, priv, WithEncryptedFields(encKey, "ssn", "role_id"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	signer.SendMsg(newAuditRecord())
+
+	if err := Verify(capture.last, pub); err != nil {
+		t.Fatalf("expected valid signature over encrypted record, got %v", err)
+	}
+
+	ssn := findField(capture.last, "ssn")
+	if ssn.String == "123-45-6789" {
+		t.Fatal("expected ssn to be encrypted")
+	}
+	plain, err := Decrypt(ssn, encKey)
+	if err != nil || plain != "123-45-6789" {
+		t.Errorf("expected decrypted ssn, got %q (%v)", plain, err)
+	}
+
+	roleID, err := Decrypt(findField(capture.last, "role_id"), encKey)
+	if err != nil || roleID != "7" {
+		t.Errorf("expected decrypted role_id 7, got %q (%v)", roleID, err)
+	}
+
+	if _, err := Decrypt(findField(capture.last, "actor"), encKey); !errors.Is(err, ErrNotEncrypted) {
+		t.Errorf("expected ErrNotEncrypted for plain field, got %v", err)
+	}
+}
+
+func TestNewSigner_InvalidKeys(t *testing.T) {
+	if _, err := NewSigner(&capturePublisher{}, ed25519.PrivateKey("short")); err == nil {
+		t.Error("expected error for invalid signing key")
+	}
+
+	_, priv, _ := ed25519.GenerateKey(nil)
+	if _, err := NewSigner(&capturePublisher{}, priv, WithEncryptedFields([]byte("bad"), "ssn")); err == nil {
+		t.Error("expected error for invalid encryption key")
+	}
+}
+
+func TestVerify_MissingSignature(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	if err := Verify(newAuditRecord(), pub); !errors.Is(err, ErrMissingSignature) {
+		t.Errorf("expected ErrMissingSignature, got %v", err)
+	}
+}
+
+func TestVerify_TamperedTimeAndSeq(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	for name, tamper := range map[string]func(*models.LogData){
+		"backdated": func(d *models.LogData) {
+			f := findField(d, FieldTimeKey)
+			f.Time = f.Time.Add(-time.Hour)
+		},
+		"time removed": func(d *models.LogData) {
+			*findField(d, FieldTimeKey) = models.LogField{Key: "other"}
+		},
+		"reordered": func(d *models.LogData) { findField(d, FieldSeqKey).Uint64 = 41 },
+	} {
+		capture := &capturePublisher{}
+		signer, err := NewSigner(capture, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signer.SendMsg(newAuditRecord())
+		if err := Verify(capture.last, pub); err != nil {
+			t.Fatalf("%s: expected valid signature, got %v", name, err)
+		}
+		tamper(capture.last)
+		if err := Verify(capture.last, pub); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: expected ErrInvalidSignature, got %v", name, err)
+		}
+	}
+}
+
+func TestVerifyJSON_RoundTrip(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	capture := &capturePublisher{}
+	signer, err := NewSigner(capture, priv, WithKeyID("k1"), WithEncryptedFields(make([]byte, 32), "ssn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := newAuditRecord()
+	record.Time = record.Time.Add(123456789 * time.Nanosecond)
+	record.Fields = append(record.Fields,
+		&models.LogField{Key: "ratio", Type: models.FieldTypeFloat, Float: 0.25},
+		&models.LogField{Key: "took", Type: models.FieldTypeDuration, Duration: 1500 * time.Millisecond},
+		&models.LogField{Key: "query", Type: models.FieldTypeObject, Object: map[string]any{"q": "<a&b>", "limit": 10}},
+	)
+	signer.SendMsg(record)
+
+	// The JSON encoder keeps the record time at second precision and has no
+	// sequence number; the signature must not depend on either.
+	line, err := encoder.NewJSON("app", "prod").Encode(capture.last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyJSON(line, pub); err != nil {
+		t.Fatalf("expected the JSON record to verify, got %v\n%s", err, line)
+	}
+
+	// A sink that re-encodes the document, reordering keys, must not matter.
+	var doc map[string]any
+	if err := json.Unmarshal(line, &doc); err != nil {
+		t.Fatal(err)
+	}
+	reencoded, _ := json.Marshal(doc)
+	if err := VerifyJSON(reencoded, pub); err != nil {
+		t.Fatalf("expected the re-encoded record to verify, got %v\n%s", err, reencoded)
+	}
+
+	payload := doc["payload"].(map[string]any)
+	for name, tamper := range map[string]func(){
+		"backdated": func() { payload[FieldTimeKey] = "2026-03-01T11:00:00.123456789Z" },
+		"reordered": func() { payload[FieldSeqKey] = 41 },
+		"retyped":   func() { payload["role_id"] = "7" },
+	} {
+		saved := make(map[string]any, len(payload))
+		for k, v := range payload {
+			saved[k] = v
+		}
+		tamper()
+		tampered, _ := json.Marshal(doc)
+		if err := VerifyJSON(tampered, pub); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: expected ErrInvalidSignature, got %v", name, err)
+		}
+		for k, v := range saved {
+			payload[k] = v
+		}
+	}
+}
+
+func TestSigner_CyclicObject(t *testing.T) {
+	type node struct {
+		Name   string
+		Parent *node
+	}
+	n := &node{Name: "root"}
+	n.Parent = n
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	capture := &capturePublisher{}
+	signer, err := NewSigner(capture, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := newAuditRecord()
+	record.Fields = append(record.Fields, &models.LogField{Key: "entity", Type: models.FieldTypeObject, Object: n})
+	signer.SendMsg(record)
+	if err := Verify(capture.last, pub); err != nil {
+		t.Errorf("expected a record with a cyclic object to verify, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package glog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const bootstrapComponent = "glogger.bootstrap"
+
+// Compile-time check that BootstrapLogger implements interfaces.Logger.
+var _ interfaces.Logger = (*BootstrapLogger)(nil)
+
+// BootstrapLogger reports problems that happen before (or instead of) a
+// working pipeline: publisher construction failures, bad credentials,
+// unreachable endpoints. It writes one JSON object per line synchronously,
+// with no buffering, workers or publishers that could themselves fail.
+type BootstrapLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+var (
+	bootstrapOnce   sync.Once
+	bootstrapLogger *BootstrapLogger
+)
+
+// Bootstrap returns the process-wide bootstrap logger writing to stderr.
+func Bootstrap() *BootstrapLogger {
+	bootstrapOnce.Do(func() {
+		bootstrapLogger = NewBootstrapLogger(os.Stderr)
+	})
+	return bootstrapLogger
+}
+
+func NewBootstrapLogger(w io.Writer) *BootstrapLogger {
+	return &BootstrapLogger{w: w}
+}
+
+func (b *BootstrapLogger) Error(ctx context.Context, err error, options ...models.Option) {
+	b.write(ctx, models.ErrorLevel, err.Error(), options)
+}
+
+func (b *BootstrapLogger) Errors(ctx context.Context, errs []error, options ...models.Option) {
+	for _, err := range errs {
+		b.Error(ctx, err, options...)
+	}
+}
+
+func (b *BootstrapLogger) Info(ctx context.Context, message string, options ...models.Option) {
+	b.write(ctx, models.InfoLevel, message, options)
+}
+
+func (b *BootstrapLogger) Warning(ctx context.Context, message string, options ...models.Option) {
+	b.write(ctx, models.WarnLevel, message, options)
+}
+
+func (b *BootstrapLogger) Debug(ctx context.Context, message string, options ...models.Option) {
+	b.write(ctx, models.DebugLevel, message, options)
+}
+
+func (b *BootstrapLogger) Log(ctx context.Context, level models.LogLevel, message string, options ...models.Option) {
+	b.write(ctx, level, message, options)
+}
+
+// SendMsg lets the bootstrap logger act as a last-resort publisher.
+func (b *BootstrapLogger) SendMsg(data *models.LogData) {
+	b.writeRecord(data.Time, data.Level, data.Msg, data.Fields)
+}
+
+func (b *BootstrapLogger) write(_ context.Context, level models.LogLevel, msg string, options []models.Option) {
+	opts := &models.Options{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	fields := opts.GetFields()
+	component := opts.GetComponent()
+	if component == "" {
+		component = bootstrapComponent
+	}
+	fields = append(fields, &models.LogField{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: component})
+	b.writeRecord(opts.GetTimestamp(), level, msg, fields)
+}
+
+func (b *BootstrapLogger) writeRecord(ts time.Time, level models.LogLevel, msg string, fields []*models.LogField) {
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	record := map[string]any{
+		"timestamp": ts.UTC().Format(time.RFC3339Nano),
+		"level":     level.String(),
+		"msg":       msg,
+	}
+	if len(fields) > 0 {
+		payload := make(map[string]any, len(fields))
+		for _, f := range fields {
+			if f == nil {
+				continue
+			}
+			payload[f.Key] = bootstrapFieldValue(f)
+		}
+		record["payload"] = payload
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(record); err != nil {
+		buf.Reset()
+		fmt.Fprintf(&buf, "{\"level\":%q,\"msg\":%q,\"bootstrap_error\":%q}\n", level.String(), msg, err.Error())
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, _ = b.w.Write(buf.Bytes())
+}
+
+func bootstrapFieldValue(f *models.LogField) any {
+	switch f.Type {
+	case models.FieldTypeString:
+		return f.String
+	case models.FieldTypeInt:
+		return f.Integer
+	case models.FieldTypeFloat:
+		return f.Float
+	case models.FieldTypeBool:
+		return f.Bool
+	case models.FieldTypeInt64:
+		return f.Int64
+	case models.FieldTypeUint64:
+		return f.Uint64
+	case models.FieldTypeBinary:
+		return f.Binary
+	case models.FieldTypeDuration:
+		return f.Duration.String()
+	case models.FieldTypeTime:
+		return f.Time.Format(time.RFC3339Nano)
+	default:
+		b, _ := safejson.Marshal(f.Object)
+		return json.RawMessage(b)
+	}
+}
@@ -0,0 +1,53 @@
+package glog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+	"testing"
+)
+
+func TestBootstrapLogger_StructuredLines(t *testing.T) {
+	var buf bytes.Buffer
+	b := NewBootstrapLogger(&buf)
+
+	b.Error(context.Background(), errors.New("dial loki: connection refused"),
+		models.WithStringField("publisher", "loki"))
+	b.Info(context.Background(), "falling back to stdout", models.WithComponent("config"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+
+	var first struct {
+		Level   string         `json:"level"`
+		Msg     string         `json:"msg"`
+		Payload map[string]any `json:"payload"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("invalid JSON line: %v", err)
+	}
+	if first.Level != "error" || first.Msg != "dial loki: connection refused" {
+		t.Errorf("unexpected record: %+v", first)
+	}
+	if first.Payload["publisher"] != "loki" || first.Payload[models.FieldComponentKey] != bootstrapComponent {
+		t.Errorf("unexpected payload: %v", first.Payload)
+	}
+	if !strings.Contains(lines[1], `"component":"config"`) {
+		t.Errorf("expected explicit component to win: %s", lines[1])
+	}
+}
+
+func TestBootstrapLogger_UnserializableObject(t *testing.T) {
+	var buf bytes.Buffer
+	b := NewBootstrapLogger(&buf)
+	b.Warning(context.Background(), "odd value", models.WithObjectField("ch", make(chan int)))
+
+	if !strings.Contains(buf.String(), `"ch":"<unserializable: chan int>"`) {
+		t.Errorf("expected placeholder for unserializable object: %q", buf.String())
+	}
+}
@@ -0,0 +1,72 @@
+package glog
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+)
+
+// BridgeDefaults are the minimum dimensions every record from a bridge (a
+// record whose models.FieldSourceKey starts with models.SourceBridgePrefix)
+// is given, so that slog, logr or std log output stays queryable by the same
+// app, env and component as records from a glog Logger.
+type BridgeDefaults struct {
+	// AppID and Env are set on the record context when it has no
+	// models.AppID or models.EnvName.
+	AppID string
+	Env   string
+	// Component is added when the record has none. Empty means the bridge
+	// name, e.g. "slog" for source "bridge/slog".
+	Component string
+}
+
+// WithBridgeDefaults fills missing app, env and component on bridge records.
+// Without it, bridge records only get their component filled.
+func WithBridgeDefaults(defaults BridgeDefaults) ServiceOption {
+	return func(ls *LoggerService) {
+		ls.bridgeDefaults = defaults
+	}
+}
+
+// fillBridgeDefaults runs after normalize. It replaces Ctx and Fields rather
+// than changing them in place, because the bridge may still hold them.
+func (ls *LoggerService) fillBridgeDefaults(logData *models.LogData) {
+	var source string
+	hasComponent := false
+	for _, f := range logData.Fields {
+		if f.Type != models.FieldTypeString {
+			continue
+		}
+		switch f.Key {
+		case models.FieldSourceKey:
+			source = f.String
+		case models.FieldComponentKey:
+			hasComponent = true
+		}
+	}
+	bridge, ok := strings.CutPrefix(source, models.SourceBridgePrefix)
+	if !ok {
+		return
+	}
+
+	d := ls.bridgeDefaults
+	if d.AppID != "" {
+		if appID, _ := logData.Ctx.Value(models.AppID).(string); appID == "" {
+			logData.Ctx = context.WithValue(logData.Ctx, models.AppID, d.AppID)
+		}
+	}
+	if d.Env != "" {
+		if env, _ := logData.Ctx.Value(models.EnvName).(string); env == "" {
+			logData.Ctx = context.WithValue(logData.Ctx, models.EnvName, d.Env)
+		}
+	}
+	if !hasComponent {
+		component := d.Component
+		if component == "" {
+			component = bridge
+		}
+		fields := make([]*models.LogField, len(logData.Fields), len(logData.Fields)+1)
+		copy(fields, logData.Fields)
+		logData.Fields = append(fields, &models.LogField{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: component})
+	}
+}
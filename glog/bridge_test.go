@@ -0,0 +1,62 @@
+package glog
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+)
+
+func TestBridgeDefaults_FillMissingDimensions(t *testing.T) {
+	ls := NewLoggerService(WithBlockingSend(), WithBridgeDefaults(BridgeDefaults{AppID: "shop", Env: "prod"}))
+	mock := &mockPublisher{logs: make([]*models.LogData, 0)}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+
+	sourceField := &models.LogField{Key: models.FieldSourceKey, Type: models.FieldTypeString, String: "bridge/slog"}
+	fields := []*models.LogField{sourceField}
+	ls.GetInputChan() <- &models.LogData{Msg: "from slog", Level: models.InfoLevel, Fields: fields}
+
+	ctx := context.WithValue(context.Background(), models.EnvName, "staging")
+	ls.NewLogger().Info(ctx, "native", models.WithComponent("api"))
+	ls.Stop()
+
+	logs := mock.GetLogs()
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(logs))
+	}
+	for _, data := range logs {
+		switch data.Msg {
+		case "from slog":
+			if data.Ctx.Value(models.AppID) != "shop" || data.Ctx.Value(models.EnvName) != "prod" {
+				t.Error("expected app and env defaults on the bridge record")
+			}
+			if f := fieldByKey(data, models.FieldComponentKey); f == nil || f.String != "slog" {
+				t.Errorf("expected component to default to the bridge name, got %+v", f)
+			}
+		case "native":
+			if data.Ctx.Value(models.AppID) != nil || data.Ctx.Value(models.EnvName) != "staging" {
+				t.Error("records from a Logger must not get bridge defaults")
+			}
+		}
+	}
+	if len(fields) != 1 || cap(fields) != 1 {
+		t.Error("the bridge's Fields slice must not be modified")
+	}
+}
+
+func TestBridgeDefaults_KeepExistingValues(t *testing.T) {
+	ls := NewLoggerService(WithBridgeDefaults(BridgeDefaults{AppID: "shop", Env: "prod", Component: "legacy"}))
+	ctx := context.WithValue(context.Background(), models.AppID, "billing")
+	data := &models.LogData{Ctx: ctx, Fields: []*models.LogField{
+		{Key: models.FieldSourceKey, Type: models.FieldTypeString, String: "bridge/stdlog"},
+		{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: "jobs"},
+	}}
+	ls.fillBridgeDefaults(data)
+
+	if data.Ctx.Value(models.AppID) != "billing" || data.Ctx.Value(models.EnvName) != "prod" {
+		t.Error("expected existing app kept and missing env filled")
+	}
+	if len(data.Fields) != 2 || fieldByKey(data, models.FieldComponentKey).String != "jobs" {
+		t.Error("expected existing component kept")
+	}
+}
@@ -0,0 +1,161 @@
+package glog
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+const redactedValue = "[REDACTED]"
+
+// secretKeyPattern matches config keys whose values never leave the process.
+var secretKeyPattern = regexp.MustCompile(`(?i)(secret|password|passwd|token|api[_-]?key|credential|private[_-]?key|dsn|auth)`)
+
+type bundleOptions struct {
+	service *LoggerService
+	logs    fs.FS
+	config  any
+}
+
+// BundleOption configures DumpSupportBundle.
+type BundleOption func(*bundleOptions)
+
+// WithBundleService includes the service pipeline stats.
+func WithBundleService(ls *LoggerService) BundleOption {
+	return func(o *bundleOptions) {
+		o.service = ls
+	}
+}
+
+// WithBundleLogs includes every file of fsys under logs/ (see the logfs
+// package for a view over the ring buffer or the SQLite store).
+func WithBundleLogs(fsys fs.FS) BundleOption {
+	return func(o *bundleOptions) {
+		o.logs = fsys
+	}
+}
+
+// WithBundleConfig includes a JSON snapshot of cfg with secret-looking keys
+// redacted.
+func WithBundleConfig(cfg any) BundleOption {
+	return func(o *bundleOptions) {
+		o.config = cfg
+	}
+}
+
+// DumpSupportBundle writes a zip archive with build info and, depending on
+// the options, pipeline stats, a redacted config snapshot and recent logs.
+func DumpSupportBundle(ctx context.Context, w io.Writer, opts ...BundleOption) error {
+	o := &bundleOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	zw := zip.NewWriter(w)
+	if err := writeZipFile(zw, "buildinfo.txt", []byte(buildInfo())); err != nil {
+		return err
+	}
+	if o.service != nil {
+		stats, err := json.MarshalIndent(o.service.Stats(), "", "  ")
+		if err != nil {
+			return fmt.Errorf("glogger: marshal stats: %w", err)
+		}
+		if err := writeZipFile(zw, "stats.json", stats); err != nil {
+			return err
+		}
+	}
+	if o.config != nil {
+		cfg, err := redactedJSON(o.config)
+		if err != nil {
+			return fmt.Errorf("glogger: marshal config: %w", err)
+		}
+		if err := writeZipFile(zw, "config.json", cfg); err != nil {
+			return err
+		}
+	}
+	if o.logs != nil {
+		err := fs.WalkDir(o.logs, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if d.IsDir() {
+				return nil
+			}
+			content, err := fs.ReadFile(o.logs, name)
+			if err != nil {
+				return err
+			}
+			return writeZipFile(zw, path.Join("logs", name), content)
+		})
+		if err != nil {
+			return fmt.Errorf("glogger: collect logs: %w", err)
+		}
+	}
+	return zw.Close()
+}
+
+func writeZipFile(zw *zip.Writer, name string, content []byte) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return fmt.Errorf("glogger: create %s: %w", name, err)
+	}
+	if _, err := f.Write(content); err != nil {
+		return fmt.Errorf("glogger: write %s: %w", name, err)
+	}
+	return nil
+}
+
+func buildInfo() string {
+	info := fmt.Sprintf("go: %s\nos/arch: %s/%s\ncpus: %d\ngenerated: %s\n",
+		runtime.Version(), runtime.GOOS, runtime.GOARCH, runtime.NumCPU(), time.Now().UTC().Format(time.RFC3339))
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info += "\n" + bi.String()
+	}
+	return info
+}
+
+// redactedJSON round-trips cfg through JSON so that redaction works on any
+// struct or map without reflection on the caller's types.
+func redactedJSON(cfg any) ([]byte, error) {
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(redact(generic), "", "  ")
+}
+
+func redact(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			if secretKeyPattern.MatchString(k) {
+				val[k] = redactedValue
+				continue
+			}
+			val[k] = redact(child)
+		}
+		return val
+	case []any:
+		for i, child := range val {
+			val[i] = redact(child)
+		}
+		return val
+	default:
+		return v
+	}
+}
@@ -0,0 +1,86 @@
+package glog
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestDumpSupportBundle(t *testing.T) {
+	_, _, service := setupTestLogger()
+	defer service.Stop()
+
+	cfg := map[string]any{
+		"publishers": []any{
+			map[string]any{"type": "loki", "url": "http://loki:3100", "api_key": "abc"},
+		},
+		"password": "hunter2",
+		"workers":  4,
+	}
+	logs := fstest.MapFS{"all.ndjson": {Data: []byte("{\"msg\":\"hello\"}\n")}}
+
+	var buf bytes.Buffer
+	err := DumpSupportBundle(context.Background(), &buf,
+		WithBundleService(service),
+		WithBundleConfig(cfg),
+		WithBundleLogs(logs))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	files := readZip(t, buf.Bytes())
+	if !strings.Contains(files["buildinfo.txt"], "go: ") {
+		t.Error("expected build info")
+	}
+	if files["logs/all.ndjson"] != "{\"msg\":\"hello\"}\n" {
+		t.Errorf("unexpected logs content: %q", files["logs/all.ndjson"])
+	}
+
+	var stats ServiceStats
+	if err := json.Unmarshal([]byte(files["stats.json"]), &stats); err != nil || stats.Publishers != 1 {
+		t.Errorf("unexpected stats %q (%v)", files["stats.json"], err)
+	}
+
+	config := files["config.json"]
+	if strings.Contains(config, "hunter2") || strings.Contains(config, "abc") {
+		t.Errorf("secrets leaked into bundle: %s", config)
+	}
+	if !strings.Contains(config, "http://loki:3100") {
+		t.Errorf("expected non-secret values to be kept: %s", config)
+	}
+}
+
+func TestDumpSupportBundle_Minimal(t *testing.T) {
+	var buf bytes.Buffer
+	if err := DumpSupportBundle(context.Background(), &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	files := readZip(t, buf.Bytes())
+	if len(files) != 1 {
+		t.Errorf("expected only build info, got %d files", len(files))
+	}
+}
@@ -0,0 +1,181 @@
+package glog
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	FieldCanonicalSuppressedKey = "suppressed_records"
+	FieldHTTPMethodKey          = "http_method"
+	FieldHTTPPathKey            = "http_path"
+	FieldHTTPStatusKey          = "http_status"
+	FieldHTTPPanicKey           = "http_panic"
+)
+
+type canonicalKey struct{}
+
+// canonicalLine accumulates fields for the single summary record of a unit
+// of work (usually an HTTP request).
+type canonicalLine struct {
+	mu         sync.Mutex
+	options    []models.Option
+	suppress   bool
+	suppressed int
+	emitted    bool
+}
+
+// CanonicalOption configures StartCanonical and CanonicalMiddleware.
+type CanonicalOption func(*canonicalLine)
+
+// WithSuppressInfo drops Info and Debug records logged with the canonical
+// context by a glog Logger; the summary reports how many were
+// dropped. Warnings and errors are always written.
+func WithSuppressInfo() CanonicalOption {
+	return func(c *canonicalLine) {
+		c.suppress = true
+	}
+}
+
+// StartCanonical returns a context that collects fields added with
+// AddToCanonical until EmitCanonical writes them as one record.
+func StartCanonical(ctx context.Context, opts ...CanonicalOption) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	c := &canonicalLine{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return context.WithValue(ctx, canonicalKey{}, c)
+}
+
+// AddToCanonical adds fields to the canonical line of ctx. It is a no-op when
+// ctx has none, so library code can call it unconditionally.
+func AddToCanonical(ctx context.Context, options ...models.Option) {
+	c := canonicalFrom(ctx)
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.options = append(c.options, options...)
+}
+
+// EmitCanonical writes the canonical line of ctx at the given level with the
+// accumulated fields followed by options. Only the first call emits.
+func EmitCanonical(ctx context.Context, logger interfaces.Logger, level models.LogLevel, message string, options ...models.Option) {
+	c := canonicalFrom(ctx)
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	if c.emitted {
+		c.mu.Unlock()
+		return
+	}
+	c.emitted = true
+	all := append(append([]models.Option{}, c.options...), options...)
+	if c.suppressed > 0 {
+		all = append(all, models.WithIntField(FieldCanonicalSuppressedKey, c.suppressed))
+	}
+	c.mu.Unlock()
+
+	logger.Log(ctx, level, message, all...)
+}
+
+// CanonicalMiddleware emits one summary record per request with method,
+// path, status and duration plus everything handlers added with
+// AddToCanonical. Requests answered with a 5xx status are logged as errors.
+// When the handler panics, the record has status 500 and the panic value,
+// and the panic continues once it is written.
+func CanonicalMiddleware(logger interfaces.Logger, next http.Handler, opts ...CanonicalOption) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		ctx := StartCanonical(r.Context(), opts...)
+		rec := &canonicalRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		defer func() {
+			status := rec.status
+			var extra []models.Option
+			p := recover()
+			if p != nil {
+				status = http.StatusInternalServerError
+				extra = append(extra, models.WithStringField(FieldHTTPPanicKey, fmt.Sprint(p)))
+			}
+			level := models.InfoLevel
+			if status >= http.StatusInternalServerError {
+				level = models.ErrorLevel
+			}
+			EmitCanonical(ctx, logger, level, "canonical-log-line", append([]models.Option{
+				models.WithStringField(FieldHTTPMethodKey, r.Method),
+				models.WithStringField(FieldHTTPPathKey, r.URL.Path),
+				models.WithIntField(FieldHTTPStatusKey, status),
+				durationField(time.Since(started)),
+			}, extra...)...)
+			if p != nil {
+				panic(p)
+			}
+		}()
+		next.ServeHTTP(rec, r.WithContext(ctx))
+	})
+}
+
+type canonicalRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *canonicalRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *canonicalRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Flush and Hijack pass through for handlers that type-assert
+// http.Flusher and http.Hijacker, such as SSE and websocket handlers.
+func (r *canonicalRecorder) Flush() {
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+func (r *canonicalRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(r.ResponseWriter).Hijack()
+}
+
+func canonicalFrom(ctx context.Context) *canonicalLine {
+	if ctx == nil {
+		return nil
+	}
+	c, _ := ctx.Value(canonicalKey{}).(*canonicalLine)
+	return c
+}
+
+// suppressedByCanonical reports whether a record at level should be dropped
+// in favour of the canonical line of ctx, counting it if so.
+func suppressedByCanonical(ctx context.Context, level models.LogLevel) bool {
+	if level > models.InfoLevel {
+		return false
+	}
+	c := canonicalFrom(ctx)
+	if c == nil || !c.suppress {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.emitted {
+		return false
+	}
+	c.suppressed++
+	return true
+}
@@ -0,0 +1,162 @@
+package glog
+
+import (
+	"bufio"
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCanonicalMiddleware_SingleSummaryRecord(t *testing.T) {
+	ls := NewLoggerService(WithBlockingSend())
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+	logger := ls.NewLogger()
+
+	handler := CanonicalMiddleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Info(r.Context(), "loading cart")
+		AddToCanonical(r.Context(), models.WithStringField("user_id", "u-42"))
+		logger.Warning(r.Context(), "slow inventory lookup")
+		AddToCanonical(r.Context(), models.WithIntField("cart_items", 3))
+		w.WriteHeader(http.StatusCreated)
+	}), WithSuppressInfo())
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/checkout", nil))
+	ls.Stop()
+
+	logs := mock.GetLogs()
+	if len(logs) != 2 {
+		t.Fatalf("expected warning and canonical line, got %d records", len(logs))
+	}
+	var summary *models.LogData
+	for _, log := range logs {
+		if log.Msg == "canonical-log-line" {
+			summary = log
+		}
+	}
+	if summary == nil {
+		t.Fatal("expected canonical line")
+	}
+	if summary.Level != models.InfoLevel {
+		t.Errorf("expected info level, got %v", summary.Level)
+	}
+	if f := fieldByKey(summary, "user_id"); f == nil || f.String != "u-42" {
+		t.Error("expected accumulated user_id")
+	}
+	if f := fieldByKey(summary, "cart_items"); f == nil || f.Integer != 3 {
+		t.Error("expected accumulated cart_items")
+	}
+	if f := fieldByKey(summary, FieldHTTPStatusKey); f == nil || f.Integer != http.StatusCreated {
+		t.Error("expected http status")
+	}
+	if f := fieldByKey(summary, FieldCanonicalSuppressedKey); f == nil || f.Integer != 1 {
+		t.Error("expected one suppressed record")
+	}
+	if f := fieldByKey(summary, FieldOpDurationKey); f == nil || f.Type != models.FieldTypeFloat {
+		t.Error("expected duration_ms as a float, like operation records")
+	}
+}
+
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (h *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	return nil, nil, nil
+}
+
+func TestCanonicalMiddleware_PassesThroughWriterInterfaces(t *testing.T) {
+	ls := NewLoggerService(WithBlockingSend())
+	ls.AddLogger("mock", &mockPublisher{})
+	ls.Start()
+	defer ls.Stop()
+
+	w := &hijackableRecorder{ResponseRecorder: httptest.NewRecorder()}
+	var flusher, hijacker, unwrapped bool
+	handler := CanonicalMiddleware(ls.NewLogger(), http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if f, ok := rw.(http.Flusher); ok {
+			f.Flush()
+			flusher = true
+		}
+		if h, ok := rw.(http.Hijacker); ok {
+			_, _, _ = h.Hijack()
+			hijacker = true
+		}
+		if u, ok := rw.(interface{ Unwrap() http.ResponseWriter }); ok {
+			unwrapped = u.Unwrap() == w
+		}
+	}))
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if !flusher || !w.Flushed {
+		t.Error("expected Flush to reach the underlying writer")
+	}
+	if !hijacker || !w.hijacked {
+		t.Error("expected Hijack to reach the underlying writer")
+	}
+	if !unwrapped {
+		t.Error("expected Unwrap to return the underlying writer")
+	}
+}
+
+func TestEmitCanonical_OnlyOnce(t *testing.T) {
+	ls := NewLoggerService(WithBlockingSend())
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+	logger := ls.NewLogger()
+
+	ctx := StartCanonical(context.Background())
+	EmitCanonical(ctx, logger, models.ErrorLevel, "job summary")
+	EmitCanonical(ctx, logger, models.ErrorLevel, "job summary")
+	EmitCanonical(context.Background(), logger, models.InfoLevel, "no canonical line")
+	ls.Stop()
+
+	logs := mock.GetLogs()
+	if len(logs) != 1 || logs[0].Level != models.ErrorLevel {
+		t.Fatalf("expected one error summary, got %+v", logs)
+	}
+}
+
+func TestCanonicalMiddleware_LogsPanicAndRepanics(t *testing.T) {
+	ls := NewLoggerService(WithBlockingSend())
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+	logger := ls.NewLogger()
+
+	handler := CanonicalMiddleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		AddToCanonical(r.Context(), models.WithStringField("user_id", "u-42"))
+		panic("nil cart")
+	}))
+
+	func() {
+		defer func() {
+			if p := recover(); p != "nil cart" {
+				t.Errorf("expected the panic to continue, got %v", p)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/cart", nil))
+	}()
+	ls.Stop()
+
+	logs := mock.GetLogs()
+	if len(logs) != 1 || logs[0].Level != models.ErrorLevel {
+		t.Fatalf("expected one error summary, got %+v", logs)
+	}
+	if f := fieldByKey(logs[0], FieldHTTPStatusKey); f == nil || f.Integer != http.StatusInternalServerError {
+		t.Error("expected status 500")
+	}
+	if f := fieldByKey(logs[0], FieldHTTPPanicKey); f == nil || f.String != "nil cart" {
+		t.Error("expected the panic value")
+	}
+	if f := fieldByKey(logs[0], "user_id"); f == nil {
+		t.Error("expected the fields added before the panic")
+	}
+}
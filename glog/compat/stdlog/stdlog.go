@@ -0,0 +1,130 @@
+// Package stdlog mirrors the package-level API of the standard library log
+// package on top of glogger, so legacy code can migrate by rewriting the
+// import path:
+//
+//	import log "github.com/alexnobleburn/glogger/glog/compat/stdlog"
+//
+// Print* records are logged at InfoLevel, Fatal* and Panic* at ErrorLevel.
+// Until SetLogger is called, records go to glog.Bootstrap (stderr).
+package stdlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Source tags every record written through this package.
+const Source = models.SourceBridgePrefix + "stdlog"
+
+var (
+	mu         sync.RWMutex
+	logger     interfaces.Logger = glog.Bootstrap()
+	prefix     string
+	beforeExit func()
+	exit       = os.Exit
+)
+
+// SetLogger routes all records to l.
+func SetLogger(l interfaces.Logger) {
+	mu.Lock()
+	defer mu.Unlock()
+	logger = l
+}
+
+// SetPrefix sets a prefix prepended to every message, like log.SetPrefix.
+func SetPrefix(p string) {
+	mu.Lock()
+	defer mu.Unlock()
+	prefix = p
+}
+
+func Prefix() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return prefix
+}
+
+// OnExit registers fn to run before Fatal* exits the process, typically the
+// Stop method of the service so that the fatal record is not lost.
+func OnExit(fn func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	beforeExit = fn
+}
+
+func Print(v ...any) {
+	info(fmt.Sprint(v...))
+}
+
+func Printf(format string, v ...any) {
+	info(fmt.Sprintf(format, v...))
+}
+
+func Println(v ...any) {
+	info(fmt.Sprintln(v...))
+}
+
+func Fatal(v ...any) {
+	fatal(fmt.Sprint(v...))
+}
+
+func Fatalf(format string, v ...any) {
+	fatal(fmt.Sprintf(format, v...))
+}
+
+func Fatalln(v ...any) {
+	fatal(fmt.Sprintln(v...))
+}
+
+// Panic logs the message and then panics with it, like log.Panic.
+func Panic(v ...any) {
+	s := fmt.Sprint(v...)
+	logError(s)
+	panic(s)
+}
+
+func Panicf(format string, v ...any) {
+	s := fmt.Sprintf(format, v...)
+	logError(s)
+	panic(s)
+}
+
+func Panicln(v ...any) {
+	s := fmt.Sprintln(v...)
+	logError(s)
+	panic(s)
+}
+
+func current() (interfaces.Logger, string) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return logger, prefix
+}
+
+func info(msg string) {
+	l, p := current()
+	l.Info(context.Background(), p+strings.TrimSuffix(msg, "\n"), models.WithSource(Source))
+}
+
+func logError(msg string) {
+	l, p := current()
+	l.Error(context.Background(), errors.New(p+strings.TrimSuffix(msg, "\n")), models.WithSource(Source))
+}
+
+func fatal(msg string) {
+	logError(msg)
+	mu.RLock()
+	fn := beforeExit
+	mu.RUnlock()
+	if fn != nil {
+		fn()
+	}
+	exit(1)
+}
@@ -0,0 +1,82 @@
+package stdlog
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+)
+
+type entry struct {
+	level models.LogLevel
+	msg   string
+}
+
+type captureLogger struct {
+	entries []entry
+}
+
+func (c *captureLogger) Error(_ context.Context, err error, _ ...models.Option) {
+	c.entries = append(c.entries, entry{models.ErrorLevel, err.Error()})
+}
+
+func (c *captureLogger) Errors(ctx context.Context, errs []error, options ...models.Option) {
+	for _, err := range errs {
+		c.Error(ctx, err, options...)
+	}
+}
+
+func (c *captureLogger) Info(_ context.Context, message string, _ ...models.Option) {
+	c.entries = append(c.entries, entry{models.InfoLevel, message})
+}
+
+func (c *captureLogger) Warning(_ context.Context, message string, _ ...models.Option) {
+	c.entries = append(c.entries, entry{models.WarnLevel, message})
+}
+
+func (c *captureLogger) Debug(_ context.Context, message string, _ ...models.Option) {
+	c.entries = append(c.entries, entry{models.DebugLevel, message})
+}
+
+func (c *captureLogger) Log(_ context.Context, level models.LogLevel, message string, _ ...models.Option) {
+	c.entries = append(c.entries, entry{level, message})
+}
+
+func TestStdlog_PrintFatalPanic(t *testing.T) {
+	capture := &captureLogger{}
+	SetLogger(capture)
+	SetPrefix("legacy: ")
+	exitCode := -1
+	flushed := false
+	exit = func(code int) { exitCode = code }
+	OnExit(func() { flushed = true })
+
+	Printf("user %d logged in", 42)
+	Println("done")
+	Fatal("cannot continue")
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("unexpected panic value %v", r)
+			}
+		}()
+		Panic("boom")
+	}()
+
+	want := []entry{
+		{models.InfoLevel, "legacy: user 42 logged in"},
+		{models.InfoLevel, "legacy: done"},
+		{models.ErrorLevel, "legacy: cannot continue"},
+		{models.ErrorLevel, "legacy: boom"},
+	}
+	if len(capture.entries) != len(want) {
+		t.Fatalf("expected %d entries, got %+v", len(want), capture.entries)
+	}
+	for i := range want {
+		if capture.entries[i] != want[i] {
+			t.Errorf("entry %d: expected %+v, got %+v", i, want[i], capture.entries[i])
+		}
+	}
+	if exitCode != 1 || !flushed {
+		t.Errorf("expected flush before exit(1), got code %d flushed %v", exitCode, flushed)
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/console"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/publishers"
+	"github.com/alexnobleburn/glogger/glog/zap"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	glog.RegisterPublisher("stdout", newStdout)
+	glog.RegisterPublisher("stderr", newStderr)
+	glog.RegisterPublisher("file", newFile)
+	glog.RegisterPublisher("discard", func(glog.PublisherSpec) (interfaces.LogPublisher, error) {
+		return publishers.NewNull(), nil
+	})
+}
+
+// writerSettings are the settings of the stdout, stderr and file types.
+type writerSettings struct {
+	// Format is json (default), console, logfmt or ecs; stdout also
+	// accepts auto, which picks console on a terminal.
+	Format string `json:"format"`
+	// Path is the file a file publisher appends to.
+	Path string `json:"path"`
+}
+
+func newStdout(spec glog.PublisherSpec) (interfaces.LogPublisher, error) {
+	var s writerSettings
+	if err := spec.Decode(&s); err != nil {
+		return nil, err
+	}
+	if f := strings.ToLower(s.Format); f == "" || f == "json" || f == "console" || f == "auto" {
+		return console.NewFromConfig(f, spec.AppID, spec.Env)
+	}
+	return writerPublisher(os.Stdout, s.Format, spec)
+}
+
+func newStderr(spec glog.PublisherSpec) (interfaces.LogPublisher, error) {
+	var s writerSettings
+	if err := spec.Decode(&s); err != nil {
+		return nil, err
+	}
+	return writerPublisher(os.Stderr, s.Format, spec)
+}
+
+func newFile(spec glog.PublisherSpec) (interfaces.LogPublisher, error) {
+	var s writerSettings
+	if err := spec.Decode(&s); err != nil {
+		return nil, err
+	}
+	if s.Path == "" {
+		return nil, fmt.Errorf("file publisher needs a path")
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(s.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	p, err := writerPublisher(f, s.Format, spec)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &filePublisher{LogPublisher: p, f: f}, nil
+}
+
+func writerPublisher(w io.Writer, format string, spec glog.PublisherSpec) (interfaces.LogPublisher, error) {
+	switch strings.ToLower(format) {
+	case "", "json":
+		return zap.NewZapLoggerWithWriter(spec.AppID, spec.Env, w), nil
+	case "console":
+		return console.New(w), nil
+	case "logfmt":
+		return publishers.NewWriter(w, encoder.NewLogfmt(spec.AppID, spec.Env)), nil
+	case "ecs":
+		return publishers.NewWriter(w, encoder.NewECS(spec.AppID, spec.Env)), nil
+	}
+	return nil, fmt.Errorf("unknown format %q (want json, console, logfmt or ecs)", format)
+}
+
+// filePublisher closes the file it writes to when the service stops.
+type filePublisher struct {
+	interfaces.LogPublisher
+	f *os.File
+}
+
+func (p *filePublisher) Flush(ctx context.Context) error {
+	if f, ok := p.LogPublisher.(interfaces.Flusher); ok {
+		if err := f.Flush(ctx); err != nil {
+			return err
+		}
+	}
+	return p.f.Sync()
+}
+
+func (p *filePublisher) Stop(ctx context.Context) error {
+	if err := p.Flush(ctx); err != nil {
+		p.f.Close()
+		return err
+	}
+	return p.f.Close()
+}
@@ -0,0 +1,367 @@
+// Package config builds a LoggerService from a JSON or YAML document, so
+// deployments can change log routing, levels and buffers without
+// recompiling:
+//
+//	level: info
+//	levels:
+//	  http: warn
+//	workers: 8
+//	send_timeout: 200ms
+//	publishers:
+//	  - id: stdout
+//	    type: stdout
+//	    format: auto
+//	  - id: audit
+//	    type: file
+//	    path: /var/log/app/audit.log
+//	    level: warn
+//	    redact: [password, token]
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"os"
+	"sort"
+	"time"
+)
+
+// Config describes a LoggerService. Zero values keep the glog defaults.
+type Config struct {
+	AppID string `json:"app_id"`
+	Env   string `json:"env"`
+	// Level is the minimum level of every logger of the service.
+	Level string `json:"level"`
+	// Levels sets the minimum level per logger name prefix, see
+	// glog.WithNamedLevels.
+	Levels map[string]string `json:"levels"`
+	// ComponentLevels sets the minimum level per component, see
+	// glog.WithComponentLevels.
+	ComponentLevels map[string]string `json:"component_levels"`
+	InputBufferSize int               `json:"input_buffer_size"`
+	JobBufferSize   int               `json:"job_buffer_size"`
+	Workers         int               `json:"workers"`
+	SendTimeout     Duration          `json:"send_timeout"`
+	Blocking        bool              `json:"blocking"`
+	ShedByLevel     bool              `json:"shed_by_level"`
+	Development     bool              `json:"development"`
+	OrderingFields  bool              `json:"ordering_fields"`
+	Publishers      []Publisher       `json:"publishers"`
+}
+
+// Publisher describes one publisher and what it receives.
+type Publisher struct {
+	ID string `json:"id"`
+	// Type is a name registered with glog.RegisterPublisher; this package
+	// registers stdout, stderr, file and discard.
+	Type string `json:"type"`
+	// Level drops records below it for this publisher only.
+	Level string `json:"level"`
+	// Component keeps only records of this component or below it.
+	Component string `json:"component"`
+	// Sample keeps one record out of Sample below ErrorLevel, as
+	// glog.WithSampleRate; SetSampleRate changes it at runtime.
+	Sample int      `json:"sample"`
+	Redact []string `json:"redact"`
+	Drop   []string `json:"drop"`
+	// Settings holds the remaining keys of the entry, such as format or
+	// path, for the publisher factory.
+	Settings map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON keeps the keys that are not Publisher fields in Settings.
+func (p *Publisher) UnmarshalJSON(b []byte) error {
+	type plain Publisher
+	if err := json.Unmarshal(b, (*plain)(p)); err != nil {
+		return err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	for _, key := range []string{"id", "type", "level", "component", "sample", "redact", "drop"} {
+		delete(raw, key)
+	}
+	p.Settings = nil
+	if len(raw) > 0 {
+		p.Settings = raw
+	}
+	return nil
+}
+
+// decode decodes Settings into v, rejecting unknown keys.
+func (p Publisher) decode(v any) error {
+	if len(p.Settings) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(p.Settings)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// Duration is a time.Duration written as a string such as "200ms".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Parse decodes a JSON or YAML document. A document starting with '{' is
+// read as JSON, anything else as YAML. Unknown keys are errors, so a typo
+// does not silently fall back to a default.
+func Parse(doc []byte) (*Config, error) {
+	doc = bytes.TrimSpace(doc)
+	if len(doc) == 0 || doc[0] != '{' {
+		v, err := parseYAML(doc)
+		if err != nil {
+			return nil, fmt.Errorf("config: yaml: %w", err)
+		}
+		if doc, err = json.Marshal(v); err != nil {
+			return nil, fmt.Errorf("config: yaml: %w", err)
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.DisallowUnknownFields()
+	var c Config
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	return &c, nil
+}
+
+// Load reads and parses the file at path.
+func Load(path string) (*Config, error) {
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	return Parse(doc)
+}
+
+// NewService loads the file at path and builds a service from it.
+func NewService(path string, opts ...glog.ServiceOption) (*glog.LoggerService, error) {
+	c, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return c.Build(opts...)
+}
+
+// Build creates a LoggerService with the configured options and publishers.
+// opts are applied after the configured options, e.g. for an error handler.
+// The service is not started.
+func (c *Config) Build(opts ...glog.ServiceOption) (*glog.LoggerService, error) {
+	svcOpts, err := c.serviceOptions()
+	if err != nil {
+		return nil, err
+	}
+	pubs, err := c.routedPublishers(nil)
+	if err != nil {
+		return nil, err
+	}
+	return newService(svcOpts, opts, pubs), nil
+}
+
+func newService(svcOpts, opts []glog.ServiceOption, pubs []glog.RoutedPublisher) *glog.LoggerService {
+	ls := glog.NewLoggerService(append(svcOpts, opts...)...)
+	for _, p := range pubs {
+		ls.AddLogger(p.ID, p.Publisher, p.Options...)
+	}
+	return ls
+}
+
+// routedPublishers builds the configured publishers. reuse, if not nil,
+// returns a publisher already running for an entry, which is kept instead
+// of building a new one.
+func (c *Config) routedPublishers(reuse func(id string, pc Publisher) interfaces.LogPublisher) ([]glog.RoutedPublisher, error) {
+	var pubs []glog.RoutedPublisher
+	var built []interfaces.LogPublisher
+	fail := func(err error) ([]glog.RoutedPublisher, error) {
+		// Close the files opened for the publishers built so far.
+		for _, p := range built {
+			if s, ok := p.(interfaces.Stopper); ok {
+				_ = s.Stop(context.Background())
+			}
+		}
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for i, pc := range c.Publishers {
+		id := pc.id()
+		if seen[id] {
+			return fail(fmt.Errorf("config: publishers[%d]: duplicate id %q", i, id))
+		}
+		seen[id] = true
+		popts, err := pc.options()
+		if err != nil {
+			return fail(fmt.Errorf("config: publisher %q: %w", id, err))
+		}
+		var p interfaces.LogPublisher
+		if reuse != nil {
+			p = reuse(id, pc)
+		}
+		if p == nil {
+			if p, err = c.publisher(id, pc); err != nil {
+				return fail(fmt.Errorf("config: publisher %q: %w", id, err))
+			}
+			built = append(built, p)
+		}
+		pubs = append(pubs, glog.RoutedPublisher{ID: id, Publisher: p, Options: popts})
+	}
+	return pubs, nil
+}
+
+func (c *Config) serviceOptions() ([]glog.ServiceOption, error) {
+	var opts []glog.ServiceOption
+	if c.InputBufferSize > 0 {
+		opts = append(opts, glog.WithInputBufferSize(c.InputBufferSize))
+	}
+	if c.JobBufferSize > 0 {
+		opts = append(opts, glog.WithJobBufferSize(c.JobBufferSize))
+	}
+	if c.Workers > 0 {
+		opts = append(opts, glog.WithNumWorkers(c.Workers))
+	}
+	if c.SendTimeout > 0 {
+		opts = append(opts, glog.WithSendTimeout(time.Duration(c.SendTimeout)))
+	}
+	if c.Blocking {
+		opts = append(opts, glog.WithBlockingSend())
+	}
+	if c.ShedByLevel {
+		opts = append(opts, glog.WithShedByLevel())
+	}
+	if c.Development {
+		opts = append(opts, glog.WithDevelopment())
+	}
+	if c.OrderingFields {
+		opts = append(opts, glog.WithOrderingFields())
+	}
+
+	named, components, err := c.levels()
+	if err != nil {
+		return nil, err
+	}
+	if len(named) > 0 {
+		opts = append(opts, glog.WithNamedLevels(named))
+	}
+	if len(components) > 0 {
+		opts = append(opts, glog.WithComponentLevels(components))
+	}
+	return opts, nil
+}
+
+// levels parses Level and Levels into the named levels, and
+// ComponentLevels.
+func (c *Config) levels() (named, components map[string]models.LogLevel, err error) {
+	named = make(map[string]models.LogLevel)
+	if c.Level != "" {
+		level, err := models.ParseLevel(c.Level)
+		if err != nil {
+			return nil, nil, fmt.Errorf("config: level: %w", err)
+		}
+		named[""] = level
+	}
+	for prefix, name := range c.Levels {
+		level, err := models.ParseLevel(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("config: levels.%s: %w", prefix, err)
+		}
+		named[prefix] = level
+	}
+	components = make(map[string]models.LogLevel, len(c.ComponentLevels))
+	for component, name := range c.ComponentLevels {
+		level, err := models.ParseLevel(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("config: component_levels.%s: %w", component, err)
+		}
+		components[component] = level
+	}
+	return named, components, nil
+}
+
+func (c *Config) publisher(id string, pc Publisher) (interfaces.LogPublisher, error) {
+	if pc.Type == "" {
+		return nil, fmt.Errorf("missing type")
+	}
+	decoded := false
+	p, err := glog.NewPublisher(pc.Type, glog.PublisherSpec{
+		ID:    id,
+		AppID: c.AppID,
+		Env:   c.Env,
+		Decode: func(v any) error {
+			decoded = true
+			return pc.decode(v)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !decoded && len(pc.Settings) > 0 {
+		return nil, fmt.Errorf("%s publishers take no settings, got %v", pc.Type, settingKeys(pc.Settings))
+	}
+	return p, nil
+}
+
+func settingKeys(settings map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// id defaults to the type, so a single publisher of a type needs no ID.
+func (pc Publisher) id() string {
+	if pc.ID != "" {
+		return pc.ID
+	}
+	return pc.Type
+}
+
+func (pc Publisher) options() ([]glog.PublisherOption, error) {
+	var opts []glog.PublisherOption
+	if pc.Level != "" {
+		level, err := models.ParseLevel(pc.Level)
+		if err != nil {
+			return nil, fmt.Errorf("level: %w", err)
+		}
+		opts = append(opts, glog.WithMinLevel(level))
+	}
+	if pc.Component != "" {
+		opts = append(opts, glog.WithFilter(glog.HasComponent(pc.Component)))
+	}
+	if pc.Sample > 1 {
+		opts = append(opts, glog.WithSampleRate(pc.Sample))
+	}
+	var processors []glog.Processor
+	if len(pc.Drop) > 0 {
+		processors = append(processors, glog.DropFields(pc.Drop...))
+	}
+	if len(pc.Redact) > 0 {
+		processors = append(processors, glog.RedactFields(pc.Redact...))
+	}
+	if len(processors) > 0 {
+		opts = append(opts, glog.WithProcessors(processors...))
+	}
+	return opts, nil
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/publishers"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const yamlDoc = `
+# routing for the shop service
+app_id: shop
+env: "prod"
+level: info
+levels:
+  http: warn
+  "": info
+workers: 8
+send_timeout: 200ms
+publishers:
+  - id: audit
+    type: file
+    path: PATH
+    format: logfmt   # one line per record
+    level: warn
+    redact: [password, "token"]
+  - type: discard
+    sample: 10
+`
+
+const jsonDoc = `{
+  "app_id": "shop",
+  "env": "prod",
+  "level": "info",
+  "levels": {"http": "warn", "": "info"},
+  "workers": 8,
+  "send_timeout": "200ms",
+  "publishers": [
+    {"id": "audit", "type": "file", "path": "PATH", "format": "logfmt", "level": "warn", "redact": ["password", "token"]},
+    {"type": "discard", "sample": 10}
+  ]
+}`
+
+func TestParse_YAMLMatchesJSON(t *testing.T) {
+	fromYAML, err := Parse([]byte(yamlDoc))
+	if err != nil {
+		t.Fatalf("yaml: %v", err)
+	}
+	fromJSON, err := Parse([]byte(jsonDoc))
+	if err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	if !reflect.DeepEqual(fromYAML, fromJSON) {
+		t.Errorf("yaml and json differ:\n%+v\n%+v", fromYAML, fromJSON)
+	}
+	if time.Duration(fromYAML.SendTimeout) != 200*time.Millisecond || fromYAML.Workers != 8 {
+		t.Errorf("unexpected service settings %+v", fromYAML)
+	}
+	if p := fromYAML.Publishers[0]; string(p.Settings["format"]) != `"logfmt"` || len(p.Redact) != 2 || p.Redact[1] != "token" {
+		t.Errorf("unexpected publisher %+v", p)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for name, doc := range map[string]string{
+		"unknown key":      "levle: info\n",
+		"bad duration":     "send_timeout: soon\n",
+		"bad indentation":  "level: info\n    workers: 2\n",
+		"anchor":           "level: &lvl info\n",
+		"multi-line value": "level: |\n  info\n",
+		"not a mapping":    "level info\n",
+	} {
+		if _, err := Parse([]byte(doc)); err == nil || !strings.HasPrefix(err.Error(), "config: ") {
+			t.Errorf("%s: expected a config error, got %v", name, err)
+		}
+	}
+}
+
+func TestBuild_RoutesAndFiltersPerPublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.log")
+	cfgPath := filepath.Join(t.TempDir(), "glog.yaml")
+	if err := os.WriteFile(cfgPath, []byte(strings.Replace(yamlDoc, "PATH", path, 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ls, err := NewService(cfgPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ls.Start()
+	logger := ls.NewLogger()
+	ctx := context.Background()
+	logger.Info(ctx, "below the publisher level")
+	logger.Warning(ctx, "login failed")
+	logger.Named("http").Info(ctx, "below the named level")
+	ls.Stop()
+
+	if n := ls.Stats().Publishers; n != 2 {
+		t.Errorf("expected 2 publishers, got %d", n)
+	}
+	if rate := ls.PublisherStates()["discard"].SampleRate; rate != 10 {
+		t.Errorf("expected the configured sample rate, got %d", rate)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	if !strings.Contains(out, "login failed") || strings.Contains(out, "below the") {
+		t.Errorf("unexpected file output %q", out)
+	}
+	if !strings.Contains(out, "service_name=shop") {
+		t.Errorf("expected logfmt with the configured app, got %q", out)
+	}
+}
+
+func TestBuild_InvalidPublishers(t *testing.T) {
+	for name, c := range map[string]*Config{
+		"unknown type":   {Publishers: []Publisher{{Type: "carrier-pigeon"}}},
+		"missing path":   {Publishers: []Publisher{{Type: "file"}}},
+		"unknown format": {Publishers: []Publisher{{Type: "stderr", Settings: map[string]json.RawMessage{"format": []byte(`"xml"`)}}}},
+		"unknown key":    {Publishers: []Publisher{{Type: "file", Settings: map[string]json.RawMessage{"paht": []byte(`"a.log"`)}}}},
+		"no settings":    {Publishers: []Publisher{{Type: "discard", Settings: map[string]json.RawMessage{"path": []byte(`"a.log"`)}}}},
+		"duplicate id":   {Publishers: []Publisher{{Type: "discard"}, {Type: "discard"}}},
+		"bad level":      {Level: "loud"},
+		"bad component":  {ComponentLevels: map[string]string{"database": "chatty"}},
+	} {
+		if _, err := c.Build(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestBuild_RegisteredPublisher(t *testing.T) {
+	type queueSettings struct {
+		Queue string `json:"queue"`
+	}
+	var got queueSettings
+	var gotSpec glog.PublisherSpec
+	glog.RegisterPublisher("test-queue", func(spec glog.PublisherSpec) (interfaces.LogPublisher, error) {
+		gotSpec = spec
+		return publishers.NewNull(), spec.Decode(&got)
+	})
+
+	c, err := Parse([]byte("app_id: shop\npublishers:\n  - id: q\n    type: test-queue\n    queue: logs\n    level: warn\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Build(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Queue != "logs" || gotSpec.ID != "q" || gotSpec.AppID != "shop" {
+		t.Errorf("unexpected settings %+v and spec %+v", got, gotSpec)
+	}
+}
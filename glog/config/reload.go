@@ -0,0 +1,198 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// reloadTimeout bounds a reload triggered by a signal, including delivering
+// the records in flight to the publishers it removes.
+const reloadTimeout = 10 * time.Second
+
+// Reloader builds a service from a config file and applies later versions
+// of the file to it while it runs:
+//
+//	r, err := config.NewReloader("/etc/app/glog.yaml")
+//	service := r.Service()
+//	service.Start()
+//	defer r.ReloadOnSignal(nil)()
+//
+// A reload changes the levels and the publishers. A publisher whose id,
+// type and settings are unchanged is kept running, with its level, filter
+// and processors updated. Settings of the service itself, such as buffer
+// sizes or workers, only take effect on restart; a file that changes them
+// is rejected.
+type Reloader struct {
+	path string
+	svc  *glog.LoggerService
+
+	mu      sync.Mutex
+	cfg     *Config
+	running map[string]runningPublisher
+}
+
+type runningPublisher struct {
+	config    Publisher
+	publisher interfaces.LogPublisher
+}
+
+// NewReloader loads the file at path and builds a service from it, as
+// NewService does.
+func NewReloader(path string, opts ...glog.ServiceOption) (*Reloader, error) {
+	c, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	svcOpts, err := c.serviceOptions()
+	if err != nil {
+		return nil, err
+	}
+	pubs, err := c.routedPublishers(nil)
+	if err != nil {
+		return nil, err
+	}
+	r := &Reloader{path: path, svc: newService(svcOpts, opts, pubs)}
+	r.commit(c, pubs)
+	return r, nil
+}
+
+// Service returns the service the reloader configures.
+func (r *Reloader) Service() *glog.LoggerService {
+	return r.svc
+}
+
+// Reload re-reads the file and applies it with
+// glog.LoggerService.Reconfigure, then re-reads GLOG_DISABLE and
+// GLOG_FORCE_STDOUT with ApplyEnv. If the file cannot be read, is invalid or
+// changes settings that need a restart, nothing changes and the error is
+// returned.
+func (r *Reloader) Reload(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, err := Load(r.path)
+	if err != nil {
+		return err
+	}
+	if changed := restartSettings(r.cfg, c); len(changed) > 0 {
+		return fmt.Errorf("config: %s changed; restart to apply", strings.Join(changed, ", "))
+	}
+	named, components, err := c.levels()
+	if err != nil {
+		return err
+	}
+	pubs, err := c.routedPublishers(func(id string, pc Publisher) interfaces.LogPublisher {
+		if running, ok := r.running[id]; ok && running.config.Type == pc.Type &&
+			reflect.DeepEqual(running.config.Settings, pc.Settings) {
+			return running.publisher
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = r.svc.Reconfigure(ctx, glog.Routing{
+		NamedLevels:     named,
+		ComponentLevels: components,
+		Publishers:      pubs,
+	})
+	if errors.Is(err, glog.ErrReconfigureAfterStop) {
+		for _, p := range pubs {
+			if !r.isRunning(p.ID, p.Publisher) {
+				_ = glog.StopPublisher(ctx, p.Publisher)
+			}
+		}
+		return err
+	}
+	// The new routing is in place even if a removed publisher failed to
+	// flush or stop. The kill switches apply to it as well, and may have
+	// changed with the file.
+	r.commit(c, pubs)
+	r.svc.ApplyEnv()
+	return err
+}
+
+// isRunning reports whether p is the publisher already running under id,
+// which Reload reuses only under the same id.
+func (r *Reloader) isRunning(id string, p interfaces.LogPublisher) bool {
+	running, ok := r.running[id]
+	return ok && glog.SamePublisher(running.publisher, p)
+}
+
+func (r *Reloader) commit(c *Config, pubs []glog.RoutedPublisher) {
+	r.cfg = c
+	r.running = make(map[string]runningPublisher, len(pubs))
+	for i, p := range pubs {
+		r.running[p.ID] = runningPublisher{config: c.Publishers[i], publisher: p.Publisher}
+	}
+}
+
+// ReloadOnSignal reloads the file whenever the process receives one of
+// sigs, SIGHUP by default, until the returned function is called.
+// onReload gets the result of every reload; if it is nil, failures are
+// printed.
+func (r *Reloader) ReloadOnSignal(onReload func(error), sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	if onReload == nil {
+		onReload = func(err error) {
+			if err != nil {
+				fmt.Println(err)
+			}
+		}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ch:
+				ctx, cancel := context.WithTimeout(context.Background(), reloadTimeout)
+				onReload(r.Reload(ctx))
+				cancel()
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+// restartSettings returns the json names of the settings that differ
+// between old and c and only apply when the service is built.
+func restartSettings(old, c *Config) []string {
+	reloadable := map[string]bool{"level": true, "levels": true, "component_levels": true, "publishers": true}
+	var changed []string
+	ov, cv := reflect.ValueOf(old).Elem(), reflect.ValueOf(c).Elem()
+	for i := 0; i < ov.NumField(); i++ {
+		name, _, _ := strings.Cut(ov.Type().Field(i).Tag.Get("json"), ",")
+		if reloadable[name] {
+			continue
+		}
+		if !reflect.DeepEqual(ov.Field(i).Interface(), cv.Field(i).Interface()) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// reloadPublishers counts the publishers built by the test-reload type;
+// reloadBuilt keeps the last one built per name setting.
+var (
+	reloadPublishers atomic.Int64
+	reloadBuilt      sync.Map
+)
+
+func init() {
+	glog.RegisterPublisher("test-reload", func(spec glog.PublisherSpec) (interfaces.LogPublisher, error) {
+		var s struct {
+			Name string `json:"name"`
+		}
+		reloadPublishers.Add(1)
+		p := &recordingPublisher{}
+		err := spec.Decode(&s)
+		reloadBuilt.Store(s.Name, p)
+		return p, err
+	})
+	glog.RegisterPublisher("test-func", func(glog.PublisherSpec) (interfaces.LogPublisher, error) {
+		return funcPublisher(func(*models.LogData) {}), nil
+	})
+}
+
+type funcPublisher func(*models.LogData)
+
+func (f funcPublisher) SendMsg(data *models.LogData) { f(data) }
+
+type recordingPublisher struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (p *recordingPublisher) SendMsg(data *models.LogData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.msgs = append(p.msgs, data.Msg)
+}
+
+func writeConfig(t *testing.T, path, doc string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloader_AppliesLevelsAndPublishers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "glog.yaml")
+	writeConfig(t, path, "level: info\npublishers:\n  - id: a\n    type: test-reload\n    name: one\n")
+	r, err := NewReloader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls := r.Service()
+	ls.Start()
+	defer ls.Stop()
+	before := reloadPublishers.Load()
+
+	writeConfig(t, path, `level: debug
+component_levels:
+  db: error
+publishers:
+  - id: a
+    type: test-reload
+    name: one
+    level: warn
+  - id: b
+    type: test-reload
+    name: two
+`)
+	if err := r.Reload(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := reloadPublishers.Load() - before; n != 1 {
+		t.Errorf("expected only the new publisher built, got %d", n)
+	}
+	if ls.Level() != models.DebugLevel || ls.ComponentLevels()["db"] != models.ErrorLevel {
+		t.Errorf("expected the levels applied, got %v and %v", ls.Level(), ls.ComponentLevels())
+	}
+	states := ls.PublisherStates()
+	if len(states) != 2 || states["a"].MinLevel != "warn" {
+		t.Errorf("unexpected publishers %+v", states)
+	}
+
+	writeConfig(t, path, "level: debug\npublishers:\n  - id: a\n    type: test-reload\n    name: changed\n")
+	if err := r.Reload(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := reloadPublishers.Load() - before; n != 2 {
+		t.Errorf("expected a publisher with changed settings rebuilt, got %d builds", n)
+	}
+	if len(ls.PublisherStates()) != 1 {
+		t.Errorf("expected the removed publisher gone, got %+v", ls.PublisherStates())
+	}
+}
+
+func TestReloader_RejectsInvalidFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "glog.yaml")
+	writeConfig(t, path, "level: info\nworkers: 2\npublishers:\n  - type: discard\n")
+	r, err := NewReloader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, doc := range map[string]string{
+		"restart setting": "level: debug\nworkers: 4\npublishers:\n  - type: discard\n",
+		"bad level":       "level: loud\nworkers: 2\npublishers:\n  - type: discard\n",
+		"bad publisher":   "level: debug\nworkers: 2\npublishers:\n  - type: file\n",
+	} {
+		writeConfig(t, path, doc)
+		if err := r.Reload(context.Background()); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if r.Service().Level() != models.InfoLevel || len(r.Service().PublisherStates()) != 1 {
+		t.Errorf("expected a rejected file to change nothing")
+	}
+}
+
+func TestReloader_ReloadOnSignal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "glog.yaml")
+	writeConfig(t, path, "level: info\n")
+	r, err := NewReloader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Service().Start()
+	defer r.Service().Stop()
+
+	results := make(chan error, 1)
+	stop := r.ReloadOnSignal(func(err error) { results <- err })
+	defer stop()
+
+	writeConfig(t, path, "level: error\n")
+	proc, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proc.Signal(syscall.SIGHUP); err != nil {
+		t.Skip(err)
+	}
+	select {
+	case err := <-results:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a reload on SIGHUP")
+	}
+	if r.Service().Level() != models.ErrorLevel {
+		t.Errorf("expected the new level, got %v", r.Service().Level())
+	}
+}
+
+func TestReloader_ReappliesEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "glog.yaml")
+	writeConfig(t, path, "publishers:\n  - id: env-a\n    type: test-reload\n    name: env-a\n")
+	r, err := NewReloader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls := r.Service()
+	ls.Start()
+	defer ls.Stop()
+
+	t.Setenv(glog.EnvDisable, "env-b")
+	writeConfig(t, path, `publishers:
+  - id: env-a
+    type: test-reload
+    name: env-a
+  - id: env-b
+    type: test-reload
+    name: env-b
+`)
+	if err := r.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ls.NewLogger().Info(context.Background(), "after reload")
+	if err := ls.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	a, _ := reloadBuilt.Load("env-a")
+	b, _ := reloadBuilt.Load("env-b")
+	if n := len(a.(*recordingPublisher).msgs); n != 1 {
+		t.Errorf("expected the enabled publisher to get the record, got %d", n)
+	}
+	if n := len(b.(*recordingPublisher).msgs); n != 0 {
+		t.Errorf("expected GLOG_DISABLE applied after the reload, got %d records", n)
+	}
+}
+
+func TestReloader_FuncPublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "glog.yaml")
+	writeConfig(t, path, "publishers:\n  - id: f\n    type: test-func\n")
+	r, err := NewReloader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls := r.Service()
+	ls.Start()
+	for i := 0; i < 2; i++ {
+		if err := r.Reload(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ls.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop did not return after reloading a func publisher")
+	}
+	if err := r.Reload(context.Background()); err == nil {
+		t.Error("expected an error reloading after Stop")
+	}
+}
@@ -0,0 +1,285 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The YAML reader is written by hand so that the module needs no YAML
+// dependency. It covers what configuration files use: block mappings and
+// sequences, flow sequences of scalars, quoted and plain scalars and
+// comments. Anchors, tags and multi-line scalars are rejected.
+
+type yamlLine struct {
+	num     int
+	indent  int
+	content string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// parseYAML decodes doc into maps, slices and scalars that encoding/json can
+// marshal.
+func parseYAML(doc []byte) (any, error) {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(string(doc), "\n") {
+		raw = strings.TrimRight(raw, " \t\r")
+		if raw == "---" || raw == "..." {
+			continue
+		}
+		content := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(content, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		content = stripComment(content)
+		if content == "" {
+			continue
+		}
+		p.lines = append(p.lines, yamlLine{num: i + 1, indent: len(raw) - len(strings.TrimLeft(raw, " ")), content: content})
+	}
+	if len(p.lines) == 0 {
+		return map[string]any{}, nil
+	}
+	v, err := p.block(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].num)
+	}
+	return v, nil
+}
+
+func (p *yamlParser) block(indent int) (any, error) {
+	if isSeqItem(p.lines[p.pos].content) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) sequence(indent int) (any, error) {
+	items := []any{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || !isSeqItem(line.content) {
+			break
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(line.content, "-"), " ")
+		switch {
+		case rest == "":
+			p.pos++
+			v, err := p.nested(indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		case isMappingEntry(rest):
+			// "- key: value" starts a mapping indented at the key.
+			p.lines[p.pos] = yamlLine{num: line.num, indent: line.indent + len(line.content) - len(rest), content: rest}
+			v, err := p.mapping(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		default:
+			v, err := scalar(rest, line.num)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+			p.pos++
+		}
+	}
+	return items, nil
+}
+
+func (p *yamlParser) mapping(indent int) (any, error) {
+	m := map[string]any{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+		}
+		if isSeqItem(line.content) {
+			break
+		}
+		key, value, ok := splitEntry(line.content)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\", got %q", line.num, line.content)
+		}
+		k, err := scalar(key, line.num)
+		if err != nil {
+			return nil, err
+		}
+		name := fmt.Sprint(k)
+		if _, dup := m[name]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.num, name)
+		}
+		p.pos++
+		if value != "" {
+			if m[name], err = scalar(value, line.num); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		// A sequence may sit at the same indentation as its key.
+		if p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isSeqItem(p.lines[p.pos].content) {
+			m[name], err = p.sequence(indent)
+		} else {
+			m[name], err = p.nested(indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// nested parses the block indented deeper than parent, or returns nil if
+// there is none.
+func (p *yamlParser) nested(parent int) (any, error) {
+	if p.pos >= len(p.lines) || p.lines[p.pos].indent <= parent {
+		return nil, nil
+	}
+	return p.block(p.lines[p.pos].indent)
+}
+
+func isSeqItem(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ")
+}
+
+func isMappingEntry(content string) bool {
+	if content == "" || strings.ContainsRune("\"'[{", rune(content[0])) {
+		return false
+	}
+	_, _, ok := splitEntry(content)
+	return ok
+}
+
+// splitEntry splits "key: value" at the first colon followed by a space or
+// the end of the line, outside quotes.
+func splitEntry(content string) (key, value string, ok bool) {
+	var quote byte
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ':' && (i+1 == len(content) || content[i+1] == ' '):
+			return strings.TrimSpace(content[:i]), strings.TrimSpace(content[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// stripComment removes a trailing comment, which starts with '#' at the
+// beginning of the line or after a space, outside quotes.
+func stripComment(content string) string {
+	var quote byte
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || content[i-1] == ' '):
+			return strings.TrimRight(content[:i], " ")
+		}
+	}
+	return content
+}
+
+func scalar(s string, num int) (any, error) {
+	switch {
+	case s == "":
+		return nil, nil
+	case s[0] == '"':
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid quoted string %s", num, s)
+		}
+		return v, nil
+	case s[0] == '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return nil, fmt.Errorf("line %d: invalid quoted string %s", num, s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case s[0] == '[':
+		return flowSequence(s, num)
+	case s == "{}":
+		return map[string]any{}, nil
+	case strings.ContainsRune("{&*!|>", rune(s[0])):
+		return nil, fmt.Errorf("line %d: unsupported YAML syntax %q", num, s)
+	}
+	switch s {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null", "~":
+		return nil, nil
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	return s, nil
+}
+
+// flowSequence parses "[a, b, c]" with scalar elements.
+func flowSequence(s string, num int) (any, error) {
+	if s[len(s)-1] != ']' {
+		return nil, fmt.Errorf("line %d: unterminated sequence %s", num, s)
+	}
+	inner := strings.TrimSpace(s[1 : len(s)-1])
+	items := []any{}
+	if inner == "" {
+		return items, nil
+	}
+	var quote byte
+	start := 0
+	for i := 0; i <= len(inner); i++ {
+		if i < len(inner) {
+			c := inner[i]
+			if quote != 0 {
+				if c == quote {
+					quote = 0
+				}
+				continue
+			}
+			if c == '"' || c == '\'' {
+				quote = c
+				continue
+			}
+			if c == '[' || c == '{' {
+				return nil, fmt.Errorf("line %d: nested flow collections are not supported", num)
+			}
+			if c != ',' {
+				continue
+			}
+		}
+		v, err := scalar(strings.TrimSpace(inner[start:i]), num)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+		start = i + 1
+	}
+	return items, nil
+}
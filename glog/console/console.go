@@ -0,0 +1,266 @@
+// Package console writes records for people reading a terminal during local
+// development: aligned columns, colored levels, object fields as indented
+// JSON and stacks one frame per line.
+//
+//	12:00:00.000 INFO  checkout  order placed          id=7 user=bob
+//	12:00:01.250 ERROR payments  card declined         order=7
+//	    stack: payments.go:42
+//	           checkout.go:17
+//
+// NewFromConfig picks this publisher or the JSON zap publisher by
+// configuration, so the same code logs for humans locally and for
+// machines in production.
+package console
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"github.com/alexnobleburn/glogger/glog/sanitize"
+	"github.com/alexnobleburn/glogger/glog/zap"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultTimeLayout = "15:04:05.000"
+	maxComponentWidth = 20
+	messageWidth      = 40
+	stackSeparator    = " <- "
+)
+
+const (
+	colorReset   = "\x1b[0m"
+	colorBold    = "\x1b[1m"
+	colorDim     = "\x1b[2m"
+	colorRed     = "\x1b[31m"
+	colorYellow  = "\x1b[33m"
+	colorCyan    = "\x1b[36m"
+	colorGray    = "\x1b[90m"
+	colorBoldRed = "\x1b[1;31m"
+)
+
+// Compile-time check that Publisher implements interfaces.LogPublisher.
+var _ interfaces.LogPublisher = (*Publisher)(nil)
+
+// Publisher writes one aligned line per record, followed by any object
+// fields and the stack on indented lines. The component column widens to
+// the longest component seen, up to 20 characters.
+type Publisher struct {
+	mu             sync.Mutex
+	w              io.Writer
+	color          bool
+	timeLayout     string
+	componentWidth int
+}
+
+// Option configures a Publisher.
+type Option func(*Publisher)
+
+// WithColor forces colors on or off. By default colors are used when the
+// writer is a terminal and NO_COLOR is not set.
+func WithColor(enabled bool) Option {
+	return func(p *Publisher) {
+		p.color = enabled
+	}
+}
+
+// WithTimeLayout sets the layout of the time column (default
+// "15:04:05.000", local time).
+func WithTimeLayout(layout string) Option {
+	return func(p *Publisher) {
+		if layout != "" {
+			p.timeLayout = layout
+		}
+	}
+}
+
+func New(w io.Writer, opts ...Option) *Publisher {
+	p := &Publisher{w: w, color: isTerminal(w), timeLayout: defaultTimeLayout}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// NewStdout writes to os.Stdout.
+func NewStdout(opts ...Option) *Publisher {
+	return New(os.Stdout, opts...)
+}
+
+// NewFromConfig returns the publisher for format: "console" (or "pretty")
+// for this package writing to stdout, "json" (or "") for
+// zap.NewZapLogger(appID, env), and "auto" for console when stdout is a
+// terminal and JSON otherwise.
+func NewFromConfig(format, appID, env string) (interfaces.LogPublisher, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "console", "pretty":
+		return NewStdout(), nil
+	case "json", "":
+		return zap.NewZapLogger(appID, env), nil
+	case "auto":
+		if isTerminal(os.Stdout) {
+			return NewStdout(), nil
+		}
+		return zap.NewZapLogger(appID, env), nil
+	}
+	return nil, fmt.Errorf("console: unknown log format %q (want console, json or auto)", format)
+}
+
+func isTerminal(w io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var component, stack string
+	var fields, objects []*models.LogField
+	for _, f := range data.Fields {
+		switch {
+		case f == nil:
+		case f.Key == models.FieldComponentKey && f.Type == models.FieldTypeString:
+			component = f.String
+		case f.Key == models.FieldFilenameKey && f.Type == models.FieldTypeString:
+			stack = f.String
+		case f.Type == models.FieldTypeObject:
+			objects = append(objects, f)
+		default:
+			fields = append(fields, f)
+		}
+	}
+	component = sanitize.String(component, sanitize.PolicyEscape)
+	if n := utf8.RuneCountInString(component); n > p.componentWidth {
+		p.componentWidth = min(n, maxComponentWidth)
+	}
+
+	var buf bytes.Buffer
+	p.paint(&buf, colorDim, data.TimeOr(time.Now()).Local().Format(p.timeLayout))
+	buf.WriteByte(' ')
+	level := strings.ToUpper(data.Level.String())
+	p.paint(&buf, levelColor(data.Level), pad(level, 5))
+	buf.WriteByte(' ')
+	if p.componentWidth > 0 {
+		p.paint(&buf, colorBold, pad(component, p.componentWidth))
+		buf.WriteByte(' ')
+	}
+	msg := sanitize.String(data.Msg, sanitize.PolicyEscape)
+	if len(fields) > 0 {
+		msg = pad(msg, messageWidth)
+	}
+	buf.WriteString(msg)
+	for _, f := range fields {
+		buf.WriteByte(' ')
+		p.paint(&buf, colorGray, sanitize.String(f.Key, sanitize.PolicyEscape)+"=")
+		buf.WriteString(scalar(f))
+	}
+	buf.WriteByte('\n')
+
+	for _, f := range objects {
+		b, _ := safejson.Marshal(f.Object)
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, b, "    ", "  ") != nil {
+			pretty.Reset()
+			pretty.Write(b)
+		}
+		buf.WriteString("    ")
+		p.paint(&buf, colorGray, sanitize.String(f.Key, sanitize.PolicyEscape)+":")
+		buf.WriteByte(' ')
+		buf.Write(pretty.Bytes())
+		buf.WriteByte('\n')
+	}
+	if stack != "" {
+		for i, frame := range strings.Split(stack, stackSeparator) {
+			if i == 0 {
+				buf.WriteString("    ")
+				p.paint(&buf, colorGray, "stack:")
+				buf.WriteByte(' ')
+			} else {
+				buf.WriteString("           ")
+			}
+			buf.WriteString(sanitize.String(frame, sanitize.PolicyEscape))
+			buf.WriteByte('\n')
+		}
+	}
+	_, _ = p.w.Write(buf.Bytes())
+}
+
+func (p *Publisher) paint(buf *bytes.Buffer, color, s string) {
+	if !p.color || s == "" {
+		buf.WriteString(s)
+		return
+	}
+	buf.WriteString(color)
+	buf.WriteString(s)
+	buf.WriteString(colorReset)
+}
+
+func levelColor(level models.LogLevel) string {
+	switch {
+	case level <= models.DebugLevel:
+		return colorGray
+	case level == models.InfoLevel:
+		return colorCyan
+	case level == models.WarnLevel:
+		return colorYellow
+	case level == models.ErrorLevel:
+		return colorRed
+	default:
+		return colorBoldRed
+	}
+}
+
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// scalar renders a non-object field, quoting strings that contain spaces.
+func scalar(f *models.LogField) string {
+	switch f.Type {
+	case models.FieldTypeInt:
+		return strconv.Itoa(f.Integer)
+	case models.FieldTypeFloat:
+		return strconv.FormatFloat(f.Float, 'g', -1, 64)
+	case models.FieldTypeBool:
+		return strconv.FormatBool(f.Bool)
+	case models.FieldTypeArray:
+		b, _ := safejson.Marshal(f.Object)
+		return string(b)
+	case models.FieldTypeInt64:
+		return strconv.FormatInt(f.Int64, 10)
+	case models.FieldTypeUint64:
+		return strconv.FormatUint(f.Uint64, 10)
+	case models.FieldTypeBinary:
+		return base64.StdEncoding.EncodeToString(f.Binary)
+	case models.FieldTypeDuration:
+		return f.Duration.String()
+	case models.FieldTypeTime:
+		return f.Time.Format(time.RFC3339Nano)
+	}
+	s := f.String
+	if s == "" || strings.ContainsAny(s, " \t\"=") || sanitize.String(s, sanitize.PolicyEscape) != s {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,121 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"github.com/alexnobleburn/glogger/glog/glogtest"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/zap"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func record(level models.LogLevel, msg string, fields ...*models.LogField) *models.LogData {
+	return &models.LogData{
+		Ctx:    context.Background(),
+		Time:   time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local),
+		Level:  level,
+		Msg:    msg,
+		Fields: fields,
+	}
+}
+
+func TestPublisher_AlignsColumns(t *testing.T) {
+	var buf bytes.Buffer
+	p := New(&buf, WithColor(false))
+	p.SendMsg(record(models.InfoLevel, "order placed",
+		&models.LogField{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: "checkout"},
+		&models.LogField{Key: "id", Type: models.FieldTypeInt, Integer: 7},
+		&models.LogField{Key: "user", Type: models.FieldTypeString, String: "bob smith"},
+	))
+	p.SendMsg(record(models.WarnLevel, "slow",
+		&models.LogField{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: "db"},
+	))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+	want := "12:00:00.000 INFO  checkout order placed" + strings.Repeat(" ", messageWidth-len("order placed")) + ` id=7 user="bob smith"`
+	if lines[0] != want {
+		t.Errorf("expected\n%q\ngot\n%q", want, lines[0])
+	}
+	if lines[1] != "12:00:00.000 WARN  db       slow" {
+		t.Errorf("expected the component column to keep its width, got %q", lines[1])
+	}
+}
+
+func TestPublisher_PrettyPrintsObjectsAndStack(t *testing.T) {
+	var buf bytes.Buffer
+	p := New(&buf, WithColor(false))
+	p.SendMsg(record(models.ErrorLevel, "card declined",
+		&models.LogField{Key: "cart", Type: models.FieldTypeObject, Object: map[string]int{"items": 2}},
+		&models.LogField{Key: models.FieldFilenameKey, Type: models.FieldTypeString, String: "payments.go:42 <- checkout.go:17"},
+	))
+	want := "12:00:00.000 ERROR card declined\n" +
+		"    cart: {\n" +
+		"      \"items\": 2\n" +
+		"    }\n" +
+		"    stack: payments.go:42\n" +
+		"           checkout.go:17\n"
+	if buf.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, buf.String())
+	}
+}
+
+func TestPublisher_ColorsLevels(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, WithColor(true)).SendMsg(record(models.ErrorLevel, "boom"))
+	if !strings.Contains(buf.String(), colorRed+"ERROR"+colorReset) {
+		t.Errorf("expected a red level, got %q", buf.String())
+	}
+
+	buf.Reset()
+	New(&buf).SendMsg(record(models.ErrorLevel, "boom"))
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("expected no colors for a non-terminal writer, got %q", buf.String())
+	}
+}
+
+func TestPublisher_EscapesNewlines(t *testing.T) {
+	var buf bytes.Buffer
+	New(&buf, WithColor(false)).SendMsg(record(models.InfoLevel, "a\nb",
+		&models.LogField{Key: "v", Type: models.FieldTypeString, String: "x\ny"},
+	))
+	if strings.Count(buf.String(), "\n") != 1 {
+		t.Errorf("expected one line, got %q", buf.String())
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	for format, want := range map[string]string{"console": "console", "Pretty": "console", "json": "zap", "": "zap"} {
+		pub, err := NewFromConfig(format, "test-app", "test")
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", format, err)
+		}
+		switch pub.(type) {
+		case *Publisher:
+			if want != "console" {
+				t.Errorf("expected the zap publisher for %q", format)
+			}
+		case *zap.Logger:
+			if want != "zap" {
+				t.Errorf("expected the console publisher for %q", format)
+			}
+		default:
+			t.Errorf("unexpected publisher %T for %q", pub, format)
+		}
+	}
+	if _, err := NewFromConfig("xml", "test-app", "test"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestPublisher_Conformance(t *testing.T) {
+	glogtest.RunPublisherConformance(t, func(t testing.TB) interfaces.LogPublisher {
+		return New(io.Discard)
+	})
+}
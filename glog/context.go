@@ -0,0 +1,44 @@
+package glog
+
+import (
+	"context"
+)
+
+const componentSeparator = "."
+
+type componentPathKey struct{}
+
+// PushComponent returns a context whose records are attributed to component
+// nested under any component already pushed, e.g. "api" then "checkout"
+// yields "api.checkout". An explicit WithComponent option is appended to the
+// path as the innermost element.
+func PushComponent(ctx context.Context, component string) context.Context {
+	if component == "" {
+		return ctx
+	}
+	if parent := ComponentPath(ctx); parent != "" {
+		component = parent + componentSeparator + component
+	}
+	return context.WithValue(ctx, componentPathKey{}, component)
+}
+
+// ComponentPath returns the component path pushed onto ctx, if any.
+func ComponentPath(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	path, _ := ctx.Value(componentPathKey{}).(string)
+	return path
+}
+
+func resolveComponent(ctx context.Context, explicit string) string {
+	path := ComponentPath(ctx)
+	switch {
+	case path == "":
+		return explicit
+	case explicit == "":
+		return path
+	default:
+		return path + componentSeparator + explicit
+	}
+}
@@ -0,0 +1,56 @@
+package glog
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+	"time"
+)
+
+func componentOf(data *models.LogData) string {
+	for _, f := range data.Fields {
+		if f.Key == models.FieldComponentKey {
+			return f.String
+		}
+	}
+	return ""
+}
+
+func TestPushComponent_Path(t *testing.T) {
+	ctx := PushComponent(context.Background(), "api")
+	ctx = PushComponent(ctx, "checkout")
+	ctx = PushComponent(ctx, "")
+
+	if got := ComponentPath(ctx); got != "api.checkout" {
+		t.Errorf("expected api.checkout, got %q", got)
+	}
+	if got := ComponentPath(nil); got != "" {
+		t.Errorf("expected empty path for nil context, got %q", got)
+	}
+}
+
+func TestPushComponent_AppliedToRecords(t *testing.T) {
+	logger, mock, service := setupTestLogger()
+	defer service.Stop()
+
+	ctx := PushComponent(PushComponent(context.Background(), "api"), "checkout")
+
+	logger.Info(ctx, "from path")
+	logger.Warning(ctx, "with explicit", models.WithComponent("tax"))
+	logger.Error(ctx, context.Canceled)
+	logs := waitForLogs(mock, 3, time.Second)
+
+	if len(logs) != 3 {
+		t.Fatalf("expected 3 logs, got %d", len(logs))
+	}
+	want := map[string]string{
+		"from path":              "api.checkout",
+		"with explicit":          "api.checkout.tax",
+		context.Canceled.Error(): "api.checkout",
+	}
+	for _, l := range logs {
+		if got := componentOf(l); got != want[l.Msg] {
+			t.Errorf("%q: expected component %q, got %q", l.Msg, want[l.Msg], got)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// Package correlation generates request correlation IDs, carries them in
+// the context and propagates them over HTTP, so every record of a request
+// and of the calls it makes to other services shares one ID.
+//
+//	service := glog.NewLoggerService(glog.WithContextExtractors(correlation.Extractor))
+//	handler := correlation.Middleware(glog.CanonicalMiddleware(logger, mux))
+//	client := &http.Client{Transport: correlation.NewTransport(nil)}
+package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net/http"
+	"time"
+)
+
+const (
+	// Header is the default HTTP header carrying the ID.
+	Header = "X-Request-ID"
+	// FieldKey is the record field the ID is written to.
+	FieldKey = "request_id"
+
+	// maxIDLength bounds IDs accepted from incoming requests.
+	maxIDLength = 128
+)
+
+type idKey struct{}
+
+// NewContext returns a context carrying id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// FromContext returns the ID carried by ctx, if any.
+func FromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok := ctx.Value(idKey{}).(string)
+	return id, ok && id != ""
+}
+
+// Ensure returns ctx and its ID, adding a new ULID if ctx has none, e.g. at
+// the start of a background job.
+func Ensure(ctx context.Context) (context.Context, string) {
+	if id, ok := FromContext(ctx); ok {
+		return ctx, id
+	}
+	id := NewULID()
+	return NewContext(ctx, id), id
+}
+
+// Extractor writes the ID of the record's context as FieldKey; register it
+// with glog.WithContextExtractors.
+func Extractor(ctx context.Context) []*models.LogField {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return []*models.LogField{{Key: FieldKey, Type: models.FieldTypeString, String: id}}
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a ULID: 26 characters that sort by creation time, with 48
+// bits of milliseconds followed by 80 random bits.
+func NewULID() string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(time.Now().UnixMilli())<<16)
+	_, _ = rand.Read(b[6:])
+	// 128 bits as 26 base32 digits, the first one carrying 3 bits.
+	var out [26]byte
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// NewUUID returns a random (version 4) UUID.
+func NewUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	var out [36]byte
+	hex.Encode(out[0:8], b[0:4])
+	out[8] = '-'
+	hex.Encode(out[9:13], b[4:6])
+	out[13] = '-'
+	hex.Encode(out[14:18], b[6:8])
+	out[18] = '-'
+	hex.Encode(out[19:23], b[8:10])
+	out[23] = '-'
+	hex.Encode(out[24:], b[10:])
+	return string(out[:])
+}
+
+type options struct {
+	header   string
+	generate func() string
+}
+
+// Option configures Middleware and NewTransport.
+type Option func(*options)
+
+// WithHeader uses name instead of X-Request-ID.
+func WithHeader(name string) Option {
+	return func(o *options) {
+		if name != "" {
+			o.header = name
+		}
+	}
+}
+
+// WithGenerator creates new IDs with generate instead of NewULID, e.g.
+// NewUUID.
+func WithGenerator(generate func() string) Option {
+	return func(o *options) {
+		if generate != nil {
+			o.generate = generate
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{header: Header, generate: NewULID}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Middleware takes the ID from the request header, or generates one when
+// the header is missing or not a plausible ID, stores it in the request
+// context and echoes it in the response header.
+func Middleware(next http.Handler, opts ...Option) http.Handler {
+	o := newOptions(opts)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(o.header)
+		if !valid(id) {
+			id = o.generate()
+		}
+		w.Header().Set(o.header, id)
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
+
+// valid accepts printable ASCII IDs of reasonable length, so a client cannot
+// inject line breaks or huge values into the logs.
+func valid(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Transport is an http.RoundTripper that sets the ID of the request
+// context on outgoing requests that do not carry one yet.
+type Transport struct {
+	base   http.RoundTripper
+	header string
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// NewTransport wraps base, or http.DefaultTransport if base is nil.
+// WithGenerator does not apply: requests without an ID in their context
+// are sent unchanged.
+func NewTransport(base http.RoundTripper, opts ...Option) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{base: base, header: newOptions(opts).header}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id, ok := FromContext(req.Context())
+	if !ok || req.Header.Get(t.header) != "" {
+		return t.base.RoundTrip(req)
+	}
+	// A RoundTripper must not modify the caller's request.
+	req = req.Clone(req.Context())
+	req.Header.Set(t.header, id)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,115 @@
+package correlation
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestIDs(t *testing.T) {
+	ulid := regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`)
+	a := NewULID()
+	time.Sleep(2 * time.Millisecond)
+	b := NewULID()
+	if !ulid.MatchString(a) || !ulid.MatchString(b) {
+		t.Fatalf("invalid ULIDs %q %q", a, b)
+	}
+	if a >= b {
+		t.Errorf("expected ULIDs to sort by time, got %q then %q", a, b)
+	}
+	if id := NewUUID(); !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id) {
+		t.Errorf("invalid UUID %q", id)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var seen string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = FromContext(r.Context())
+	}))
+
+	for name, tc := range map[string]struct {
+		header string
+		keep   bool
+	}{
+		"propagated": {header: "abc-123", keep: true},
+		"missing":    {},
+		"injection":  {header: "abc\nlevel=error"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.header != "" {
+			req.Header.Set(Header, tc.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if seen == "" || rec.Header().Get(Header) != seen {
+			t.Errorf("%s: expected the ID in context and response, got %q and %q", name, seen, rec.Header().Get(Header))
+		}
+		if (seen == tc.header) != tc.keep {
+			t.Errorf("%s: unexpected ID %q", name, seen)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("X-Correlation-ID"))
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: NewTransport(nil, WithHeader("X-Correlation-ID"))}
+
+	ctx := NewContext(context.Background(), "req-7")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if req.Header.Get("X-Correlation-ID") != "" {
+		t.Error("expected the caller's request left unchanged")
+	}
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	if resp, err = client.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(got) != 2 || got[0] != "req-7" || got[1] != "" {
+		t.Errorf("unexpected propagated IDs %q", got)
+	}
+}
+
+type recorder struct {
+	ch chan *models.LogData
+}
+
+func (r *recorder) SendMsg(data *models.LogData) { r.ch <- data }
+
+func TestExtractor(t *testing.T) {
+	rec := &recorder{ch: make(chan *models.LogData, 1)}
+	ls := glog.NewLoggerService(glog.WithContextExtractors(Extractor))
+	ls.AddLogger("rec", rec)
+	ls.Start()
+	defer ls.Stop()
+
+	ctx, id := Ensure(context.Background())
+	if again, _ := Ensure(ctx); again != ctx {
+		t.Error("expected Ensure to keep an existing ID")
+	}
+	ls.NewLogger().Info(ctx, "hello")
+
+	select {
+	case data := <-rec.ch:
+		if len(data.Fields) != 1 || data.Fields[0].Key != FieldKey || data.Fields[0].String != id {
+			t.Errorf("expected %s=%s, got %+v", FieldKey, id, data.Fields)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no record")
+	}
+}
@@ -0,0 +1,100 @@
+package glog
+
+import (
+	"github.com/alexnobleburn/glogger/glog/models"
+	"reflect"
+	"time"
+)
+
+// FieldRepeatCountKey holds how many identical records a collapsed record
+// stands for, not counting the first one, which was written when it
+// arrived.
+const FieldRepeatCountKey = "repeat_count"
+
+// dedupState is owned by the main worker, so it needs no lock.
+type dedupState struct {
+	window time.Duration
+	// first is the record that opened the window; repeats counts the
+	// identical records since and last is the latest of them.
+	first   *models.LogData
+	repeats int
+	last    *models.LogData
+	timer   *time.Timer
+}
+
+// WithDeduplication collapses identical consecutive records, syslog-style:
+// the first one is written at once, and the ones repeating it within
+// window are held back and written as one record, the last repeat with
+// FieldRepeatCountKey set to how many there were. Records are identical
+// when their level, message and fields are; the collapsed record is
+// written when the window ends, when a different record arrives, or on
+// Flush and Stop. A tight retry loop then costs two lines per window.
+func WithDeduplication(window time.Duration) ServiceOption {
+	return func(ls *LoggerService) {
+		if window > 0 {
+			ls.dedup = &dedupState{window: window}
+		}
+	}
+}
+
+// timerC is the channel the main worker waits on for the end of the
+// window, or nil when nothing is held back.
+func (d *dedupState) timerC() <-chan time.Time {
+	if d == nil || d.timer == nil {
+		return nil
+	}
+	return d.timer.C
+}
+
+// hold reports whether logData repeats the record that opened the window
+// and is held back. A record that does not is written after the held
+// ones and opens a new window.
+func (ls *LoggerService) hold(logData *models.LogData) bool {
+	d := ls.dedup
+	if d == nil || logData.Internal {
+		return false
+	}
+	if d.first != nil && sameRecord(d.first, logData) {
+		d.repeats++
+		d.last = logData
+		return true
+	}
+	ls.releaseRepeats()
+	d.first = logData
+	d.timer = time.NewTimer(d.window)
+	return false
+}
+
+// releaseRepeats writes the collapsed record, if any, and closes the
+// window.
+func (ls *LoggerService) releaseRepeats() {
+	d := ls.dedup
+	if d == nil {
+		return
+	}
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	last, repeats := d.last, d.repeats
+	d.first, d.last, d.repeats = nil, nil, 0
+	if repeats == 0 {
+		return
+	}
+	fields := make([]*models.LogField, len(last.Fields), len(last.Fields)+1)
+	copy(fields, last.Fields)
+	last.Fields = append(fields, &models.LogField{Key: FieldRepeatCountKey, Type: models.FieldTypeInt, Integer: repeats})
+	ls.route(last)
+}
+
+func sameRecord(a, b *models.LogData) bool {
+	if a.Level != b.Level || a.Msg != b.Msg || len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for i := range a.Fields {
+		if !reflect.DeepEqual(a.Fields[i], b.Fields[i]) {
+			return false
+		}
+	}
+	return true
+}
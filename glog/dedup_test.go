@@ -0,0 +1,70 @@
+package glog
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+	"time"
+)
+
+func repeatCount(logData *models.LogData) int {
+	for _, f := range logData.Fields {
+		if f.Key == FieldRepeatCountKey {
+			return f.Integer
+		}
+	}
+	return 0
+}
+
+func TestDeduplication_CollapsesConsecutiveRepeats(t *testing.T) {
+	ls := NewLoggerService(WithDeduplication(time.Hour), WithNumWorkers(1), WithBlockingSend())
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+
+	logger := ls.NewLogger()
+	ctx := context.Background()
+	for i := 0; i < 1000; i++ {
+		logger.Warning(ctx, "retrying", models.WithStringField("host", "db1"))
+	}
+	logger.Warning(ctx, "retrying", models.WithStringField("host", "db2"))
+	logger.Info(ctx, "retrying", models.WithStringField("host", "db2"))
+	logger.Info(ctx, "retrying", models.WithStringField("host", "db2"))
+	ls.Stop()
+
+	logs := mock.GetLogs()
+	if len(logs) != 5 {
+		t.Fatalf("expected 5 records, got %d", len(logs))
+	}
+	want := []int{0, 999, 0, 0, 1}
+	for i, logData := range logs {
+		if got := repeatCount(logData); got != want[i] {
+			t.Errorf("record %d: expected repeat_count %d, got %d", i, want[i], got)
+		}
+	}
+}
+
+func TestDeduplication_WindowEnds(t *testing.T) {
+	ls := NewLoggerService(WithDeduplication(20 * time.Millisecond))
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+	defer ls.Stop()
+
+	logger := ls.NewLogger()
+	ctx := context.Background()
+	logger.Info(ctx, "tick")
+	logger.Info(ctx, "tick")
+	logs := waitForLogs(mock, 2, time.Second)
+	if len(logs) != 2 || repeatCount(logs[1]) != 1 {
+		t.Fatalf("expected the repeat written when the window ends, got %d records", len(logs))
+	}
+
+	logger.Info(ctx, "tick")
+	if err := ls.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if logs := mock.GetLogs(); len(logs) != 3 || repeatCount(logs[2]) != 0 {
+		t.Errorf("expected a new window to write the record at once, got %d records", len(logs))
+	}
+}
@@ -0,0 +1,118 @@
+package glog
+
+import (
+	"context"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/publishers"
+	"github.com/alexnobleburn/glogger/glog/webhook"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type failingPublisher struct {
+	mockPublisher
+	err error
+}
+
+func (p *failingPublisher) TrySendMsg(data *models.LogData) error {
+	p.SendMsg(data)
+	return p.err
+}
+
+func TestService_ReportsDeliveryErrors(t *testing.T) {
+	var mu sync.Mutex
+	var handled []error
+	ls := NewLoggerService(WithErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, err)
+	}))
+	backendDown := errors.New("backend down")
+	failing := &failingPublisher{err: backendDown}
+	healthy := &failingPublisher{}
+	ls.AddLogger("failing", failing)
+	ls.AddLogger("healthy", healthy)
+	ls.Start()
+
+	ls.NewLogger().Info(context.Background(), "hello")
+	if err := ls.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected flush error: %v", err)
+	}
+	ls.Stop()
+
+	if len(failing.GetLogs()) != 1 || len(healthy.GetLogs()) != 1 {
+		t.Errorf("expected both publishers to receive the record")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handled) != 1 || !errors.Is(handled[0], ErrDeliveryFailed) || !errors.Is(handled[0], backendDown) {
+		t.Fatalf("expected one delivery error, got %v", handled)
+	}
+	if got := ls.Stats().Failed; got != 1 {
+		t.Errorf("expected Failed 1, got %d", got)
+	}
+}
+
+type brokenWriter struct{}
+
+func (brokenWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestService_ReportsBuiltinSinkErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var handled []error
+	ls := NewLoggerService(WithErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, err)
+	}))
+	var ownMu sync.Mutex
+	var own []error
+	ls.AddLogger("webhook", webhook.New(webhook.Config{URL: srv.URL, Retries: 1, Backoff: time.Millisecond, FlushInterval: 5 * time.Millisecond,
+		ErrorHandler: func(err error) {
+			ownMu.Lock()
+			defer ownMu.Unlock()
+			own = append(own, err)
+		}}))
+	ls.AddLogger("file", publishers.NewWriter(brokenWriter{}, encoder.NewJSON("", "")))
+	ls.Start()
+	ls.NewLogger().Info(context.Background(), "hello")
+	// The webhook fails in its background flush, after SendMsg returned.
+	deadline := time.Now().Add(2 * time.Second)
+	for ls.Stats().Failed < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	ls.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	var webhookErr, fileErr bool
+	for _, err := range handled {
+		if !errors.Is(err, ErrDeliveryFailed) {
+			continue
+		}
+		webhookErr = webhookErr || strings.Contains(err.Error(), `"webhook"`)
+		fileErr = fileErr || strings.Contains(err.Error(), "disk full")
+	}
+	if !webhookErr || !fileErr {
+		t.Errorf("expected delivery errors of both sinks reported to the service, got %v", handled)
+	}
+	ownMu.Lock()
+	defer ownMu.Unlock()
+	if len(own) == 0 {
+		t.Error("expected the webhook's own error handler to still receive its errors")
+	}
+	if got := ls.Stats().Failed; got < 2 {
+		t.Errorf("expected the failures counted, got %d", got)
+	}
+}
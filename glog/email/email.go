@@ -0,0 +1,247 @@
+// Package email collects warnings and errors and mails them as a periodic
+// digest instead of one message per record.
+package email
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/internal/report"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"mime"
+	"net"
+	"net/smtp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultInterval   = 15 * time.Minute
+	defaultMaxRecords = 500
+)
+
+// SendFunc has the signature of smtp.SendMail.
+type SendFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// Config configures a Publisher.
+type Config struct {
+	// Addr is the SMTP server, host:port.
+	Addr string
+	// Username and Password enable PLAIN auth against Addr's host.
+	Username string
+	Password string
+	From     string
+	To       []string
+	// Subject prefixes the digest subject (default "[glogger]").
+	Subject string
+	// MinLevel is the lowest level collected (default models.WarnLevel).
+	MinLevel models.LogLevel
+	// Interval between digests (default 15m). Nothing is sent for an
+	// interval without records.
+	Interval time.Duration
+	// MaxRecords caps the records listed in one digest (default 500);
+	// the rest are only counted in its summary.
+	MaxRecords int
+	// AppID and Env name the service in the subject when the records carry
+	// no models.AppID or models.EnvName.
+	AppID string
+	Env   string
+	// Encoder renders each record as one line of the digest. Defaults to
+	// encoder.NewConsole().
+	Encoder interfaces.Encoder
+	// Send defaults to smtp.SendMail.
+	Send SendFunc
+	// ErrorHandler receives delivery errors. Once the publisher is added to a
+	// service, the service error handler receives them too. Without either,
+	// they are printed.
+	ErrorHandler func(error)
+}
+
+// Compile-time checks that Publisher implements interfaces.LogPublisher and
+// interfaces.ErrorReporter.
+var (
+	_ interfaces.LogPublisher  = (*Publisher)(nil)
+	_ interfaces.ErrorReporter = (*Publisher)(nil)
+)
+
+// Publisher buffers records between digests. SendMsg only appends to the
+// buffer; mail is sent from a background goroutine.
+type Publisher struct {
+	cfg  Config
+	errs *report.Handler
+	auth smtp.Auth
+	now  func() time.Time
+
+	mu      sync.Mutex
+	records [][]byte
+	counts  map[models.LogLevel]int
+	first   time.Time
+
+	sendMu    sync.Mutex
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+func New(cfg Config) (*Publisher, error) {
+	if cfg.Addr == "" || cfg.From == "" || len(cfg.To) == 0 {
+		return nil, errors.New("email: Addr, From and To are required")
+	}
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("email: invalid Addr: %w", err)
+	}
+	if cfg.Subject == "" {
+		cfg.Subject = "[glogger]"
+	}
+	if cfg.MinLevel < models.WarnLevel {
+		cfg.MinLevel = models.WarnLevel
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+	if cfg.MaxRecords <= 0 {
+		cfg.MaxRecords = defaultMaxRecords
+	}
+	if cfg.Encoder == nil {
+		cfg.Encoder = encoder.NewConsole()
+	}
+	if cfg.Send == nil {
+		cfg.Send = smtp.SendMail
+	}
+	errs := report.New(cfg.ErrorHandler)
+	cfg.ErrorHandler = errs.Handle
+	p := &Publisher{
+		cfg:    cfg,
+		now:    time.Now,
+		counts: make(map[models.LogLevel]int),
+		stopCh: make(chan struct{}),
+		errs:   errs,
+	}
+	if cfg.Username != "" {
+		p.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+	p.wg.Add(1)
+	go p.run()
+	return p, nil
+}
+
+// ReportErrorsTo implements interfaces.ErrorReporter.
+func (p *Publisher) ReportErrorsTo(report func(error)) {
+	p.errs.ReportTo(report)
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	if data.Level < p.cfg.MinLevel {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.counts) == 0 {
+		p.first = data.TimeOr(p.now())
+	}
+	p.counts[data.Level]++
+	if len(p.records) >= p.cfg.MaxRecords {
+		return
+	}
+	line, err := p.cfg.Encoder.Encode(data)
+	if err != nil {
+		line = []byte(data.Msg)
+	}
+	p.records = append(p.records, bytes.TrimRight(line, "\n"))
+}
+
+func (p *Publisher) run() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.Flush(context.Background()); err != nil {
+				p.cfg.ErrorHandler(err)
+			}
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+// Flush mails the records collected so far as one digest. A failed digest
+// is not retried; its records are lost and the error is returned.
+func (p *Publisher) Flush(ctx context.Context) error {
+	p.sendMu.Lock()
+	defer p.sendMu.Unlock()
+	p.mu.Lock()
+	records, counts, first := p.records, p.counts, p.first
+	p.records, p.counts = nil, make(map[models.LogLevel]int)
+	p.mu.Unlock()
+	if len(counts) == 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	msg := p.message(records, counts, first)
+	if err := p.cfg.Send(p.cfg.Addr, p.auth, p.cfg.From, p.cfg.To, msg); err != nil {
+		return fmt.Errorf("email: send digest: %w", err)
+	}
+	return nil
+}
+
+// Close sends the last digest and stops the background goroutine.
+func (p *Publisher) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.stopCh)
+	})
+	p.wg.Wait()
+	return p.Flush(context.Background())
+}
+
+func (p *Publisher) message(records [][]byte, counts map[models.LogLevel]int, first time.Time) []byte {
+	total := 0
+	levels := make([]models.LogLevel, 0, len(counts))
+	for l, n := range counts {
+		total += n
+		levels = append(levels, l)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i] > levels[j] })
+	summary := make([]string, 0, len(levels))
+	for _, l := range levels {
+		summary = append(summary, fmt.Sprintf("%d %s", counts[l], l))
+	}
+
+	service := p.cfg.AppID
+	if p.cfg.Env != "" {
+		service += " (" + p.cfg.Env + ")"
+	}
+	subject := fmt.Sprintf("%s %d records", p.cfg.Subject, total)
+	if service != "" {
+		subject += " from " + strings.TrimSpace(service)
+	}
+	subject += ": " + strings.Join(summary, ", ")
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", p.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(p.cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", p.now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	fmt.Fprintf(&b, "%s since %s\r\n", strings.Join(summary, ", "), first.UTC().Format(time.RFC3339))
+	if len(records) < total {
+		fmt.Fprintf(&b, "Showing the first %d of %d records.\r\n", len(records), total)
+	}
+	b.WriteString("\r\n")
+	for _, r := range records {
+		b.WriteString(strings.ReplaceAll(string(r), "\n", "\r\n"))
+		b.WriteString("\r\n")
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,98 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type outbox struct {
+	mu   sync.Mutex
+	msgs []string
+	to   [][]string
+	fail bool
+}
+
+func (o *outbox) send(addr string, _ smtp.Auth, from string, to []string, msg []byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.fail {
+		return errors.New("connection refused")
+	}
+	o.msgs = append(o.msgs, string(msg))
+	o.to = append(o.to, to)
+	return nil
+}
+
+func record(level models.LogLevel, msg string) *models.LogData {
+	return &models.LogData{Ctx: context.Background(), Msg: msg, Level: level}
+}
+
+func TestPublisher_SendsOneDigest(t *testing.T) {
+	box := &outbox{}
+	pub, err := New(Config{
+		Addr:       "smtp.example.com:587",
+		From:       "alerts@example.com",
+		To:         []string{"ops@example.com"},
+		AppID:      "shop",
+		MaxRecords: 2,
+		Send:       box.send,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pub.SendMsg(record(models.InfoLevel, "ignored"))
+	pub.SendMsg(record(models.WarnLevel, "slow query"))
+	pub.SendMsg(record(models.ErrorLevel, "payment failed"))
+	pub.SendMsg(record(models.ErrorLevel, "payment failed again"))
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := pub.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	box.mu.Lock()
+	defer box.mu.Unlock()
+	if len(box.msgs) != 1 {
+		t.Fatalf("expected exactly one digest, got %d", len(box.msgs))
+	}
+	msg := box.msgs[0]
+	if !strings.Contains(msg, "Subject: [glogger] 3 records from shop: 2 error, 1 warn\r\n") {
+		t.Errorf("unexpected subject in %q", msg)
+	}
+	if !strings.Contains(msg, "Showing the first 2 of 3 records.") {
+		t.Error("expected the digest to say it was capped")
+	}
+	if !strings.Contains(msg, "slow query") || !strings.Contains(msg, "payment failed") || strings.Contains(msg, "again") || strings.Contains(msg, "ignored") {
+		t.Errorf("unexpected digest body %q", msg)
+	}
+	if box.to[0][0] != "ops@example.com" {
+		t.Errorf("unexpected recipients %v", box.to[0])
+	}
+}
+
+func TestPublisher_ReportsSendErrors(t *testing.T) {
+	box := &outbox{fail: true}
+	pub, err := New(Config{Addr: "smtp:25", From: "a@b", To: []string{"c@d"}, Send: box.send})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pub.SendMsg(record(models.ErrorLevel, "boom"))
+	if err := pub.Close(); err == nil {
+		t.Error("expected the failed digest reported")
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	if _, err := New(Config{Addr: "smtp:25", From: "a@b"}); err == nil {
+		t.Error("expected error without recipients")
+	}
+	if _, err := New(Config{Addr: "smtp", From: "a@b", To: []string{"c@d"}}); err == nil {
+		t.Error("expected error for an address without port")
+	}
+}
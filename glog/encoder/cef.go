@@ -0,0 +1,120 @@
+package encoder
+
+import (
+	"bytes"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Compile-time check that CEF implements interfaces.Encoder.
+var _ interfaces.Encoder = (*CEF)(nil)
+
+const cefDefaultSignature = "log"
+
+// CEF renders records in ArcSight Common Event Format for SIEM ingestion:
+//
+//	CEF:0|Acme|shop|1.4|checkout|order placed|3|rt=1714564800000 msg=order placed serviceName=shop env=prod id=7
+//
+// The signature ID is the component ("log" if there is none), the name is the
+// message and severity maps debug..fatal onto 1..10. Fields become extension
+// keys with non-alphanumeric characters removed.
+type CEF struct {
+	vendor  string
+	product string
+	version string
+	appID   string
+	env     string
+}
+
+// NewCEF returns a CEF encoder identifying the device as vendor, product and
+// version. appID and env are used unless the record context overrides them.
+func NewCEF(vendor, product, version, appID, env string) *CEF {
+	return &CEF{vendor: vendor, product: product, version: version, appID: appID, env: env}
+}
+
+func (c *CEF) Encode(data *models.LogData) ([]byte, error) {
+	appID, env := identity(data, c.appID, c.env)
+	signature := stringField(data, models.FieldComponentKey)
+	if signature == "" {
+		signature = cefDefaultSignature
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("CEF:0|")
+	for _, h := range []string{c.vendor, c.product, c.version, signature, data.Msg} {
+		buf.WriteString(cefHeader(h))
+		buf.WriteByte('|')
+	}
+	buf.WriteString(strconv.Itoa(cefSeverity(data.Level)))
+	buf.WriteByte('|')
+
+	ext := func(key, value string) {
+		if key == "" {
+			return
+		}
+		if buf.Bytes()[buf.Len()-1] != '|' {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(cefExtension(value))
+	}
+	ext("rt", strconv.FormatInt(timestamp(data).UnixMilli(), 10))
+	ext("msg", data.Msg)
+	ext("serviceName", appID)
+	ext("env", env)
+	for _, f := range data.Fields {
+		if f == nil {
+			continue
+		}
+		ext(cefKey(f.Key), fieldText(f, nil, nil))
+	}
+	return buf.Bytes(), nil
+}
+
+// cefSeverity maps levels onto the CEF 0-10 scale.
+func cefSeverity(level models.LogLevel) int {
+	switch {
+	case level <= models.DebugLevel:
+		return 1
+	case level == models.InfoLevel:
+		return 3
+	case level == models.WarnLevel:
+		return 5
+	case level == models.ErrorLevel:
+		return 7
+	case level == models.DPanicLevel:
+		return 8
+	case level == models.PanicLevel:
+		return 9
+	default:
+		return 10
+	}
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+)
+
+func cefHeader(s string) string {
+	return cefHeaderEscaper.Replace(s)
+}
+
+func cefExtension(s string) string {
+	return cefExtensionEscaper.Replace(s)
+}
+
+// cefKey keeps letters and digits only, as extension keys may not contain
+// spaces, '=' or punctuation.
+func cefKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return -1
+	}, key)
+}
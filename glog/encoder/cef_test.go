@@ -0,0 +1,27 @@
+package encoder
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"regexp"
+	"testing"
+)
+
+func TestCEF_Encode(t *testing.T) {
+	out, err := NewCEF("Acme", "shop|web", "1.4", "shop", "prod").Encode(&models.LogData{
+		Ctx:   context.Background(),
+		Msg:   "login failed",
+		Level: models.ErrorLevel,
+		Fields: []*models.LogField{
+			{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: "auth"},
+			{Key: "user_name", Type: models.FieldTypeString, String: "a=b\nc"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	re := regexp.MustCompile(`^CEF:0\|Acme\|shop\\\|web\|1\.4\|auth\|login failed\|7\|rt=\d+ msg=login failed serviceName=shop env=prod component=auth username=a\\=b\\nc$`)
+	if !re.Match(out) {
+		t.Errorf("unexpected CEF line %s", out)
+	}
+}
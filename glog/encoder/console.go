@@ -0,0 +1,51 @@
+package encoder
+
+import (
+	"bytes"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+)
+
+// Compile-time check that Console implements interfaces.Encoder.
+var _ interfaces.Encoder = (*Console)(nil)
+
+const consoleTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// Console renders records for humans reading a terminal:
+//
+//	2024-05-01T12:00:00.000Z INFO  [checkout] order placed id=7 user=bob
+//
+// The component is shown in brackets and left out of the fields; app and env
+// are omitted.
+type Console struct{}
+
+func NewConsole() *Console {
+	return &Console{}
+}
+
+func (c *Console) Encode(data *models.LogData) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(timestamp(data).Format(consoleTimeLayout))
+	buf.WriteByte(' ')
+	level := strings.ToUpper(data.Level.String())
+	buf.WriteString(level)
+	if pad := 6 - len(level); pad > 0 {
+		buf.WriteString(strings.Repeat(" ", pad))
+	} else {
+		buf.WriteByte(' ')
+	}
+	if component := stringField(data, models.FieldComponentKey); component != "" {
+		buf.WriteByte('[')
+		buf.WriteString(component)
+		buf.WriteString("] ")
+	}
+	buf.WriteString(strings.ReplaceAll(data.Msg, "\n", `\n`))
+	for _, f := range data.Fields {
+		if f == nil || (f.Key == models.FieldComponentKey && f.Type == models.FieldTypeString) {
+			continue
+		}
+		writeLogfmtPair(&buf, f.Key, fieldText(f, nil, nil))
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,27 @@
+package encoder
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+	"testing"
+)
+
+func TestConsole_Encode(t *testing.T) {
+	out, err := NewConsole().Encode(&models.LogData{
+		Ctx:   context.Background(),
+		Msg:   "two\nlines",
+		Level: models.WarnLevel,
+		Fields: []*models.LogField{
+			{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: "checkout"},
+			{Key: "id", Type: models.FieldTypeInt, Integer: 7},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, rest, _ := strings.Cut(string(out), " ")
+	if want := `WARN  [checkout] two\nlines id=7`; rest != want {
+		t.Errorf("expected %q, got %q", want, rest)
+	}
+}
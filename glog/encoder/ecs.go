@@ -0,0 +1,157 @@
+package encoder
+
+import (
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"strings"
+	"time"
+)
+
+// Compile-time check that ECS implements interfaces.Encoder.
+var _ interfaces.Encoder = (*ECS)(nil)
+
+// ECSVersion is the Elastic Common Schema version the output follows.
+const ECSVersion = "8.11.0"
+
+// ecsFields maps glogger's own field keys, and keys commonly used for
+// tracing, to their ECS names.
+var ecsFields = map[string]string{
+	models.FieldComponentKey: "log.logger",
+	models.FieldErrKey:       "error.message",
+	models.FieldFilenameKey:  "error.stack_trace",
+	"trace_id":               "trace.id",
+	"span_id":                "span.id",
+	"transaction_id":         "transaction.id",
+}
+
+// ECSOption configures an ECS encoder.
+type ECSOption func(*ECS)
+
+// WithECSFields maps further field keys to ECS names, e.g.
+// {"user_id": "user.id", "status": "http.response.status_code"}. Mapped
+// fields keep their type. A mapping to "" writes the field as a label even
+// if it has a default mapping.
+func WithECSFields(mapping map[string]string) ECSOption {
+	return func(e *ECS) {
+		for k, v := range mapping {
+			e.fields[k] = v
+		}
+	}
+}
+
+// ECS renders records as Elastic Common Schema documents, so Elasticsearch
+// can ingest them without an ingest pipeline:
+//
+//	{"@timestamp":"...","ecs":{"version":"8.11.0"},"labels":{"id":"7"},"log":{"level":"info","logger":"checkout"},"message":"...","service":{"environment":"prod","name":"shop"}}
+//
+// The component becomes log.logger, the stack captured by Logger.Error
+// becomes error.stack_trace (with the message as error.message), and the
+// error, trace_id, span_id and transaction_id fields get their ECS names.
+// Other fields are written as labels, which ECS defines as keyword values,
+// so numbers and objects are written as strings and dots in keys become
+// underscores.
+type ECS struct {
+	appID  string
+	env    string
+	fields map[string]string
+}
+
+func NewECS(appID, env string, opts ...ECSOption) *ECS {
+	e := &ECS{appID: appID, env: env, fields: make(map[string]string, len(ecsFields))}
+	for k, v := range ecsFields {
+		e.fields[k] = v
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+func (e *ECS) Encode(data *models.LogData) ([]byte, error) {
+	appID, env := identity(data, e.appID, e.env)
+	doc := make(map[string]any)
+	labels := make(map[string]string)
+	for _, f := range data.Fields {
+		if f == nil {
+			continue
+		}
+		name := e.fields[f.Key]
+		if name == "" {
+			labels[strings.ReplaceAll(f.Key, ".", "_")] = fieldText(f, nil, nil)
+			continue
+		}
+		setPath(doc, name, ecsValue(f))
+		if name == "error.stack_trace" && e.fields[models.FieldErrKey] == "error.message" {
+			// Logger.Error writes the error text as the message.
+			if _, ok := lookupPath(doc, "error.message"); !ok {
+				setPath(doc, "error.message", data.Msg)
+			}
+		}
+	}
+	if len(labels) > 0 {
+		doc["labels"] = labels
+	}
+	// The core fields are set last so that a mapping cannot replace them.
+	setPath(doc, "@timestamp", timestamp(data).Format(time.RFC3339Nano))
+	setPath(doc, "ecs.version", ECSVersion)
+	setPath(doc, "log.level", data.Level.String())
+	setPath(doc, "message", data.Msg)
+	setPath(doc, "service.name", appID)
+	setPath(doc, "service.environment", env)
+	return safejson.Marshal(doc)
+}
+
+func ecsValue(f *models.LogField) any {
+	switch f.Type {
+	case models.FieldTypeString:
+		return f.String
+	case models.FieldTypeInt:
+		return f.Integer
+	case models.FieldTypeFloat:
+		return f.Float
+	case models.FieldTypeBool:
+		return f.Bool
+	case models.FieldTypeInt64:
+		return f.Int64
+	case models.FieldTypeUint64:
+		return f.Uint64
+	case models.FieldTypeBinary:
+		return f.Binary
+	case models.FieldTypeDuration:
+		// ECS durations, like event.duration, are in nanoseconds.
+		return int64(f.Duration)
+	case models.FieldTypeTime:
+		return f.Time.Format(time.RFC3339Nano)
+	default:
+		return f.Object
+	}
+}
+
+// setPath stores v at the dotted path, creating nested objects and
+// replacing any non-object value in the way.
+func setPath(doc map[string]any, path string, v any) {
+	parts := strings.Split(path, ".")
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := doc[p].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			doc[p] = next
+		}
+		doc = next
+	}
+	doc[parts[len(parts)-1]] = v
+}
+
+func lookupPath(doc map[string]any, path string) (any, bool) {
+	parts := strings.Split(path, ".")
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := doc[p].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		doc = next
+	}
+	v, ok := doc[parts[len(parts)-1]]
+	return v, ok
+}
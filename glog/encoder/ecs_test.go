@@ -0,0 +1,105 @@
+package encoder
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+)
+
+func TestECS_Encode(t *testing.T) {
+	ctx := context.WithValue(context.Background(), models.EnvName, "staging")
+	out, err := NewECS("shop", "prod").Encode(&models.LogData{
+		Ctx:   ctx,
+		Msg:   "order placed",
+		Level: models.InfoLevel,
+		Fields: []*models.LogField{
+			{Key: "order.id", Type: models.FieldTypeInt, Integer: 7},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var doc struct {
+		Timestamp string            `json:"@timestamp"`
+		Message   string            `json:"message"`
+		Labels    map[string]string `json:"labels"`
+		Log       struct {
+			Level string `json:"level"`
+		} `json:"log"`
+		Service struct {
+			Name        string `json:"name"`
+			Environment string `json:"environment"`
+		} `json:"service"`
+		ECS struct {
+			Version string `json:"version"`
+		} `json:"ecs"`
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("invalid JSON %s: %v", out, err)
+	}
+	if doc.Timestamp == "" || doc.Message != "order placed" || doc.Log.Level != "info" || doc.ECS.Version != ECSVersion {
+		t.Errorf("unexpected document %s", out)
+	}
+	if doc.Service.Name != "shop" || doc.Service.Environment != "staging" {
+		t.Errorf("unexpected service %+v", doc.Service)
+	}
+	if doc.Labels["order_id"] != "7" {
+		t.Errorf("unexpected labels %v", doc.Labels)
+	}
+}
+
+func TestECS_FieldMapping(t *testing.T) {
+	out, err := NewECS("shop", "prod", WithECSFields(map[string]string{
+		"status":   "http.response.status_code",
+		"trace_id": "",
+	})).Encode(&models.LogData{
+		Ctx:   context.Background(),
+		Msg:   "connection refused",
+		Level: models.ErrorLevel,
+		Fields: []*models.LogField{
+			{Key: models.FieldFilenameKey, Type: models.FieldTypeString, String: "db.go:12 <- main.go:3"},
+			{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: "checkout"},
+			{Key: "status", Type: models.FieldTypeInt, Integer: 502},
+			{Key: "span_id", Type: models.FieldTypeString, String: "00f067aa0ba902b7"},
+			{Key: "trace_id", Type: models.FieldTypeString, String: "4bf92f35"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var doc struct {
+		Log struct {
+			Level  string `json:"level"`
+			Logger string `json:"logger"`
+		} `json:"log"`
+		Error struct {
+			Message    string `json:"message"`
+			StackTrace string `json:"stack_trace"`
+		} `json:"error"`
+		HTTP struct {
+			Response struct {
+				StatusCode int `json:"status_code"`
+			} `json:"response"`
+		} `json:"http"`
+		Span struct {
+			ID string `json:"id"`
+		} `json:"span"`
+		Labels map[string]string `json:"labels"`
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("invalid JSON %s: %v", out, err)
+	}
+	if doc.Log.Level != "error" || doc.Log.Logger != "checkout" {
+		t.Errorf("unexpected log object %+v", doc.Log)
+	}
+	if doc.Error.StackTrace != "db.go:12 <- main.go:3" || doc.Error.Message != "connection refused" {
+		t.Errorf("unexpected error object %+v", doc.Error)
+	}
+	if doc.HTTP.Response.StatusCode != 502 || doc.Span.ID != "00f067aa0ba902b7" {
+		t.Errorf("expected mapped fields to keep their type, got %s", out)
+	}
+	if len(doc.Labels) != 1 || doc.Labels["trace_id"] != "4bf92f35" {
+		t.Errorf("expected only the unmapped trace_id as a label, got %v", doc.Labels)
+	}
+}
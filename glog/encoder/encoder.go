@@ -0,0 +1,74 @@
+package encoder
+
+import (
+	"context"
+	"encoding/base64"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"strconv"
+	"time"
+)
+
+// identity returns the app and env of a record: the context values if set,
+// otherwise the encoder defaults.
+func identity(data *models.LogData, defaultAppID, defaultEnv string) (string, string) {
+	ctx := data.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	appID, ok := ctx.Value(models.AppID).(string)
+	if !ok || appID == "" {
+		appID = defaultAppID
+	}
+	env, ok := ctx.Value(models.EnvName).(string)
+	if !ok || env == "" {
+		env = defaultEnv
+	}
+	return appID, env
+}
+
+// timestamp returns the event time of data, or now if it has none.
+func timestamp(data *models.LogData) time.Time {
+	return data.TimeOr(time.Now()).UTC()
+}
+
+// stringField returns the value of the first string field named key.
+func stringField(data *models.LogData, key string) string {
+	for _, f := range data.Fields {
+		if f != nil && f.Key == key && f.Type == models.FieldTypeString {
+			return f.String
+		}
+	}
+	return ""
+}
+
+// fieldText renders a field value for the text encoders; objects are written
+// as JSON.
+func fieldText(f *models.LogField, opts []safejson.Option, onError func(error)) string {
+	switch f.Type {
+	case models.FieldTypeString:
+		return f.String
+	case models.FieldTypeInt:
+		return strconv.Itoa(f.Integer)
+	case models.FieldTypeFloat:
+		return strconv.FormatFloat(f.Float, 'g', -1, 64)
+	case models.FieldTypeBool:
+		return strconv.FormatBool(f.Bool)
+	case models.FieldTypeInt64:
+		return strconv.FormatInt(f.Int64, 10)
+	case models.FieldTypeUint64:
+		return strconv.FormatUint(f.Uint64, 10)
+	case models.FieldTypeBinary:
+		return base64.StdEncoding.EncodeToString(f.Binary)
+	case models.FieldTypeDuration:
+		return f.Duration.String()
+	case models.FieldTypeTime:
+		return f.Time.Format(time.RFC3339Nano)
+	default:
+		b, err := safejson.Marshal(f.Object, opts...)
+		if err != nil && onError != nil {
+			onError(err)
+		}
+		return string(b)
+	}
+}
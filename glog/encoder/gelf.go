@@ -0,0 +1,91 @@
+package encoder
+
+import (
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"math"
+	"os"
+	"strings"
+)
+
+// Compile-time check that GELF implements interfaces.Encoder.
+var _ interfaces.Encoder = (*GELF)(nil)
+
+// GELF renders records as Graylog Extended Log Format 1.1 messages:
+//
+//	{"_env":"prod","_id_":7,"_service_name":"shop","host":"web-1","level":6,"short_message":"order placed","timestamp":1714564800.123,"version":"1.1"}
+//
+// The level is the syslog severity. Fields become additional fields with a
+// leading underscore; characters GELF does not allow in names become '_',
+// and "_id", which GELF reserves, is written as "_id_". GELF values are
+// strings or numbers, so bools and objects are written as strings. A
+// multi-line message keeps its first line as short_message and the whole
+// text as full_message.
+type GELF struct {
+	host  string
+	appID string
+	env   string
+}
+
+// NewGELF creates a GELF encoder. An empty host defaults to os.Hostname.
+func NewGELF(host, appID, env string) *GELF {
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	return &GELF{host: host, appID: appID, env: env}
+}
+
+func (g *GELF) Encode(data *models.LogData) ([]byte, error) {
+	appID, env := identity(data, g.appID, g.env)
+	short, _, multiline := strings.Cut(data.Msg, "\n")
+	if short == "" {
+		short = "-"
+	}
+	doc := map[string]any{
+		"version":       "1.1",
+		"host":          g.host,
+		"short_message": short,
+		"timestamp":     float64(timestamp(data).UnixMilli()) / 1000,
+		"level":         models.SyslogSeverityEncoder(data.Level),
+	}
+	if multiline {
+		doc["full_message"] = data.Msg
+	}
+	if appID != "" {
+		doc["_service_name"] = appID
+	}
+	if env != "" {
+		doc["_env"] = env
+	}
+	for _, f := range data.Fields {
+		if f == nil {
+			continue
+		}
+		key := gelfKey(f.Key)
+		switch {
+		case f.Type == models.FieldTypeInt:
+			doc[key] = f.Integer
+		case f.Type == models.FieldTypeFloat && !math.IsNaN(f.Float) && !math.IsInf(f.Float, 0):
+			doc[key] = f.Float
+		default:
+			doc[key] = fieldText(f, nil, nil)
+		}
+	}
+	return safejson.Marshal(doc)
+}
+
+// gelfKey returns the additional field name for key.
+func gelfKey(key string) string {
+	key = "_" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, key)
+	if key == "_id" {
+		return "_id_"
+	}
+	return key
+}
@@ -0,0 +1,53 @@
+package encoder
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+	"time"
+)
+
+func TestGELF_Encode(t *testing.T) {
+	out, err := NewGELF("web-1", "shop", "prod").Encode(&models.LogData{
+		Ctx:   context.Background(),
+		Msg:   "order failed\ncaused by: timeout",
+		Level: models.ErrorLevel,
+		Time:  time.Date(2024, 5, 1, 12, 0, 0, 123e6, time.UTC),
+		Fields: []*models.LogField{
+			{Key: "id", Type: models.FieldTypeInt, Integer: 7},
+			{Key: "user name", Type: models.FieldTypeString, String: "bob"},
+			{Key: "paid", Type: models.FieldTypeBool, Bool: false},
+			{Key: "cart", Type: models.FieldTypeObject, Object: map[string]int{"n": 2}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("invalid JSON %s: %v", out, err)
+	}
+	want := map[string]any{
+		"version":       "1.1",
+		"host":          "web-1",
+		"short_message": "order failed",
+		"full_message":  "order failed\ncaused by: timeout",
+		"timestamp":     1714564800.123,
+		"level":         float64(3),
+		"_service_name": "shop",
+		"_env":          "prod",
+		"_id_":          float64(7),
+		"_user_name":    "bob",
+		"_paid":         "false",
+		"_cart":         `{"n":2}`,
+	}
+	for k, v := range want {
+		if doc[k] != v {
+			t.Errorf("%s = %#v, want %#v", k, doc[k], v)
+		}
+	}
+	if len(doc) != len(want) {
+		t.Errorf("unexpected keys in %s", out)
+	}
+}
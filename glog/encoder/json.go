@@ -0,0 +1,181 @@
+// Package encoder serializes records for publishers that ship bytes rather
+// than calling a logging library, such as Kafka, HTTP sinks or
+// publishers.Writer. Every encoder implements interfaces.Encoder, so format
+// and transport can be combined freely.
+package encoder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"strconv"
+	"time"
+)
+
+// Compile-time checks that JSON implements interfaces.Encoder and
+// interfaces.SchemaDescriber.
+var (
+	_ interfaces.Encoder         = (*JSON)(nil)
+	_ interfaces.SchemaDescriber = (*JSON)(nil)
+)
+
+// JSON renders records in the same shape as the zap publisher:
+//
+//	{"level":"info","timestamp":"...","msg":"...","service_name":"...","env":"...","payload":{...}}
+//
+// Object fields go through safejson, so the output is always valid JSON.
+type JSON struct {
+	appID      string
+	env        string
+	keys       models.OutputKeys
+	flat       bool
+	objectOpts []safejson.Option
+	onError    func(error)
+}
+
+// JSONOption configures a JSON encoder.
+type JSONOption func(*JSON)
+
+// WithMaxObjectDepth limits nesting of object fields (default
+// safejson.DefaultMaxDepth).
+func WithMaxObjectDepth(depth int) JSONOption {
+	return func(j *JSON) {
+		j.objectOpts = append(j.objectOpts, safejson.WithMaxDepth(depth))
+	}
+}
+
+// WithErrorHandler receives object fields that had to be replaced by
+// placeholders.
+func WithErrorHandler(handler func(error)) JSONOption {
+	return func(j *JSON) {
+		j.onError = handler
+	}
+}
+
+// WithOutputKeys renames the message, level and timestamp keys; use
+// models.OmitKey to leave one out.
+func WithOutputKeys(keys models.OutputKeys) JSONOption {
+	return func(j *JSON) {
+		j.keys = keys.WithDefaults()
+	}
+}
+
+// WithFlatLayout writes fields at the top level instead of under "payload".
+// A field whose key collides with a top-level key is written as
+// "fields.<key>".
+func WithFlatLayout() JSONOption {
+	return func(j *JSON) {
+		j.flat = true
+	}
+}
+
+// NewJSON returns a JSON encoder. appID and env are used unless the record
+// context carries models.AppID or models.EnvName.
+func NewJSON(appID, env string, opts ...JSONOption) *JSON {
+	j := &JSON{appID: appID, env: env, keys: models.DefaultOutputKeys()}
+	for _, opt := range opts {
+		opt(j)
+	}
+	return j
+}
+
+// Encode returns one JSON object without a trailing newline.
+func (j *JSON) Encode(data *models.LogData) ([]byte, error) {
+	appID, env := identity(data, j.appID, j.env)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	key := func(k string) {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		j.string(&buf, k)
+		buf.WriteByte(':')
+	}
+	if j.keys.Level != models.OmitKey {
+		key(j.keys.Level)
+		j.string(&buf, data.Level.String())
+	}
+	if j.keys.Timestamp != models.OmitKey {
+		key(j.keys.Timestamp)
+		j.string(&buf, timestamp(data).Format(time.RFC3339))
+	}
+	if j.keys.Message != models.OmitKey {
+		key(j.keys.Message)
+		j.string(&buf, data.Msg)
+	}
+	key("service_name")
+	j.string(&buf, appID)
+	key("env")
+	j.string(&buf, env)
+
+	if !j.flat {
+		key("payload")
+		buf.WriteByte('{')
+		first = true
+	}
+	for _, f := range data.Fields {
+		if f == nil {
+			continue
+		}
+		if j.flat {
+			key(j.keys.FlatFieldKey(f.Key))
+		} else {
+			key(f.Key)
+		}
+		j.field(&buf, f)
+	}
+	if !j.flat {
+		buf.WriteByte('}')
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (j *JSON) field(buf *bytes.Buffer, f *models.LogField) {
+	switch f.Type {
+	case models.FieldTypeString:
+		j.string(buf, f.String)
+	case models.FieldTypeInt:
+		buf.WriteString(strconv.Itoa(f.Integer))
+	case models.FieldTypeFloat:
+		j.object(buf, f.Key, f.Float)
+	case models.FieldTypeBool:
+		buf.WriteString(strconv.FormatBool(f.Bool))
+	case models.FieldTypeInt64:
+		buf.WriteString(strconv.FormatInt(f.Int64, 10))
+	case models.FieldTypeUint64:
+		buf.WriteString(strconv.FormatUint(f.Uint64, 10))
+	case models.FieldTypeBinary:
+		j.string(buf, base64.StdEncoding.EncodeToString(f.Binary))
+	case models.FieldTypeDuration:
+		j.string(buf, f.Duration.String())
+	case models.FieldTypeTime:
+		j.string(buf, f.Time.Format(time.RFC3339Nano))
+	default:
+		j.object(buf, f.Key, f.Object)
+	}
+}
+
+func (j *JSON) object(buf *bytes.Buffer, key string, v any) {
+	b, err := safejson.Marshal(v, j.objectOpts...)
+	if err != nil && j.onError != nil {
+		j.onError(fmt.Errorf("field %q: %w", key, err))
+	}
+	buf.Write(b)
+}
+
+func (j *JSON) string(buf *bytes.Buffer, s string) {
+	b, _ := safejson.Marshal(s)
+	buf.Write(b)
+}
+
+// Schema describes the records this encoder writes.
+func (j *JSON) Schema() *models.Schema {
+	return models.RecordSchema("glogger JSON encoder", j.keys, j.flat, nil)
+}
@@ -0,0 +1,102 @@
+package encoder
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSON_Encode(t *testing.T) {
+	enc := NewJSON("shop", "prod")
+	ctx := context.WithValue(context.Background(), models.EnvName, "staging")
+
+	out, err := enc.Encode(&models.LogData{
+		Ctx:   ctx,
+		Msg:   "order <placed>\n",
+		Level: models.WarnLevel,
+		Fields: []*models.LogField{
+			{Key: "id", Type: models.FieldTypeInt, Integer: 7},
+			{Key: "ok", Type: models.FieldTypeBool, Bool: true},
+			{Key: "ratio", Type: models.FieldTypeFloat, Float: 0.5},
+			{Key: "user", Type: models.FieldTypeString, String: "bob"},
+			{Key: "cart", Type: models.FieldTypeObject, Object: map[string]int{"n": 2}},
+			{Key: "took", Type: models.FieldTypeDuration, Duration: 1500 * time.Millisecond},
+			{Key: "at", Type: models.FieldTypeTime, Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+			{Key: "big", Type: models.FieldTypeUint64, Uint64: math.MaxUint64},
+			{Key: "raw", Type: models.FieldTypeBinary, Binary: []byte("hi")},
+			nil,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got struct {
+		Level   string         `json:"level"`
+		Msg     string         `json:"msg"`
+		Service string         `json:"service_name"`
+		Env     string         `json:"env"`
+		Payload map[string]any `json:"payload"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("invalid JSON %s: %v", out, err)
+	}
+	if !strings.Contains(string(out), `"big":18446744073709551615`) {
+		t.Errorf("expected the exact uint64, got %s", out)
+	}
+	if got.Level != "warn" || got.Msg != "order <placed>\n" || got.Service != "shop" || got.Env != "staging" {
+		t.Errorf("unexpected envelope: %+v", got)
+	}
+	if got.Payload["id"] != 7.0 || got.Payload["ok"] != true || got.Payload["user"] != "bob" ||
+		got.Payload["took"] != "1.5s" || got.Payload["at"] != "2024-05-01T12:00:00Z" || got.Payload["raw"] != "aGk=" {
+		t.Errorf("unexpected payload: %v", got.Payload)
+	}
+}
+
+func TestJSON_InvalidValues(t *testing.T) {
+	var problems int
+	enc := NewJSON("shop", "prod", WithErrorHandler(func(error) { problems++ }))
+	out, _ := enc.Encode(&models.LogData{
+		Msg: "bad values",
+		Fields: []*models.LogField{
+			{Key: "nan", Type: models.FieldTypeFloat, Float: math.NaN()},
+			{Key: "ch", Type: models.FieldTypeObject, Object: make(chan int)},
+		},
+	})
+	if !json.Valid(out) {
+		t.Errorf("expected valid JSON, got %s", out)
+	}
+	if problems != 2 {
+		t.Errorf("expected 2 reported problems, got %d", problems)
+	}
+}
+
+func TestJSON_OutputKeysAndFlatLayout(t *testing.T) {
+	enc := NewJSON("shop", "prod",
+		WithOutputKeys(models.OutputKeys{Message: "message", Level: "severity", Timestamp: models.OmitKey}),
+		WithFlatLayout())
+	out, _ := enc.Encode(&models.LogData{
+		Msg:   "legacy",
+		Level: models.WarnLevel,
+		Fields: []*models.LogField{
+			{Key: "env", Type: models.FieldTypeString, String: "collides"},
+			{Key: "id", Type: models.FieldTypeInt, Integer: 1},
+		},
+	})
+	want := `{"severity":"warn","message":"legacy","service_name":"shop","env":"prod","fields.env":"collides","id":1}`
+	if string(out) != want {
+		t.Errorf("expected %s, got %s", want, out)
+	}
+}
+
+func TestJSON_ExplicitTimestamp(t *testing.T) {
+	eventTime := time.Date(2023, 3, 14, 15, 9, 26, 0, time.FixedZone("CET", 3600))
+	out, _ := NewJSON("shop", "prod").Encode(&models.LogData{Msg: "backfilled", Time: eventTime})
+	if !strings.Contains(string(out), `"timestamp":"2023-03-14T14:09:26Z"`) {
+		t.Errorf("expected the event time in UTC, got %s", out)
+	}
+}
@@ -0,0 +1,81 @@
+package encoder
+
+import (
+	"bytes"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Compile-time check that Logfmt implements interfaces.Encoder.
+var _ interfaces.Encoder = (*Logfmt)(nil)
+
+// Logfmt renders records as logfmt key=value pairs:
+//
+//	time=2024-05-01T12:00:00Z level=info msg="order placed" service_name=shop env=prod id=7
+//
+// Fields follow in record order; object fields are written as quoted JSON.
+type Logfmt struct {
+	appID string
+	env   string
+}
+
+func NewLogfmt(appID, env string) *Logfmt {
+	return &Logfmt{appID: appID, env: env}
+}
+
+func (l *Logfmt) Encode(data *models.LogData) ([]byte, error) {
+	appID, env := identity(data, l.appID, l.env)
+	var buf bytes.Buffer
+	writeLogfmtPair(&buf, "time", timestamp(data).Format(time.RFC3339))
+	writeLogfmtPair(&buf, "level", data.Level.String())
+	writeLogfmtPair(&buf, "msg", data.Msg)
+	writeLogfmtPair(&buf, "service_name", appID)
+	writeLogfmtPair(&buf, "env", env)
+	for _, f := range data.Fields {
+		if f == nil {
+			continue
+		}
+		writeLogfmtPair(&buf, f.Key, fieldText(f, nil, nil))
+	}
+	return buf.Bytes(), nil
+}
+
+func writeLogfmtPair(buf *bytes.Buffer, key, value string) {
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(logfmtKey(key))
+	buf.WriteByte('=')
+	buf.WriteString(logfmtValue(value))
+}
+
+// logfmtKey replaces characters that would end the key with '_'.
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// logfmtValue quotes values that are empty or contain spaces, quotes, '=' or
+// non-printable characters.
+func logfmtValue(value string) string {
+	if value == "" {
+		return `""`
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return strconv.Quote(value)
+		}
+	}
+	return value
+}
@@ -0,0 +1,33 @@
+package encoder
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+	"testing"
+)
+
+func TestLogfmt_Encode(t *testing.T) {
+	out, err := NewLogfmt("shop", "prod").Encode(&models.LogData{
+		Ctx:   context.Background(),
+		Msg:   "order placed",
+		Level: models.InfoLevel,
+		Fields: []*models.LogField{
+			{Key: "id", Type: models.FieldTypeInt, Integer: 7},
+			{Key: "user name", Type: models.FieldTypeString, String: `bob "b"`},
+			{Key: "empty", Type: models.FieldTypeString},
+			{Key: "cart", Type: models.FieldTypeObject, Object: map[string]int{"n": 2}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, rest, _ := strings.Cut(string(out), " ")
+	want := `level=info msg="order placed" service_name=shop env=prod id=7 user_name="bob \"b\"" empty="" cart="{\"n\":2}"`
+	if rest != want {
+		t.Errorf("expected %s, got %s", want, rest)
+	}
+	if !strings.HasPrefix(string(out), "time=") {
+		t.Errorf("expected time first, got %s", out)
+	}
+}
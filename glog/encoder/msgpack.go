@@ -0,0 +1,266 @@
+package encoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"math"
+	"sort"
+	"time"
+)
+
+// Compile-time check that MsgPack implements interfaces.Encoder.
+var _ interfaces.Encoder = (*MsgPack)(nil)
+
+// MsgPack renders records as MessagePack maps with the keys of the JSON
+// encoder (level, timestamp, msg, service_name, env and payload). It is
+// smaller and cheaper to produce than JSON, for network publishers whose
+// consumers read MessagePack (Fluentd, Vector, custom collectors).
+//
+// The timestamp uses the MessagePack timestamp extension (type -1). Object
+// fields go through safejson and are written as nested maps and arrays.
+// Encoding never fails; the output is not newline-delimited, so use it with
+// message-oriented transports (Kafka, NATS, MQTT, UDP) rather than
+// publishers.Writer.
+type MsgPack struct {
+	appID string
+	env   string
+}
+
+func NewMsgPack(appID, env string) *MsgPack {
+	return &MsgPack{appID: appID, env: env}
+}
+
+func (m *MsgPack) Encode(data *models.LogData) ([]byte, error) {
+	appID, env := identity(data, m.appID, m.env)
+	var buf bytes.Buffer
+	mpMapHeader(&buf, 6)
+	mpString(&buf, "level")
+	mpString(&buf, data.Level.String())
+	mpString(&buf, "timestamp")
+	mpTime(&buf, timestamp(data))
+	mpString(&buf, "msg")
+	mpString(&buf, data.Msg)
+	mpString(&buf, "service_name")
+	mpString(&buf, appID)
+	mpString(&buf, "env")
+	mpString(&buf, env)
+
+	mpString(&buf, "payload")
+	n := 0
+	for _, f := range data.Fields {
+		if f != nil {
+			n++
+		}
+	}
+	mpMapHeader(&buf, n)
+	for _, f := range data.Fields {
+		if f == nil {
+			continue
+		}
+		mpString(&buf, f.Key)
+		switch f.Type {
+		case models.FieldTypeString:
+			mpString(&buf, f.String)
+		case models.FieldTypeInt:
+			mpInt(&buf, int64(f.Integer))
+		case models.FieldTypeFloat:
+			mpFloat(&buf, f.Float)
+		case models.FieldTypeBool:
+			mpBool(&buf, f.Bool)
+		case models.FieldTypeInt64:
+			mpInt(&buf, f.Int64)
+		case models.FieldTypeUint64:
+			mpUint(&buf, f.Uint64)
+		case models.FieldTypeBinary:
+			mpBinary(&buf, f.Binary)
+		case models.FieldTypeDuration:
+			mpString(&buf, f.Duration.String())
+		case models.FieldTypeTime:
+			mpTime(&buf, f.Time)
+		default:
+			mpObject(&buf, f.Object)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// mpObject writes v with the structure safejson gives it.
+func mpObject(buf *bytes.Buffer, v any) {
+	b, _ := safejson.Marshal(v)
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var decoded any
+	if err := dec.Decode(&decoded); err != nil {
+		mpString(buf, string(b))
+		return
+	}
+	mpValue(buf, decoded)
+}
+
+// mpValue writes a value decoded from JSON.
+func mpValue(buf *bytes.Buffer, v any) {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		mpBool(buf, v)
+	case string:
+		mpString(buf, v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			mpInt(buf, i)
+		} else if f, err := v.Float64(); err == nil {
+			mpFloat(buf, f)
+		} else {
+			mpString(buf, v.String())
+		}
+	case []any:
+		mpArrayHeader(buf, len(v))
+		for _, e := range v {
+			mpValue(buf, e)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		mpMapHeader(buf, len(keys))
+		for _, k := range keys {
+			mpString(buf, k)
+			mpValue(buf, v[k])
+		}
+	default:
+		mpString(buf, fmt.Sprint(v))
+	}
+}
+
+func mpBool(buf *bytes.Buffer, b bool) {
+	if b {
+		buf.WriteByte(0xc3)
+	} else {
+		buf.WriteByte(0xc2)
+	}
+}
+
+func mpString(buf *bytes.Buffer, s string) {
+	switch n := len(s); {
+	case n < 32:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		buf.WriteByte(0xdb)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+	buf.WriteString(s)
+}
+
+func mpInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i < 128:
+		buf.WriteByte(byte(i))
+	case i >= -32 && i < 0:
+		buf.WriteByte(byte(int8(i)))
+	case i >= 0 && i <= math.MaxUint8:
+		buf.WriteByte(0xcc)
+		buf.WriteByte(byte(i))
+	case i >= 0 && i <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(i)))
+	case i >= 0 && i <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(i)))
+	case i >= 0:
+		buf.WriteByte(0xcf)
+		buf.Write(binary.BigEndian.AppendUint64(nil, uint64(i)))
+	case i >= math.MinInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(int8(i)))
+	case i >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(int16(i))))
+	case i >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(int32(i))))
+	default:
+		buf.WriteByte(0xd3)
+		buf.Write(binary.BigEndian.AppendUint64(nil, uint64(i)))
+	}
+}
+
+func mpUint(buf *bytes.Buffer, u uint64) {
+	if u <= math.MaxInt64 {
+		mpInt(buf, int64(u))
+		return
+	}
+	buf.WriteByte(0xcf)
+	buf.Write(binary.BigEndian.AppendUint64(nil, u))
+}
+
+func mpBinary(buf *bytes.Buffer, b []byte) {
+	switch n := len(b); {
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xc4)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xc5)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		buf.WriteByte(0xc6)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+	buf.Write(b)
+}
+
+func mpFloat(buf *bytes.Buffer, f float64) {
+	buf.WriteByte(0xcb)
+	buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+}
+
+func mpMapHeader(buf *bytes.Buffer, n int) {
+	mpHeader(buf, n, 0x80, 0xde, 0xdf)
+}
+
+func mpArrayHeader(buf *bytes.Buffer, n int) {
+	mpHeader(buf, n, 0x90, 0xdc, 0xdd)
+}
+
+func mpHeader(buf *bytes.Buffer, n int, fix, b16, b32 byte) {
+	switch {
+	case n < 16:
+		buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(b16)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		buf.WriteByte(b32)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+// mpTime writes the timestamp extension in its smallest form.
+func mpTime(buf *bytes.Buffer, t time.Time) {
+	sec, nsec := t.Unix(), int64(t.Nanosecond())
+	switch {
+	case sec >= 0 && sec>>34 == 0 && nsec == 0 && sec <= math.MaxUint32:
+		buf.Write([]byte{0xd6, 0xff})
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(sec)))
+	case sec >= 0 && sec>>34 == 0:
+		buf.Write([]byte{0xd7, 0xff})
+		buf.Write(binary.BigEndian.AppendUint64(nil, uint64(nsec)<<34|uint64(sec)))
+	default:
+		buf.Write([]byte{0xc7, 12, 0xff})
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(nsec)))
+		buf.Write(binary.BigEndian.AppendUint64(nil, uint64(sec)))
+	}
+}
@@ -0,0 +1,200 @@
+package encoder
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// mpDecode is a minimal MessagePack decoder for the types MsgPack writes.
+func mpDecode(b []byte) (any, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, fmt.Errorf("unexpected end")
+	}
+	c, b := b[0], b[1:]
+	u := func(n int) uint64 {
+		var v uint64
+		for _, x := range b[:n] {
+			v = v<<8 | uint64(x)
+		}
+		return v
+	}
+	collection := func(n int, isMap bool) (any, []byte, error) {
+		if isMap {
+			m := make(map[string]any, n)
+			for i := 0; i < n; i++ {
+				k, rest, err := mpDecode(b)
+				if err != nil {
+					return nil, nil, err
+				}
+				v, rest, err := mpDecode(rest)
+				if err != nil {
+					return nil, nil, err
+				}
+				m[k.(string)], b = v, rest
+			}
+			return m, b, nil
+		}
+		a := make([]any, n)
+		for i := range a {
+			v, rest, err := mpDecode(b)
+			if err != nil {
+				return nil, nil, err
+			}
+			a[i], b = v, rest
+		}
+		return a, b, nil
+	}
+	switch {
+	case c < 0x80:
+		return int64(c), b, nil
+	case c >= 0xe0:
+		return int64(int8(c)), b, nil
+	case c&0xf0 == 0x80:
+		return collection(int(c&0x0f), true)
+	case c&0xf0 == 0x90:
+		return collection(int(c&0x0f), false)
+	case c&0xe0 == 0xa0:
+		n := int(c & 0x1f)
+		return string(b[:n]), b[n:], nil
+	}
+	switch c {
+	case 0xc0:
+		return nil, b, nil
+	case 0xc2, 0xc3:
+		return c == 0xc3, b, nil
+	case 0xcb:
+		return math.Float64frombits(u(8)), b[8:], nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n := 1 << (c - 0xcc)
+		return int64(u(n)), b[n:], nil
+	case 0xd0:
+		return int64(int8(b[0])), b[1:], nil
+	case 0xd1:
+		return int64(int16(u(2))), b[2:], nil
+	case 0xd2:
+		return int64(int32(u(4))), b[4:], nil
+	case 0xd3:
+		return int64(u(8)), b[8:], nil
+	case 0xd9, 0xda, 0xdb:
+		l := 1 << (c - 0xd9)
+		n := int(u(l))
+		return string(b[l : l+n]), b[l+n:], nil
+	case 0xdc, 0xde:
+		n := int(u(2))
+		b = b[2:]
+		return collection(n, c == 0xde)
+	case 0xd6:
+		return time.Unix(int64(binary.BigEndian.Uint32(b[1:5])), 0), b[5:], nil
+	case 0xd7:
+		v := binary.BigEndian.Uint64(b[1:9])
+		return time.Unix(int64(v&(1<<34-1)), int64(v>>34)), b[9:], nil
+	case 0xc7:
+		nsec := binary.BigEndian.Uint32(b[2:6])
+		sec := int64(binary.BigEndian.Uint64(b[6:14]))
+		return time.Unix(sec, int64(nsec)), b[14:], nil
+	}
+	return nil, nil, fmt.Errorf("unsupported type byte %#x", c)
+}
+
+func TestMsgPack_Encode(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC)
+	data := &models.LogData{
+		Ctx:   context.WithValue(context.Background(), models.EnvName, "staging"),
+		Msg:   "order placed",
+		Level: models.WarnLevel,
+		Time:  at,
+		Fields: []*models.LogField{
+			{Key: "id", Type: models.FieldTypeInt, Integer: 70000},
+			{Key: "delta", Type: models.FieldTypeInt, Integer: -200},
+			{Key: "total", Type: models.FieldTypeFloat, Float: 12.5},
+			{Key: "paid", Type: models.FieldTypeBool, Bool: true},
+			{Key: "cart", Type: models.FieldTypeObject, Object: map[string]any{"items": []string{"book", "pen"}, "n": 2}},
+			nil,
+		},
+	}
+	out, err := NewMsgPack("shop", "prod").Encode(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, rest, err := mpDecode(out)
+	if err != nil || len(rest) != 0 {
+		t.Fatalf("decode: %v (%d trailing bytes)", err, len(rest))
+	}
+	doc := got.(map[string]any)
+	if ts, _ := doc["timestamp"].(time.Time); !ts.Equal(at) {
+		t.Errorf("expected timestamp %v, got %v", at, doc["timestamp"])
+	}
+	delete(doc, "timestamp")
+	want := map[string]any{
+		"level":        "warn",
+		"msg":          "order placed",
+		"service_name": "shop",
+		"env":          "staging",
+		"payload": map[string]any{
+			"id":    int64(70000),
+			"delta": int64(-200),
+			"total": 12.5,
+			"paid":  true,
+			"cart":  map[string]any{"items": []any{"book", "pen"}, "n": int64(2)},
+		},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("expected %v, got %v", want, doc)
+	}
+
+	js, _ := NewJSON("shop", "prod").Encode(data)
+	if len(out) >= len(js) {
+		t.Errorf("expected MessagePack (%d bytes) to be smaller than JSON (%d bytes)", len(out), len(js))
+	}
+}
+
+func TestMsgPack_Timestamps(t *testing.T) {
+	for _, at := range []time.Time{
+		time.Unix(1714564800, 0),
+		time.Unix(1714564800, 5),
+		time.Date(1960, 1, 1, 0, 0, 0, 7, time.UTC),
+	} {
+		out, _ := NewMsgPack("", "").Encode(&models.LogData{Ctx: context.Background(), Time: at})
+		got, _, err := mpDecode(out)
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if ts, _ := got.(map[string]any)["timestamp"].(time.Time); !ts.Equal(at) {
+			t.Errorf("expected %v, got %v", at, ts)
+		}
+	}
+}
+
+func BenchmarkMsgPack_Encode(b *testing.B) {
+	benchmarkEncoder(b, NewMsgPack("shop", "prod"))
+}
+
+func BenchmarkJSON_Encode(b *testing.B) {
+	benchmarkEncoder(b, NewJSON("shop", "prod"))
+}
+
+func benchmarkEncoder(b *testing.B, enc interfaces.Encoder) {
+	data := &models.LogData{
+		Ctx:   context.Background(),
+		Msg:   "request handled",
+		Level: models.InfoLevel,
+		Time:  time.Now(),
+		Fields: []*models.LogField{
+			{Key: "method", Type: models.FieldTypeString, String: "GET"},
+			{Key: "status", Type: models.FieldTypeInt, Integer: 200},
+			{Key: "duration_ms", Type: models.FieldTypeFloat, Float: 12.5},
+			{Key: "cached", Type: models.FieldTypeBool},
+		},
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = enc.Encode(data)
+	}
+}
@@ -0,0 +1,144 @@
+package encoder
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/sanitize"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Compile-time check that Template implements interfaces.Encoder.
+var _ interfaces.Encoder = (*Template)(nil)
+
+// TemplateRecord is the data a Template is executed with. Text values are
+// sanitized so that a record always renders as one line.
+type TemplateRecord struct {
+	// Time is the event time formatted with the Template's time layout;
+	// Timestamp is the same time unformatted.
+	Time      string
+	Timestamp time.Time
+	// Level is the lowercase level name, e.g. "warn".
+	Level     string
+	Msg       string
+	Component string
+	AppID     string
+	Env       string
+	// Fields renders the fields other than the component as logfmt
+	// key=value pairs.
+	Fields string
+
+	data *models.LogData
+}
+
+// Field returns the value of the named field as text, or "" if the record
+// has no such field.
+func (r TemplateRecord) Field(key string) string {
+	for _, f := range r.data.Fields {
+		if f != nil && f.Key == key {
+			return sanitize.String(fieldText(f, nil, nil), sanitize.PolicyEscape)
+		}
+	}
+	return ""
+}
+
+// TemplateOption configures a Template encoder.
+type TemplateOption func(*Template)
+
+// WithTimeLayout sets the layout of TemplateRecord.Time (default
+// time.RFC3339).
+func WithTimeLayout(layout string) TemplateOption {
+	return func(t *Template) {
+		if layout != "" {
+			t.timeLayout = layout
+		}
+	}
+}
+
+// WithLocalTime formats TemplateRecord.Time in loc instead of UTC.
+func WithLocalTime(loc *time.Location) TemplateOption {
+	return func(t *Template) {
+		if loc != nil {
+			t.loc = loc
+		}
+	}
+}
+
+// Template renders records with a text/template, for mandated legacy
+// layouts:
+//
+//	{{.Time}} [{{.Level | upper | pad 5}}] {{.Component}} {{.Msg}} {{.Fields}}
+//
+// Besides the TemplateRecord fields and methods ({{.Field "user_id"}}), the
+// template can use upper, lower and pad (pad N s right-pads s to N runes).
+type Template struct {
+	tmpl       *template.Template
+	appID      string
+	env        string
+	timeLayout string
+	loc        *time.Location
+}
+
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"pad": func(n int, s string) string {
+		if pad := n - len([]rune(s)); pad > 0 {
+			return s + strings.Repeat(" ", pad)
+		}
+		return s
+	},
+}
+
+// NewTemplate parses text. appID and env are used unless the record context
+// carries models.AppID or models.EnvName. The template is tried on a sample
+// record, so a misspelled field is reported here rather than on every
+// record.
+func NewTemplate(text, appID, env string, opts ...TemplateOption) (*Template, error) {
+	tmpl, err := template.New("record").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("encoder: parse template: %w", err)
+	}
+	t := &Template{tmpl: tmpl, appID: appID, env: env, timeLayout: time.RFC3339, loc: time.UTC}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if _, err := t.Encode(&models.LogData{Msg: "sample"}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Encode executes the template for data. Line breaks the template itself
+// produces are kept; those in record values are escaped.
+func (t *Template) Encode(data *models.LogData) ([]byte, error) {
+	appID, env := identity(data, t.appID, t.env)
+	ts := timestamp(data).In(t.loc)
+	rec := TemplateRecord{
+		Time:      ts.Format(t.timeLayout),
+		Timestamp: ts,
+		Level:     data.Level.String(),
+		Msg:       sanitize.String(data.Msg, sanitize.PolicyEscape),
+		Component: sanitize.String(stringField(data, models.FieldComponentKey), sanitize.PolicyEscape),
+		AppID:     sanitize.String(appID, sanitize.PolicyEscape),
+		Env:       sanitize.String(env, sanitize.PolicyEscape),
+		data:      data,
+	}
+	var fields bytes.Buffer
+	for _, f := range data.Fields {
+		if f == nil || (f.Key == models.FieldComponentKey && f.Type == models.FieldTypeString) {
+			continue
+		}
+		writeLogfmtPair(&fields, f.Key, fieldText(f, nil, nil))
+	}
+	rec.Fields = fields.String()
+
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, rec); err != nil {
+		return nil, fmt.Errorf("encoder: execute template: %w", err)
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
@@ -0,0 +1,43 @@
+package encoder
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+	"time"
+)
+
+func TestTemplate_Encode(t *testing.T) {
+	enc, err := NewTemplate(`{{.Time}} [{{.Level | upper | pad 5}}] {{.Component}} {{.Msg}} {{.Fields}} user={{.Field "user"}}`,
+		"shop", "prod", WithTimeLayout("2006-01-02 15:04:05"))
+	if err != nil {
+		t.Fatalf("NewTemplate: %v", err)
+	}
+	out, err := enc.Encode(&models.LogData{
+		Ctx:   context.Background(),
+		Msg:   "order placed\nforged line",
+		Level: models.InfoLevel,
+		Time:  time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Fields: []*models.LogField{
+			{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: "checkout"},
+			{Key: "id", Type: models.FieldTypeInt, Integer: 7},
+			{Key: "user", Type: models.FieldTypeString, String: "bob smith"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	want := `2024-05-01 12:00:00 [INFO ] checkout order placed\nforged line id=7 user="bob smith" user=bob smith`
+	if string(out) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, out)
+	}
+}
+
+func TestNewTemplate_Errors(t *testing.T) {
+	if _, err := NewTemplate(`{{.Msg`, "", ""); err == nil {
+		t.Error("expected a parse error")
+	}
+	if _, err := NewTemplate(`{{.Message}}`, "", ""); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
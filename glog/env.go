@@ -0,0 +1,60 @@
+package glog
+
+import (
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// EnvDisable lists publisher IDs to skip, e.g. GLOG_DISABLE=datadog,loki.
+	EnvDisable = "GLOG_DISABLE"
+	// EnvForceStdout routes every record to stdout as JSON lines instead of
+	// the registered publishers, e.g. GLOG_FORCE_STDOUT=1.
+	EnvForceStdout = "GLOG_FORCE_STDOUT"
+
+	forcedStdoutID = "glog.stdout"
+)
+
+// envOverrides is the kill-switch state read from the environment.
+type envOverrides struct {
+	disabled map[string]bool
+	stdout   interfaces.LogPublisher
+}
+
+// ApplyEnv re-reads GLOG_DISABLE and GLOG_FORCE_STDOUT. Start calls it; call
+// it again on reload so operators can neutralize a misbehaving sink without
+// a redeploy.
+func (ls *LoggerService) ApplyEnv() {
+	overrides := &envOverrides{}
+	for _, id := range strings.Split(os.Getenv(EnvDisable), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			if overrides.disabled == nil {
+				overrides.disabled = make(map[string]bool)
+			}
+			overrides.disabled[id] = true
+		}
+	}
+	if force, _ := strconv.ParseBool(os.Getenv(EnvForceStdout)); force {
+		overrides.stdout = NewBootstrapLogger(os.Stdout)
+	}
+
+	if overrides.stdout != nil {
+		ls.errorHandler(fmt.Errorf("glogger: %s set, all records go to stdout", EnvForceStdout))
+	} else if len(overrides.disabled) > 0 {
+		ids := make([]string, 0, len(overrides.disabled))
+		for id := range overrides.disabled {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		ls.errorHandler(fmt.Errorf("glogger: %s set, publishers disabled: %s", EnvDisable, strings.Join(ids, ",")))
+	}
+
+	if overrides.disabled == nil && overrides.stdout == nil {
+		overrides = nil
+	}
+	ls.env.Store(overrides)
+}
@@ -0,0 +1,53 @@
+package glog
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestApplyEnv_DisablePublishers(t *testing.T) {
+	t.Setenv(EnvDisable, "datadog, loki")
+	ls := NewLoggerService(WithBlockingSend(), WithErrorHandler(func(error) {}))
+	datadog, loki, local := &mockPublisher{}, &mockPublisher{}, &mockPublisher{}
+	ls.AddLogger("datadog", datadog)
+	ls.AddLogger("loki", loki)
+	ls.AddLogger("local", local)
+	ls.Start()
+	logger := ls.NewLogger()
+
+	logger.Info(context.Background(), "while disabled")
+	waitForLogs(local, 1, 2*time.Second)
+
+	t.Setenv(EnvDisable, "")
+	ls.ApplyEnv()
+	logger.Info(context.Background(), "after reload")
+	ls.Stop()
+
+	if got := len(datadog.GetLogs()); got != 1 {
+		t.Errorf("expected datadog to receive only the record after reload, got %d", got)
+	}
+	if got := len(loki.GetLogs()); got != 1 {
+		t.Errorf("expected loki to receive only the record after reload, got %d", got)
+	}
+	if got := len(local.GetLogs()); got != 2 {
+		t.Errorf("expected local to receive both records, got %d", got)
+	}
+}
+
+func TestApplyEnv_ForceStdout(t *testing.T) {
+	t.Setenv(EnvForceStdout, "1")
+	var reported []error
+	ls := NewLoggerService(WithErrorHandler(func(err error) { reported = append(reported, err) }))
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+	ls.Stop()
+
+	if env := ls.env.Load(); env == nil || env.stdout == nil {
+		t.Fatal("expected stdout override")
+	}
+	if len(reported) != 1 {
+		t.Errorf("expected the override to be reported once, got %v", reported)
+	}
+}
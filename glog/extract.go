@@ -0,0 +1,81 @@
+package glog
+
+import (
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+)
+
+// ContextExtractor returns fields taken from a record's context, such as a
+// request ID or tenant set by middleware. It may return nil.
+type ContextExtractor func(ctx context.Context) []*models.LogField
+
+// WithContextExtractors adds the fields the extractors return to every
+// record of the service. They run in the pipeline, off the caller's
+// goroutine. A field whose key the record already has is skipped, so
+// options given at the call win.
+func WithContextExtractors(extractors ...ContextExtractor) ServiceOption {
+	return func(ls *LoggerService) {
+		ls.extractors = append(ls.extractors, extractors...)
+	}
+}
+
+// WithContextExtractors returns a child logger that adds the fields the
+// extractors return to each of its records. Unlike the service option they
+// run at the call, for values that must be read on the caller's goroutine.
+func (l *Logger) WithContextExtractors(extractors ...ContextExtractor) *Logger {
+	child := *l
+	child.extractors = append(append([]ContextExtractor{}, l.extractors...), extractors...)
+	return &child
+}
+
+// extractFields appends the fields of extractors to logData. A panicking
+// extractor is reported to onPanic and skipped.
+func extractFields(logData *models.LogData, extractors []ContextExtractor, onPanic func(error)) {
+	if len(extractors) == 0 || logData.Ctx == nil {
+		return
+	}
+	keys := make(map[string]struct{}, len(logData.Fields))
+	for _, f := range logData.Fields {
+		if f != nil {
+			keys[f.Key] = struct{}{}
+		}
+	}
+	for _, extract := range extractors {
+		for _, f := range runExtractor(logData.Ctx, extract, onPanic) {
+			if f == nil || f.Key == "" {
+				continue
+			}
+			if _, dup := keys[f.Key]; dup {
+				continue
+			}
+			keys[f.Key] = struct{}{}
+			logData.Fields = append(logData.Fields, f)
+		}
+	}
+}
+
+func runExtractor(ctx context.Context, extract ContextExtractor, onPanic func(error)) (fields []*models.LogField) {
+	defer func() {
+		if r := recover(); r != nil {
+			fields = nil
+			if onPanic != nil {
+				onPanic(fmt.Errorf("glogger: panic in context extractor: %v", r))
+			}
+		}
+	}()
+	return extract(ctx)
+}
+
+// ContextString returns an extractor that writes the string stored in ctx
+// under ctxKey as the field key, e.g.
+// ContextString(requestIDKey{}, "request_id").
+func ContextString(ctxKey any, key string) ContextExtractor {
+	return func(ctx context.Context) []*models.LogField {
+		v, ok := ctx.Value(ctxKey).(string)
+		if !ok || v == "" {
+			return nil
+		}
+		return []*models.LogField{{Key: key, Type: models.FieldTypeString, String: v}}
+	}
+}
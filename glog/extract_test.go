@@ -0,0 +1,87 @@
+package glog
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type requestIDKey struct{}
+
+type tenantKey struct{}
+
+func TestContextExtractors_Service(t *testing.T) {
+	var mu sync.Mutex
+	var errs []string
+	ls := NewLoggerService(
+		WithContextExtractors(
+			ContextString(requestIDKey{}, "request_id"),
+			func(context.Context) []*models.LogField { panic("boom") },
+			ContextString(tenantKey{}, "tenant"),
+		),
+		WithErrorHandler(func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err.Error())
+		}),
+	)
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+	defer ls.Stop()
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	ctx = context.WithValue(ctx, tenantKey{}, "acme")
+	logger := ls.NewLogger()
+	logger.Info(ctx, "with context")
+	logger.Info(ctx, "explicit wins", models.WithStringField("tenant", "other"))
+	logger.Info(context.Background(), "no values")
+
+	logs := byMsg(waitForLogs(mock, 3, time.Second))
+	if got := fields(logs["with context"]); got["request_id"] != "req-1" || got["tenant"] != "acme" {
+		t.Errorf("expected extracted fields, got %v", got)
+	}
+	if got := fields(logs["explicit wins"]); got["tenant"] != "other" || len(logs["explicit wins"].Fields) != 2 {
+		t.Errorf("expected the explicit field kept once, got %v", got)
+	}
+	if n := len(logs["no values"].Fields); n != 0 {
+		t.Errorf("expected no fields without context values, got %d", n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 3 || !strings.Contains(errs[0], "panic in context extractor") {
+		t.Errorf("expected the panicking extractor reported per record, got %v", errs)
+	}
+}
+
+func TestContextExtractors_Logger(t *testing.T) {
+	logger, mock, ls := setupTestLogger()
+	defer ls.Stop()
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-2")
+	withID := logger.WithContextExtractors(ContextString(requestIDKey{}, "request_id"))
+	withID.Info(ctx, "child")
+	logger.Info(ctx, "parent")
+
+	logs := byMsg(waitForLogs(mock, 2, time.Second))
+	if got := fields(logs["child"]); got["request_id"] != "req-2" {
+		t.Errorf("expected the child to extract the request ID, got %v", got)
+	}
+	if n := len(logs["parent"].Fields); n != 0 {
+		t.Errorf("expected the parent unchanged, got %d fields", n)
+	}
+}
+
+func fields(data *models.LogData) map[string]string {
+	m := make(map[string]string)
+	if data == nil {
+		return m
+	}
+	for _, f := range data.Fields {
+		m[f.Key] = f.String
+	}
+	return m
+}
@@ -0,0 +1,141 @@
+package glog
+
+import (
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"regexp"
+	"strings"
+)
+
+// defaultHealthCheckPaths are the probe endpoints DropHealthChecks drops
+// when called without paths.
+var defaultHealthCheckPaths = []string{"/health", "/healthz", "/livez", "/readyz", "/ready", "/ping"}
+
+// FilterFunc adapts a function to interfaces.Filter.
+type FilterFunc func(data *models.LogData) bool
+
+func (f FilterFunc) Allow(data *models.LogData) bool {
+	return f(data)
+}
+
+// WithFilters drops, for every publisher, the records one of the filters
+// does not allow. Filters run in the main worker before interceptors, in
+// order, and the records they drop are counted in Stats().Filtered. A
+// panicking filter is reported and the record kept.
+func WithFilters(filters ...interfaces.Filter) ServiceOption {
+	return func(ls *LoggerService) {
+		for _, f := range filters {
+			if f != nil {
+				ls.filters = append(ls.filters, f)
+			}
+		}
+	}
+}
+
+// WithPublisherFilters is WithFilter for interfaces.Filter values: the
+// publisher gets only the records every filter allows. As with WithFilter,
+// a panicking filter is reported and the record not sent to this publisher.
+func WithPublisherFilters(filters ...interfaces.Filter) PublisherOption {
+	return func(e *publisherEntry) {
+		for _, f := range filters {
+			if f != nil {
+				e.filters = append(e.filters, f.Allow)
+			}
+		}
+	}
+}
+
+// allowed runs the service filters on logData.
+func (ls *LoggerService) allowed(logData *models.LogData) bool {
+	if logData.Internal {
+		return true
+	}
+	for i, f := range ls.filters {
+		if !ls.runFilter(i, f, logData) {
+			ls.stats.filtered.Add(1)
+			return false
+		}
+	}
+	return true
+}
+
+func (ls *LoggerService) runFilter(i int, f interfaces.Filter, logData *models.LogData) (allow bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			ls.stats.panics.Add(1)
+			ls.errorHandler(fmt.Errorf("glogger: panic in filter %d: %v", i, r))
+			allow = true
+		}
+	}()
+	return f.Allow(logData)
+}
+
+// filterPanic counts and reports a panic in a publisher filter.
+func (ls *LoggerService) filterPanic(err error) {
+	ls.stats.panics.Add(1)
+	ls.errorHandler(err)
+}
+
+func runPublisherFilter(id string, i int, keep func(*models.LogData) bool, logData *models.LogData, onPanic func(error)) (allow bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			allow = false
+			if onPanic != nil {
+				onPanic(fmt.Errorf("glogger: panic in filter %d of publisher %q: %v", i, id, r))
+			}
+		}
+	}()
+	return keep(logData)
+}
+
+// DropMessages drops records whose message matches re.
+func DropMessages(re *regexp.Regexp) FilterFunc {
+	return func(data *models.LogData) bool {
+		return !re.MatchString(data.Msg)
+	}
+}
+
+// DropComponents drops records of the given components and of the
+// components nested below them.
+func DropComponents(components ...string) FilterFunc {
+	matchers := make([]func(*models.LogData) bool, len(components))
+	for i, c := range components {
+		matchers[i] = HasComponent(c)
+	}
+	return func(data *models.LogData) bool {
+		for _, match := range matchers {
+			if match(data) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// DropHealthChecks drops records whose FieldHTTPPathKey field is one of
+// paths, such as the canonical lines of load balancer probes. Without
+// paths it drops /health, /healthz, /livez, /readyz, /ready and /ping.
+// Records at ErrorLevel and above are kept, so a failing probe still
+// shows.
+func DropHealthChecks(paths ...string) FilterFunc {
+	if len(paths) == 0 {
+		paths = defaultHealthCheckPaths
+	}
+	set := make(map[string]struct{}, len(paths))
+	for _, p := range paths {
+		set[strings.TrimSuffix(p, "/")] = struct{}{}
+	}
+	return func(data *models.LogData) bool {
+		if data.Level >= models.ErrorLevel {
+			return true
+		}
+		for _, f := range data.Fields {
+			if f != nil && f.Key == FieldHTTPPathKey && f.Type == models.FieldTypeString {
+				_, probe := set[strings.TrimSuffix(f.String, "/")]
+				return !probe
+			}
+		}
+		return true
+	}
+}
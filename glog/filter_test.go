@@ -0,0 +1,81 @@
+package glog
+
+import (
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"regexp"
+	"testing"
+)
+
+func TestWithFilters_ServiceAndPublisher(t *testing.T) {
+	ls := NewLoggerService(WithFilters(
+		DropHealthChecks(),
+		DropComponents("metrics"),
+		DropMessages(regexp.MustCompile(`^cache (hit|miss)`)),
+	))
+	all, audit := &mockPublisher{}, &mockPublisher{}
+	ls.AddLogger("all", all)
+	ls.AddLogger("audit", audit, WithPublisherFilters(FilterFunc(HasComponent("audit"))))
+	ls.Start()
+
+	logger := ls.NewLogger()
+	ctx := context.Background()
+	logger.Info(ctx, "request", models.WithStringField(FieldHTTPPathKey, "/healthz"))
+	logger.Error(ctx, fmt.Errorf("probe failed"), models.WithStringField(FieldHTTPPathKey, "/healthz"))
+	logger.Info(ctx, "request", models.WithStringField(FieldHTTPPathKey, "/orders"))
+	logger.Info(ctx, "scrape", models.WithComponent("metrics.http"))
+	logger.Info(ctx, "cache hit")
+	logger.Info(ctx, "login", models.WithComponent("audit"))
+	ls.Stop()
+
+	logs := byMsg(all.GetLogs())
+	if len(logs) != 3 || logs["probe failed"] == nil || logs["request"] == nil || logs["login"] == nil {
+		t.Errorf("unexpected records %v", logs)
+	}
+	if logs := audit.GetLogs(); len(logs) != 1 || logs[0].Msg != "login" {
+		t.Errorf("expected only the audit record in audit, got %d records", len(logs))
+	}
+	if n := ls.Stats().Filtered; n != 3 {
+		t.Errorf("expected 3 records filtered, got %d", n)
+	}
+}
+
+func TestWithFilters_PanicKeepsRecord(t *testing.T) {
+	var handled []error
+	ls := NewLoggerService(
+		WithErrorHandler(func(err error) { handled = append(handled, err) }),
+		WithFilters(FilterFunc(func(*models.LogData) bool { panic("bad filter") })),
+	)
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+	ls.NewLogger().Info(context.Background(), "kept")
+	ls.Stop()
+
+	if len(mock.GetLogs()) != 1 || len(handled) != 1 {
+		t.Errorf("expected the record kept and the panic reported, got %d records and %v", len(mock.GetLogs()), handled)
+	}
+}
+
+func TestWithPublisherFilters_PanicRejectsRecord(t *testing.T) {
+	var handled []error
+	ls := NewLoggerService(WithErrorHandler(func(err error) { handled = append(handled, err) }))
+	bad, good := &mockPublisher{}, &mockPublisher{}
+	ls.AddLogger("bad", bad, WithPublisherFilters(FilterFunc(func(*models.LogData) bool { panic("bad filter") })))
+	ls.AddLogger("good", good)
+	ls.Start()
+	ls.NewLogger().Info(context.Background(), "first")
+	ls.NewLogger().Info(context.Background(), "second")
+	ls.Stop()
+
+	if n := len(bad.GetLogs()); n != 0 {
+		t.Errorf("expected the panicking publisher filter to reject records, got %d", n)
+	}
+	if n := len(good.GetLogs()); n != 2 {
+		t.Errorf("expected the other publisher to keep receiving records, got %d", n)
+	}
+	if len(handled) != 2 || ls.Stats().Panics != 2 {
+		t.Errorf("expected both panics counted and reported, got %d and %v", ls.Stats().Panics, handled)
+	}
+}
@@ -0,0 +1,99 @@
+package glog
+
+import (
+	"context"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type flushingPublisher struct {
+	mockPublisher
+	flushes atomic.Int32
+	err     error
+}
+
+func (p *flushingPublisher) Flush(ctx context.Context) error {
+	p.flushes.Add(1)
+	return p.err
+}
+
+func TestFlush_WaitsForQueuedRecords(t *testing.T) {
+	ls := NewLoggerService(WithBlockingSend())
+	slow := &flushingPublisher{mockPublisher: mockPublisher{
+		sendFunc: func(*models.LogData) { time.Sleep(5 * time.Millisecond) },
+	}}
+	ls.AddLogger("slow", slow)
+	ls.Start()
+	defer ls.Stop()
+
+	logger := ls.NewLogger()
+	for i := 0; i < 10; i++ {
+		logger.Info(context.Background(), "work")
+	}
+	if err := ls.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(slow.GetLogs()); n != 10 {
+		t.Errorf("expected 10 records delivered before Flush returned, got %d", n)
+	}
+	if slow.flushes.Load() != 1 {
+		t.Errorf("expected the publisher flushed once, got %d", slow.flushes.Load())
+	}
+}
+
+func TestFlush_ReportsPublisherErrors(t *testing.T) {
+	ls := NewLoggerService()
+	ls.AddLogger("broken", &flushingPublisher{err: errors.New("disk full")})
+	ls.Start()
+	defer ls.Stop()
+
+	err := ls.Flush(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `"broken"`) || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("expected the publisher error, got %v", err)
+	}
+}
+
+func TestFlush_HonoursContext(t *testing.T) {
+	ls := NewLoggerService()
+	ls.AddLogger("mock", &mockPublisher{})
+
+	// Not started: nothing drains the queue.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := ls.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestFlush_AfterStop(t *testing.T) {
+	logger, _, ls := setupTestLogger()
+	ls.Stop()
+
+	if err := ls.Flush(context.Background()); err != nil {
+		t.Errorf("expected nil after Stop, got %v", err)
+	}
+	if err := logger.Sync(); err != nil {
+		t.Errorf("expected nil after Stop, got %v", err)
+	}
+}
+
+func TestLogger_Sync(t *testing.T) {
+	logger, mock, ls := setupTestLogger()
+	defer ls.Stop()
+
+	logger.Info(context.Background(), "one")
+	logger.Warning(context.Background(), "two")
+	if err := logger.Sync(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(mock.GetLogs()); n != 2 {
+		t.Errorf("expected 2 records after Sync, got %d", n)
+	}
+	if err := (&Logger{}).Sync(); err != nil {
+		t.Errorf("expected a bare logger to sync trivially, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+// Package glogtest provides helpers for testing glogger publishers and
+// pipelines.
+package glogtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+// DefaultSendBudget matches the service's default send timeout: a publisher
+// that regularly needs longer will see its messages reported as timed out.
+const DefaultSendBudget = 100 * time.Millisecond
+
+// PublisherFactory builds a fresh publisher for each conformance check.
+type PublisherFactory func(t testing.TB) interfaces.LogPublisher
+
+type conformanceConfig struct {
+	sendBudget time.Duration
+	goroutines int
+	perRoutine int
+	skipLevels map[models.LogLevel]bool
+}
+
+type ConformanceOption func(*conformanceConfig)
+
+// WithSendBudget overrides the maximum duration a single SendMsg may take.
+func WithSendBudget(d time.Duration) ConformanceOption {
+	return func(c *conformanceConfig) {
+		if d > 0 {
+			c.sendBudget = d
+		}
+	}
+}
+
+// WithConcurrency sets the number of goroutines and messages per goroutine of
+// the concurrency check.
+func WithConcurrency(goroutines, perRoutine int) ConformanceOption {
+	return func(c *conformanceConfig) {
+		if goroutines > 0 && perRoutine > 0 {
+			c.goroutines = goroutines
+			c.perRoutine = perRoutine
+		}
+	}
+}
+
+// RunPublisherConformance checks the contract every publisher must meet:
+//
+//   - every level from Debug to DPanic and unknown levels are accepted
+//     (Panic and Fatal are excluded because backends may legitimately panic
+//     or exit on them);
+//   - all field types, nil contexts, nil/empty Fields, nil entries inside
+//     Fields, unknown field types and empty messages are handled without
+//     panicking;
+//   - concurrent SendMsg calls are safe;
+//   - a single SendMsg stays within the send budget;
+//   - if the publisher has Flush(ctx) error or Close() error, flushing works,
+//     Close is idempotent and SendMsg after Close does not panic.
+func RunPublisherConformance(t *testing.T, factory PublisherFactory, opts ...ConformanceOption) {
+	cfg := &conformanceConfig{
+		sendBudget: DefaultSendBudget,
+		goroutines: 8,
+		perRoutine: 50,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	t.Run("Levels", func(t *testing.T) {
+		p := newPublisher(t, factory)
+		for _, level := range []models.LogLevel{
+			models.DebugLevel, models.InfoLevel, models.WarnLevel, models.ErrorLevel, models.DPanicLevel, models.LogLevel(42),
+		} {
+			send(t, p, &models.LogData{Ctx: context.Background(), Msg: "level " + level.String(), Level: level})
+		}
+	})
+
+	t.Run("FieldTypes", func(t *testing.T) {
+		p := newPublisher(t, factory)
+		send(t, p, &models.LogData{
+			Ctx:    context.Background(),
+			Msg:    "all field types",
+			Level:  models.InfoLevel,
+			Fields: AllFieldTypes(),
+		})
+	})
+
+	t.Run("EdgeInputs", func(t *testing.T) {
+		p := newPublisher(t, factory)
+		send(t, p, &models.LogData{Ctx: nil, Msg: "nil context", Level: models.InfoLevel})
+		send(t, p, &models.LogData{Ctx: context.Background(), Msg: "", Level: models.InfoLevel})
+		send(t, p, &models.LogData{Ctx: context.Background(), Msg: "nil fields", Level: models.InfoLevel, Fields: nil})
+		send(t, p, &models.LogData{Ctx: context.Background(), Msg: "empty fields", Level: models.InfoLevel, Fields: []*models.LogField{}})
+		send(t, p, &models.LogData{Ctx: context.Background(), Msg: "nil field entry", Level: models.InfoLevel, Fields: []*models.LogField{
+			nil, {Key: "after_nil", Type: models.FieldTypeString, String: "v"},
+		}})
+		send(t, p, &models.LogData{Ctx: context.Background(), Msg: "unknown field type", Level: models.InfoLevel, Fields: []*models.LogField{
+			{Key: "odd", Type: models.FieldType(99)},
+		}})
+	})
+
+	t.Run("Concurrency", func(t *testing.T) {
+		p := newPublisher(t, factory)
+		var wg sync.WaitGroup
+		wg.Add(cfg.goroutines)
+		for g := 0; g < cfg.goroutines; g++ {
+			go func(id int) {
+				defer wg.Done()
+				for i := 0; i < cfg.perRoutine; i++ {
+					p.SendMsg(&models.LogData{
+						Ctx:   context.Background(),
+						Msg:   fmt.Sprintf("concurrent %d-%d", id, i),
+						Level: models.InfoLevel,
+						Fields: []*models.LogField{
+							{Key: "goroutine", Type: models.FieldTypeInt, Integer: id},
+						},
+					})
+				}
+			}(g)
+		}
+		wg.Wait()
+	})
+
+	t.Run("SendBudget", func(t *testing.T) {
+		p := newPublisher(t, factory)
+		data := &models.LogData{Ctx: context.Background(), Msg: "timed", Level: models.InfoLevel, Fields: AllFieldTypes()}
+		start := time.Now()
+		p.SendMsg(data)
+		if elapsed := time.Since(start); elapsed > cfg.sendBudget {
+			t.Errorf("SendMsg took %v, budget is %v", elapsed, cfg.sendBudget)
+		}
+	})
+
+	t.Run("FlushClose", func(t *testing.T) {
+		p := factory(t)
+		p.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "before flush", Level: models.InfoLevel})
+		if f, ok := p.(interface{ Flush(context.Context) error }); ok {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := f.Flush(ctx); err != nil {
+				t.Errorf("Flush failed: %v", err)
+			}
+		}
+		c, ok := p.(interface{ Close() error })
+		if !ok {
+			return
+		}
+		if err := c.Close(); err != nil {
+			t.Errorf("Close failed: %v", err)
+		}
+		if err := c.Close(); err != nil && !errors.Is(err, ErrClosed) {
+			t.Errorf("second Close must be a no-op, got %v", err)
+		}
+		send(t, p, &models.LogData{Ctx: context.Background(), Msg: "after close", Level: models.InfoLevel})
+	})
+}
+
+// ErrClosed may be returned by publishers from a repeated Close call.
+var ErrClosed = errors.New("glogtest: publisher already closed")
+
+// AllFieldTypes returns one field of every supported type.
+func AllFieldTypes() []*models.LogField {
+	return []*models.LogField{
+		{Key: "string", Type: models.FieldTypeString, String: "value"},
+		{Key: "int", Type: models.FieldTypeInt, Integer: 42},
+		{Key: "float", Type: models.FieldTypeFloat, Float: 3.14},
+		{Key: "bool", Type: models.FieldTypeBool, Bool: true},
+		{Key: "duration", Type: models.FieldTypeDuration, Duration: 1500 * time.Millisecond},
+		{Key: "time", Type: models.FieldTypeTime, Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		{Key: "int64", Type: models.FieldTypeInt64, Int64: math.MinInt64},
+		{Key: "uint64", Type: models.FieldTypeUint64, Uint64: math.MaxUint64},
+		{Key: "binary", Type: models.FieldTypeBinary, Binary: []byte{0x00, 0xff, '\n'}},
+		{Key: "array", Type: models.FieldTypeArray, Object: []string{"a", "b"}},
+		{Key: "object", Type: models.FieldTypeObject, Object: map[string]any{"nested": []int{1, 2, 3}}},
+		{Key: "nil_object", Type: models.FieldTypeObject, Object: nil},
+	}
+}
+
+func newPublisher(t *testing.T, factory PublisherFactory) interfaces.LogPublisher {
+	p := factory(t)
+	if c, ok := p.(interface{ Close() error }); ok {
+		t.Cleanup(func() { _ = c.Close() })
+	}
+	return p
+}
+
+func send(t *testing.T, p interfaces.LogPublisher, data *models.LogData) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("SendMsg panicked for %q: %v", data.Msg, r)
+		}
+	}()
+	p.SendMsg(data)
+}
@@ -0,0 +1,140 @@
+package glogtest
+
+import (
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrInjected is the error reported for injected delivery failures.
+var ErrInjected = errors.New("glogtest: injected failure")
+
+// FaultConfig describes the faults a Flaky publisher injects. Rates are
+// probabilities in [0, 1] evaluated independently for every message.
+type FaultConfig struct {
+	// Latency is added before delivery with probability LatencyRate
+	// (always when LatencyRate is zero).
+	Latency     time.Duration
+	LatencyRate float64
+	// ErrorRate fails delivery: the message is not forwarded and OnError is
+	// called with ErrInjected.
+	ErrorRate float64
+	// PanicRate makes SendMsg panic.
+	PanicRate float64
+	// HangRate makes SendMsg block until Release is called.
+	HangRate float64
+	// Seed makes the fault sequence reproducible. Zero uses a fixed seed.
+	Seed int64
+	// OnError is called for every injected delivery failure.
+	OnError func(data *models.LogData, err error)
+}
+
+// FaultStats counts what a Flaky publisher did.
+type FaultStats struct {
+	Delivered int64
+	Delayed   int64
+	Failed    int64
+	Panicked  int64
+	Hung      int64
+}
+
+// Compile-time check that Flaky implements interfaces.LogPublisher.
+var _ interfaces.LogPublisher = (*Flaky)(nil)
+
+// Flaky wraps a publisher and injects latency, failures, panics and hangs,
+// so retry, timeout and failover behaviour can be tested without a broken
+// backend.
+type Flaky struct {
+	next interfaces.LogPublisher
+
+	mu      sync.Mutex
+	cfg     FaultConfig
+	rnd     *rand.Rand
+	release chan struct{}
+
+	delivered atomic.Int64
+	delayed   atomic.Int64
+	failed    atomic.Int64
+	panicked  atomic.Int64
+	hung      atomic.Int64
+}
+
+func NewFlaky(next interfaces.LogPublisher, cfg FaultConfig) *Flaky {
+	f := &Flaky{next: next, release: make(chan struct{})}
+	f.SetFaults(cfg)
+	return f
+}
+
+// SetFaults replaces the fault configuration, e.g. to simulate a backend
+// recovering mid-test.
+func (f *Flaky) SetFaults(cfg FaultConfig) {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = 1
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cfg = cfg
+	f.rnd = rand.New(rand.NewSource(seed))
+}
+
+// Release unblocks every SendMsg currently hanging. Later hangs block again
+// until the next Release.
+func (f *Flaky) Release() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	close(f.release)
+	f.release = make(chan struct{})
+}
+
+func (f *Flaky) Stats() FaultStats {
+	return FaultStats{
+		Delivered: f.delivered.Load(),
+		Delayed:   f.delayed.Load(),
+		Failed:    f.failed.Load(),
+		Panicked:  f.panicked.Load(),
+		Hung:      f.hung.Load(),
+	}
+}
+
+func (f *Flaky) SendMsg(data *models.LogData) {
+	f.mu.Lock()
+	cfg := f.cfg
+	hang := f.roll(cfg.HangRate)
+	doPanic := f.roll(cfg.PanicRate)
+	fail := f.roll(cfg.ErrorRate)
+	delay := cfg.Latency > 0 && (cfg.LatencyRate == 0 || f.roll(cfg.LatencyRate))
+	release := f.release
+	f.mu.Unlock()
+
+	if hang {
+		f.hung.Add(1)
+		<-release
+	}
+	if delay {
+		f.delayed.Add(1)
+		time.Sleep(cfg.Latency)
+	}
+	if doPanic {
+		f.panicked.Add(1)
+		panic("glogtest: injected panic")
+	}
+	if fail {
+		f.failed.Add(1)
+		if cfg.OnError != nil {
+			cfg.OnError(data, ErrInjected)
+		}
+		return
+	}
+	f.next.SendMsg(data)
+	f.delivered.Add(1)
+}
+
+// roll must be called with f.mu held.
+func (f *Flaky) roll(rate float64) bool {
+	return rate > 0 && f.rnd.Float64() < rate
+}
@@ -0,0 +1,93 @@
+package glogtest
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingPublisher struct {
+	n atomic.Int64
+}
+
+func (c *countingPublisher) SendMsg(*models.LogData) {
+	c.n.Add(1)
+}
+
+func newData() *models.LogData {
+	return &models.LogData{Ctx: context.Background(), Msg: "flaky", Level: models.InfoLevel}
+}
+
+func TestFlaky_Errors(t *testing.T) {
+	next := &countingPublisher{}
+	var reported atomic.Int64
+	f := NewFlaky(next, FaultConfig{
+		ErrorRate: 0.5,
+		Seed:      7,
+		OnError:   func(*models.LogData, error) { reported.Add(1) },
+	})
+
+	for i := 0; i < 200; i++ {
+		f.SendMsg(newData())
+	}
+
+	stats := f.Stats()
+	if stats.Failed == 0 || stats.Delivered == 0 {
+		t.Fatalf("expected a mix of failures and deliveries, got %+v", stats)
+	}
+	if stats.Failed+stats.Delivered != 200 || next.n.Load() != stats.Delivered {
+		t.Errorf("inconsistent stats %+v, forwarded %d", stats, next.n.Load())
+	}
+	if reported.Load() != stats.Failed {
+		t.Errorf("expected OnError for every failure, got %d of %d", reported.Load(), stats.Failed)
+	}
+}
+
+func TestFlaky_Panic(t *testing.T) {
+	f := NewFlaky(&countingPublisher{}, FaultConfig{PanicRate: 1})
+	defer func() {
+		if recover() == nil {
+			t.Error("expected injected panic")
+		}
+	}()
+	f.SendMsg(newData())
+}
+
+func TestFlaky_HangAndRelease(t *testing.T) {
+	next := &countingPublisher{}
+	f := NewFlaky(next, FaultConfig{HangRate: 1})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		f.SendMsg(newData())
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if next.n.Load() != 0 {
+		t.Fatal("expected SendMsg to hang")
+	}
+
+	f.SetFaults(FaultConfig{})
+	f.Release()
+	wg.Wait()
+	if next.n.Load() != 1 {
+		t.Error("expected message to be delivered after release")
+	}
+}
+
+func TestFlaky_Latency(t *testing.T) {
+	f := NewFlaky(&countingPublisher{}, FaultConfig{Latency: 20 * time.Millisecond})
+	start := time.Now()
+	f.SendMsg(newData())
+	if time.Since(start) < 20*time.Millisecond {
+		t.Error("expected latency to be injected")
+	}
+	if f.Stats().Delayed != 1 {
+		t.Errorf("expected 1 delayed message, got %+v", f.Stats())
+	}
+}
@@ -0,0 +1,194 @@
+package glogtest
+
+import (
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	FieldSourceKey   = "glogtest_source"
+	FieldSequenceKey = "glogtest_seq"
+)
+
+// PropertyConfig drives RunProperties.
+type PropertyConfig struct {
+	Producers          int
+	RecordsPerProducer int
+	// MaxJitter bounds the random pause producers take between records.
+	MaxJitter time.Duration
+	// Seed makes the run reproducible; zero picks a time-based seed that is
+	// logged so failures can be replayed.
+	Seed int64
+}
+
+// PipelineSetup builds the pipeline under test around the collector and
+// returns the logger to drive plus a stop function that must drain it.
+type PipelineSetup func(collector interfaces.LogPublisher) (logger interfaces.Logger, stop func())
+
+// Stamp identifies a record emitted by the harness.
+type Stamp struct {
+	Source int
+	Seq    int
+}
+
+// PropertyResult holds what was sent and what arrived, in arrival order.
+type PropertyResult struct {
+	Sent      int
+	Delivered []Stamp
+	Seed      int64
+}
+
+// RunProperties drives randomized concurrent producers through the pipeline.
+// Each record carries its producer and a per-producer sequence number, so
+// the assertions on the result can check loss, duplication and ordering.
+func RunProperties(t testing.TB, cfg PropertyConfig, setup PipelineSetup) *PropertyResult {
+	t.Helper()
+	if cfg.Producers <= 0 {
+		cfg.Producers = 8
+	}
+	if cfg.RecordsPerProducer <= 0 {
+		cfg.RecordsPerProducer = 200
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	t.Logf("glogtest: property seed %d", cfg.Seed)
+
+	collector := &stampCollector{}
+	logger, stop := setup(collector)
+
+	levels := []models.LogLevel{models.DebugLevel, models.InfoLevel, models.WarnLevel}
+	var wg sync.WaitGroup
+	wg.Add(cfg.Producers)
+	for p := 0; p < cfg.Producers; p++ {
+		rnd := rand.New(rand.NewSource(cfg.Seed + int64(p)))
+		go func(source int, rnd *rand.Rand) {
+			defer wg.Done()
+			ctx := context.Background()
+			for seq := 0; seq < cfg.RecordsPerProducer; seq++ {
+				opts := []models.Option{
+					models.WithIntField(FieldSourceKey, source),
+					models.WithIntField(FieldSequenceKey, seq),
+				}
+				msg := fmt.Sprintf("property %d-%d", source, seq)
+				logger.Log(ctx, levels[rnd.Intn(len(levels))], msg, opts...)
+				if cfg.MaxJitter > 0 && rnd.Intn(4) == 0 {
+					time.Sleep(time.Duration(rnd.Int63n(int64(cfg.MaxJitter))))
+				}
+			}
+		}(p, rnd)
+	}
+	wg.Wait()
+	stop()
+
+	return &PropertyResult{
+		Sent:      cfg.Producers * cfg.RecordsPerProducer,
+		Delivered: collector.stamps(),
+		Seed:      cfg.Seed,
+	}
+}
+
+// AssertNoLoss fails unless every record arrived exactly once.
+func (r *PropertyResult) AssertNoLoss(t testing.TB) {
+	t.Helper()
+	r.AssertNoDuplicates(t)
+	if len(r.Delivered) != r.Sent {
+		t.Errorf("expected %d records without loss, got %d (seed %d)", r.Sent, len(r.Delivered), r.Seed)
+	}
+}
+
+// AssertBoundedLoss fails if more than maxLossRatio of the records were lost
+// or if anything arrived twice.
+func (r *PropertyResult) AssertBoundedLoss(t testing.TB, maxLossRatio float64) {
+	t.Helper()
+	r.AssertNoDuplicates(t)
+	lost := r.Sent - len(r.Delivered)
+	if ratio := float64(lost) / float64(r.Sent); ratio > maxLossRatio {
+		t.Errorf("lost %d of %d records (%.2f), bound is %.2f (seed %d)", lost, r.Sent, ratio, maxLossRatio, r.Seed)
+	}
+}
+
+func (r *PropertyResult) AssertNoDuplicates(t testing.TB) {
+	t.Helper()
+	seen := make(map[Stamp]bool, len(r.Delivered))
+	for _, s := range r.Delivered {
+		if seen[s] {
+			t.Errorf("record %d-%d delivered twice (seed %d)", s.Source, s.Seq, r.Seed)
+			return
+		}
+		seen[s] = true
+	}
+}
+
+// AssertPerSourceOrder fails if any producer's records arrived out of order.
+// Gaps from dropped records are allowed.
+func (r *PropertyResult) AssertPerSourceOrder(t testing.TB) {
+	t.Helper()
+	last := make(map[int]int)
+	for _, s := range r.Delivered {
+		if prev, ok := last[s.Source]; ok && s.Seq <= prev {
+			t.Errorf("source %d: seq %d arrived after %d (seed %d)", s.Source, s.Seq, prev, r.Seed)
+			return
+		}
+		last[s.Source] = s.Seq
+	}
+}
+
+// LostBySource returns the missing sequence numbers per producer.
+func (r *PropertyResult) LostBySource(producers, perProducer int) map[int][]int {
+	got := make(map[Stamp]bool, len(r.Delivered))
+	for _, s := range r.Delivered {
+		got[s] = true
+	}
+	lost := make(map[int][]int)
+	for p := 0; p < producers; p++ {
+		for seq := 0; seq < perProducer; seq++ {
+			if !got[Stamp{Source: p, Seq: seq}] {
+				lost[p] = append(lost[p], seq)
+			}
+		}
+	}
+	for _, seqs := range lost {
+		sort.Ints(seqs)
+	}
+	return lost
+}
+
+type stampCollector struct {
+	mu       sync.Mutex
+	received []Stamp
+}
+
+func (c *stampCollector) SendMsg(data *models.LogData) {
+	stamp := Stamp{Source: -1, Seq: -1}
+	for _, f := range data.Fields {
+		if f == nil {
+			continue
+		}
+		switch f.Key {
+		case FieldSourceKey:
+			stamp.Source = f.Integer
+		case FieldSequenceKey:
+			stamp.Seq = f.Integer
+		}
+	}
+	if stamp.Source < 0 || stamp.Seq < 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.received = append(c.received, stamp)
+}
+
+func (c *stampCollector) stamps() []Stamp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Stamp(nil), c.received...)
+}
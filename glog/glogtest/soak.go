@@ -0,0 +1,183 @@
+package glogtest
+
+import (
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"os"
+	"runtime"
+	"time"
+)
+
+// SoakConfig drives Soak.
+type SoakConfig struct {
+	// Duration of the load phase (default 1 minute).
+	Duration time.Duration
+	// Warmup is excluded from the growth baseline (default 10% of Duration).
+	Warmup time.Duration
+	// SampleInterval between resource samples (default 1 second).
+	SampleInterval time.Duration
+	// Rate is the number of records per second; zero logs as fast as possible.
+	Rate int
+	// MaxGoroutineGrowth is the allowed difference in goroutines between the
+	// start and the end of the run, after the pipeline is stopped.
+	MaxGoroutineGrowth int
+	// MaxHeapGrowth is the allowed live heap growth in bytes between the
+	// post-warmup baseline and the end of the load phase.
+	MaxHeapGrowth uint64
+	// MaxFDGrowth is the allowed open file descriptor growth. FD counting is
+	// only supported where /proc/self/fd exists.
+	MaxFDGrowth int
+	// OnSample is called for every sample, e.g. to print progress.
+	OnSample func(SoakSample)
+}
+
+// SoakSample is one resource measurement.
+type SoakSample struct {
+	Elapsed    time.Duration
+	Goroutines int
+	HeapAlloc  uint64
+	OpenFDs    int
+	Sent       int64
+}
+
+// SoakReport summarizes a soak run. Leaks lists every threshold violation.
+type SoakReport struct {
+	Samples []SoakSample
+	Before  SoakSample
+	After   SoakSample
+	Leaks   []string
+}
+
+func (r *SoakReport) Failed() bool {
+	return len(r.Leaks) > 0
+}
+
+// Soak runs the pipeline built by setup under sustained load and reports
+// goroutine, heap and file descriptor growth. It returns early with
+// ctx.Err() if ctx is cancelled.
+func Soak(ctx context.Context, cfg SoakConfig, setup PipelineSetup) (*SoakReport, error) {
+	if cfg.Duration <= 0 {
+		cfg.Duration = time.Minute
+	}
+	if cfg.Warmup <= 0 {
+		cfg.Warmup = cfg.Duration / 10
+	}
+	if cfg.SampleInterval <= 0 {
+		cfg.SampleInterval = time.Second
+	}
+	if cfg.MaxGoroutineGrowth <= 0 {
+		cfg.MaxGoroutineGrowth = 2
+	}
+	if cfg.MaxHeapGrowth == 0 {
+		cfg.MaxHeapGrowth = 16 << 20
+	}
+	if cfg.MaxFDGrowth <= 0 {
+		cfg.MaxFDGrowth = 2
+	}
+
+	report := &SoakReport{}
+	start := time.Now()
+	report.Before = takeSample(0, 0)
+
+	logger, stop := setup(discardPublisher{})
+
+	var (
+		sent     int64
+		baseline *SoakSample
+		interval time.Duration
+	)
+	if cfg.Rate > 0 {
+		interval = time.Second / time.Duration(cfg.Rate)
+	}
+	nextSample := start.Add(cfg.SampleInterval)
+	deadline := start.Add(cfg.Duration)
+	bg := context.Background()
+
+	for time.Now().Before(deadline) {
+		if err := ctx.Err(); err != nil {
+			stop()
+			return report, err
+		}
+		logger.Info(bg, "soak", models.WithIntField("seq", int(sent)), models.WithComponent("soak"))
+		sent++
+		if interval > 0 {
+			time.Sleep(interval)
+		}
+
+		if now := time.Now(); now.After(nextSample) {
+			s := takeSample(now.Sub(start), sent)
+			report.Samples = append(report.Samples, s)
+			if baseline == nil && s.Elapsed >= cfg.Warmup {
+				baseline = &s
+			}
+			if cfg.OnSample != nil {
+				cfg.OnSample(s)
+			}
+			nextSample = now.Add(cfg.SampleInterval)
+		}
+	}
+
+	loaded := takeSample(time.Since(start), sent)
+	stop()
+	report.After = takeSample(time.Since(start), sent)
+
+	if baseline == nil {
+		baseline = &report.Before
+	}
+	if growth := report.After.Goroutines - report.Before.Goroutines; growth > cfg.MaxGoroutineGrowth {
+		report.Leaks = append(report.Leaks,
+			fmt.Sprintf("goroutines grew by %d after stop (limit %d)", growth, cfg.MaxGoroutineGrowth))
+	}
+	if loaded.HeapAlloc > baseline.HeapAlloc && loaded.HeapAlloc-baseline.HeapAlloc > cfg.MaxHeapGrowth {
+		report.Leaks = append(report.Leaks,
+			fmt.Sprintf("heap grew by %d bytes under load (limit %d)", loaded.HeapAlloc-baseline.HeapAlloc, cfg.MaxHeapGrowth))
+	}
+	if report.Before.OpenFDs >= 0 && report.After.OpenFDs >= 0 {
+		if growth := report.After.OpenFDs - report.Before.OpenFDs; growth > cfg.MaxFDGrowth {
+			report.Leaks = append(report.Leaks,
+				fmt.Sprintf("open file descriptors grew by %d (limit %d)", growth, cfg.MaxFDGrowth))
+		}
+	}
+	return report, nil
+}
+
+func takeSample(elapsed time.Duration, sent int64) SoakSample {
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return SoakSample{
+		Elapsed:    elapsed,
+		Goroutines: settledGoroutines(),
+		HeapAlloc:  ms.HeapAlloc,
+		OpenFDs:    openFDs(),
+		Sent:       sent,
+	}
+}
+
+// settledGoroutines gives exiting goroutines a moment to finish so that
+// short-lived helpers are not reported as leaks.
+func settledGoroutines() int {
+	n := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		time.Sleep(5 * time.Millisecond)
+		m := runtime.NumGoroutine()
+		if m == n {
+			break
+		}
+		n = m
+	}
+	return n
+}
+
+func openFDs() int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+	return len(entries)
+}
+
+type discardPublisher struct{}
+
+func (discardPublisher) SendMsg(*models.LogData) {}
@@ -0,0 +1,85 @@
+package glog
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"net/http"
+	"sync"
+)
+
+// PublisherHealth is the status of one publisher. Publishers that do not
+// implement interfaces.HealthChecker are reported healthy with Checked false.
+type PublisherHealth struct {
+	Healthy bool   `json:"healthy"`
+	Checked bool   `json:"checked"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Health runs the health checks of every registered publisher concurrently
+// and returns their status keyed by publisher ID. A check that has not
+// returned when ctx is done is reported with ctx.Err().
+func (ls *LoggerService) Health(ctx context.Context) map[string]PublisherHealth {
+	ls.mutex.RLock()
+	res := make(map[string]PublisherHealth, len(ls.loggers))
+	checkers := make(map[string]interfaces.HealthChecker)
+	for id, entry := range ls.loggers {
+		if c, ok := entry.publisher.(interfaces.HealthChecker); ok {
+			checkers[id] = c
+		} else {
+			res[id] = PublisherHealth{Healthy: true}
+		}
+	}
+	ls.mutex.RUnlock()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for id, c := range checkers {
+		wg.Add(1)
+		go func(id string, c interfaces.HealthChecker) {
+			defer wg.Done()
+			err := check(ctx, c)
+			h := PublisherHealth{Healthy: err == nil, Checked: true}
+			if err != nil {
+				h.Error = err.Error()
+			}
+			mu.Lock()
+			res[id] = h
+			mu.Unlock()
+		}(id, c)
+	}
+	wg.Wait()
+	return res
+}
+
+// check runs one health check but gives up when ctx is done, so a hung
+// backend cannot stall a readiness probe.
+func check(ctx context.Context, c interfaces.HealthChecker) error {
+	errCh := make(chan error, 1)
+	go func() { errCh <- c.HealthCheck(ctx) }()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// HealthHandler serves Health as JSON for readiness probes. It answers 503
+// if any publisher is unhealthy.
+func (ls *LoggerService) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		health := ls.Health(r.Context())
+		status := http.StatusOK
+		for _, h := range health {
+			if !h.Healthy {
+				status = http.StatusServiceUnavailable
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(health)
+	})
+}
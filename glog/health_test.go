@@ -0,0 +1,71 @@
+package glog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type checkedPublisher struct {
+	mockPublisher
+	check func(ctx context.Context) error
+}
+
+func (p *checkedPublisher) HealthCheck(ctx context.Context) error {
+	return p.check(ctx)
+}
+
+func TestHealth_PerPublisher(t *testing.T) {
+	ls := NewLoggerService()
+	ls.AddLogger("plain", &mockPublisher{})
+	ls.AddLogger("up", &checkedPublisher{check: func(context.Context) error { return nil }})
+	ls.AddLogger("audit", &checkedPublisher{check: func(context.Context) error { return errors.New("connection refused") }})
+	ls.AddLogger("hung", &checkedPublisher{check: func(context.Context) error { select {} }})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	health := ls.Health(ctx)
+
+	if h := health["plain"]; !h.Healthy || h.Checked {
+		t.Errorf("expected an unchecked healthy publisher, got %+v", h)
+	}
+	if h := health["up"]; !h.Healthy || !h.Checked {
+		t.Errorf("expected a checked healthy publisher, got %+v", h)
+	}
+	if h := health["audit"]; h.Healthy || h.Error != "connection refused" {
+		t.Errorf("expected the audit sink reported down, got %+v", h)
+	}
+	if h := health["hung"]; h.Healthy || h.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("expected a hung check to time out, got %+v", h)
+	}
+}
+
+func TestHealthHandler_Status(t *testing.T) {
+	var down error
+	ls := NewLoggerService()
+	ls.AddLogger("audit", &checkedPublisher{check: func(context.Context) error { return down }})
+
+	rec := httptest.NewRecorder()
+	ls.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+
+	down = errors.New("connection refused")
+	rec = httptest.NewRecorder()
+	ls.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rec.Code)
+	}
+	var body map[string]PublisherHealth
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if body["audit"].Error != "connection refused" {
+		t.Errorf("unexpected body %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,340 @@
+// Package honeycomb sends each record as a Honeycomb event through the batch
+// events API. Fields become event columns, with object fields flattened into
+// dotted column names, and the dataset defaults to the record's app.
+package honeycomb
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/internal/batch"
+	"github.com/alexnobleburn/glogger/glog/internal/report"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultAPIURL   = "https://api.honeycomb.io"
+	defaultDataset  = "logs"
+	defaultRetries  = 3
+	maxFlattenDepth = 8
+)
+
+// Config configures a Publisher.
+type Config struct {
+	// APIKey is sent as X-Honeycomb-Team. Required.
+	APIKey string
+	// Dataset sends every record to one dataset. When empty, the dataset is
+	// the record's app (models.AppID in its context, else AppID), or "logs".
+	Dataset string
+	// AppID and Env are used when the record context has no models.AppID or
+	// models.EnvName.
+	AppID string
+	Env   string
+	// APIURL defaults to https://api.honeycomb.io; set it for the EU region
+	// (https://api.eu1.honeycomb.io) or a Refinery proxy.
+	APIURL string
+	// BatchSize, FlushInterval and MaxBuffered control batching (defaults
+	// 100, 1s and 10000).
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxBuffered   int
+	// Retries for 429 and 5xx responses and network errors (default 3), with
+	// exponential backoff starting at Backoff (default 100ms).
+	Retries int
+	Backoff time.Duration
+	// Client defaults to an http.Client with a 10s timeout.
+	Client *http.Client
+	// ErrorHandler receives encoding and delivery errors. Once the publisher
+	// is added to a service, the service error handler receives them too.
+	// Without either, they are printed.
+	ErrorHandler func(error)
+}
+
+// Compile-time checks that Publisher implements interfaces.LogPublisher and
+// interfaces.ErrorReporter.
+var (
+	_ interfaces.LogPublisher  = (*Publisher)(nil)
+	_ interfaces.ErrorReporter = (*Publisher)(nil)
+)
+
+// Publisher posts buffered events, one request per dataset in each batch.
+// Refused events are counted in Rejected and reported, not retried.
+type Publisher struct {
+	cfg      Config
+	errs     *report.Handler
+	batcher  *batch.Batcher[*event]
+	rejected atomic.Int64
+}
+
+type event struct {
+	dataset string
+	body    json.RawMessage
+	// sent is set once the dataset's request succeeded, so a retry of the
+	// batch only resends the datasets that failed.
+	sent bool
+}
+
+func New(cfg Config) (*Publisher, error) {
+	if cfg.APIKey == "" {
+		return nil, errors.New("honeycomb: APIKey is required")
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = defaultAPIURL
+	}
+	cfg.APIURL = strings.TrimRight(cfg.APIURL, "/")
+	if cfg.Retries == 0 {
+		cfg.Retries = defaultRetries
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	errs := report.New(cfg.ErrorHandler)
+	cfg.ErrorHandler = errs.Handle
+	p := &Publisher{cfg: cfg, errs: errs}
+	p.batcher = batch.New(batch.Config{
+		Size:         cfg.BatchSize,
+		Interval:     cfg.FlushInterval,
+		MaxBuffered:  cfg.MaxBuffered,
+		Retries:      cfg.Retries,
+		Backoff:      cfg.Backoff,
+		ErrorHandler: cfg.ErrorHandler,
+	}, p.send)
+	return p, nil
+}
+
+// ReportErrorsTo implements interfaces.ErrorReporter.
+func (p *Publisher) ReportErrorsTo(report func(error)) {
+	p.errs.ReportTo(report)
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	appID, env := p.cfg.AppID, p.cfg.Env
+	if data.Ctx != nil {
+		if v, ok := data.Ctx.Value(models.AppID).(string); ok && v != "" {
+			appID = v
+		}
+		if v, ok := data.Ctx.Value(models.EnvName).(string); ok && v != "" {
+			env = v
+		}
+	}
+	dataset := p.cfg.Dataset
+	if dataset == "" {
+		dataset = appID
+	}
+	if dataset == "" {
+		dataset = defaultDataset
+	}
+
+	body, err := json.Marshal(struct {
+		Time time.Time      `json:"time"`
+		Data map[string]any `json:"data"`
+	}{data.TimeOr(time.Now()).UTC(), columns(data, appID, env)})
+	if err != nil {
+		p.cfg.ErrorHandler(fmt.Errorf("honeycomb: encode: %w", err))
+		return
+	}
+	if err := p.batcher.Add(&event{dataset: dataset, body: body}); err != nil {
+		p.cfg.ErrorHandler(fmt.Errorf("honeycomb: %w", err))
+	}
+}
+
+// columns flattens data into event columns. Fields named like the standard
+// columns (service.name, env, level, message) do not replace them.
+func columns(data *models.LogData, appID, env string) map[string]any {
+	cols := make(map[string]any, len(data.Fields)+4)
+	for _, f := range data.Fields {
+		if f == nil {
+			continue
+		}
+		switch f.Type {
+		case models.FieldTypeString:
+			cols[f.Key] = f.String
+		case models.FieldTypeInt:
+			cols[f.Key] = f.Integer
+		case models.FieldTypeFloat:
+			cols[f.Key] = f.Float
+		case models.FieldTypeBool:
+			cols[f.Key] = f.Bool
+		case models.FieldTypeInt64:
+			cols[f.Key] = f.Int64
+		case models.FieldTypeUint64:
+			cols[f.Key] = f.Uint64
+		case models.FieldTypeBinary:
+			cols[f.Key] = base64.StdEncoding.EncodeToString(f.Binary)
+		case models.FieldTypeDuration:
+			// Milliseconds as a number keep the column usable in aggregates.
+			cols[f.Key] = float64(f.Duration) / float64(time.Millisecond)
+		case models.FieldTypeTime:
+			cols[f.Key] = f.Time.Format(time.RFC3339Nano)
+		default:
+			flattenObject(cols, f.Key, f.Object)
+		}
+	}
+	if appID != "" {
+		cols["service.name"] = appID
+	}
+	if env != "" {
+		cols["env"] = env
+	}
+	cols["level"] = data.Level.String()
+	cols["message"] = data.Msg
+	return cols
+}
+
+// flattenObject adds v under key, expanding JSON objects into key.child
+// columns. Arrays and values nested too deeply are kept as JSON.
+func flattenObject(cols map[string]any, key string, v any) {
+	b, err := safejson.Marshal(v)
+	if err != nil {
+		cols[key] = safejson.Placeholder(v)
+		return
+	}
+	var decoded any
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		cols[key] = string(b)
+		return
+	}
+	flatten(cols, key, decoded, 0)
+}
+
+func flatten(cols map[string]any, key string, v any, depth int) {
+	obj, ok := v.(map[string]any)
+	if !ok || depth >= maxFlattenDepth {
+		if _, isArray := v.([]any); isArray || ok {
+			b, _ := json.Marshal(v)
+			cols[key] = string(b)
+			return
+		}
+		cols[key] = v
+		return
+	}
+	for k, child := range obj {
+		flatten(cols, key+"."+k, child, depth+1)
+	}
+}
+
+// Flush sends everything buffered so far.
+func (p *Publisher) Flush(ctx context.Context) error {
+	return p.batcher.Flush(ctx)
+}
+
+// Close flushes and stops the background goroutine.
+func (p *Publisher) Close() error {
+	return p.batcher.Close()
+}
+
+// Dropped returns how many records were discarded because the buffer was
+// full.
+func (p *Publisher) Dropped() int64 {
+	return p.batcher.Dropped()
+}
+
+// Rejected returns how many events Honeycomb refused, either with the whole
+// request (e.g. a bad API key) or individually (e.g. too large).
+func (p *Publisher) Rejected() int64 {
+	return p.rejected.Load()
+}
+
+func (p *Publisher) send(ctx context.Context, events []*event) error {
+	var datasets []string
+	byDataset := make(map[string][]*event)
+	for _, e := range events {
+		if e.sent {
+			continue
+		}
+		if _, ok := byDataset[e.dataset]; !ok {
+			datasets = append(datasets, e.dataset)
+		}
+		byDataset[e.dataset] = append(byDataset[e.dataset], e)
+	}
+
+	var retry error
+	for _, dataset := range datasets {
+		group := byDataset[dataset]
+		retryable, err := p.post(ctx, dataset, group)
+		if err != nil && retryable {
+			if retry == nil {
+				retry = err
+			}
+			continue
+		}
+		if err != nil {
+			p.cfg.ErrorHandler(err)
+		}
+		// Delivered or refused for good: a retry must not send these again.
+		for _, e := range group {
+			e.sent = true
+		}
+	}
+	return retry
+}
+
+// post sends one dataset's events and reports per-event failures. retryable
+// tells whether a failed request may succeed later.
+func (p *Publisher) post(ctx context.Context, dataset string, events []*event) (retryable bool, err error) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, e := range events {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(e.body)
+	}
+	buf.WriteByte(']')
+
+	u := p.cfg.APIURL + "/1/batch/" + url.PathEscape(dataset)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, &buf)
+	if err != nil {
+		return false, fmt.Errorf("honeycomb: build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Honeycomb-Team", p.cfg.APIKey)
+
+	resp, err := p.cfg.Client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("honeycomb: send to %q: %w", dataset, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("honeycomb: send to %q: %s: %s", dataset, resp.Status, bytes.TrimSpace(msg))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return true, err
+		}
+		p.rejected.Add(int64(len(events)))
+		return false, err
+	}
+
+	var statuses []struct {
+		Status int    `json:"status"`
+		Error  string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		return false, nil
+	}
+	failed, first := 0, ""
+	for _, s := range statuses {
+		if s.Status/100 != 2 {
+			if failed == 0 {
+				first = s.Error
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		p.rejected.Add(int64(failed))
+		return false, fmt.Errorf("honeycomb: %d of %d events to %q rejected: %s", failed, len(events), dataset, first)
+	}
+	return false, nil
+}
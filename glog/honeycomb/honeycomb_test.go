@@ -0,0 +1,142 @@
+package honeycomb
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type honeycombEvent struct {
+	Time time.Time      `json:"time"`
+	Data map[string]any `json:"data"`
+}
+
+type fakeAPI struct {
+	mu       sync.Mutex
+	events   map[string][]honeycombEvent
+	keys     []string
+	failures int // requests to answer with 503
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	f.keys = append(f.keys, r.Header.Get("X-Honeycomb-Team"))
+	var batch []honeycombEvent
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dataset := strings.TrimPrefix(r.URL.Path, "/1/batch/")
+	f.events[dataset] = append(f.events[dataset], batch...)
+	statuses := make([]map[string]any, len(batch))
+	for i, e := range batch {
+		statuses[i] = map[string]any{"status": 202}
+		if e.Data["message"] == "too big" {
+			statuses[i] = map[string]any{"status": 400, "error": "event exceeds max event size"}
+		}
+	}
+	_ = json.NewEncoder(w).Encode(statuses)
+}
+
+func TestPublisher_EventsAndDatasets(t *testing.T) {
+	api := &fakeAPI{events: make(map[string][]honeycombEvent)}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	var errs []error
+	p, err := New(Config{
+		APIKey:       "key",
+		AppID:        "checkout",
+		Env:          "prod",
+		APIURL:       srv.URL,
+		ErrorHandler: func(err error) { errs = append(errs, err) },
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	p.SendMsg(&models.LogData{
+		Ctx:   context.Background(),
+		Level: models.ErrorLevel,
+		Msg:   "payment failed",
+		Time:  at,
+		Fields: []*models.LogField{
+			{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: "billing"},
+			{Key: "amount", Type: models.FieldTypeFloat, Float: 9.5},
+			{Key: "user", Type: models.FieldTypeObject, Object: map[string]any{"id": 7, "plan": map[string]string{"tier": "pro"}}},
+			{Key: "tags", Type: models.FieldTypeObject, Object: []string{"a", "b"}},
+		},
+	})
+	ctx := context.WithValue(context.Background(), models.AppID, "search")
+	p.SendMsg(&models.LogData{Ctx: ctx, Level: models.InfoLevel, Msg: "query"})
+	p.SendMsg(&models.LogData{Ctx: context.Background(), Level: models.InfoLevel, Msg: "too big"})
+
+	if err := p.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if len(api.events["checkout"]) != 2 || len(api.events["search"]) != 1 {
+		t.Fatalf("expected 2 events in checkout and 1 in search, got %v", api.events)
+	}
+	e := api.events["checkout"][0]
+	if !e.Time.Equal(at) {
+		t.Errorf("expected event time %v, got %v", at, e.Time)
+	}
+	want := map[string]any{
+		"service.name":   "checkout",
+		"env":            "prod",
+		"level":          "error",
+		"message":        "payment failed",
+		"component":      "billing",
+		"amount":         9.5,
+		"user.id":        float64(7),
+		"user.plan.tier": "pro",
+		"tags":           `["a","b"]`,
+	}
+	for k, v := range want {
+		if e.Data[k] != v {
+			t.Errorf("column %q = %#v, want %#v", k, e.Data[k], v)
+		}
+	}
+	if api.keys[0] != "key" {
+		t.Errorf("expected the API key header, got %q", api.keys[0])
+	}
+	if p.Rejected() != 1 || len(errs) != 1 || !strings.Contains(errs[0].Error(), "max event size") {
+		t.Errorf("expected one rejected event reported, got %d and %v", p.Rejected(), errs)
+	}
+}
+
+func TestPublisher_RetriesServerErrors(t *testing.T) {
+	api := &fakeAPI{events: make(map[string][]honeycombEvent), failures: 1}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	p, err := New(Config{APIKey: "key", Dataset: "all", APIURL: srv.URL, Backoff: time.Millisecond, ErrorHandler: func(error) {}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	p.SendMsg(&models.LogData{Ctx: context.Background(), Level: models.InfoLevel, Msg: "one"})
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if len(api.events["all"]) != 1 {
+		t.Errorf("expected the event delivered once after a retry, got %v", api.events)
+	}
+}
+
+func TestNew_RequiresAPIKey(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Error("expected an error without an API key")
+	}
+}
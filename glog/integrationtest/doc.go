@@ -0,0 +1,15 @@
+// Package integrationtest runs publishers against real backends started with
+// docker-compose.yml in this directory. It is a module of its own, so the
+// drivers and clients the tests use never become dependencies of glogger.
+// The tests carry the "integration" build tag and are skipped by a plain go
+// test:
+//
+//	docker compose -f glog/integrationtest/docker-compose.yml up -d
+//	cd glog/integrationtest && go test -tags integration ./...
+//
+// Endpoints default to the compose ports and can be overridden with
+// GLOG_IT_LOKI_URL, GLOG_IT_POSTGRES_DSN, GLOG_IT_KAFKA_BROKER and
+// GLOG_IT_NATS_URL. A test skips when its backend is not reachable. Add a
+// service to the compose file and a test here when adding a network
+// publisher.
+package integrationtest
@@ -0,0 +1,89 @@
+//go:build integration
+
+package integrationtest
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/kafka"
+	"github.com/alexnobleburn/glogger/glog/models"
+	kafkago "github.com/segmentio/kafka-go"
+	"testing"
+	"time"
+)
+
+// kafkaProducer adapts a kafka-go Writer to kafka.Producer.
+type kafkaProducer struct {
+	w *kafkago.Writer
+}
+
+func (p kafkaProducer) Produce(ctx context.Context, topic string, msgs []kafka.Message) error {
+	out := make([]kafkago.Message, len(msgs))
+	for i, m := range msgs {
+		out[i] = kafkago.Message{Topic: topic, Key: m.Key, Value: m.Value}
+	}
+	return p.w.WriteMessages(ctx, out...)
+}
+
+func TestKafka_MessagesHaveKeyAndFields(t *testing.T) {
+	broker := endpoint("GLOG_IT_KAFKA_BROKER", defaultKafkaBroker)
+	waitTCP(t, "kafka", broker, 30*time.Second)
+	topic := runID()
+
+	w := &kafkago.Writer{
+		Addr:                   kafkago.TCP(broker),
+		AllowAutoTopicCreation: true,
+		RequiredAcks:           kafkago.RequireAll,
+		BatchTimeout:           10 * time.Millisecond,
+	}
+	defer w.Close()
+	var errs []error
+	pub := kafka.New(kafkaProducer{w: w}, kafka.Config{
+		Topic:        topic,
+		KeyField:     "order_id",
+		Retries:      5,
+		ErrorHandler: func(err error) { errs = append(errs, err) },
+	})
+
+	ls := glog.NewLoggerService(glog.WithBlockingSend())
+	ls.AddLogger("kafka", pub)
+	ls.Start()
+	logger := ls.NewLogger()
+	for i := 0; i < 3; i++ {
+		logger.Warning(context.Background(), "integration record",
+			models.WithComponent("checkout"),
+			models.WithIntField("seq", i),
+			models.WithStringField("order_id", "o-1"))
+	}
+	// Stop flushes and closes the publisher.
+	ls.Stop()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected produce errors: %v", errs)
+	}
+
+	r := kafkago.NewReader(kafkago.ReaderConfig{Brokers: []string{broker}, Topic: topic, MaxWait: 100 * time.Millisecond})
+	defer r.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		m, err := r.ReadMessage(ctx)
+		if err != nil {
+			t.Fatalf("expected 3 messages, got %d: %v", i, err)
+		}
+		if string(m.Key) != "o-1" {
+			t.Errorf("expected the order_id key, got %q", m.Key)
+		}
+		var rec struct {
+			Level   string         `json:"level"`
+			Msg     string         `json:"msg"`
+			Payload map[string]any `json:"payload"`
+		}
+		if err := json.Unmarshal(m.Value, &rec); err != nil {
+			t.Fatalf("value is not JSON: %q", m.Value)
+		}
+		if rec.Level != "warn" || rec.Msg != "integration record" || rec.Payload["component"] != "checkout" || rec.Payload["seq"] != float64(i) {
+			t.Errorf("unexpected record %+v", rec)
+		}
+	}
+}
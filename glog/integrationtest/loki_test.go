@@ -0,0 +1,50 @@
+//go:build integration
+
+package integrationtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/loki"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+	"time"
+)
+
+func TestLoki_RecordsArriveWithLabelsAndFields(t *testing.T) {
+	baseURL := lokiURL(t)
+	app := runID()
+
+	ls := glog.NewLoggerService(glog.WithBlockingSend())
+	ls.AddLogger("loki", loki.New(loki.Config{URL: baseURL, AppID: app, Env: "integration"}))
+	ls.Start()
+	logger := ls.NewLogger()
+	for i := 0; i < 3; i++ {
+		logger.Warning(context.Background(), "integration record",
+			models.WithComponent("checkout"),
+			models.WithIntField("seq", i))
+	}
+	ls.Stop()
+
+	lines, err := queryLoki(context.Background(), baseURL, fmt.Sprintf(`{app=%q}`, app), 3, 30*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range lines {
+		if l.Labels["level"] != "warn" || l.Labels["component"] != "checkout" || l.Labels["env"] != "integration" {
+			t.Errorf("unexpected labels %v", l.Labels)
+		}
+		var rec struct {
+			Msg     string         `json:"msg"`
+			Payload map[string]any `json:"payload"`
+		}
+		if err := json.Unmarshal([]byte(l.Line), &rec); err != nil {
+			t.Fatalf("line is not JSON: %q", l.Line)
+		}
+		if rec.Msg != "integration record" || rec.Payload["seq"] == nil {
+			t.Errorf("unexpected record %+v", rec)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+//go:build integration
+
+package integrationtest
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/models"
+	gnats "github.com/alexnobleburn/glogger/glog/nats"
+	"github.com/nats-io/nats.go"
+	"testing"
+	"time"
+)
+
+func TestNATS_SubjectsAndPayload(t *testing.T) {
+	url := endpoint("GLOG_IT_NATS_URL", defaultNATSURL)
+	nc, err := nats.Connect(url, nats.Timeout(5*time.Second))
+	if err != nil {
+		t.Skipf("nats not available at %s: %v", url, err)
+	}
+	defer nc.Close()
+	app := runID()
+	sub, err := nc.SubscribeSync("logs." + app + ".>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := nc.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	ls := glog.NewLoggerService(glog.WithBlockingSend())
+	ls.AddLogger("nats", gnats.New(nc, gnats.Config{AppID: app, Env: "integration"}))
+	ls.Start()
+	logger := ls.NewLogger()
+	logger.Info(context.Background(), "integration record", models.WithIntField("seq", 0))
+	logger.Warning(context.Background(), "integration record", models.WithIntField("seq", 1))
+	// Stop flushes the connection.
+	ls.Stop()
+
+	for i, level := range []string{"info", "warn"} {
+		msg, err := sub.NextMsg(10 * time.Second)
+		if err != nil {
+			t.Fatalf("expected 2 messages, got %d: %v", i, err)
+		}
+		if want := "logs." + app + ".integration." + level; msg.Subject != want {
+			t.Errorf("expected subject %s, got %s", want, msg.Subject)
+		}
+		var rec struct {
+			Level   string         `json:"level"`
+			Msg     string         `json:"msg"`
+			Service string         `json:"service_name"`
+			Payload map[string]any `json:"payload"`
+		}
+		if err := json.Unmarshal(msg.Data, &rec); err != nil {
+			t.Fatalf("message is not JSON: %q", msg.Data)
+		}
+		if rec.Level != level || rec.Msg != "integration record" || rec.Service != app || rec.Payload["seq"] != float64(i) {
+			t.Errorf("unexpected record %+v", rec)
+		}
+	}
+}
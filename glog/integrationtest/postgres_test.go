@@ -0,0 +1,77 @@
+//go:build integration
+
+package integrationtest
+
+import (
+	"context"
+	"database/sql"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/postgres"
+	_ "github.com/lib/pq"
+	"testing"
+	"time"
+)
+
+func TestPostgres_RowsHaveColumnsAndFields(t *testing.T) {
+	db, err := sql.Open("postgres", endpoint("GLOG_IT_POSTGRES_DSN", defaultPostgresDSN))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for db.PingContext(ctx) != nil {
+		select {
+		case <-ctx.Done():
+			t.Skipf("postgres not available: %v", db.PingContext(context.Background()))
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+
+	table := "glog_" + runID()
+	pub, err := postgres.New(ctx, db, postgres.Config{Table: table, CreateTable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Exec("DROP TABLE " + table)
+
+	ls := glog.NewLoggerService(glog.WithBlockingSend())
+	ls.AddLogger("postgres", pub)
+	ls.Start()
+	logger := ls.NewLogger()
+	for i := 0; i < 3; i++ {
+		logger.Warning(context.Background(), "integration record",
+			models.WithComponent("checkout"),
+			models.WithIntField("seq", i),
+			models.WithStringField("order_id", "o-1"))
+	}
+	// Stop flushes and closes the publisher.
+	ls.Stop()
+
+	rows, err := db.QueryContext(ctx, "SELECT level, component, msg, fields->>'seq', fields->>'order_id' FROM "+table+" ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var n int
+	for rows.Next() {
+		var level, component, msg, seq, orderID string
+		if err := rows.Scan(&level, &component, &msg, &seq, &orderID); err != nil {
+			t.Fatal(err)
+		}
+		if level != "warn" || component != "checkout" || msg != "integration record" || orderID != "o-1" {
+			t.Errorf("unexpected row %q %q %q %q", level, component, msg, orderID)
+		}
+		if want := string(rune('0' + n)); seq != want {
+			t.Errorf("expected seq %s, got %q", want, seq)
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 rows, got %d", n)
+	}
+}
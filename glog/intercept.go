@@ -0,0 +1,55 @@
+package glog
+
+import (
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+)
+
+// WithInterceptors runs the interceptors, in order, on every record in the
+// main worker before it is routed to any publisher, for enrichment,
+// mutation, filtering or metrics that apply to all of them. An interceptor
+// may modify the record it receives, or return nil to drop it for every
+// publisher, except models.LogData.Internal records, which are kept.
+// Per-publisher processors run afterwards on their own copy. Interceptors
+// run one record at a time, so they need no locking but should be quick; a
+// slow one delays the whole pipeline. A panicking interceptor is reported
+// and skipped.
+func WithInterceptors(interceptors ...Processor) ServiceOption {
+	return func(ls *LoggerService) {
+		for _, i := range interceptors {
+			if i != nil {
+				ls.interceptors = append(ls.interceptors, i)
+			}
+		}
+	}
+}
+
+// intercept runs the interceptors on logData and returns nil if one of them
+// dropped it.
+func (ls *LoggerService) intercept(logData *models.LogData) *models.LogData {
+	for i, interceptor := range ls.interceptors {
+		next, ok := ls.runInterceptor(i, interceptor, logData)
+		if !ok {
+			continue
+		}
+		if next == nil {
+			if logData.Internal {
+				continue
+			}
+			return nil
+		}
+		logData = next
+	}
+	return logData
+}
+
+func (ls *LoggerService) runInterceptor(i int, interceptor Processor, logData *models.LogData) (next *models.LogData, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			ls.stats.panics.Add(1)
+			ls.errorHandler(fmt.Errorf("glogger: panic in interceptor %d: %v", i, r))
+			ok = false
+		}
+	}()
+	return interceptor(logData), true
+}
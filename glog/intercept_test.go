@@ -0,0 +1,73 @@
+package glog
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWithInterceptors_EnrichFilterAndCount(t *testing.T) {
+	var counted int
+	var mu sync.Mutex
+	var handled []error
+	ls := NewLoggerService(
+		WithErrorHandler(func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			handled = append(handled, err)
+		}),
+		WithInterceptors(
+			func(logData *models.LogData) *models.LogData {
+				counted++
+				return logData
+			},
+			func(logData *models.LogData) *models.LogData {
+				if logData.Msg == "health check" {
+					return nil
+				}
+				return logData
+			},
+			func(logData *models.LogData) *models.LogData {
+				if logData.Msg == "boom" {
+					panic("bad interceptor")
+				}
+				logData.Fields = append(logData.Fields, &models.LogField{Key: "region", Type: models.FieldTypeString, String: "eu"})
+				return logData
+			},
+		),
+	)
+	a, b := &mockPublisher{}, &mockPublisher{}
+	ls.AddLogger("a", a)
+	ls.AddLogger("b", b)
+	ls.Start()
+
+	logger := ls.NewLogger()
+	ctx := context.Background()
+	logger.Info(ctx, "health check")
+	logger.Info(ctx, "order placed")
+	logger.Info(ctx, "boom")
+	ls.Stop()
+
+	for name, mock := range map[string]*mockPublisher{"a": a, "b": b} {
+		logs := byMsg(mock.GetLogs())
+		if len(logs) != 2 || logs["health check"] != nil {
+			t.Fatalf("%s: expected the health check dropped, got %v", name, logs)
+		}
+		if fields(logs["order placed"])["region"] != "eu" {
+			t.Errorf("%s: expected the record enriched, got %v", name, fields(logs["order placed"]))
+		}
+		if _, ok := fields(logs["boom"])["region"]; ok {
+			t.Errorf("%s: expected the panicking interceptor skipped", name)
+		}
+	}
+	if counted != 3 {
+		t.Errorf("expected every record counted once, got %d", counted)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handled) != 1 || !strings.Contains(handled[0].Error(), "interceptor 2") || ls.Stats().Panics != 1 {
+		t.Errorf("expected the panic reported, got %v", handled)
+	}
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import (
+	"github.com/alexnobleburn/glogger/glog/models"
+)
+
+// Encoder renders one record as bytes, without a trailing newline.
+type Encoder interface {
+	Encode(data *models.LogData) ([]byte, error)
+}
@@ -0,0 +1,9 @@
+package interfaces
+
+import "github.com/alexnobleburn/glogger/glog/models"
+
+// Filter decides whether a record is kept. It gets the shared record and
+// must not modify it.
+type Filter interface {
+	Allow(data *models.LogData) bool
+}
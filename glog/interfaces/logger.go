@@ -11,4 +11,7 @@ type Logger interface {
 	Info(ctx context.Context, message string, options ...models.Option)
 	Warning(ctx context.Context, message string, options ...models.Option)
 	Debug(ctx context.Context, message string, options ...models.Option)
+	// Log logs message at level, so adapters need no switch over the
+	// level-specific methods.
+	Log(ctx context.Context, level models.LogLevel, message string, options ...models.Option)
 }
@@ -1,9 +1,53 @@
 package interfaces
 
 import (
+	"context"
 	"github.com/alexnobleburn/glogger/glog/models"
 )
 
 type LogPublisher interface {
 	SendMsg(data *models.LogData)
 }
+
+// ErrorPublisher is implemented by publishers that can tell when a delivery
+// failed. The service calls TrySendMsg instead of SendMsg and passes a
+// non-nil error to its error handler.
+type ErrorPublisher interface {
+	LogPublisher
+	TrySendMsg(data *models.LogData) error
+}
+
+// ErrorReporter is implemented by publishers that deliver in the background,
+// where SendMsg cannot return delivery errors. When the publisher is added,
+// the service calls ReportErrorsTo; the publisher then passes its errors to
+// report as well as to its own error handler, and the service counts them
+// as failed deliveries.
+type ErrorReporter interface {
+	ReportErrorsTo(report func(error))
+}
+
+// Flusher is implemented by publishers that buffer records. Flush delivers
+// everything buffered so far or returns when ctx is done.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Starter is implemented by publishers that need setup, such as opening a
+// connection. The service calls Start from its own Start, or from AddLogger
+// once it is running.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper is implemented by publishers that need a graceful shutdown. The
+// service calls Stop once its pipeline has drained and the publishers have
+// been flushed. Publishers that are only an io.Closer are closed instead.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// HealthChecker is implemented by publishers that can tell whether their
+// backend is reachable. HealthCheck returns nil when it is.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"github.com/alexnobleburn/glogger/glog/models"
+)
+
+// SchemaDescriber is implemented by publishers and encoders that can
+// describe their output as a JSON Schema.
+type SchemaDescriber interface {
+	Schema() *models.Schema
+}
@@ -0,0 +1,250 @@
+// Package batch buffers items for publishers that ship records in batches
+// (Kafka, Loki, ...) and retries failed batches with exponential backoff.
+package batch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrClosed = errors.New("batch: closed")
+
+// Config configures a Batcher. Zero values fall back to the defaults noted.
+type Config struct {
+	// Size triggers a flush when that many items are buffered. Default 100.
+	Size int
+	// Interval flushes a partial batch. Default 1s.
+	Interval time.Duration
+	// MaxBuffered bounds the items kept while the backend is failing; the
+	// oldest are dropped first. Default 10000.
+	MaxBuffered int
+	// Retries is how many times a failed batch is retried within one flush
+	// before the flush gives up and keeps the batch for the next one.
+	Retries int
+	// Backoff is the wait before the first retry, doubled for each further
+	// retry. Default 100ms.
+	Backoff time.Duration
+	// ErrorHandler receives errors from background flushes.
+	ErrorHandler func(error)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error that retrying cannot fix (e.g. HTTP 400). The
+// batch is dropped instead of kept for the next flush.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+type partialError struct {
+	n   int
+	err error
+}
+
+func (e *partialError) Error() string { return e.err.Error() }
+func (e *partialError) Unwrap() error { return e.err }
+
+// Partial reports that the first n items of a batch were delivered before
+// err. Retries and later flushes send only the rest.
+func Partial(n int, err error) error {
+	return &partialError{n: n, err: err}
+}
+
+// Batcher collects items and sends them from a background goroutine.
+type Batcher[T any] struct {
+	cfg  Config
+	send func(ctx context.Context, items []T) error
+
+	mu      sync.Mutex
+	pending []T
+	dropped int64
+	closed  bool
+	lastErr error
+
+	flushMu sync.Mutex
+	kick    chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func New[T any](cfg Config, send func(ctx context.Context, items []T) error) *Batcher[T] {
+	if cfg.Size <= 0 {
+		cfg.Size = 100
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.MaxBuffered <= 0 {
+		cfg.MaxBuffered = 10000
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 100 * time.Millisecond
+	}
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = func(error) {}
+	}
+	b := &Batcher[T]{
+		cfg:  cfg,
+		send: send,
+		kick: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	b.wg.Add(1)
+	go b.run()
+	return b
+}
+
+// Add buffers item. It returns ErrClosed after Close.
+func (b *Batcher[T]) Add(item T) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	b.pending = append(b.pending, item)
+	if over := len(b.pending) - b.cfg.MaxBuffered; over > 0 {
+		b.pending = b.pending[over:]
+		b.dropped += int64(over)
+	}
+	full := len(b.pending) >= b.cfg.Size
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.kick <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Flush sends everything buffered so far, retrying each batch. A batch that
+// still fails stays buffered for the next flush unless the error is
+// Permanent.
+func (b *Batcher[T]) Flush(ctx context.Context) error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+	for {
+		b.mu.Lock()
+		n := min(len(b.pending), b.cfg.Size)
+		items := b.pending[:n:n]
+		droppedBefore := b.dropped
+		b.mu.Unlock()
+		if n == 0 {
+			return nil
+		}
+
+		sent, err := b.sendWithRetry(ctx, items)
+		var perm *permanentError
+		permanent := errors.As(err, &perm)
+		if err == nil || permanent {
+			sent = n
+		}
+
+		b.mu.Lock()
+		// MaxBuffered may have trimmed the head of the batch while sending.
+		trimmed := int(b.dropped - droppedBefore)
+		if permanent {
+			b.dropped += int64(max(n-max(trimmed, 0), 0))
+		}
+		b.pending = b.pending[min(max(sent-trimmed, 0), len(b.pending)):]
+		b.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// sendWithRetry returns how many leading items were delivered before the
+// last error, as reported by Partial.
+func (b *Batcher[T]) sendWithRetry(ctx context.Context, items []T) (int, error) {
+	backoff := b.cfg.Backoff
+	sent := 0
+	for attempt := 0; ; attempt++ {
+		err := b.send(ctx, items[sent:])
+		var part *partialError
+		if errors.As(err, &part) {
+			sent += min(max(part.n, 0), len(items)-sent)
+		}
+		var perm *permanentError
+		permanent := errors.As(err, &perm)
+		b.mu.Lock()
+		// A permanent error means the backend answered.
+		if permanent {
+			b.lastErr = nil
+		} else {
+			b.lastErr = err
+		}
+		b.mu.Unlock()
+		if err == nil || permanent || attempt >= b.cfg.Retries {
+			return sent, err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return sent, err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// Close flushes buffered items and stops the background goroutine.
+func (b *Batcher[T]) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	close(b.done)
+	b.wg.Wait()
+	return b.Flush(context.Background())
+}
+
+// Err returns ErrClosed after Close, otherwise the error of the last send
+// attempt that failed with a non-Permanent error, or nil once a later
+// attempt succeeded. Publishers report it from their health checks.
+func (b *Batcher[T]) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	return b.lastErr
+}
+
+// Dropped returns how many items were discarded because MaxBuffered was
+// exceeded or a batch failed permanently.
+func (b *Batcher[T]) Dropped() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+func (b *Batcher[T]) run() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+		case <-b.kick:
+		}
+		if err := b.Flush(context.Background()); err != nil {
+			b.cfg.ErrorHandler(err)
+		}
+	}
+}
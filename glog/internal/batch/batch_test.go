@@ -0,0 +1,114 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBatcher_RetryThenKeep(t *testing.T) {
+	calls := 0
+	b := New(Config{Size: 10, Interval: time.Hour, Retries: 2, Backoff: time.Millisecond}, func(_ context.Context, items []int) error {
+		calls++
+		return errors.New("unavailable")
+	})
+	defer func() { _ = b.Close() }()
+
+	_ = b.Add(1)
+	if err := b.Flush(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 3 {
+		t.Errorf("expected 1 attempt plus 2 retries, got %d", calls)
+	}
+	if b.Dropped() != 0 {
+		t.Error("retriable failures must keep the batch")
+	}
+	if err := b.Err(); err == nil || err.Error() != "unavailable" {
+		t.Errorf("expected the last send error, got %v", err)
+	}
+}
+
+func TestBatcher_ErrClearsAfterSuccess(t *testing.T) {
+	fail := true
+	b := New(Config{Size: 10, Interval: time.Hour}, func(_ context.Context, items []int) error {
+		if fail {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+
+	_ = b.Add(1)
+	_ = b.Flush(context.Background())
+	fail = false
+	if err := b.Flush(context.Background()); err != nil || b.Err() != nil {
+		t.Errorf("expected the error cleared by a successful send, got %v and %v", err, b.Err())
+	}
+	_ = b.Close()
+	if !errors.Is(b.Err(), ErrClosed) {
+		t.Errorf("expected ErrClosed after Close, got %v", b.Err())
+	}
+}
+
+func TestBatcher_PartialResendsOnlyTheRest(t *testing.T) {
+	var got [][]int
+	b := New(Config{Size: 10, Interval: time.Hour, Retries: 1, Backoff: time.Millisecond}, func(_ context.Context, items []int) error {
+		got = append(got, append([]int(nil), items...))
+		if len(got) == 1 {
+			return Partial(2, errors.New("connection reset"))
+		}
+		if len(got) == 2 {
+			return Partial(1, errors.New("connection reset"))
+		}
+		return nil
+	})
+	defer func() { _ = b.Close() }()
+
+	for i := 1; i <= 4; i++ {
+		_ = b.Add(i)
+	}
+	if err := b.Flush(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "[[1 2 3 4] [3 4] [4]]"
+	if s := fmt.Sprint(got); s != want {
+		t.Errorf("expected %s, got %s", want, s)
+	}
+	if b.Dropped() != 0 {
+		t.Errorf("expected nothing dropped, got %d", b.Dropped())
+	}
+}
+
+func TestBatcher_SizeTriggersBackgroundFlush(t *testing.T) {
+	sent := make(chan []int, 1)
+	b := New(Config{Size: 3, Interval: time.Hour}, func(_ context.Context, items []int) error {
+		sent <- append([]int(nil), items...)
+		return nil
+	})
+	defer func() { _ = b.Close() }()
+
+	for i := 0; i < 3; i++ {
+		_ = b.Add(i)
+	}
+	select {
+	case items := <-sent:
+		if len(items) != 3 {
+			t.Errorf("expected a full batch, got %v", items)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a background flush once the batch was full")
+	}
+}
+
+func TestBatcher_AddAfterClose(t *testing.T) {
+	b := New(Config{}, func(context.Context, []int) error { return nil })
+	_ = b.Close()
+	if err := b.Add(1); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
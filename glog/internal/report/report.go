@@ -0,0 +1,45 @@
+// Package report routes the errors of publishers that deliver in the
+// background, where SendMsg cannot return them, to the publisher's own
+// error handler and to the service the publisher was added to.
+package report
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Handler passes errors to a configured handler and, once set with
+// ReportTo, to the service.
+type Handler struct {
+	handler func(error)
+	service atomic.Pointer[func(error)]
+}
+
+// New returns a Handler for handler, which may be nil. Without a handler
+// and before ReportTo, errors are printed with fmt.Println.
+func New(handler func(error)) *Handler {
+	return &Handler{handler: handler}
+}
+
+// Handle reports err.
+func (h *Handler) Handle(err error) {
+	if h.handler != nil {
+		h.handler(err)
+	}
+	if report := h.service.Load(); report != nil {
+		(*report)(err)
+		return
+	}
+	if h.handler == nil {
+		fmt.Println(err)
+	}
+}
+
+// ReportTo also sends later errors to report, replacing an earlier one.
+func (h *Handler) ReportTo(report func(error)) {
+	if report == nil {
+		h.service.Store(nil)
+		return
+	}
+	h.service.Store(&report)
+}
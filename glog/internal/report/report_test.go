@@ -0,0 +1,18 @@
+package report
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHandler_ReportsToHandlerAndService(t *testing.T) {
+	var own, service []error
+	h := New(func(err error) { own = append(own, err) })
+	h.Handle(errors.New("before"))
+	h.ReportTo(func(err error) { service = append(service, err) })
+	h.Handle(errors.New("after"))
+
+	if len(own) != 2 || len(service) != 1 || service[0].Error() != "after" {
+		t.Errorf("expected both errors handled and the later one reported, got %v and %v", own, service)
+	}
+}
@@ -0,0 +1,67 @@
+// Package throttle limits alert publishers to one message per key and
+// window, counting what it holds back so the next message can say so.
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepEvery bounds how many keys accumulate before expired ones are
+// removed.
+const sweepEvery = 1024
+
+type entry struct {
+	until      time.Time
+	suppressed int
+}
+
+// Throttle is safe for concurrent use.
+type Throttle struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	keys map[string]*entry
+}
+
+func New(window time.Duration) *Throttle {
+	return &Throttle{window: window, now: time.Now, keys: make(map[string]*entry)}
+}
+
+// Allow reports whether a message with key may be sent now. When it may, it
+// also returns how many messages with the same key were held back since the
+// last one was allowed.
+func (t *Throttle) Allow(key string) (bool, int) {
+	if t.window <= 0 {
+		return true, 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	e, ok := t.keys[key]
+	if ok && now.Before(e.until) {
+		e.suppressed++
+		return false, 0
+	}
+	if !ok {
+		if len(t.keys) >= sweepEvery {
+			t.sweep(now)
+		}
+		e = &entry{}
+		t.keys[key] = e
+	}
+	suppressed := e.suppressed
+	e.until = now.Add(t.window)
+	e.suppressed = 0
+	return true, suppressed
+}
+
+// sweep drops keys whose window ended with nothing held back.
+func (t *Throttle) sweep(now time.Time) {
+	for k, e := range t.keys {
+		if !now.Before(e.until) && e.suppressed == 0 {
+			delete(t.keys, k)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottle_AllowsOncePerWindowAndCountsSuppressed(t *testing.T) {
+	now := time.Unix(0, 0)
+	th := New(time.Minute)
+	th.now = func() time.Time { return now }
+
+	if ok, n := th.Allow("a"); !ok || n != 0 {
+		t.Fatalf("expected first message allowed, got %v %d", ok, n)
+	}
+	for i := 0; i < 3; i++ {
+		if ok, _ := th.Allow("a"); ok {
+			t.Fatal("expected repeats within the window to be held back")
+		}
+	}
+	if ok, _ := th.Allow("b"); !ok {
+		t.Error("expected other keys to be independent")
+	}
+
+	now = now.Add(time.Minute)
+	if ok, n := th.Allow("a"); !ok || n != 3 {
+		t.Errorf("expected the next window to report 3 held back, got %v %d", ok, n)
+	}
+}
+
+func TestThrottle_SweepsExpiredKeys(t *testing.T) {
+	now := time.Unix(0, 0)
+	th := New(time.Second)
+	th.now = func() time.Time { return now }
+	for i := 0; i < sweepEvery; i++ {
+		th.Allow(string(rune(i + 'A')))
+	}
+	now = now.Add(time.Second)
+	th.Allow("new")
+	if len(th.keys) != 1 {
+		t.Errorf("expected expired keys swept, got %d", len(th.keys))
+	}
+}
@@ -0,0 +1,148 @@
+// Package kafka publishes records to a Kafka topic in batches. It does not
+// depend on a Kafka client: wrap the client you already use (segmentio's
+// kafka-go Writer, sarama's SyncProducer, franz-go) in a Producer.
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/internal/batch"
+	"github.com/alexnobleburn/glogger/glog/internal/report"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"time"
+)
+
+var ErrClosed = errors.New("kafka: publisher closed")
+
+// Message is one record ready to be produced.
+type Message struct {
+	Key   []byte
+	Value []byte
+}
+
+// Producer writes a batch of messages to topic. Implementations should
+// return only once the batch is acknowledged or has failed.
+type Producer interface {
+	Produce(ctx context.Context, topic string, msgs []Message) error
+}
+
+// Config configures a Publisher.
+type Config struct {
+	Topic string
+	// BatchSize triggers a flush when that many records are buffered.
+	// Default 100.
+	BatchSize int
+	// FlushInterval flushes a partial batch. Default 1s.
+	FlushInterval time.Duration
+	// MaxBuffered bounds the records kept while the producer is failing;
+	// older records are dropped first. Default 10000.
+	MaxBuffered int
+	// Retries is how many times a failed batch is retried, with exponential
+	// backoff, before it is kept for the next flush. Default 0.
+	Retries int
+	// KeyField names a string field used as the message key, so records of
+	// the same entity land on the same partition. Empty means no key.
+	KeyField string
+	// Encoder renders the message value, e.g. encoder.NewLogfmt or
+	// encoder.NewGELF. Defaults to encoder.NewJSON("", "").
+	Encoder interfaces.Encoder
+	// ErrorHandler receives produce errors. Once the publisher is added to a
+	// service, the service error handler receives them too. Without either,
+	// they are printed.
+	ErrorHandler func(error)
+}
+
+// Compile-time checks that Publisher implements interfaces.LogPublisher,
+// interfaces.HealthChecker and interfaces.ErrorReporter.
+var (
+	_ interfaces.LogPublisher  = (*Publisher)(nil)
+	_ interfaces.HealthChecker = (*Publisher)(nil)
+	_ interfaces.ErrorReporter = (*Publisher)(nil)
+)
+
+// Publisher buffers encoded records and produces them in batches from a
+// background goroutine. The service flushes it on Stop; call Close to also
+// stop the background goroutine.
+type Publisher struct {
+	producer Producer
+	cfg      Config
+	errs     *report.Handler
+	batcher  *batch.Batcher[Message]
+}
+
+func New(producer Producer, cfg Config) *Publisher {
+	if cfg.Encoder == nil {
+		cfg.Encoder = encoder.NewJSON("", "")
+	}
+	errs := report.New(cfg.ErrorHandler)
+	cfg.ErrorHandler = errs.Handle
+	p := &Publisher{producer: producer, cfg: cfg, errs: errs}
+	p.batcher = batch.New(batch.Config{
+		Size:         cfg.BatchSize,
+		Interval:     cfg.FlushInterval,
+		MaxBuffered:  cfg.MaxBuffered,
+		Retries:      cfg.Retries,
+		ErrorHandler: cfg.ErrorHandler,
+	}, p.produce)
+	return p
+}
+
+// ReportErrorsTo implements interfaces.ErrorReporter.
+func (p *Publisher) ReportErrorsTo(report func(error)) {
+	p.errs.ReportTo(report)
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	value, err := p.cfg.Encoder.Encode(data)
+	if err != nil {
+		p.cfg.ErrorHandler(fmt.Errorf("kafka: encode: %w", err))
+		return
+	}
+	msg := Message{Value: value}
+	if p.cfg.KeyField != "" {
+		for _, f := range data.Fields {
+			if f != nil && f.Key == p.cfg.KeyField && f.Type == models.FieldTypeString {
+				msg.Key = []byte(f.String)
+				break
+			}
+		}
+	}
+	if err := p.batcher.Add(msg); err != nil {
+		p.cfg.ErrorHandler(ErrClosed)
+	}
+}
+
+func (p *Publisher) produce(ctx context.Context, msgs []Message) error {
+	if err := p.producer.Produce(ctx, p.cfg.Topic, msgs); err != nil {
+		return fmt.Errorf("kafka: produce to %q: %w", p.cfg.Topic, err)
+	}
+	return nil
+}
+
+// Flush produces everything buffered so far. Failed batches stay buffered
+// and are retried on the next flush.
+func (p *Publisher) Flush(ctx context.Context) error {
+	return p.batcher.Flush(ctx)
+}
+
+// Close flushes buffered records and stops the background goroutine.
+// Records sent after Close are rejected.
+func (p *Publisher) Close() error {
+	return p.batcher.Close()
+}
+
+// Dropped returns how many records were discarded because MaxBuffered was
+// exceeded.
+func (p *Publisher) Dropped() int64 {
+	return p.batcher.Dropped()
+}
+
+// HealthCheck returns the error of the last failed delivery to the broker
+// until a batch goes through again, and an error once the publisher is
+// closed.
+func (p *Publisher) HealthCheck(ctx context.Context) error {
+	return p.batcher.Err()
+}
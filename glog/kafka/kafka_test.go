@@ -0,0 +1,105 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/glogtest"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeProducer struct {
+	mu      sync.Mutex
+	batches [][]Message
+	fail    error
+}
+
+func (f *fakeProducer) Produce(_ context.Context, topic string, msgs []Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail != nil {
+		return f.fail
+	}
+	if topic != "logs" {
+		return errors.New("unexpected topic " + topic)
+	}
+	f.batches = append(f.batches, append([]Message(nil), msgs...))
+	return nil
+}
+
+func (f *fakeProducer) messages() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var all []Message
+	for _, b := range f.batches {
+		all = append(all, b...)
+	}
+	return all
+}
+
+func TestPublisher_BatchesAndFlushesOnStop(t *testing.T) {
+	producer := &fakeProducer{}
+	pub := New(producer, Config{Topic: "logs", BatchSize: 10, FlushInterval: time.Hour, KeyField: "user_id"})
+	defer pub.Close()
+
+	ls := glog.NewLoggerService(glog.WithBlockingSend())
+	ls.AddLogger("kafka", pub)
+	ls.Start()
+	logger := ls.NewLogger()
+	for i := 0; i < 25; i++ {
+		logger.Info(context.Background(), "checkout", models.WithStringField("user_id", "u-1"))
+	}
+	ls.Stop()
+
+	msgs := producer.messages()
+	if len(msgs) != 25 {
+		t.Fatalf("expected 25 produced messages after Stop, got %d", len(msgs))
+	}
+	if string(msgs[0].Key) != "u-1" || !strings.Contains(string(msgs[0].Value), `"msg":"checkout"`) {
+		t.Errorf("unexpected message %s / %s", msgs[0].Key, msgs[0].Value)
+	}
+	for _, b := range producer.batches {
+		if len(b) > 10 {
+			t.Errorf("batch of %d exceeds BatchSize", len(b))
+		}
+	}
+}
+
+func TestPublisher_RetainsRecordsWhileProducerFails(t *testing.T) {
+	producer := &fakeProducer{fail: errors.New("broker down")}
+	pub := New(producer, Config{Topic: "logs", FlushInterval: time.Hour, MaxBuffered: 3, ErrorHandler: func(error) {}})
+	defer pub.Close()
+
+	for i := 0; i < 5; i++ {
+		pub.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "m", Level: models.InfoLevel})
+	}
+	if err := pub.Flush(context.Background()); err == nil {
+		t.Fatal("expected flush error while broker is down")
+	}
+	if pub.Dropped() != 2 {
+		t.Errorf("expected 2 dropped records, got %d", pub.Dropped())
+	}
+
+	producer.mu.Lock()
+	producer.fail = nil
+	producer.mu.Unlock()
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := len(producer.messages()); got != 3 {
+		t.Errorf("expected 3 retained records to be produced, got %d", got)
+	}
+}
+
+func TestPublisher_Conformance(t *testing.T) {
+	glogtest.RunPublisherConformance(t, func(t testing.TB) interfaces.LogPublisher {
+		pub := New(&fakeProducer{}, Config{Topic: "logs"})
+		t.Cleanup(func() { _ = pub.Close() })
+		return pub
+	})
+}
@@ -0,0 +1,140 @@
+package glog
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/audit"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net/http"
+)
+
+// SetLevel sets the minimum level of every logger of the service. It is the
+// override for the empty prefix of WithNamedLevels, so longer named
+// prefixes still take precedence, and it can be called while the service
+// runs. Records below the level are dropped before they are built or
+// queued.
+func (ls *LoggerService) SetLevel(level models.LogLevel) {
+	ls.SetNamedLevel("", level)
+}
+
+// Level returns the level set with SetLevel, or DebugLevel if none is set.
+func (ls *LoggerService) Level() models.LogLevel {
+	if levels := ls.namedLevels.Load(); levels != nil {
+		if level, ok := (*levels)[""]; ok {
+			return level
+		}
+	}
+	return models.DebugLevel
+}
+
+// WithComponentLevels sets the minimum level per component, e.g.
+// {"database": models.DebugLevel} to trace one subsystem while everything
+// else stays at the service level. Components match by dotted prefix like
+// logger names, with the component pushed on the context and the one given
+// with models.WithComponent combined as in the records. A matching
+// component override takes precedence over the named and service levels.
+func WithComponentLevels(levels map[string]models.LogLevel) ServiceOption {
+	return func(ls *LoggerService) {
+		for component, level := range levels {
+			ls.SetComponentLevel(component, level)
+		}
+	}
+}
+
+// SetComponentLevel sets the minimum level for component and the components
+// below it, and can be called while the service runs. An empty component is
+// ignored; use SetLevel.
+func (ls *LoggerService) SetComponentLevel(component string, level models.LogLevel) {
+	if component == "" {
+		return
+	}
+	ls.namedMu.Lock()
+	defer ls.namedMu.Unlock()
+	setPrefixLevel(&ls.componentLevels, component, level)
+}
+
+// ClearComponentLevel removes the override set for component.
+func (ls *LoggerService) ClearComponentLevel(component string) {
+	ls.namedMu.Lock()
+	defer ls.namedMu.Unlock()
+	clearPrefixLevel(&ls.componentLevels, component)
+}
+
+// skip reports whether a record at level is dropped before it is built:
+// below the level, or inside a quiet section or, for messages, a
+// canonical line. Records of an Internal logger are never skipped.
+func (l *Logger) skip(ctx context.Context, level models.LogLevel, component func() string, canonical bool) bool {
+	if l.internal {
+		return false
+	}
+	return !l.levelEnabled(ctx, level, component) || suppressedBySection(ctx, level) ||
+		(canonical && suppressedByCanonical(ctx, level))
+}
+
+// levelEnabled is enabled with the component overrides applied. component
+// is only called when overrides are set, since resolving it means applying
+// the call's options.
+func (l *Logger) levelEnabled(ctx context.Context, level models.LogLevel, component func() string) bool {
+	if l.svc != nil {
+		if levels := l.svc.componentLevels.Load(); levels != nil && len(*levels) > 0 {
+			if min, ok := matchPrefix(*levels, component()); ok {
+				return level >= min
+			}
+		}
+	}
+	return l.enabled(level)
+}
+
+// Enabled reports whether a record at level would be logged, to guard
+// values that are expensive to compute. Component overrides are not
+// considered, since the component is only known at the call.
+func (l *Logger) Enabled(level models.LogLevel) bool {
+	return l.enabled(level)
+}
+
+type levelBody struct {
+	Level string `json:"level"`
+}
+
+// maxLevelBodySize bounds the body of a LevelHandler PUT.
+const maxLevelBodySize = 1 << 10
+
+// LevelHandler serves the service level as {"level":"info"}. PUT with the
+// same body, or with ?level=debug, changes it. Every request is
+// authenticated with auth and recorded with audit.Middleware through the
+// service's Internal logger, with the new and previous level; a nil auth
+// admits everyone and should only be used behind an authenticating proxy.
+// admin.Handler serves the same change alongside the other runtime
+// settings.
+func (ls *LoggerService) LevelHandler(auth audit.Authenticator) http.Handler {
+	return audit.Middleware(ls.NewLogger().Internal(), auth, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			body := levelBody{Level: r.URL.Query().Get("level")}
+			if body.Level == "" {
+				dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxLevelBodySize))
+				dec.DisallowUnknownFields()
+				if err := dec.Decode(&body); err != nil {
+					http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			level, err := models.ParseLevel(body.Level)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			audit.AddDetails(r.Context(),
+				models.WithStringField("change", level.String()),
+				models.WithStringField("previous", ls.Level().String()))
+			ls.SetLevel(level)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(levelBody{Level: ls.Level().String()})
+	}))
+}
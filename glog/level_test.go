@@ -0,0 +1,160 @@
+package glog
+
+import (
+	"context"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/audit"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetLevel_AtRuntime(t *testing.T) {
+	logger, mock, ls := setupTestLogger()
+	defer ls.Stop()
+	ctx := context.Background()
+
+	if ls.Level() != models.DebugLevel || !logger.Enabled(models.DebugLevel) {
+		t.Fatalf("expected everything enabled by default, got %v", ls.Level())
+	}
+	ls.SetLevel(models.WarnLevel)
+	if logger.Enabled(models.InfoLevel) || !logger.Enabled(models.WarnLevel) {
+		t.Error("expected Info disabled and Warning enabled")
+	}
+	logger.Info(ctx, "dropped")
+	ls.SetNamedLevel("db", models.DebugLevel)
+	logger.Named("db").Debug(ctx, "boosted")
+	ls.SetLevel(models.DebugLevel)
+	logger.Debug(ctx, "debug on")
+
+	logs := byMsg(waitForLogs(mock, 2, time.Second))
+	if len(logs) != 2 || logs["boosted"] == nil || logs["debug on"] == nil {
+		t.Errorf("unexpected records %v", logs)
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	ls := NewLoggerService(WithBlockingSend(), WithNumWorkers(1))
+	audited := &mockPublisher{}
+	ls.AddLogger("audit", audited, WithFilter(HasComponent("glogger.admin")))
+	ls.Start()
+	defer ls.Stop()
+	h := ls.LevelHandler(audit.TokenAuth(map[string]string{"secret": "ops"}))
+	put := func(target, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := put("/level", `{"level":"error"}`, ""); rec.Code != http.StatusUnauthorized || ls.Level() != models.DebugLevel {
+		t.Fatalf("expected an unauthenticated request rejected, got %d", rec.Code)
+	}
+	if rec := put("/level", `{"level":"warn"}`, "secret"); rec.Code != http.StatusOK || ls.Level() != models.WarnLevel {
+		t.Fatalf("expected warn, got %d %q", rec.Code, rec.Body.String())
+	}
+	put("/level?level=debug", "", "secret")
+	if ls.Level() != models.DebugLevel {
+		t.Errorf("expected debug from the query, got %v", ls.Level())
+	}
+	req := httptest.NewRequest(http.MethodGet, "/level", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if strings.TrimSpace(rec.Body.String()) != `{"level":"debug"}` {
+		t.Errorf("unexpected body %q", rec.Body.String())
+	}
+	if rec := put("/level?level=loud", "", "secret"); rec.Code != http.StatusBadRequest || ls.Level() != models.DebugLevel {
+		t.Errorf("expected an invalid level rejected, got %d", rec.Code)
+	}
+	if rec := put("/level", `{"level":"`+strings.Repeat("x", maxLevelBodySize)+`"}`, "secret"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected an oversized body rejected, got %d", rec.Code)
+	}
+
+	ls.SetLevel(models.ErrorLevel)
+	put("/level", `{"level":"fatal"}`, "secret")
+	if err := ls.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	logs := audited.GetLogs()
+	if len(logs) != 7 {
+		t.Fatalf("expected every request audited regardless of the level, got %d", len(logs))
+	}
+	if logs[0].Msg != "admin access denied" {
+		t.Errorf("expected the rejected request recorded first, got %q", logs[0].Msg)
+	}
+	last := fields(logs[len(logs)-1])
+	if last["change"] != "fatal" || last["previous"] != "error" || last["actor"] != "ops" {
+		t.Errorf("expected the change, previous level and actor recorded, got %v", last)
+	}
+}
+
+func TestComponentLevels(t *testing.T) {
+	ls := NewLoggerService(WithComponentLevels(map[string]models.LogLevel{"database": models.DebugLevel}))
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+	defer ls.Stop()
+	ls.SetLevel(models.InfoLevel)
+	ls.SetComponentLevel("payments", models.ErrorLevel)
+
+	logger := ls.NewLogger()
+	ctx := context.Background()
+	logger.Debug(ctx, "db debug", models.WithComponent("database"))
+	logger.Debug(PushComponent(ctx, "database"), "db pool debug", models.WithComponent("pool"))
+	logger.Debugf(ctx, "db debugf %d", 1, models.WithComponent("database"))
+	logger.Debug(ctx, "api debug", models.WithComponent("api"))
+	logger.Warning(ctx, "payments warning", models.WithComponent("payments"))
+	logger.Error(ctx, errors.New("payments error"), models.WithComponent("payments"))
+	logger.With(models.WithComponent("payments")).Info(ctx, "bound payments info")
+
+	logs := byMsg(waitForLogs(mock, 4, time.Second))
+	for _, msg := range []string{"db debug", "db pool debug", "db debugf 1", "payments error"} {
+		if logs[msg] == nil {
+			t.Errorf("expected %q logged", msg)
+		}
+	}
+	if len(logs) != 4 {
+		t.Errorf("expected 4 records, got %d", len(logs))
+	}
+
+	ls.ClearComponentLevel("database")
+	logger.Debug(ctx, "db debug again", models.WithComponent("database"))
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := byMsg(mock.GetLogs())["db debug again"]; ok {
+		t.Error("expected the cleared override to fall back to the service level")
+	}
+}
+
+func TestLogger_InternalBypassesLevelsAndFilters(t *testing.T) {
+	ls := NewLoggerService(
+		WithFilters(DropComponents("glogger")),
+		WithRateLimit(models.InfoLevel, 1, 1),
+		WithDeduplication(time.Hour),
+	)
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock, WithSampleRate(100))
+	ls.SetLevel(models.ErrorLevel)
+	ls.Start()
+
+	internal := ls.NewLogger().Internal()
+	ctx := Suppress(context.Background())
+	for i := 0; i < 3; i++ {
+		internal.Info(ctx, "level changed", models.WithComponent("glogger.admin"))
+	}
+	ls.NewLogger().Info(context.Background(), "dropped")
+	ls.Stop()
+
+	logs := mock.GetLogs()
+	if len(logs) != 3 || !logs[0].Internal {
+		t.Errorf("expected every internal record delivered and marked, got %d records", len(logs))
+	}
+}
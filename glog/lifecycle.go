@@ -0,0 +1,67 @@
+package glog
+
+import (
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"io"
+)
+
+// publishersOf returns the registered publishers that implement T, keyed by
+// ID.
+func publishersOf[T any](ls *LoggerService) map[string]T {
+	ls.mutex.RLock()
+	defer ls.mutex.RUnlock()
+	res := make(map[string]T)
+	for id, entry := range ls.loggers {
+		if p, ok := entry.publisher.(T); ok {
+			res[id] = p
+		}
+	}
+	return res
+}
+
+func (ls *LoggerService) startPublishers() {
+	for id, p := range publishersOf[interfaces.Starter](ls) {
+		ls.startPublisher(id, p)
+	}
+}
+
+// startPublisher reports a failed start; the publisher stays registered, so
+// one that reconnects on its own can still deliver later records.
+func (ls *LoggerService) startPublisher(id string, s interfaces.Starter) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultLifecycleTimeout)
+	defer cancel()
+	if err := s.Start(ctx); err != nil {
+		ls.errorHandler(fmt.Errorf("glogger: start publisher %q: %w", id, err))
+	}
+}
+
+// stopPublishers stops every publisher after the stop hooks ran, so hooks
+// can still rely on the publishers' connections.
+func (ls *LoggerService) stopPublishers() {
+	if !ls.started.Load() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultLifecycleTimeout)
+	defer cancel()
+	for id, p := range publishersOf[interfaces.LogPublisher](ls) {
+		if err := StopPublisher(ctx, p); err != nil {
+			ls.errorHandler(fmt.Errorf("glogger: stop publisher %q: %w", id, err))
+		}
+	}
+}
+
+// StopPublisher shuts p down the way the service does on Stop: with Stop
+// when p is an interfaces.Stopper, otherwise with Close when it is an
+// io.Closer, as the batching publishers are. Other publishers are left
+// alone.
+func StopPublisher(ctx context.Context, p interfaces.LogPublisher) error {
+	switch s := p.(type) {
+	case interfaces.Stopper:
+		return s.Stop(ctx)
+	case io.Closer:
+		return s.Close()
+	}
+	return nil
+}
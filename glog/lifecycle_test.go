@@ -0,0 +1,135 @@
+package glog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/webhook"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type lifecyclePublisher struct {
+	mockPublisher
+	events   *[]string
+	name     string
+	startErr error
+}
+
+func (p *lifecyclePublisher) Start(ctx context.Context) error {
+	*p.events = append(*p.events, p.name+" start")
+	return p.startErr
+}
+
+func (p *lifecyclePublisher) Flush(ctx context.Context) error {
+	*p.events = append(*p.events, p.name+" flush")
+	return nil
+}
+
+func (p *lifecyclePublisher) Stop(ctx context.Context) error {
+	*p.events = append(*p.events, p.name+" stop")
+	return nil
+}
+
+func TestLifecycle_StartFlushStop(t *testing.T) {
+	var events []string
+	ls := NewLoggerService()
+	ls.AddLogger("sink", &lifecyclePublisher{events: &events, name: "sink"})
+	ls.OnStop(func(ctx context.Context) { events = append(events, "hook") })
+	if len(events) != 0 {
+		t.Fatalf("expected no calls before Start, got %v", events)
+	}
+
+	ls.Start()
+	ls.Stop()
+	ls.Stop()
+
+	if got := strings.Join(events, ","); got != "sink start,sink flush,hook,sink stop" {
+		t.Errorf("unexpected lifecycle order %q", got)
+	}
+}
+
+func TestLifecycle_AddAfterStartAndErrors(t *testing.T) {
+	var mu sync.Mutex
+	var handled []error
+	ls := NewLoggerService(WithErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, err)
+	}))
+	ls.Start()
+
+	var events []string
+	refused := errors.New("connection refused")
+	pub := &lifecyclePublisher{events: &events, name: "late", startErr: refused}
+	ls.AddLogger("late", pub)
+	if len(events) != 1 || events[0] != "late start" {
+		t.Fatalf("expected a publisher added after Start to be started, got %v", events)
+	}
+
+	ls.NewLogger().Info(context.Background(), "still registered")
+	ls.Stop()
+
+	if len(pub.GetLogs()) != 1 {
+		t.Errorf("expected a publisher that failed to start to stay registered")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handled) != 1 || !errors.Is(handled[0], refused) || !strings.Contains(handled[0].Error(), `"late"`) {
+		t.Errorf("expected the start error reported, got %v", handled)
+	}
+}
+
+func TestLifecycle_StopWithoutStart(t *testing.T) {
+	var events []string
+	ls := NewLoggerService()
+	ls.AddLogger("sink", &lifecyclePublisher{events: &events, name: "sink"})
+	ls.Stop()
+
+	for _, e := range events {
+		if e == "sink stop" {
+			t.Errorf("expected a publisher that was never started not to be stopped, got %v", events)
+		}
+	}
+}
+
+func TestLifecycle_StopClosesBatchingPublisher(t *testing.T) {
+	var received atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []json.RawMessage
+		_ = json.NewDecoder(r.Body).Decode(&batch)
+		received.Add(int64(len(batch)))
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var errs []error
+	pub := webhook.New(webhook.Config{URL: srv.URL, FlushInterval: time.Hour, ErrorHandler: func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}})
+	ls := NewLoggerService()
+	ls.AddLogger("webhook", pub)
+	ls.Start()
+	ls.NewLogger().Info(context.Background(), "before stop")
+	ls.Stop()
+
+	if n := received.Load(); n != 1 {
+		t.Errorf("expected the buffered record delivered on Stop, got %d", n)
+	}
+	// A closed batcher refuses new records instead of buffering them for a
+	// goroutine that no longer runs.
+	pub.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "after stop"})
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "closed") {
+		t.Errorf("expected the publisher closed by Stop, got %v", errs)
+	}
+}
@@ -0,0 +1,234 @@
+// Package livetail streams records as they are published to HTTP clients,
+// over WebSocket or as a plain NDJSON stream, giving deployed services a
+// built-in "live tail" for debugging.
+//
+// The handler exposes every record it sees; mount it behind the same
+// authentication as other debug endpoints.
+package livetail
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/query"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultClientBuffer = 256
+	defaultMaxClients   = 16
+)
+
+type Option func(*Tail)
+
+// WithClientBuffer sets how many records may queue for one client before
+// further records are dropped for it (default 256).
+func WithClientBuffer(n int) Option {
+	return func(t *Tail) {
+		if n > 0 {
+			t.clientBuffer = n
+		}
+	}
+}
+
+// WithMaxClients caps concurrent viewers (default 16); further requests get
+// 503.
+func WithMaxClients(n int) Option {
+	return func(t *Tail) {
+		if n > 0 {
+			t.maxClients = n
+		}
+	}
+}
+
+// WithEncoder replaces the default encoder.NewJSON("", "").
+func WithEncoder(enc interfaces.Encoder) Option {
+	return func(t *Tail) {
+		if enc != nil {
+			t.encoder = enc
+		}
+	}
+}
+
+// Compile-time checks that Tail is a publisher and an HTTP handler.
+var (
+	_ interfaces.LogPublisher = (*Tail)(nil)
+	_ http.Handler            = (*Tail)(nil)
+)
+
+// Tail is a publisher that fans records out to connected viewers. With no
+// viewers connected, SendMsg returns immediately. A viewer that cannot keep
+// up loses records rather than slowing the pipeline, and is told how many.
+type Tail struct {
+	encoder      interfaces.Encoder
+	clientBuffer int
+	maxClients   int
+	now          func() time.Time
+
+	mu      sync.RWMutex
+	clients map[*client]struct{}
+	dropped atomic.Int64
+}
+
+type client struct {
+	filter  query.Filter
+	ch      chan []byte
+	dropped atomic.Int64
+}
+
+func New(opts ...Option) *Tail {
+	t := &Tail{
+		encoder:      encoder.NewJSON("", ""),
+		clientBuffer: defaultClientBuffer,
+		maxClients:   defaultMaxClients,
+		now:          time.Now,
+		clients:      make(map[*client]struct{}),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+func (t *Tail) SendMsg(data *models.LogData) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if len(t.clients) == 0 {
+		return
+	}
+	rec := query.Record{Time: data.TimeOr(t.now()), Data: data}
+	var line []byte
+	for c := range t.clients {
+		if !c.filter.Match(rec) {
+			continue
+		}
+		if line == nil {
+			b, err := t.encoder.Encode(data)
+			if err != nil {
+				return
+			}
+			line = bytes.TrimRight(b, "\n")
+		}
+		select {
+		case c.ch <- line:
+		default:
+			c.dropped.Add(1)
+			t.dropped.Add(1)
+		}
+	}
+}
+
+// Clients returns the number of connected viewers.
+func (t *Tail) Clients() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.clients)
+}
+
+// Dropped returns how many records were skipped for viewers that fell
+// behind.
+func (t *Tail) Dropped() int64 {
+	return t.dropped.Load()
+}
+
+// ServeHTTP streams matching records until the client disconnects. It
+// upgrades WebSocket requests and otherwise writes NDJSON, so that
+//
+//	curl -N 'http://host/debug/tail?level=warn&component=billing'
+//
+// works too. The query accepts the parameters of query.ParseFilter except
+// since, until and limit.
+func (t *Tail) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	filter, err := query.ParseFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Since, filter.Until, filter.Limit = time.Time{}, time.Time{}, 0
+
+	c := &client{filter: filter, ch: make(chan []byte, t.clientBuffer)}
+	t.mu.Lock()
+	if len(t.clients) >= t.maxClients {
+		t.mu.Unlock()
+		http.Error(w, "too many live tail viewers", http.StatusServiceUnavailable)
+		return
+	}
+	t.clients[c] = struct{}{}
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.clients, c)
+		t.mu.Unlock()
+	}()
+
+	if isWebSocket(r) {
+		t.serveWebSocket(w, r, c)
+		return
+	}
+	t.serveStream(w, r, c)
+}
+
+func (t *Tail) serveWebSocket(w http.ResponseWriter, r *http.Request, c *client) {
+	ws, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+	closed := make(chan struct{})
+	go func() {
+		ws.readLoop()
+		close(closed)
+	}()
+	for {
+		select {
+		case <-closed:
+			return
+		case line := <-c.ch:
+			if n := c.dropped.Swap(0); n > 0 {
+				if err := ws.WriteText(droppedNotice(n)); err != nil {
+					return
+				}
+			}
+			if err := ws.WriteText(line); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (t *Tail) serveStream(w http.ResponseWriter, r *http.Request, c *client) {
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line := <-c.ch:
+			if n := c.dropped.Swap(0); n > 0 {
+				_, _ = w.Write(append(droppedNotice(n), '\n'))
+			}
+			if _, err := w.Write(append(line, '\n')); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// droppedNotice tells a viewer that records were skipped because it fell
+// behind.
+func droppedNotice(n int64) []byte {
+	return []byte(fmt.Sprintf(`{"livetail_dropped":%d}`, n))
+}
@@ -0,0 +1,184 @@
+package livetail
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func record(level models.LogLevel, component, msg string) *models.LogData {
+	return &models.LogData{
+		Ctx:    context.Background(),
+		Level:  level,
+		Msg:    msg,
+		Fields: []*models.LogField{{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: component}},
+	}
+}
+
+func waitClients(t *testing.T, tail *Tail, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for tail.Clients() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d clients, have %d", n, tail.Clients())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// dialWebSocket performs the client handshake and returns the connection
+// positioned after the response headers.
+func dialWebSocket(t *testing.T, srv *httptest.Server, path string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	const key = "dGhlIHNhbXBsZSBub25jZQ=="
+	_, _ = io.WriteString(conn, "GET "+path+" HTTP/1.1\r\nHost: test\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: "+key+"\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+	sum := sha1.Sum([]byte(key + wsGUID))
+	if got, want := resp.Header.Get("Sec-WebSocket-Accept"), base64.StdEncoding.EncodeToString(sum[:]); got != want {
+		t.Fatalf("Sec-WebSocket-Accept = %q, want %q", got, want)
+	}
+	return conn, br
+}
+
+func readText(t *testing.T, conn net.Conn, br *bufio.Reader) map[string]any {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var hdr [2]byte
+	if _, err := io.ReadFull(br, hdr[:]); err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	if hdr[0] != 0x80|opText || hdr[1]&0x80 != 0 {
+		t.Fatalf("unexpected frame header %x", hdr)
+	}
+	n := int(hdr[1])
+	if n == 126 {
+		var ext [2]byte
+		_, _ = io.ReadFull(br, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		t.Fatalf("read payload: %v", err)
+	}
+	var rec map[string]any
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		t.Fatalf("payload %q: %v", payload, err)
+	}
+	return rec
+}
+
+func TestWebSocketFilters(t *testing.T) {
+	tail := New()
+	srv := httptest.NewServer(tail)
+	defer srv.Close()
+
+	conn, br := dialWebSocket(t, srv, "/?level=warn&component=billing")
+	defer conn.Close()
+	waitClients(t, tail, 1)
+
+	tail.SendMsg(record(models.InfoLevel, "billing", "too quiet"))
+	tail.SendMsg(record(models.ErrorLevel, "auth", "other component"))
+	tail.SendMsg(record(models.ErrorLevel, "billing", "charge failed"))
+
+	rec := readText(t, conn, br)
+	if rec["msg"] != "charge failed" || rec["level"] != "error" {
+		t.Errorf("unexpected record %v", rec)
+	}
+
+	// A masked close frame ends the session.
+	_, _ = conn.Write([]byte{0x80 | opClose, 0x80, 0, 0, 0, 0})
+	waitClients(t, tail, 0)
+}
+
+func TestNDJSONStream(t *testing.T) {
+	tail := New()
+	srv := httptest.NewServer(tail)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?msg=deploy", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	waitClients(t, tail, 1)
+
+	tail.SendMsg(record(models.InfoLevel, "api", "request served"))
+	tail.SendMsg(record(models.InfoLevel, "api", "deploy finished"))
+
+	sc := bufio.NewScanner(resp.Body)
+	if !sc.Scan() {
+		t.Fatalf("no line: %v", sc.Err())
+	}
+	var rec map[string]any
+	if err := json.Unmarshal(sc.Bytes(), &rec); err != nil || rec["msg"] != "deploy finished" {
+		t.Errorf("unexpected line %q (%v)", sc.Text(), err)
+	}
+}
+
+func TestMaxClientsAndSlowViewer(t *testing.T) {
+	tail := New(WithMaxClients(1), WithClientBuffer(1))
+	srv := httptest.NewServer(tail)
+	defer srv.Close()
+
+	conn, _ := dialWebSocket(t, srv, "/")
+	defer conn.Close()
+	waitClients(t, tail, 1)
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 over the limit, got %d", resp.StatusCode)
+	}
+
+	// The viewer never reads, so once its buffer and socket fill up records
+	// are dropped instead of blocking SendMsg.
+	big := strings.Repeat("x", 64<<10)
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 200; i++ {
+			tail.SendMsg(record(models.InfoLevel, "api", big))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("SendMsg blocked on a slow viewer")
+	}
+	if tail.Dropped() == 0 {
+		t.Error("expected records dropped for the slow viewer")
+	}
+}
@@ -0,0 +1,216 @@
+// Package logfs exposes recent records of a query.Source as a read-only
+// fs.FS, so tooling that works on files (support bundles, tar, zip) can pick
+// up logs without knowing about glogger.
+//
+// Layout (every file is NDJSON, one record per line, oldest first):
+//
+//	all.ndjson
+//	by-hour/2006-01-02T15.ndjson
+//	by-component/<component>.ndjson
+package logfs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/query"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	allFile        = "all.ndjson"
+	hourDir        = "by-hour"
+	componentDir   = "by-component"
+	noComponent    = "_none"
+	hourFileLayout = "2006-01-02T15"
+)
+
+// FS snapshots the source on every Open of the root or a file, so each walk
+// sees consistent content.
+type FS struct {
+	src    query.Source
+	filter query.Filter
+}
+
+// New exposes the records of src matching filter.
+func New(src query.Source, filter query.Filter) *FS {
+	return &FS{src: src, filter: filter}
+}
+
+func (f *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	tree := f.snapshot()
+	if content, ok := tree.files[name]; ok {
+		return &file{name: path.Base(name), reader: bytes.NewReader(content), size: int64(len(content)), modTime: tree.modTime}, nil
+	}
+	if entries, ok := tree.dirs[name]; ok {
+		return &dir{name: path.Base(name), entries: entries, modTime: tree.modTime}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+type tree struct {
+	files   map[string][]byte
+	dirs    map[string][]fs.DirEntry
+	modTime time.Time
+}
+
+func (f *FS) snapshot() *tree {
+	t := &tree{files: make(map[string][]byte), dirs: make(map[string][]fs.DirEntry)}
+	buffers := make(map[string]*bytes.Buffer)
+	appendLine := func(name string, line []byte) {
+		b, ok := buffers[name]
+		if !ok {
+			b = &bytes.Buffer{}
+			buffers[name] = b
+		}
+		b.Write(line)
+	}
+
+	buffers[allFile] = &bytes.Buffer{}
+	it := f.src.Query(f.filter)
+	for it.Next() {
+		rec := it.Record()
+		line := encode(rec)
+		if rec.Time.After(t.modTime) {
+			t.modTime = rec.Time
+		}
+		appendLine(allFile, line)
+		appendLine(path.Join(hourDir, rec.Time.UTC().Format(hourFileLayout)+".ndjson"), line)
+		appendLine(path.Join(componentDir, componentFileName(rec)+".ndjson"), line)
+	}
+
+	for name, b := range buffers {
+		t.files[name] = b.Bytes()
+	}
+	t.dirs["."] = nil
+	t.dirs[hourDir] = nil
+	t.dirs[componentDir] = nil
+	for name, content := range t.files {
+		parent := path.Dir(name)
+		t.dirs[parent] = append(t.dirs[parent], fileInfo{name: path.Base(name), size: int64(len(content)), modTime: t.modTime})
+	}
+	t.dirs["."] = append(t.dirs["."],
+		fileInfo{name: hourDir, dir: true, modTime: t.modTime},
+		fileInfo{name: componentDir, dir: true, modTime: t.modTime})
+	for _, entries := range t.dirs {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	}
+	return t
+}
+
+func componentFileName(rec query.Record) string {
+	for _, f := range rec.Data.Fields {
+		if f != nil && f.Key == models.FieldComponentKey && f.String != "" {
+			return strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(f.String)
+		}
+	}
+	return noComponent
+}
+
+type jsonRecord struct {
+	Time   time.Time      `json:"timestamp"`
+	Level  string         `json:"level"`
+	Msg    string         `json:"msg"`
+	Fields map[string]any `json:"payload,omitempty"`
+}
+
+func encode(rec query.Record) []byte {
+	jr := jsonRecord{Time: rec.Time.UTC(), Level: rec.Data.Level.String(), Msg: rec.Data.Msg}
+	for _, f := range rec.Data.Fields {
+		if f == nil {
+			continue
+		}
+		if jr.Fields == nil {
+			jr.Fields = make(map[string]any)
+		}
+		jr.Fields[f.Key] = query.FieldString(f)
+	}
+	b, err := json.Marshal(jr)
+	if err != nil {
+		b, _ = json.Marshal(jsonRecord{Time: jr.Time, Level: jr.Level, Msg: jr.Msg})
+	}
+	return append(b, '\n')
+}
+
+type fileInfo struct {
+	name    string
+	size    int64
+	dir     bool
+	modTime time.Time
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi fileInfo) IsDir() bool        { return fi.dir }
+func (fi fileInfo) Sys() any           { return nil }
+
+func (fi fileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+func (fi fileInfo) Type() fs.FileMode          { return fi.Mode().Type() }
+func (fi fileInfo) Info() (fs.FileInfo, error) { return fi, nil }
+
+type file struct {
+	name    string
+	reader  *bytes.Reader
+	size    int64
+	modTime time.Time
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	return fileInfo{name: f.name, size: f.size, modTime: f.modTime}, nil
+}
+
+func (f *file) Read(p []byte) (int, error) { return f.reader.Read(p) }
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	return f.reader.Seek(offset, whence)
+}
+func (f *file) ReadAt(p []byte, off int64) (int, error) { return f.reader.ReadAt(p, off) }
+func (f *file) Close() error                            { return nil }
+
+type dir struct {
+	name    string
+	entries []fs.DirEntry
+	offset  int
+	modTime time.Time
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) {
+	return fileInfo{name: d.name, dir: true, modTime: d.modTime}, nil
+}
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+func (d *dir) Close() error { return nil }
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+	return remaining[:n], nil
+}
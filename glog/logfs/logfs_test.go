@@ -0,0 +1,85 @@
+package logfs
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/query"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type staticSource []query.Record
+
+func (s staticSource) Query(filter query.Filter) *query.Iterator {
+	var res []query.Record
+	for _, rec := range s {
+		if filter.Match(rec) {
+			res = append(res, rec)
+		}
+	}
+	return query.NewIterator(res)
+}
+
+func newSource() staticSource {
+	base := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	rec := func(offset time.Duration, level models.LogLevel, msg, component string) query.Record {
+		data := &models.LogData{Ctx: context.Background(), Msg: msg, Level: level}
+		if component != "" {
+			data.Fields = []*models.LogField{{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: component}}
+		}
+		return query.Record{Time: base.Add(offset), Data: data}
+	}
+	return staticSource{
+		rec(0, models.InfoLevel, "started", ""),
+		rec(10*time.Minute, models.ErrorLevel, "db down", "db"),
+		rec(40*time.Minute, models.WarnLevel, "slow query", "db"),
+	}
+}
+
+func TestFS_Conformance(t *testing.T) {
+	fsys := New(newSource(), query.Filter{})
+	if err := fstest.TestFS(fsys,
+		"all.ndjson",
+		"by-hour/2024-05-01T10.ndjson",
+		"by-hour/2024-05-01T11.ndjson",
+		"by-component/db.ndjson",
+		"by-component/_none.ndjson",
+	); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFS_Content(t *testing.T) {
+	fsys := New(newSource(), query.Filter{})
+
+	all, err := fs.ReadFile(fsys, "all.ndjson")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Count(string(all), "\n") != 3 {
+		t.Errorf("expected 3 lines, got %q", all)
+	}
+
+	db, err := fs.ReadFile(fsys, "by-component/db.ndjson")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(db), `"msg":"db down"`) || strings.Contains(string(db), "started") {
+		t.Errorf("unexpected component file: %s", db)
+	}
+
+	if _, err := fsys.Open("missing.ndjson"); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestFS_Filter(t *testing.T) {
+	fsys := New(newSource(), query.Filter{Levels: query.LevelsFrom(models.ErrorLevel)})
+	all, _ := fs.ReadFile(fsys, "all.ndjson")
+	if strings.Count(string(all), "\n") != 1 {
+		t.Errorf("expected only the error record, got %q", all)
+	}
+}
@@ -7,7 +7,7 @@ import (
 	"github.com/alexnobleburn/glogger/glog/models"
 	"github.com/pkg/errors"
 	"strings"
-	"sync/atomic"
+	"time"
 )
 
 // Compile-time check that Logger implements interfaces.Logger.
@@ -15,37 +15,87 @@ var _ interfaces.Logger = (*Logger)(nil)
 
 type Logger struct {
 	logChan chan<- *models.LogData
-	stopped *atomic.Bool
+	// svc is nil for loggers created with NewLogger from a bare channel.
+	svc *LoggerService
+	// options are bound with With and applied before each call's options.
+	options []models.Option
+	// name is the dotted name set with Named.
+	name string
+	// extractors are added with WithContextExtractors.
+	extractors []ContextExtractor
+	// internal is set with Internal.
+	internal bool
 }
 
 func NewLogger(logChan chan<- *models.LogData) *Logger {
 	return &Logger{logChan: logChan}
 }
 
-func (l *Logger) Error(ctx context.Context, err error, options ...models.Option) {
+// Internal returns a logger for records about the logging system itself,
+// such as audit records of admin changes: its records are marked
+// models.LogData.Internal and bypass the service, named and component
+// levels, quiet sections, rate limits, service filters and interceptor
+// drops, deduplication and sampling. Waiting for room in a full buffer
+// instead of dropping, they are only subject to per-publisher routing
+// (WithMinLevel, WithFilter and disabled publishers). Use it sparingly.
+func (l *Logger) Internal() *Logger {
+	child := *l
+	child.internal = true
+	return &child
+}
+
+// With returns a logger that applies options to every record before the
+// options of the call, so a component or fields can be bound once:
+//
+//	payments := logger.With(models.WithComponent("payment"), models.WithStringField("provider", "stripe"))
+//	payments.Info(ctx, "charge created") // component=payment provider=stripe
+//
+// A component given at the call replaces the bound one; fields from both
+// are kept. The derived logger shares the parent's service.
+func (l *Logger) With(options ...models.Option) *Logger {
+	child := *l
+	child.options = append(append([]models.Option{}, l.options...), options...)
+	return &child
+}
+
+func (l *Logger) applyOptions(options []models.Option) *models.Options {
 	opts := &models.Options{}
+	for _, opt := range l.options {
+		opt(opts)
+	}
 	for _, opt := range options {
 		opt(opts)
 	}
-	l.error(ctx, err, opts)
+	return opts
+}
+
+func (l *Logger) Error(ctx context.Context, err error, options ...models.Option) {
+	opts := l.applyOptions(options)
+	l.error(ctx, models.ErrorLevel, err, opts)
 }
 
 func (l *Logger) Errors(ctx context.Context, errs []error, options ...models.Option) {
-	opts := &models.Options{}
-	for _, opt := range options {
-		opt(opts)
-	}
+	opts := l.applyOptions(options)
 	for _, err := range errs {
-		l.error(ctx, err, opts)
+		l.error(ctx, models.ErrorLevel, err, opts)
 	}
 }
 
-func (l *Logger) error(ctx context.Context, err error, opts *models.Options) {
+func (l *Logger) error(ctx context.Context, level models.LogLevel, err error, opts *models.Options) {
+	component := func() string { return resolveComponent(ctx, opts.GetComponent()) }
+	if l.skip(ctx, level, component, false) {
+		return
+	}
+	l.sendError(ctx, level, err, opts)
+}
+
+func (l *Logger) sendError(ctx context.Context, level models.LogLevel, err error, opts *models.Options) {
 	logData := &models.LogData{
 		Ctx:    ctx,
 		Msg:    err.Error(),
 		Fields: []*models.LogField{},
-		Level:  models.ErrorLevel,
+		Level:  level,
+		Time:   eventTime(opts),
 	}
 
 	if opts.WithStackTrace() {
@@ -63,12 +113,15 @@ func (l *Logger) error(ctx context.Context, err error, opts *models.Options) {
 			&models.LogField{Key: models.FieldFilenameKey, Type: models.FieldTypeString, String: strings.Join(fileNames, " <- ")})
 	}
 
-	if len(opts.GetFields()) > 0 {
-		logData.Fields = append(logData.Fields, opts.GetFields()...)
+	if fields := l.validFields(opts.GetFields()); len(fields) > 0 {
+		logData.Fields = append(logData.Fields, fields...)
 	}
-	if opts.GetComponent() != "" {
+	if component := resolveComponent(ctx, opts.GetComponent()); component != "" {
 		logData.Fields = append(logData.Fields,
-			&models.LogField{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: opts.GetComponent()})
+			&models.LogField{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: component})
+	}
+	if l.name != "" {
+		logData.Fields = append(logData.Fields, l.nameField())
 	}
 
 	l.sendData(logData)
@@ -86,34 +139,125 @@ func (l *Logger) Debug(ctx context.Context, message string, options ...models.Op
 	l.logMsg(ctx, models.DebugLevel, message, options...)
 }
 
+// Log logs message at level, for wrappers and adapters that receive the
+// level as a value. ErrorLevel and above take the Error path, so
+// models.WithStackTrace applies; levels above ErrorLevel reach publishers
+// unchanged, and the zap publisher panics or exits for PanicLevel and
+// FatalLevel as zap does.
+func (l *Logger) Log(ctx context.Context, level models.LogLevel, message string, options ...models.Option) {
+	if level < models.ErrorLevel {
+		l.logMsg(ctx, level, message, options...)
+		return
+	}
+	opts := l.applyOptions(options)
+	l.error(ctx, level, errors.New(message), opts)
+}
+
 func (l *Logger) logMsg(ctx context.Context, level models.LogLevel, message string, options ...models.Option) {
-	opts := &models.Options{}
-	for _, opt := range options {
-		opt(opts)
+	component := func() string { return resolveComponent(ctx, l.applyOptions(options).GetComponent()) }
+	if l.skip(ctx, level, component, true) {
+		return
 	}
+	l.sendMsg(ctx, level, message, options)
+}
+
+func (l *Logger) sendMsg(ctx context.Context, level models.LogLevel, message string, options []models.Option) {
+	opts := l.applyOptions(options)
 
 	logData := &models.LogData{
 		Ctx:    ctx,
 		Msg:    message,
-		Fields: opts.GetFields(),
+		Fields: l.validFields(opts.GetFields()),
 		Level:  level,
+		Time:   eventTime(opts),
 	}
 
-	if opts.GetComponent() != "" {
+	if component := resolveComponent(ctx, opts.GetComponent()); component != "" {
 		logData.Fields = append(logData.Fields,
-			&models.LogField{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: opts.GetComponent()})
+			&models.LogField{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: component})
+	}
+	if l.name != "" {
+		logData.Fields = append(logData.Fields, l.nameField())
 	}
 
 	l.sendData(logData)
 }
 
+// eventTime is the WithTimestamp time or, by default, the time of the call,
+// so that records keep their order and latency even when workers deliver
+// them late.
+func eventTime(opts *models.Options) time.Time {
+	if ts := opts.GetTimestamp(); !ts.IsZero() {
+		return ts
+	}
+	return time.Now()
+}
+
 func (l *Logger) sendData(logData *models.LogData) {
-	if l.stopped != nil && l.stopped.Load() {
+	extractFields(logData, l.extractors, l.reportPanic)
+	l.stampOrder(logData)
+	if l.svc != nil && l.svc.stopped.Load() {
+		if l.svc.development || !l.svc.stats.stopReported.Swap(true) {
+			l.svc.misuse(fmt.Errorf("%w: %q", ErrLogAfterStop, logData.Msg))
+		} else {
+			l.svc.stats.misuse.Add(1)
+		}
+		return
+	}
+	if l.internal {
+		logData.Internal = true
+		l.sendBlocking(logData)
+		return
+	}
+	if l.svc != nil && l.svc.rateLimit != nil {
+		if !l.svc.rateLimit.allow(logData.Level, time.Now()) {
+			return
+		}
+	}
+	if l.svc != nil && l.svc.shed != nil {
+		if l.svc.shed.drop(logData.Level, len(l.logChan), cap(l.logChan)) {
+			return
+		}
+		if logData.Level >= models.ErrorLevel {
+			l.sendBlocking(logData)
+			return
+		}
+	}
+	if l.svc != nil && l.svc.blockingSend {
+		l.sendBlocking(logData)
 		return
 	}
 	select {
 	case l.logChan <- logData:
 	default:
 		// Channel full — drop the message to maintain non-blocking guarantee.
+		if l.svc != nil {
+			l.svc.stats.dropped.Add(1)
+		}
 	}
 }
+
+// Sync waits until the records this logger's service has queued are
+// delivered and flushed, for at most five seconds. It is a no-op for loggers
+// without a service.
+func (l *Logger) Sync() error {
+	if l.svc == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultFlushTimeout)
+	defer cancel()
+	return l.svc.Flush(ctx)
+}
+
+func (l *Logger) reportPanic(err error) {
+	if l.svc != nil {
+		l.svc.errorHandler(err)
+	}
+}
+
+func (l *Logger) sendBlocking(logData *models.LogData) {
+	// Stop may close the channel while we wait for room; the message is then
+	// dropped exactly like a write after Stop.
+	defer func() { _ = recover() }()
+	l.logChan <- logData
+}
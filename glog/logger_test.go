@@ -3,6 +3,7 @@ package glog
 import (
 	"context"
 	"fmt"
+	"github.com/alexnobleburn/glogger/glog/glogtest"
 	"github.com/alexnobleburn/glogger/glog/models"
 	"sync"
 	"testing"
@@ -50,6 +51,15 @@ func setupTestLogger() (*Logger, *mockPublisher, *LoggerService) {
 	return logger, mock, loggerService
 }
 
+// byMsg indexes logs by message, since workers may deliver them in any order.
+func byMsg(logs []*models.LogData) map[string]*models.LogData {
+	m := make(map[string]*models.LogData, len(logs))
+	for _, data := range logs {
+		m[data.Msg] = data
+	}
+	return m
+}
+
 // waitForLogs polls until the mock has at least n logs or timeout.
 func waitForLogs(mock *mockPublisher, n int, timeout time.Duration) []*models.LogData {
 	deadline := time.Now().Add(timeout)
@@ -86,6 +96,25 @@ func TestLogger_Info(t *testing.T) {
 	}
 }
 
+func TestLogger_WithTimestamp(t *testing.T) {
+	logger, mock, service := setupTestLogger()
+	defer service.Stop()
+
+	eventTime := time.Date(2023, 3, 14, 15, 9, 26, 0, time.UTC)
+	logger.Info(context.Background(), "replayed", models.WithTimestamp(eventTime))
+	logger.Error(context.Background(), fmt.Errorf("replayed error"), models.WithTimestamp(eventTime))
+	logs := waitForLogs(mock, 2, time.Second)
+
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 logs, got %d", len(logs))
+	}
+	for _, data := range logs {
+		if !data.Time.Equal(eventTime) {
+			t.Errorf("expected event time %v on %q, got %v", eventTime, data.Msg, data.Time)
+		}
+	}
+}
+
 func TestLogger_Error(t *testing.T) {
 	logger, mock, service := setupTestLogger()
 	defer service.Stop()
@@ -397,6 +426,54 @@ func TestLogger_PublisherPanic(t *testing.T) {
 	loggerService.Stop()
 }
 
+func TestLoggerService_Stats(t *testing.T) {
+	loggerService := NewLoggerService(WithInputBufferSize(1))
+	mock := &mockPublisher{logs: make([]*models.LogData, 0)}
+	loggerService.AddLogger("mock", mock)
+	logger := loggerService.NewLogger()
+
+	// Not started yet: the second message overflows the input buffer.
+	logger.Info(context.Background(), "queued")
+	logger.Info(context.Background(), "dropped")
+
+	stats := loggerService.Stats()
+	if stats.Dropped != 1 || stats.QueuedInput != 1 || stats.Publishers != 1 {
+		t.Errorf("unexpected stats before start: %+v", stats)
+	}
+
+	loggerService.Start()
+	loggerService.Stop()
+
+	if stats := loggerService.Stats(); stats.Processed != 1 {
+		t.Errorf("expected 1 processed message, got %+v", stats)
+	}
+}
+
+func TestLoggerService_FlakyPublisher(t *testing.T) {
+	loggerService := NewLoggerService(
+		WithSendTimeout(10*time.Millisecond),
+		WithErrorHandler(func(err error) {}),
+	)
+	healthy := &mockPublisher{logs: make([]*models.LogData, 0)}
+	flaky := glogtest.NewFlaky(&mockPublisher{}, glogtest.FaultConfig{Latency: 50 * time.Millisecond})
+	loggerService.AddLogger("healthy", healthy)
+	loggerService.AddLogger("flaky", flaky)
+	loggerService.Start()
+	logger := loggerService.NewLogger()
+
+	for i := 0; i < 3; i++ {
+		logger.Info(context.Background(), fmt.Sprintf("message %d", i))
+	}
+	loggerService.Stop()
+
+	if got := len(healthy.GetLogs()); got != 3 {
+		t.Errorf("expected healthy publisher to receive 3 logs, got %d", got)
+	}
+	if got := loggerService.Stats().Timeouts; got != 3 {
+		t.Errorf("expected 3 timeouts from the slow publisher, got %d", got)
+	}
+}
+
 func BenchmarkLogger_Info(b *testing.B) {
 	logger, _, service := setupTestLogger()
 	defer service.Stop()
@@ -437,3 +514,104 @@ func BenchmarkLogger_WithMultipleFields(b *testing.B) {
 			models.WithFloatField("value", 3.14))
 	}
 }
+
+func TestLogger_Log(t *testing.T) {
+	logger, mock, service := setupTestLogger()
+	defer service.Stop()
+
+	ctx := context.Background()
+	logger.Log(ctx, models.DebugLevel, "debug", models.WithComponent("adapter"))
+	logger.Log(ctx, models.WarnLevel, "warn")
+	logger.Log(ctx, models.ErrorLevel, "error", models.WithStackTrace())
+	logs := waitForLogs(mock, 3, time.Second)
+	if len(logs) != 3 {
+		t.Fatalf("expected 3 logs, got %d", len(logs))
+	}
+
+	got := byMsg(logs)
+	for msg, level := range map[string]models.LogLevel{"debug": models.DebugLevel, "warn": models.WarnLevel, "error": models.ErrorLevel} {
+		if got[msg] == nil || got[msg].Level != level {
+			t.Errorf("expected %q at %v, got %v", msg, level, got[msg])
+		}
+	}
+	if debug := got["debug"]; debug == nil || len(debug.Fields) != 1 || debug.Fields[0].String != "adapter" {
+		t.Errorf("expected the component on the debug record, got %v", debug)
+	}
+	var stack bool
+	if errRecord := got["error"]; errRecord != nil {
+		for _, f := range errRecord.Fields {
+			stack = stack || f.Key == models.FieldFilenameKey
+		}
+	}
+	if !stack {
+		t.Error("expected the error path with a stack")
+	}
+}
+
+func TestLogger_With(t *testing.T) {
+	logger, mock, service := setupTestLogger()
+	defer service.Stop()
+
+	ctx := context.Background()
+	payments := logger.With(models.WithComponent("payment"), models.WithStringField("provider", "stripe"))
+	refunds := payments.With(models.WithIntField("attempt", 2))
+	payments.Info(ctx, "charge created")
+	refunds.Error(ctx, fmt.Errorf("refund failed"), models.WithComponent("refunds"))
+	logger.Info(ctx, "unbound")
+	logs := waitForLogs(mock, 3, time.Second)
+	if len(logs) != 3 {
+		t.Fatalf("expected 3 logs, got %d", len(logs))
+	}
+
+	fields := func(data *models.LogData) map[string]string {
+		m := map[string]string{}
+		if data == nil {
+			return m
+		}
+		for _, f := range data.Fields {
+			if f.Type == models.FieldTypeInt {
+				m[f.Key] = fmt.Sprint(f.Integer)
+				continue
+			}
+			m[f.Key] = f.String
+		}
+		return m
+	}
+	got := byMsg(logs)
+	if got := fields(got["charge created"]); len(got) != 2 || got["component"] != "payment" || got["provider"] != "stripe" {
+		t.Errorf("expected the bound component and field, got %v", got)
+	}
+	if got := fields(got["refund failed"]); len(got) != 3 || got["component"] != "refunds" || got["attempt"] != "2" {
+		t.Errorf("expected the call's component to win and all fields kept, got %v", got)
+	}
+	if unbound := got["unbound"]; unbound == nil || len(unbound.Fields) != 0 {
+		t.Errorf("expected the parent logger to stay unbound, got %v", unbound)
+	}
+}
+
+func TestLogger_TimestampAtCall(t *testing.T) {
+	release := make(chan struct{})
+	service := NewLoggerService()
+	mock := &mockPublisher{sendFunc: func(*models.LogData) { <-release }}
+	service.AddLogger("mock", mock)
+	service.Start()
+	defer service.Stop()
+
+	before := time.Now()
+	logger := service.NewLogger()
+	logger.Info(context.Background(), "first")
+	logger.Error(context.Background(), fmt.Errorf("second"))
+	after := time.Now()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	logs := waitForLogs(mock, 2, time.Second)
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 logs, got %d", len(logs))
+	}
+	for _, data := range logs {
+		if data.Time.Before(before) || data.Time.After(after) {
+			t.Errorf("expected %q to be stamped at the call (%v..%v), got %v", data.Msg, before, after, data.Time)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+// Package loki pushes records to the Grafana Loki HTTP push API in batches,
+// with one stream per label set.
+package loki
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/internal/batch"
+	"github.com/alexnobleburn/glogger/glog/internal/report"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	pushPath       = "/loki/api/v1/push"
+	defaultRetries = 3
+)
+
+// Config configures a Publisher.
+type Config struct {
+	// URL is the Loki base URL, e.g. http://loki:3100.
+	URL string
+	// TenantID is sent as X-Scope-OrgID for multi-tenant Loki.
+	TenantID string
+	// Username and Password enable basic auth.
+	Username string
+	Password string
+	// AppID and Env label records whose context has no models.AppID or
+	// models.EnvName.
+	AppID string
+	Env   string
+	// Labels are added to every stream.
+	Labels map[string]string
+	// Encoder renders the log line, e.g. encoder.NewLogfmt(AppID, Env) for
+	// Loki's logfmt parser. Defaults to encoder.NewJSON(AppID, Env).
+	Encoder interfaces.Encoder
+	// BatchSize, FlushInterval and MaxBuffered control batching (defaults
+	// 100, 1s and 10000).
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxBuffered   int
+	// Retries for 429 and 5xx responses (default 3), with exponential
+	// backoff starting at Backoff (default 100ms).
+	Retries int
+	Backoff time.Duration
+	// Client defaults to an http.Client with a 10s timeout.
+	Client *http.Client
+	// ErrorHandler receives push errors. Once the publisher is added to a
+	// service, the service error handler receives them too. Without either,
+	// they are printed.
+	ErrorHandler func(error)
+}
+
+type entry struct {
+	key    string
+	labels map[string]string
+	ts     time.Time
+	line   string
+}
+
+// Compile-time checks that Publisher implements interfaces.LogPublisher,
+// interfaces.HealthChecker and interfaces.ErrorReporter.
+var (
+	_ interfaces.LogPublisher  = (*Publisher)(nil)
+	_ interfaces.HealthChecker = (*Publisher)(nil)
+	_ interfaces.ErrorReporter = (*Publisher)(nil)
+)
+
+// Publisher labels each record with app, env, level and component and
+// pushes the encoded record as the log line.
+type Publisher struct {
+	cfg     Config
+	errs    *report.Handler
+	url     string
+	batcher *batch.Batcher[entry]
+}
+
+func New(cfg Config) *Publisher {
+	if cfg.Encoder == nil {
+		cfg.Encoder = encoder.NewJSON(cfg.AppID, cfg.Env)
+	}
+	if cfg.Retries == 0 {
+		cfg.Retries = defaultRetries
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	errs := report.New(cfg.ErrorHandler)
+	cfg.ErrorHandler = errs.Handle
+	p := &Publisher{
+		cfg:  cfg,
+		url:  strings.TrimSuffix(cfg.URL, "/") + pushPath,
+		errs: errs,
+	}
+	p.batcher = batch.New(batch.Config{
+		Size:         cfg.BatchSize,
+		Interval:     cfg.FlushInterval,
+		MaxBuffered:  cfg.MaxBuffered,
+		Retries:      cfg.Retries,
+		Backoff:      cfg.Backoff,
+		ErrorHandler: cfg.ErrorHandler,
+	}, p.push)
+	return p
+}
+
+// ReportErrorsTo implements interfaces.ErrorReporter.
+func (p *Publisher) ReportErrorsTo(report func(error)) {
+	p.errs.ReportTo(report)
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	line, err := p.cfg.Encoder.Encode(data)
+	if err != nil {
+		p.cfg.ErrorHandler(fmt.Errorf("loki: encode: %w", err))
+		return
+	}
+	key, labels := p.labels(data)
+	if err := p.batcher.Add(entry{key: key, labels: labels, ts: data.TimeOr(time.Now()), line: string(line)}); err != nil {
+		p.cfg.ErrorHandler(fmt.Errorf("loki: %w", err))
+	}
+}
+
+// Flush pushes everything buffered so far.
+func (p *Publisher) Flush(ctx context.Context) error {
+	return p.batcher.Flush(ctx)
+}
+
+// Close flushes and stops the background goroutine.
+func (p *Publisher) Close() error {
+	return p.batcher.Close()
+}
+
+// Dropped returns how many records were discarded because the buffer was
+// full or Loki rejected them.
+func (p *Publisher) Dropped() int64 {
+	return p.batcher.Dropped()
+}
+
+// HealthCheck returns the error of the last failed delivery to Loki until a
+// batch goes through again, and an error once the publisher is closed.
+func (p *Publisher) HealthCheck(ctx context.Context) error {
+	return p.batcher.Err()
+}
+
+// labels returns the label set of data and its {k="v",...} form, used to
+// group entries into streams.
+func (p *Publisher) labels(data *models.LogData) (string, map[string]string) {
+	set := make(map[string]string, len(p.cfg.Labels)+4)
+	for k, v := range p.cfg.Labels {
+		set[k] = v
+	}
+	set["app"] = p.cfg.AppID
+	set["env"] = p.cfg.Env
+	if data.Ctx != nil {
+		if appID, ok := data.Ctx.Value(models.AppID).(string); ok && appID != "" {
+			set["app"] = appID
+		}
+		if env, ok := data.Ctx.Value(models.EnvName).(string); ok && env != "" {
+			set["env"] = env
+		}
+	}
+	set["level"] = data.Level.String()
+	for _, f := range data.Fields {
+		if f != nil && f.Key == models.FieldComponentKey && f.Type == models.FieldTypeString {
+			set["component"] = f.String
+		}
+	}
+
+	keys := make([]string, 0, len(set))
+	for k, v := range set {
+		if v == "" {
+			delete(set, k)
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(set[k]))
+	}
+	b.WriteByte('}')
+	return b.String(), set
+}
+
+type pushRequest struct {
+	Streams []stream `json:"streams"`
+}
+
+type stream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (p *Publisher) push(ctx context.Context, entries []entry) error {
+	byLabels := make(map[string]*stream)
+	var order []string
+	for _, e := range entries {
+		s, ok := byLabels[e.key]
+		if !ok {
+			s = &stream{Stream: e.labels}
+			byLabels[e.key] = s
+			order = append(order, e.key)
+		}
+		s.Values = append(s.Values, [2]string{strconv.FormatInt(e.ts.UnixNano(), 10), e.line})
+	}
+	req := pushRequest{Streams: make([]stream, 0, len(order))}
+	for _, key := range order {
+		req.Streams = append(req.Streams, *byLabels[key])
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return batch.Permanent(fmt.Errorf("loki: marshal push request: %w", err))
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return batch.Permanent(fmt.Errorf("loki: build request: %w", err))
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.cfg.TenantID != "" {
+		httpReq.Header.Set("X-Scope-OrgID", p.cfg.TenantID)
+	}
+	if p.cfg.Username != "" {
+		httpReq.SetBasicAuth(p.cfg.Username, p.cfg.Password)
+	}
+
+	resp, err := p.cfg.Client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("loki: push: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("loki: push: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+	return batch.Permanent(err)
+}
@@ -0,0 +1,159 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/glogtest"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeLoki struct {
+	mu       sync.Mutex
+	requests []pushRequest
+	tenants  []string
+	failures int
+	status   int
+}
+
+func (f *fakeLoki) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.URL.Path != pushPath {
+		http.NotFound(w, r)
+		return
+	}
+	if f.failures > 0 {
+		f.failures--
+		w.WriteHeader(f.status)
+		return
+	}
+	var req pushRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.requests = append(f.requests, req)
+	f.tenants = append(f.tenants, r.Header.Get("X-Scope-OrgID"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newRecord(level models.LogLevel, component string) *models.LogData {
+	return &models.LogData{
+		Ctx:   context.WithValue(context.Background(), models.EnvName, "staging"),
+		Msg:   "hello",
+		Level: level,
+		Fields: []*models.LogField{
+			{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: component},
+		},
+	}
+}
+
+func TestPublisher_GroupsStreamsByLabels(t *testing.T) {
+	loki := &fakeLoki{}
+	srv := httptest.NewServer(loki)
+	defer srv.Close()
+
+	pub := New(Config{URL: srv.URL, TenantID: "team-a", AppID: "shop", Env: "prod", FlushInterval: time.Hour})
+	defer pub.Close()
+	pub.SendMsg(newRecord(models.InfoLevel, "checkout"))
+	pub.SendMsg(newRecord(models.InfoLevel, "checkout"))
+	pub.SendMsg(newRecord(models.ErrorLevel, "payments"))
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loki.mu.Lock()
+	defer loki.mu.Unlock()
+	if len(loki.requests) != 1 || loki.tenants[0] != "team-a" {
+		t.Fatalf("expected one push for tenant team-a, got %d %v", len(loki.requests), loki.tenants)
+	}
+	streams := loki.requests[0].Streams
+	if len(streams) != 2 {
+		t.Fatalf("expected 2 streams, got %d", len(streams))
+	}
+	first := streams[0]
+	want := map[string]string{"app": "shop", "env": "staging", "level": "info", "component": "checkout"}
+	for k, v := range want {
+		if first.Stream[k] != v {
+			t.Errorf("label %s: expected %q, got %q", k, v, first.Stream[k])
+		}
+	}
+	if len(first.Values) != 2 || !json.Valid([]byte(first.Values[0][1])) {
+		t.Errorf("expected two JSON lines in the first stream, got %v", first.Values)
+	}
+}
+
+func TestPublisher_CustomEncoder(t *testing.T) {
+	loki := &fakeLoki{}
+	srv := httptest.NewServer(loki)
+	defer srv.Close()
+
+	pub := New(Config{URL: srv.URL, Encoder: encoder.NewLogfmt("shop", "prod"), FlushInterval: time.Hour})
+	defer pub.Close()
+	pub.SendMsg(newRecord(models.InfoLevel, "checkout"))
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loki.mu.Lock()
+	defer loki.mu.Unlock()
+	line := loki.requests[0].Streams[0].Values[0][1]
+	if !strings.HasPrefix(line, "time=") || !strings.Contains(line, " msg=hello ") {
+		t.Errorf("expected a logfmt line, got %q", line)
+	}
+}
+
+func TestPublisher_RetriesServerErrors(t *testing.T) {
+	loki := &fakeLoki{failures: 2, status: http.StatusServiceUnavailable}
+	srv := httptest.NewServer(loki)
+	defer srv.Close()
+
+	pub := New(Config{URL: srv.URL, FlushInterval: time.Hour, Backoff: time.Millisecond})
+	defer pub.Close()
+	pub.SendMsg(newRecord(models.InfoLevel, "api"))
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("expected retries to succeed, got %v", err)
+	}
+	loki.mu.Lock()
+	defer loki.mu.Unlock()
+	if len(loki.requests) != 1 {
+		t.Errorf("expected the batch to arrive after retries, got %d pushes", len(loki.requests))
+	}
+}
+
+func TestPublisher_DropsRejectedBatch(t *testing.T) {
+	loki := &fakeLoki{failures: 1, status: http.StatusBadRequest}
+	srv := httptest.NewServer(loki)
+	defer srv.Close()
+
+	pub := New(Config{URL: srv.URL, FlushInterval: time.Hour, Backoff: time.Millisecond})
+	defer pub.Close()
+	pub.SendMsg(newRecord(models.InfoLevel, "api"))
+	if err := pub.Flush(context.Background()); err == nil {
+		t.Fatal("expected error for rejected batch")
+	}
+	if pub.Dropped() != 1 {
+		t.Errorf("expected rejected record to be dropped, got %d", pub.Dropped())
+	}
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Errorf("expected nothing left to push, got %v", err)
+	}
+}
+
+func TestPublisher_Conformance(t *testing.T) {
+	srv := httptest.NewServer(&fakeLoki{})
+	defer srv.Close()
+	glogtest.RunPublisherConformance(t, func(t testing.TB) interfaces.LogPublisher {
+		pub := New(Config{URL: srv.URL, AppID: "shop"})
+		t.Cleanup(func() { _ = pub.Close() })
+		return pub
+	})
+}
@@ -0,0 +1,234 @@
+// Package migrate de-risks sink and schema migrations: DualWriter sends every
+// record through an old and a new publisher configuration and reports
+// field-level differences between their serialized output.
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Renderer serializes a record the way a publisher configuration would.
+type Renderer func(*models.LogData) ([]byte, error)
+
+// CaptureJSON builds a Renderer from a publisher constructor that accepts a
+// writer, e.g. zap.NewZapLoggerWithWriter. The publisher must write one JSON
+// object per record synchronously.
+func CaptureJSON(newPublisher func(w io.Writer) interfaces.LogPublisher) Renderer {
+	var mu sync.Mutex
+	var buf bytes.Buffer
+	pub := newPublisher(&buf)
+	return func(data *models.LogData) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		buf.Reset()
+		pub.SendMsg(data)
+		return bytes.Clone(bytes.TrimSpace(buf.Bytes())), nil
+	}
+}
+
+// Side is one configuration of the migration. Publisher may be nil to only
+// render the record, e.g. to shadow-test a new configuration.
+type Side struct {
+	Publisher interfaces.LogPublisher
+	Render    Renderer
+}
+
+type DiffKind string
+
+const (
+	DiffChanged DiffKind = "changed"
+	DiffMissing DiffKind = "missing" // present in old output only
+	DiffAdded   DiffKind = "added"   // present in new output only
+)
+
+// Diff is one field-level difference. Path is the dot-joined JSON path.
+type Diff struct {
+	Path string
+	Kind DiffKind
+	Old  string
+	New  string
+}
+
+func (d Diff) String() string {
+	return fmt.Sprintf("%s %s: %s -> %s", d.Kind, d.Path, d.Old, d.New)
+}
+
+// Stats counts compared and mismatched records.
+type Stats struct {
+	Records    int64
+	Mismatched int64
+	Errors     int64
+}
+
+// Option configures a DualWriter.
+type Option func(*DualWriter)
+
+// WithIgnore skips the given JSON paths (e.g. "timestamp") and everything
+// below them.
+func WithIgnore(paths ...string) Option {
+	return func(d *DualWriter) {
+		d.ignore = append(d.ignore, paths...)
+	}
+}
+
+// WithOnDiff receives every mismatched record with its differences.
+func WithOnDiff(fn func(data *models.LogData, diffs []Diff)) Option {
+	return func(d *DualWriter) {
+		d.onDiff = fn
+	}
+}
+
+func WithErrorHandler(handler func(error)) Option {
+	return func(d *DualWriter) {
+		if handler != nil {
+			d.errorHandler = handler
+		}
+	}
+}
+
+// Compile-time check that DualWriter implements interfaces.LogPublisher.
+var _ interfaces.LogPublisher = (*DualWriter)(nil)
+
+type DualWriter struct {
+	old, new     Side
+	ignore       []string
+	onDiff       func(*models.LogData, []Diff)
+	errorHandler func(error)
+	records      atomic.Int64
+	mismatched   atomic.Int64
+	errors       atomic.Int64
+}
+
+func New(old, new Side, opts ...Option) *DualWriter {
+	d := &DualWriter{
+		old:          old,
+		new:          new,
+		errorHandler: func(err error) { fmt.Println(err) },
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+func (d *DualWriter) SendMsg(data *models.LogData) {
+	if d.old.Publisher != nil {
+		d.old.Publisher.SendMsg(data)
+	}
+	if d.new.Publisher != nil {
+		d.new.Publisher.SendMsg(data)
+	}
+	if d.old.Render == nil || d.new.Render == nil {
+		return
+	}
+
+	d.records.Add(1)
+	diffs, err := d.Compare(data)
+	if err != nil {
+		d.errors.Add(1)
+		d.errorHandler(err)
+		return
+	}
+	if len(diffs) > 0 {
+		d.mismatched.Add(1)
+		if d.onDiff != nil {
+			d.onDiff(data, diffs)
+		}
+	}
+}
+
+// Compare renders data with both configurations and returns their
+// differences without publishing.
+func (d *DualWriter) Compare(data *models.LogData) ([]Diff, error) {
+	oldOut, err := render(d.old.Render, data)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: old configuration: %w", err)
+	}
+	newOut, err := render(d.new.Render, data)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: new configuration: %w", err)
+	}
+	return d.diff(oldOut, newOut), nil
+}
+
+func (d *DualWriter) Stats() Stats {
+	return Stats{
+		Records:    d.records.Load(),
+		Mismatched: d.mismatched.Load(),
+		Errors:     d.errors.Load(),
+	}
+}
+
+func render(r Renderer, data *models.LogData) (map[string]string, error) {
+	raw, err := r(data.Clone())
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, fmt.Errorf("output is not JSON: %w", err)
+	}
+	flat := make(map[string]string)
+	flatten("", v, flat)
+	return flat, nil
+}
+
+func flatten(prefix string, v any, out map[string]string) {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			flatten(path, child, out)
+		}
+	default:
+		b, _ := json.Marshal(val)
+		out[prefix] = string(b)
+	}
+}
+
+func (d *DualWriter) ignored(path string) bool {
+	for _, p := range d.ignore {
+		if path == p || strings.HasPrefix(path, p+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *DualWriter) diff(oldOut, newOut map[string]string) []Diff {
+	var diffs []Diff
+	for path, o := range oldOut {
+		if d.ignored(path) {
+			continue
+		}
+		n, ok := newOut[path]
+		switch {
+		case !ok:
+			diffs = append(diffs, Diff{Path: path, Kind: DiffMissing, Old: o})
+		case n != o:
+			diffs = append(diffs, Diff{Path: path, Kind: DiffChanged, Old: o, New: n})
+		}
+	}
+	for path, n := range newOut {
+		if d.ignored(path) {
+			continue
+		}
+		if _, ok := oldOut[path]; !ok {
+			diffs = append(diffs, Diff{Path: path, Kind: DiffAdded, New: n})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
@@ -0,0 +1,82 @@
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/zap"
+	"io"
+	"testing"
+)
+
+// flatRenderer mimics a new schema that moves payload fields to the top
+// level and renames msg to message.
+func flatRenderer(data *models.LogData) ([]byte, error) {
+	out := map[string]any{"level": data.Level.String(), "message": data.Msg, "service_name": "shop", "env": "prod"}
+	for _, f := range data.Fields {
+		out[f.Key] = f.String
+	}
+	return json.Marshal(out)
+}
+
+type countingPublisher struct {
+	n int
+}
+
+func (c *countingPublisher) SendMsg(*models.LogData) {
+	c.n++
+}
+
+func TestDualWriter_ReportsFieldDifferences(t *testing.T) {
+	var got []Diff
+	oldPub, newPub := &countingPublisher{}, &countingPublisher{}
+	d := New(
+		Side{Publisher: oldPub, Render: CaptureJSON(func(w io.Writer) interfaces.LogPublisher {
+			return zap.NewZapLoggerWithWriter("shop", "prod", w)
+		})},
+		Side{Publisher: newPub, Render: flatRenderer},
+		WithIgnore("timestamp"),
+		WithOnDiff(func(_ *models.LogData, diffs []Diff) { got = diffs }),
+	)
+
+	d.SendMsg(&models.LogData{
+		Ctx:    context.Background(),
+		Msg:    "order placed",
+		Level:  models.InfoLevel,
+		Fields: []*models.LogField{{Key: "order_id", Type: models.FieldTypeString, String: "o-1"}},
+	})
+
+	if oldPub.n != 1 || newPub.n != 1 {
+		t.Errorf("expected record written to both publishers, got %d and %d", oldPub.n, newPub.n)
+	}
+	want := []Diff{
+		{Path: "message", Kind: DiffAdded, New: `"order placed"`},
+		{Path: "msg", Kind: DiffMissing, Old: `"order placed"`},
+		{Path: "order_id", Kind: DiffAdded, New: `"o-1"`},
+		{Path: "payload.order_id", Kind: DiffMissing, Old: `"o-1"`},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("diff %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+	if s := d.Stats(); s.Records != 1 || s.Mismatched != 1 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+}
+
+func TestDualWriter_IdenticalConfigurations(t *testing.T) {
+	render := CaptureJSON(func(w io.Writer) interfaces.LogPublisher {
+		return zap.NewZapLoggerWithWriter("shop", "prod", w)
+	})
+	d := New(Side{Render: render}, Side{Render: render}, WithIgnore("timestamp"))
+
+	diffs, err := d.Compare(&models.LogData{Ctx: context.Background(), Msg: "same", Level: models.WarnLevel})
+	if err != nil || len(diffs) != 0 {
+		t.Errorf("expected no differences, got %v (%v)", diffs, err)
+	}
+}
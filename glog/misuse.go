@@ -0,0 +1,99 @@
+package glog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+)
+
+var (
+	ErrLogAfterStop = errors.New("glogger: log after Stop")
+	ErrInvalidField = errors.New("glogger: invalid field")
+	ErrNilPublisher = errors.New("glogger: nil publisher")
+)
+
+// misuseComponent is the component of the records that report misuse.
+const misuseComponent = "glogger"
+
+// WithDevelopment turns API misuse (logging after Stop, invalid fields, nil
+// publishers) and DPanic records into panics in the calling goroutine. In
+// production mode the offending message or field is dropped, and misuse is
+// reported through the error handler and as an Error record with component
+// "glogger" on the service's Internal logger. Logging after Stop is only
+// reported through the error handler, since no pipeline is left to carry
+// the record.
+func WithDevelopment() ServiceOption {
+	return func(ls *LoggerService) {
+		ls.development = true
+	}
+}
+
+// misuse reports err, panicking in development mode.
+func (ls *LoggerService) misuse(err error) {
+	ls.stats.misuse.Add(1)
+	if ls.development {
+		panic(err)
+	}
+	ls.errorHandler(err)
+	ls.logMisuse(err)
+}
+
+// logMisuse writes err as an internal Error record. Before Start nothing
+// drains the queue, so the record is only queued while there is room.
+func (ls *LoggerService) logMisuse(err error) {
+	if ls.stopped.Load() || errors.Is(err, ErrLogAfterStop) {
+		return
+	}
+	if !ls.started.Load() && len(ls.inputCh) == cap(ls.inputCh) {
+		return
+	}
+	ls.NewLogger().Internal().Error(context.Background(), err, models.WithComponent(misuseComponent))
+}
+
+// DPanic logs message at DPanicLevel. Loggers bound to a service created with
+// WithDevelopment panic after the message has been queued.
+func (l *Logger) DPanic(ctx context.Context, message string, options ...models.Option) {
+	l.logMsg(ctx, models.DPanicLevel, message, options...)
+	if l.svc != nil && l.svc.development {
+		panic(message)
+	}
+}
+
+// validFields drops nil fields, fields without a key and fields of unknown
+// type, reporting each one as misuse.
+func (l *Logger) validFields(fields []*models.LogField) []*models.LogField {
+	if l.svc == nil {
+		return fields
+	}
+	var valid []*models.LogField
+	for i, f := range fields {
+		reason := invalidFieldReason(f)
+		if reason == "" {
+			if valid != nil {
+				valid = append(valid, f)
+			}
+			continue
+		}
+		if valid == nil {
+			valid = append(make([]*models.LogField, 0, len(fields)), fields[:i]...)
+		}
+		l.svc.misuse(fmt.Errorf("%w: field %d: %s", ErrInvalidField, i, reason))
+	}
+	if valid == nil {
+		return fields
+	}
+	return valid
+}
+
+func invalidFieldReason(f *models.LogField) string {
+	switch {
+	case f == nil:
+		return "nil field"
+	case f.Key == "":
+		return "empty key"
+	case f.Type < models.FieldTypeString || f.Type > models.FieldTypeArray:
+		return fmt.Sprintf("unknown type %d for key %q", f.Type, f.Key)
+	}
+	return ""
+}
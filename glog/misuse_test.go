@@ -0,0 +1,121 @@
+package glog
+
+import (
+	"context"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync"
+	"testing"
+)
+
+func expectPanic(t *testing.T, target error, fn func()) {
+	t.Helper()
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("expected panic")
+		}
+		if target == nil {
+			return
+		}
+		if err, ok := r.(error); !ok || !errors.Is(err, target) {
+			t.Errorf("expected panic with %v, got %v", target, r)
+		}
+	}()
+	fn()
+}
+
+func TestDevelopment_PanicsOnMisuse(t *testing.T) {
+	ls := NewLoggerService(WithDevelopment())
+	expectPanic(t, ErrNilPublisher, func() { ls.AddLogger("nil", nil) })
+
+	ls.AddLogger("mock", &mockPublisher{})
+	ls.Start()
+	logger := ls.NewLogger()
+
+	expectPanic(t, ErrInvalidField, func() {
+		logger.Info(context.Background(), "bad field", models.WithStringField("", "value"))
+	})
+	expectPanic(t, nil, func() { logger.DPanic(context.Background(), "should not happen") })
+
+	ls.Stop()
+	expectPanic(t, ErrLogAfterStop, func() { logger.Info(context.Background(), "after stop") })
+}
+
+func TestProduction_MisuseDegradesGracefully(t *testing.T) {
+	var mu sync.Mutex
+	var reported []error
+	ls := NewLoggerService(WithBlockingSend(), WithErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, err)
+	}))
+	ls.AddLogger("nil", nil)
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+	logger := ls.NewLogger()
+
+	logger.Info(context.Background(), "kept",
+		models.WithStringField("", "dropped"),
+		models.WithStringField("ok", "value"))
+	logger.DPanic(context.Background(), "no panic in production")
+	ls.Stop()
+	logger.Info(context.Background(), "after stop 1")
+	logger.Info(context.Background(), "after stop 2")
+
+	logs := mock.GetLogs()
+	if len(logs) != 4 {
+		t.Fatalf("expected 2 delivered logs and 2 misuse records, got %d", len(logs))
+	}
+	var misuse []error
+	for _, log := range logs {
+		switch {
+		case log.Msg == "kept":
+			if len(log.Fields) != 1 || log.Fields[0].Key != "ok" {
+				t.Errorf("expected only the valid field to survive, got %+v", log.Fields)
+			}
+		case log.Level == models.DPanicLevel:
+		case log.Internal && log.Level == models.ErrorLevel && fields(log)[models.FieldComponentKey] == misuseComponent:
+			misuse = append(misuse, errors.New(log.Msg))
+		default:
+			t.Errorf("unexpected record %q at %v", log.Msg, log.Level)
+		}
+	}
+	if len(misuse) != 2 {
+		t.Errorf("expected misuse records for the nil publisher and the invalid field, got %v", misuse)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// nil publisher, invalid field and a single log-after-Stop report.
+	if len(reported) != 3 {
+		t.Errorf("expected 3 reported errors, got %d: %v", len(reported), reported)
+	}
+	if got := ls.Stats().Misuse; got != 4 {
+		t.Errorf("expected 4 misuse events, got %d", got)
+	}
+}
+
+func TestProduction_MisuseRecordBypassesLevel(t *testing.T) {
+	ls := NewLoggerService(WithBlockingSend(), WithErrorHandler(func(error) {}))
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+	ls.SetLevel(models.InfoLevel)
+
+	ls.NewLogger().Info(context.Background(), "kept", models.WithStringField("", "dropped"))
+	ls.SetLevel(models.FatalLevel)
+	ls.AddLogger("nil", nil)
+	ls.Stop()
+
+	var misuse []string
+	for _, log := range mock.GetLogs() {
+		if log.Internal && fields(log)[models.FieldComponentKey] == misuseComponent {
+			misuse = append(misuse, log.Msg)
+		}
+	}
+	if len(misuse) != 2 {
+		t.Errorf("expected both misuse records despite the level, got %v", misuse)
+	}
+}
@@ -0,0 +1,76 @@
+package models
+
+import "strings"
+
+// LevelEncoder renders a level in the vocabulary of a particular backend. It
+// returns either a string or an int (for numeric severities).
+type LevelEncoder func(LogLevel) any
+
+// LowercaseLevelEncoder is the default: "debug", "info", "warn", ...
+func LowercaseLevelEncoder(l LogLevel) any {
+	return l.String()
+}
+
+// UppercaseLevelEncoder renders "DEBUG", "INFO", "WARN", ...
+func UppercaseLevelEncoder(l LogLevel) any {
+	return strings.ToUpper(l.String())
+}
+
+// SyslogSeverityEncoder renders RFC 5424 numeric severities.
+func SyslogSeverityEncoder(l LogLevel) any {
+	switch l {
+	case DebugLevel:
+		return 7
+	case InfoLevel:
+		return 6
+	case WarnLevel:
+		return 4
+	case ErrorLevel:
+		return 3
+	case DPanicLevel:
+		return 2
+	case PanicLevel:
+		return 1
+	case FatalLevel:
+		return 0
+	default:
+		return 5
+	}
+}
+
+// StackdriverSeverityEncoder renders Google Cloud Logging severity names.
+func StackdriverSeverityEncoder(l LogLevel) any {
+	switch l {
+	case DebugLevel:
+		return "DEBUG"
+	case InfoLevel:
+		return "INFO"
+	case WarnLevel:
+		return "WARNING"
+	case ErrorLevel:
+		return "ERROR"
+	case DPanicLevel:
+		return "CRITICAL"
+	case PanicLevel:
+		return "ALERT"
+	case FatalLevel:
+		return "EMERGENCY"
+	default:
+		return "DEFAULT"
+	}
+}
+
+// LevelNames renders levels found in names and falls back to fallback (or
+// LowercaseLevelEncoder when nil) for the rest, e.g.
+// LevelNames(map[LogLevel]string{WarnLevel: "WARNING"}, UppercaseLevelEncoder).
+func LevelNames(names map[LogLevel]string, fallback LevelEncoder) LevelEncoder {
+	if fallback == nil {
+		fallback = LowercaseLevelEncoder
+	}
+	return func(l LogLevel) any {
+		if name, ok := names[l]; ok {
+			return name
+		}
+		return fallback(l)
+	}
+}
@@ -3,6 +3,9 @@ package models
 import (
 	"context"
 	"fmt"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"strings"
+	"time"
 )
 
 type LogLevel int8
@@ -49,10 +52,53 @@ func (l LogLevel) String() string {
 	}
 }
 
+// ParseLevel parses a level name as produced by LogLevel.String. It is
+// case-insensitive and also accepts "warning".
+func ParseLevel(text string) (LogLevel, error) {
+	switch strings.ToLower(text) {
+	case "debug":
+		return DebugLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	case "dpanic":
+		return DPanicLevel, nil
+	case "panic":
+		return PanicLevel, nil
+	case "fatal":
+		return FatalLevel, nil
+	default:
+		return InfoLevel, fmt.Errorf("unknown log level %q", text)
+	}
+}
+
 const (
 	FieldErrKey       = "error"
 	FieldComponentKey = "component"
 	FieldFilenameKey  = "filename"
+	// FieldErrCausesKey lists the messages of the errors wrapped by the one
+	// in FieldErrKey, outermost first. See WithError.
+	FieldErrCausesKey = "error_causes"
+	// FieldSeqKey and FieldGoroutineKey carry LogData.Seq and
+	// LogData.Goroutine when the service writes ordering fields.
+	FieldSeqKey       = "seq"
+	FieldGoroutineKey = "goroutine"
+	// FieldLoggerKey holds the dotted name of a logger created with
+	// Logger.Named.
+	FieldLoggerKey = "logger"
+	// FieldSampledKey and FieldSampleRateKey mark records that survived
+	// sampling; each stands for FieldSampleRateKey records.
+	FieldSampledKey    = "sampled"
+	FieldSampleRateKey = "sample_rate"
+	// FieldSourceKey names the origin of a record that did not come from a
+	// glog Logger, e.g. "bridge/slog".
+	FieldSourceKey = "source"
+	// SourceBridgePrefix starts the source of records written by bridges from
+	// other logging APIs.
+	SourceBridgePrefix = "bridge/"
 )
 
 type FieldType int8
@@ -63,6 +109,14 @@ const (
 	FieldTypeFloat
 	FieldTypeObject
 	FieldTypeBool
+	FieldTypeDuration
+	FieldTypeTime
+	FieldTypeInt64
+	FieldTypeUint64
+	FieldTypeBinary
+	// FieldTypeArray holds a slice, such as []string or []int, in Object.
+	// JSON output writes it as an array.
+	FieldTypeArray
 )
 
 type LogData struct {
@@ -70,6 +124,29 @@ type LogData struct {
 	Msg    string
 	Fields []*LogField
 	Level  LogLevel
+	// Seq numbers the records of one LoggerService in the order of the
+	// logger calls, starting at 1; zero means the record did not come from
+	// a Logger. Workers deliver records out of order, so consumers that
+	// need the call order sort by Seq.
+	Seq uint64
+	// Goroutine is the ID of the goroutine that made the call, when the
+	// service was created with glog.WithGoroutineIDs.
+	Goroutine uint64
+	// Time is the event time. Logger sets it when the call is made (or to
+	// the WithTimestamp time); when zero, publishers use the time they
+	// handle the record.
+	Time time.Time
+	// Internal marks records written with glog's Logger.Internal, which
+	// filters, deduplication and sampling let through.
+	Internal bool
+}
+
+// TimeOr returns d.Time, or now if it is not set.
+func (d *LogData) TimeOr(now time.Time) time.Time {
+	if d.Time.IsZero() {
+		return now
+	}
+	return d.Time
 }
 
 type LogField struct {
@@ -80,4 +157,78 @@ type LogField struct {
 	String  string
 	Bool    bool
 	Object  interface{}
+	// Duration and Time hold FieldTypeDuration and FieldTypeTime values.
+	Duration time.Duration
+	Time     time.Time
+	// Int64, Uint64 and Binary hold FieldTypeInt64, FieldTypeUint64 and
+	// FieldTypeBinary values. Binary is written as base64 by text and JSON
+	// output.
+	Int64  int64
+	Uint64 uint64
+	Binary []byte
+}
+
+// recordOverhead approximates the fixed per-record cost of JSON output
+// (level, timestamp, service name, env and punctuation).
+const recordOverhead = 96
+
+// Size estimates the serialized size of the record in bytes. It is meant for
+// accounting and quotas, not for exact buffer sizing.
+func (d *LogData) Size() int {
+	size := recordOverhead + len(d.Msg)
+	for _, f := range d.Fields {
+		if f == nil {
+			continue
+		}
+		size += len(f.Key) + 6
+		switch f.Type {
+		case FieldTypeString:
+			size += len(f.String)
+		case FieldTypeInt:
+			size += 8
+		case FieldTypeFloat:
+			size += 12
+		case FieldTypeBool:
+			size += 5
+		case FieldTypeInt64, FieldTypeUint64:
+			size += 8
+		case FieldTypeBinary:
+			size += (len(f.Binary) + 2) / 3 * 4
+		case FieldTypeDuration:
+			size += 12
+		case FieldTypeTime:
+			size += 32
+		case FieldTypeObject, FieldTypeArray:
+			// safejson stops at cycles and depth limits, unlike fmt.
+			raw, _ := safejson.Marshal(f.Object)
+			size += len(raw)
+		}
+	}
+	return size
+}
+
+// Clone returns a copy of the record whose Fields slice and field structs can
+// be modified without affecting other publishers. Object and Binary values are
+// shared.
+func (d *LogData) Clone() *LogData {
+	clone := *d
+	if d.Fields != nil {
+		clone.Fields = make([]*LogField, len(d.Fields))
+		for i, f := range d.Fields {
+			if f == nil {
+				continue
+			}
+			fieldCopy := *f
+			clone.Fields[i] = &fieldCopy
+		}
+	}
+	return &clone
+}
+
+// MarkSampled records that d survived 1-in-rate sampling so downstream
+// analytics can re-weight counts. It modifies d; clone shared records first.
+func (d *LogData) MarkSampled(rate int) {
+	d.Fields = append(d.Fields,
+		&LogField{Key: FieldSampledKey, Type: FieldTypeBool, Bool: true},
+		&LogField{Key: FieldSampleRateKey, Type: FieldTypeInt, Integer: rate})
 }
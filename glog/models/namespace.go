@@ -0,0 +1,90 @@
+package models
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Namespace is the value of a field created with WithNamespace: child
+// fields grouped under one key. It encodes as a JSON object with the
+// children in order, so every publisher that writes objects nests it;
+// the zap publisher writes the children with their zap types.
+type Namespace []*LogField
+
+// WithNamespace groups the fields added by opts under name, like zap's
+// Namespace:
+//
+//	models.WithNamespace("http", models.WithStringField("method", "GET"), models.WithIntField("status", 200))
+//
+// writes {"http":{"method":"GET","status":200}}. Namespaces nest. Options
+// that are not fields, such as WithComponent, apply to the record as usual.
+func WithNamespace(name string, opts ...Option) Option {
+	return func(o *Options) {
+		outer := o.fields
+		o.fields = nil
+		for _, opt := range opts {
+			opt(o)
+		}
+		children := Namespace(o.fields)
+		o.fields = append(outer, &LogField{Key: name, Type: FieldTypeObject, Object: children})
+	}
+}
+
+// MarshalJSON writes the children as one object.
+func (n Namespace) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for _, f := range n {
+		if f == nil {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		key, _ := json.Marshal(f.Key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(namespaceValue(f))
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func namespaceValue(f *LogField) []byte {
+	var v any
+	switch f.Type {
+	case FieldTypeString:
+		v = f.String
+	case FieldTypeInt:
+		v = f.Integer
+	case FieldTypeFloat:
+		if math.IsNaN(f.Float) || math.IsInf(f.Float, 0) {
+			v = strconv.FormatFloat(f.Float, 'g', -1, 64)
+		} else {
+			v = f.Float
+		}
+	case FieldTypeBool:
+		v = f.Bool
+	case FieldTypeInt64:
+		v = f.Int64
+	case FieldTypeUint64:
+		v = f.Uint64
+	case FieldTypeBinary:
+		v = base64.StdEncoding.EncodeToString(f.Binary)
+	case FieldTypeDuration:
+		v = f.Duration.String()
+	case FieldTypeTime:
+		v = f.Time.Format(time.RFC3339Nano)
+	default:
+		v = f.Object
+	}
+	b, _ := safejson.Marshal(v)
+	return b
+}
@@ -1,11 +1,17 @@
 package models
 
+import (
+	"sort"
+	"time"
+)
+
 type Option func(opts *Options)
 
 type Options struct {
 	withStackTrace bool
 	component      string
 	fields         []*LogField
+	timestamp      time.Time
 }
 
 func (o *Options) WithStackTrace() bool {
@@ -20,6 +26,10 @@ func (o *Options) GetFields() []*LogField {
 	return o.fields
 }
 
+func (o *Options) GetTimestamp() time.Time {
+	return o.timestamp
+}
+
 func WithComponent(component string) Option {
 	return func(opts *Options) {
 		opts.component = component
@@ -61,3 +71,178 @@ func WithBoolField(key string, value bool) Option {
 		opts.fields = append(opts.fields, &LogField{Key: key, Type: FieldTypeBool, Bool: value})
 	}
 }
+
+// WithInt64Field adds a 64-bit integer, for IDs and sizes that may not fit
+// an int on every platform.
+func WithInt64Field(key string, value int64) Option {
+	return func(opts *Options) {
+		opts.fields = append(opts.fields, &LogField{Key: key, Type: FieldTypeInt64, Int64: value})
+	}
+}
+
+// WithUint64Field adds an unsigned 64-bit integer.
+func WithUint64Field(key string, value uint64) Option {
+	return func(opts *Options) {
+		opts.fields = append(opts.fields, &LogField{Key: key, Type: FieldTypeUint64, Uint64: value})
+	}
+}
+
+// WithBinaryField adds raw bytes. Text and JSON output write them as
+// base64; value is not copied, so do not modify it after logging.
+func WithBinaryField(key string, value []byte) Option {
+	return func(opts *Options) {
+		opts.fields = append(opts.fields, &LogField{Key: key, Type: FieldTypeBinary, Binary: value})
+	}
+}
+
+// WithStringsField adds a list of strings, written as a JSON array.
+func WithStringsField(key string, values []string) Option {
+	return func(opts *Options) {
+		opts.fields = append(opts.fields, &LogField{Key: key, Type: FieldTypeArray, Object: values})
+	}
+}
+
+// WithIntsField adds a list of integers, written as a JSON array.
+func WithIntsField(key string, values []int) Option {
+	return func(opts *Options) {
+		opts.fields = append(opts.fields, &LogField{Key: key, Type: FieldTypeArray, Object: values})
+	}
+}
+
+// WithDurationField adds a duration. Publishers encode it natively where
+// they can, e.g. with zap.Duration, and as text such as "1.5s" otherwise.
+func WithDurationField(key string, value time.Duration) Option {
+	return func(opts *Options) {
+		opts.fields = append(opts.fields, &LogField{Key: key, Type: FieldTypeDuration, Duration: value})
+	}
+}
+
+// WithTimeField adds a point in time. Publishers encode it natively where
+// they can, e.g. with zap.Time, and as RFC 3339 text otherwise.
+func WithTimeField(key string, value time.Time) Option {
+	return func(opts *Options) {
+		opts.fields = append(opts.fields, &LogField{Key: key, Type: FieldTypeTime, Time: value})
+	}
+}
+
+// WithFields adds one field per map entry, in key order, choosing the field
+// type from the value: strings, bools, integers, floats, time.Duration,
+// time.Time, []byte, []string and []int get their own types (uint, uint32
+// and uint64 become FieldTypeUint64, int64 FieldTypeInt64), errors are
+// written as their message and anything else as an object.
+func WithFields(fields map[string]any) Option {
+	return func(opts *Options) {
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			opts.fields = append(opts.fields, fieldOf(k, fields[k]))
+		}
+	}
+}
+
+func fieldOf(key string, value any) *LogField {
+	f := &LogField{Key: key}
+	switch v := value.(type) {
+	case string:
+		f.Type, f.String = FieldTypeString, v
+	case bool:
+		f.Type, f.Bool = FieldTypeBool, v
+	case int:
+		f.Type, f.Integer = FieldTypeInt, v
+	case int8:
+		f.Type, f.Integer = FieldTypeInt, int(v)
+	case int16:
+		f.Type, f.Integer = FieldTypeInt, int(v)
+	case int32:
+		f.Type, f.Integer = FieldTypeInt, int(v)
+	case int64:
+		f.Type, f.Int64 = FieldTypeInt64, v
+	case uint8:
+		f.Type, f.Integer = FieldTypeInt, int(v)
+	case uint16:
+		f.Type, f.Integer = FieldTypeInt, int(v)
+	case uint32:
+		f.Type, f.Uint64 = FieldTypeUint64, uint64(v)
+	case uint:
+		f.Type, f.Uint64 = FieldTypeUint64, uint64(v)
+	case uint64:
+		f.Type, f.Uint64 = FieldTypeUint64, v
+	case float32:
+		f.Type, f.Float = FieldTypeFloat, float64(v)
+	case float64:
+		f.Type, f.Float = FieldTypeFloat, v
+	case time.Duration:
+		f.Type, f.Duration = FieldTypeDuration, v
+	case time.Time:
+		f.Type, f.Time = FieldTypeTime, v
+	case []byte:
+		f.Type, f.Binary = FieldTypeBinary, v
+	case []string, []int:
+		f.Type, f.Object = FieldTypeArray, v
+	case error:
+		f.Type, f.String = FieldTypeString, v.Error()
+	default:
+		f.Type, f.Object = FieldTypeObject, v
+	}
+	return f
+}
+
+// WithSource tags the record with its origin, e.g. "bridge/slog". Bridges
+// should always set it so the service can fill missing app, env and
+// component for them.
+func WithSource(source string) Option {
+	return WithStringField(FieldSourceKey, source)
+}
+
+// WithTimestamp sets the event time of the record instead of the time it is
+// published, for replay and backfill of records that happened earlier.
+func WithTimestamp(t time.Time) Option {
+	return func(opts *Options) {
+		opts.timestamp = t
+	}
+}
+
+// WithError attaches err to a record of any level without making it an
+// error record: its message goes to FieldErrKey and, if it wraps other
+// errors, their messages to the FieldErrCausesKey array, outermost first.
+// Errors joined with errors.Join are walked depth-first. A nil err adds
+// nothing.
+func WithError(err error) Option {
+	return func(opts *Options) {
+		if err == nil {
+			return
+		}
+		opts.fields = append(opts.fields, &LogField{Key: FieldErrKey, Type: FieldTypeString, String: err.Error()})
+		if causes := errorCauses(err); len(causes) > 0 {
+			opts.fields = append(opts.fields, &LogField{Key: FieldErrCausesKey, Type: FieldTypeArray, Object: causes})
+		}
+	}
+}
+
+func errorCauses(err error) []string {
+	var causes []string
+	var walk func(error)
+	walk = func(err error) {
+		var wrapped []error
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			if next := u.Unwrap(); next != nil {
+				wrapped = []error{next}
+			}
+		case interface{ Unwrap() []error }:
+			wrapped = u.Unwrap()
+		}
+		for _, next := range wrapped {
+			if next == nil {
+				continue
+			}
+			causes = append(causes, next.Error())
+			walk(next)
+		}
+	}
+	walk(err)
+	return causes
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithError(t *testing.T) {
+	root := errors.New("connection refused")
+	err := fmt.Errorf("load user: %w", errors.Join(fmt.Errorf("dial db: %w", root), errors.New("cache miss")))
+
+	opts := &Options{}
+	WithError(err)(opts)
+	WithError(nil)(opts)
+	fields := opts.GetFields()
+	if len(fields) != 2 {
+		t.Fatalf("expected 2 fields, got %d", len(fields))
+	}
+	if fields[0].Key != FieldErrKey || fields[0].String != err.Error() {
+		t.Errorf("expected the message under %q, got %+v", FieldErrKey, fields[0])
+	}
+	want := []string{
+		"dial db: connection refused\ncache miss",
+		"dial db: connection refused",
+		"connection refused",
+		"cache miss",
+	}
+	if fields[1].Key != FieldErrCausesKey || fields[1].Type != FieldTypeArray || !reflect.DeepEqual(fields[1].Object, want) {
+		t.Errorf("expected causes %q, got %+v", want, fields[1].Object)
+	}
+
+	opts = &Options{}
+	WithError(root)(opts)
+	if len(opts.GetFields()) != 1 {
+		t.Errorf("expected no causes for an unwrapped error, got %d fields", len(opts.GetFields()))
+	}
+}
+
+func TestWithFields(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	opts := &Options{}
+	WithFields(map[string]any{
+		"user":  "bob",
+		"ok":    true,
+		"n":     7,
+		"id":    int64(1) << 40,
+		"size":  uint64(1) << 63,
+		"ratio": float32(0.5),
+		"took":  time.Second,
+		"at":    at,
+		"raw":   []byte("hi"),
+		"err":   errors.New("boom"),
+		"cart":  map[string]int{"n": 2},
+		"tags":  []string{"a"},
+	})(opts)
+
+	fields := opts.GetFields()
+	keys := make([]string, len(fields))
+	byKey := make(map[string]*LogField, len(fields))
+	for i, f := range fields {
+		keys[i] = f.Key
+		byKey[f.Key] = f
+	}
+	if want := []string{"at", "cart", "err", "id", "n", "ok", "ratio", "raw", "size", "tags", "took", "user"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("expected fields in key order %v, got %v", want, keys)
+	}
+	checks := map[string]bool{
+		"user":  byKey["user"].Type == FieldTypeString && byKey["user"].String == "bob",
+		"ok":    byKey["ok"].Type == FieldTypeBool && byKey["ok"].Bool,
+		"n":     byKey["n"].Type == FieldTypeInt && byKey["n"].Integer == 7,
+		"id":    byKey["id"].Type == FieldTypeInt64 && byKey["id"].Int64 == 1<<40,
+		"size":  byKey["size"].Type == FieldTypeUint64 && byKey["size"].Uint64 == 1<<63,
+		"ratio": byKey["ratio"].Type == FieldTypeFloat && byKey["ratio"].Float == 0.5,
+		"took":  byKey["took"].Type == FieldTypeDuration && byKey["took"].Duration == time.Second,
+		"at":    byKey["at"].Type == FieldTypeTime && byKey["at"].Time.Equal(at),
+		"raw":   byKey["raw"].Type == FieldTypeBinary && string(byKey["raw"].Binary) == "hi",
+		"err":   byKey["err"].Type == FieldTypeString && byKey["err"].String == "boom",
+		"cart":  byKey["cart"].Type == FieldTypeObject,
+		"tags":  byKey["tags"].Type == FieldTypeArray,
+	}
+	for key, ok := range checks {
+		if !ok {
+			t.Errorf("unexpected field %q: %+v", key, byKey[key])
+		}
+	}
+}
+
+func TestWithNamespace(t *testing.T) {
+	opts := &Options{}
+	WithStringField("before", "x")(opts)
+	WithNamespace("http",
+		WithStringField("method", "GET"),
+		WithNamespace("response", WithIntField("status", 200), WithDurationField("took", time.Second)),
+		WithComponent("api"),
+	)(opts)
+	WithBoolField("after", true)(opts)
+
+	fields := opts.GetFields()
+	if len(fields) != 3 || fields[0].Key != "before" || fields[1].Key != "http" || fields[2].Key != "after" {
+		t.Fatalf("expected the namespace between the outer fields, got %+v", fields)
+	}
+	if opts.GetComponent() != "api" {
+		t.Errorf("expected non-field options to apply to the record, got component %q", opts.GetComponent())
+	}
+	b, err := json.Marshal(fields[1].Object)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"method":"GET","response":{"status":200,"took":"1s"}}`; string(b) != want {
+		t.Errorf("expected %s, got %s", want, b)
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+// OmitKey as an OutputKeys value leaves that key out of the output.
+const OmitKey = "-"
+
+// OutputKeys names the top-level keys of JSON output. Empty values use the
+// defaults ("msg", "level", "timestamp").
+type OutputKeys struct {
+	Message   string
+	Level     string
+	Timestamp string
+}
+
+// DefaultOutputKeys returns the keys used when none are configured.
+func DefaultOutputKeys() OutputKeys {
+	return OutputKeys{Message: "msg", Level: "level", Timestamp: "timestamp"}
+}
+
+// WithDefaults fills empty keys with the defaults.
+func (k OutputKeys) WithDefaults() OutputKeys {
+	d := DefaultOutputKeys()
+	if k.Message == "" {
+		k.Message = d.Message
+	}
+	if k.Level == "" {
+		k.Level = d.Level
+	}
+	if k.Timestamp == "" {
+		k.Timestamp = d.Timestamp
+	}
+	return k
+}
+
+// Reserved reports whether key is one of the top-level keys of a flat
+// layout, given the service and env keys every JSON publisher writes.
+func (k OutputKeys) Reserved(key string) bool {
+	switch key {
+	case k.Message, k.Level, k.Timestamp, "service_name", "env":
+		return key != OmitKey
+	}
+	return false
+}
+
+// FlatFieldKey returns the key a field is written under in a flat layout:
+// its own key, or "fields.<key>" when that would collide with a top-level
+// key.
+func (k OutputKeys) FlatFieldKey(key string) string {
+	if k.Reserved(key) {
+		return "fields." + key
+	}
+	return key
+}
@@ -0,0 +1,279 @@
+package models
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"math"
+	"time"
+)
+
+// Field numbers from log.proto. The codec is written by hand so that the
+// module needs no protobuf runtime; it follows the proto3 wire format, so
+// stubs generated from log.proto in any language read and write the same
+// bytes.
+const (
+	recordTime      = 1
+	recordLevel     = 2
+	recordMessage   = 3
+	recordFields    = 4
+	recordAppID     = 5
+	recordEnv       = 6
+	recordSeq       = 7
+	recordGoroutine = 8
+
+	fieldKey      = 1
+	fieldString   = 2
+	fieldInt      = 3
+	fieldFloat    = 4
+	fieldBool     = 5
+	fieldJSON     = 6
+	fieldDuration = 7
+	fieldTime     = 8
+	fieldInt64    = 9
+	fieldUint64   = 10
+	fieldBinary   = 11
+	fieldArray    = 12
+)
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("truncated protobuf message")
+
+// ToProto encodes data as the LogData message of log.proto. appID and env
+// are used when the record context has no AppID or EnvName; a zero record
+// time is replaced by the current time.
+func ToProto(data *LogData, appID, env string) []byte {
+	if data.Ctx != nil {
+		if v, ok := data.Ctx.Value(AppID).(string); ok && v != "" {
+			appID = v
+		}
+		if v, ok := data.Ctx.Value(EnvName).(string); ok && v != "" {
+			env = v
+		}
+	}
+	b := make([]byte, 0, 64+len(data.Msg)+32*len(data.Fields))
+	b = appendVarintField(b, recordTime, uint64(data.TimeOr(time.Now()).UnixNano()))
+	if data.Level != 0 {
+		b = appendVarintField(b, recordLevel, zigzag(int64(data.Level)))
+	}
+	b = appendBytesField(b, recordMessage, []byte(data.Msg))
+	for _, f := range data.Fields {
+		if f == nil {
+			continue
+		}
+		b = appendBytesField(b, recordFields, marshalField(f))
+	}
+	if appID != "" {
+		b = appendBytesField(b, recordAppID, []byte(appID))
+	}
+	if env != "" {
+		b = appendBytesField(b, recordEnv, []byte(env))
+	}
+	if data.Seq != 0 {
+		b = appendVarintField(b, recordSeq, data.Seq)
+	}
+	if data.Goroutine != 0 {
+		b = appendVarintField(b, recordGoroutine, data.Goroutine)
+	}
+	return b
+}
+
+func marshalField(f *LogField) []byte {
+	b := appendBytesField(nil, fieldKey, []byte(f.Key))
+	switch f.Type {
+	case FieldTypeString:
+		b = appendBytesField(b, fieldString, []byte(f.String))
+	case FieldTypeInt:
+		b = appendVarintField(b, fieldInt, uint64(f.Integer))
+	case FieldTypeFloat:
+		b = appendTag(b, fieldFloat, wireFixed64)
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(f.Float))
+	case FieldTypeBool:
+		v := uint64(0)
+		if f.Bool {
+			v = 1
+		}
+		b = appendVarintField(b, fieldBool, v)
+	case FieldTypeInt64:
+		b = appendVarintField(b, fieldInt64, uint64(f.Int64))
+	case FieldTypeUint64:
+		b = appendVarintField(b, fieldUint64, f.Uint64)
+	case FieldTypeBinary:
+		b = appendBytesField(b, fieldBinary, f.Binary)
+	case FieldTypeDuration:
+		b = appendVarintField(b, fieldDuration, uint64(f.Duration))
+	case FieldTypeTime:
+		b = appendVarintField(b, fieldTime, uint64(f.Time.UnixNano()))
+	default:
+		// safejson returns valid JSON with placeholders alongside any error.
+		raw, _ := safejson.Marshal(f.Object)
+		num := fieldJSON
+		if f.Type == FieldTypeArray {
+			num = fieldArray
+		}
+		b = appendBytesField(b, num, raw)
+	}
+	return b
+}
+
+// FromProto decodes a LogData message. The returned record's context
+// carries the app ID and environment as AppID and EnvName; other context
+// values of the sender do not travel. Unknown fields are skipped.
+func FromProto(b []byte) (*LogData, error) {
+	data := &LogData{Fields: []*LogField{}}
+	var appID, env string
+	err := walk(b, func(num int, typ int, v uint64, raw []byte) error {
+		switch {
+		case num == recordTime && typ == wireVarint:
+			data.Time = time.Unix(0, int64(v))
+		case num == recordLevel && typ == wireVarint:
+			data.Level = LogLevel(unzigzag(v))
+		case num == recordMessage && typ == wireBytes:
+			data.Msg = string(raw)
+		case num == recordFields && typ == wireBytes:
+			f, err := unmarshalField(raw)
+			if err != nil {
+				return err
+			}
+			data.Fields = append(data.Fields, f)
+		case num == recordAppID && typ == wireBytes:
+			appID = string(raw)
+		case num == recordEnv && typ == wireBytes:
+			env = string(raw)
+		case num == recordSeq && typ == wireVarint:
+			data.Seq = v
+		case num == recordGoroutine && typ == wireVarint:
+			data.Goroutine = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	if appID != "" {
+		ctx = context.WithValue(ctx, AppID, appID)
+	}
+	if env != "" {
+		ctx = context.WithValue(ctx, EnvName, env)
+	}
+	data.Ctx = ctx
+	return data, nil
+}
+
+func unmarshalField(b []byte) (*LogField, error) {
+	f := &LogField{Type: FieldTypeString}
+	err := walk(b, func(num int, typ int, v uint64, raw []byte) error {
+		switch {
+		case num == fieldKey && typ == wireBytes:
+			f.Key = string(raw)
+		case num == fieldString && typ == wireBytes:
+			f.Type, f.String = FieldTypeString, string(raw)
+		case num == fieldInt && typ == wireVarint:
+			f.Type, f.Integer = FieldTypeInt, int(int64(v))
+		case num == fieldFloat && typ == wireFixed64:
+			f.Type, f.Float = FieldTypeFloat, math.Float64frombits(v)
+		case num == fieldBool && typ == wireVarint:
+			f.Type, f.Bool = FieldTypeBool, v != 0
+		case num == fieldInt64 && typ == wireVarint:
+			f.Type, f.Int64 = FieldTypeInt64, int64(v)
+		case num == fieldUint64 && typ == wireVarint:
+			f.Type, f.Uint64 = FieldTypeUint64, v
+		case num == fieldBinary && typ == wireBytes:
+			f.Type, f.Binary = FieldTypeBinary, append([]byte{}, raw...)
+		case num == fieldDuration && typ == wireVarint:
+			f.Type, f.Duration = FieldTypeDuration, time.Duration(int64(v))
+		case num == fieldTime && typ == wireVarint:
+			f.Type, f.Time = FieldTypeTime, time.Unix(0, int64(v))
+		case (num == fieldJSON || num == fieldArray) && typ == wireBytes:
+			var obj any
+			if err := json.Unmarshal(raw, &obj); err != nil {
+				return fmt.Errorf("field %q: %w", f.Key, err)
+			}
+			f.Type, f.Object = FieldTypeObject, obj
+			if num == fieldArray {
+				f.Type = FieldTypeArray
+			}
+		}
+		return nil
+	})
+	return f, err
+}
+
+// walk calls fn for every field in b. Varint and fixed values are passed in
+// v, length-delimited values in raw.
+func walk(b []byte, fn func(num int, typ int, v uint64, raw []byte) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errTruncated
+		}
+		b = b[n:]
+		num, typ := int(tag>>3), int(tag&7)
+		var (
+			v   uint64
+			raw []byte
+		)
+		switch typ {
+		case wireVarint:
+			v, n = binary.Uvarint(b)
+			if n <= 0 {
+				return errTruncated
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return errTruncated
+			}
+			v, b = binary.LittleEndian.Uint64(b), b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return errTruncated
+			}
+			v, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		case wireBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < l {
+				return errTruncated
+			}
+			raw, b = b[n:n+int(l)], b[n+int(l):]
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d", typ)
+		}
+		if err := fn(num, typ, v, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendTag(b []byte, num, typ int) []byte {
+	return binary.AppendUvarint(b, uint64(num)<<3|uint64(typ))
+}
+
+func appendVarintField(b []byte, num int, v uint64) []byte {
+	return binary.AppendUvarint(appendTag(b, num, wireVarint), v)
+}
+
+func appendBytesField(b []byte, num int, v []byte) []byte {
+	b = binary.AppendUvarint(appendTag(b, num, wireBytes), uint64(len(v)))
+	return append(b, v...)
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
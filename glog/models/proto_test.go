@@ -0,0 +1,91 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestProto_RoundTrip(t *testing.T) {
+	ts := time.Unix(1700000000, 123)
+	ctx := context.WithValue(context.Background(), EnvName, "staging")
+	in := &LogData{
+		Ctx:   ctx,
+		Msg:   "charged",
+		Level: DebugLevel,
+		Time:  ts,
+		Seq:   41,
+		Fields: []*LogField{
+			{Key: "s", Type: FieldTypeString, String: "v"},
+			nil,
+			{Key: "i", Type: FieldTypeInt, Integer: -7},
+			{Key: "f", Type: FieldTypeFloat, Float: 2.5},
+			{Key: "b", Type: FieldTypeBool, Bool: true},
+			{Key: "o", Type: FieldTypeObject, Object: map[string]any{"k": "v"}},
+			{Key: "d", Type: FieldTypeDuration, Duration: 1500 * time.Millisecond},
+			{Key: "t", Type: FieldTypeTime, Time: ts},
+			{Key: "i64", Type: FieldTypeInt64, Int64: -1 << 62},
+			{Key: "u64", Type: FieldTypeUint64, Uint64: 1<<64 - 1},
+			{Key: "bin", Type: FieldTypeBinary, Binary: []byte{0, 1, 2}},
+			{Key: "arr", Type: FieldTypeArray, Object: []string{"a", "b"}},
+		},
+	}
+
+	out, err := FromProto(ToProto(in, "shop", "prod"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Msg != "charged" || out.Level != DebugLevel || !out.Time.Equal(ts) || out.Seq != 41 {
+		t.Errorf("unexpected record %+v", out)
+	}
+	if out.Ctx.Value(AppID) != "shop" || out.Ctx.Value(EnvName) != "staging" {
+		t.Errorf("expected config app ID and context env, got %v/%v", out.Ctx.Value(AppID), out.Ctx.Value(EnvName))
+	}
+	if len(out.Fields) != 11 {
+		t.Fatalf("expected 11 fields, got %d", len(out.Fields))
+	}
+	f := out.Fields
+	if f[0].String != "v" || f[1].Integer != -7 || f[2].Float != 2.5 || !f[3].Bool {
+		t.Errorf("unexpected scalar fields %+v %+v %+v %+v", f[0], f[1], f[2], f[3])
+	}
+	if obj, ok := f[4].Object.(map[string]any); !ok || obj["k"] != "v" {
+		t.Errorf("unexpected object field %#v", f[4].Object)
+	}
+	if f[5].Type != FieldTypeDuration || f[5].Duration != 1500*time.Millisecond || f[6].Type != FieldTypeTime || !f[6].Time.Equal(ts) {
+		t.Errorf("unexpected duration and time fields %+v %+v", f[5], f[6])
+	}
+	if f[7].Int64 != -1<<62 || f[8].Uint64 != 1<<64-1 || string(f[9].Binary) != "\x00\x01\x02" {
+		t.Errorf("unexpected 64-bit and binary fields %+v %+v %+v", f[7], f[8], f[9])
+	}
+	if arr, ok := f[10].Object.([]any); f[10].Type != FieldTypeArray || !ok || len(arr) != 2 || arr[1] != "b" {
+		t.Errorf("unexpected array field %+v", f[10])
+	}
+}
+
+func TestFromProto_SkipsUnknownAndRejectsTruncated(t *testing.T) {
+	b := ToProto(&LogData{Msg: "x", Level: ErrorLevel}, "", "")
+	// Field 15 as a fixed32 and field 16 as a string, both unknown.
+	b = append(b, 15<<3|wireFixed32, 1, 2, 3, 4)
+	b = appendBytesField(b, 16, []byte("later"))
+	out, err := FromProto(b)
+	if err != nil || out.Msg != "x" || out.Level != ErrorLevel {
+		t.Fatalf("unexpected result %+v, %v", out, err)
+	}
+	if _, err := FromProto(b[:len(b)-2]); err == nil {
+		t.Error("expected error for a truncated message")
+	}
+}
+
+func TestProto_CyclicObjectField(t *testing.T) {
+	cyclic := map[string]any{"k": "v"}
+	cyclic["self"] = cyclic
+	in := &LogData{Msg: "x", Fields: []*LogField{{Key: "o", Type: FieldTypeObject, Object: cyclic}}}
+
+	out, err := FromProto(ToProto(in, "a", "e"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if obj, ok := out.Fields[0].Object.(map[string]any); !ok || obj["k"] != "v" {
+		t.Errorf("expected the object kept with a placeholder for the cycle, got %#v", out.Fields[0].Object)
+	}
+}
@@ -0,0 +1,115 @@
+package models
+
+import "sort"
+
+// JSONSchemaDraft is the JSON Schema dialect of Schema documents.
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema profiles describe the overall shape of a publisher's output.
+const (
+	ProfileNested     = "nested"
+	ProfileFlat       = "flat"
+	ProfileTextPrefix = "text-prefix"
+)
+
+// Schema is a JSON Schema document describing the records a publisher
+// writes, so downstream teams can generate parsers and validate ingestion
+// against what the service actually produces. Reserved and Profile are
+// glogger extensions.
+type Schema struct {
+	Schema               string               `json:"$schema"`
+	Title                string               `json:"title,omitempty"`
+	Type                 string               `json:"type"`
+	Properties           map[string]*Property `json:"properties"`
+	Required             []string             `json:"required,omitempty"`
+	AdditionalProperties bool                 `json:"additionalProperties"`
+	Reserved             []string             `json:"x-glogger-reserved,omitempty"`
+	Profile              string               `json:"x-glogger-profile,omitempty"`
+}
+
+// Property describes one key of a record.
+type Property struct {
+	Type                 string               `json:"type,omitempty"`
+	Format               string               `json:"format,omitempty"`
+	Description          string               `json:"description,omitempty"`
+	Enum                 []any                `json:"enum,omitempty"`
+	Properties           map[string]*Property `json:"properties,omitempty"`
+	AdditionalProperties *bool                `json:"additionalProperties,omitempty"`
+}
+
+// wellKnownFields are the fields glogger itself adds to records.
+var wellKnownFields = map[string]*Property{
+	FieldComponentKey:  {Type: "string", Description: "component path"},
+	FieldFilenameKey:   {Type: "string", Description: "stack trace, innermost frame first, joined by \" <- \""},
+	FieldSampledKey:    {Type: "boolean", Description: "record survived sampling"},
+	FieldSampleRateKey: {Type: "integer", Description: "records this one stands for"},
+	FieldSourceKey:     {Type: "string", Description: "origin of records not written by a glog Logger"},
+}
+
+// RecordSchema describes the JSON records written with the given keys,
+// layout and level encoder (nil means LowercaseLevelEncoder), in the shape
+// shared by the zap publisher and encoder.JSON.
+func RecordSchema(title string, keys OutputKeys, flat bool, levels LevelEncoder) *Schema {
+	keys = keys.WithDefaults()
+	if levels == nil {
+		levels = LowercaseLevelEncoder
+	}
+	s := &Schema{
+		Schema:     JSONSchemaDraft,
+		Title:      title,
+		Type:       "object",
+		Properties: make(map[string]*Property),
+		Profile:    ProfileNested,
+	}
+	add := func(key string, p *Property) {
+		if key == OmitKey {
+			return
+		}
+		s.Properties[key] = p
+		s.Required = append(s.Required, key)
+		s.Reserved = append(s.Reserved, key)
+	}
+	add(keys.Level, levelProperty(levels))
+	add(keys.Timestamp, &Property{Type: "string", Format: "date-time"})
+	add(keys.Message, &Property{Type: "string"})
+	add("service_name", &Property{Type: "string"})
+	add("env", &Property{Type: "string"})
+
+	fields := make(map[string]*Property, len(wellKnownFields))
+	for key, p := range wellKnownFields {
+		fields[key] = p
+	}
+	if flat {
+		s.Profile = ProfileFlat
+		s.AdditionalProperties = true
+		for key, p := range fields {
+			s.Properties[keys.FlatFieldKey(key)] = p
+		}
+	} else {
+		open := true
+		s.Properties["payload"] = &Property{Type: "object", Properties: fields, AdditionalProperties: &open}
+		s.Reserved = append(s.Reserved, "payload")
+	}
+	sort.Strings(s.Required)
+	sort.Strings(s.Reserved)
+	return s
+}
+
+func levelProperty(levels LevelEncoder) *Property {
+	p := &Property{}
+	seen := make(map[any]bool)
+	for l := DebugLevel; l <= FatalLevel; l++ {
+		v := levels(l)
+		switch v.(type) {
+		case int:
+			p.Type = "integer"
+		default:
+			p.Type = "string"
+		}
+		if !seen[v] {
+			seen[v] = true
+			p.Enum = append(p.Enum, v)
+		}
+	}
+	return p
+}
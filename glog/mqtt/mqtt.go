@@ -0,0 +1,248 @@
+// Package mqtt publishes records to an MQTT broker, for edge and IoT
+// services that already run a broker but no log collector. It does not
+// depend on an MQTT client: wrap the client of your choice in Client.
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/internal/batch"
+	"github.com/alexnobleburn/glogger/glog/internal/report"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultTopic       = "logs/{app}/{env}/{level}"
+	defaultMaxBuffered = 10000
+)
+
+// ErrOffline is reported once when the client reports a closed connection;
+// records are buffered until it reconnects.
+var ErrOffline = errors.New("mqtt: client offline, buffering records")
+
+// Client publishes one message and returns once the broker has taken it at
+// the requested QoS. If it also has IsConnectionOpen() bool, as paho's
+// mqtt.Client does, no publish is attempted while it reports false. An
+// adapter for paho:
+//
+//	func (a adapter) Publish(topic string, qos byte, payload []byte) error {
+//		t := a.c.Publish(topic, qos, false, payload)
+//		t.Wait()
+//		return t.Error()
+//	}
+//
+//	func (a adapter) IsConnectionOpen() bool { return a.c.IsConnectionOpen() }
+type Client interface {
+	Publish(topic string, qos byte, payload []byte) error
+}
+
+// Config configures a Publisher.
+type Config struct {
+	// Topic is a template expanded per record (default
+	// "logs/{app}/{env}/{level}"). It accepts {app}, {env}, {level} and
+	// {component}; values are made safe as single topic levels.
+	Topic string
+	// QoS is the default quality of service, 0, 1 or 2.
+	QoS byte
+	// LevelQoS overrides QoS per level, e.g. {models.ErrorLevel: 1} to keep
+	// debug chatter at 0 while errors are acknowledged.
+	LevelQoS map[models.LogLevel]byte
+	// AppID and Env are used when the record context has no models.AppID or
+	// models.EnvName.
+	AppID string
+	Env   string
+	// Encoder defaults to encoder.NewJSON(AppID, Env).
+	Encoder interfaces.Encoder
+	// MaxBuffered bounds the records kept while offline (default 10000);
+	// the oldest are dropped first. BatchSize and FlushInterval control how
+	// often the buffer is drained (defaults 100 and 1s).
+	MaxBuffered   int
+	BatchSize     int
+	FlushInterval time.Duration
+	// Retries per flush (default 0: wait for the next interval), with
+	// exponential backoff starting at Backoff (default 100ms).
+	Retries int
+	Backoff time.Duration
+	// ErrorHandler receives publish errors. Once the publisher is added to a
+	// service, the service error handler receives them too. Without either,
+	// they are printed.
+	ErrorHandler func(error)
+}
+
+type message struct {
+	topic   string
+	qos     byte
+	payload []byte
+	sent    bool
+}
+
+// Compile-time checks that Publisher implements interfaces.LogPublisher,
+// interfaces.HealthChecker and interfaces.ErrorReporter.
+var (
+	_ interfaces.LogPublisher  = (*Publisher)(nil)
+	_ interfaces.HealthChecker = (*Publisher)(nil)
+	_ interfaces.ErrorReporter = (*Publisher)(nil)
+)
+
+// Publisher buffers records and publishes them from a background goroutine,
+// so a slow or disconnected broker never blocks the pipeline. A message is
+// published once even when the rest of its batch has to be retried.
+type Publisher struct {
+	cfg     Config
+	errs    *report.Handler
+	client  Client
+	topic   *strings.Replacer
+	batcher *batch.Batcher[*message]
+	offline atomic.Bool
+}
+
+func New(client Client, cfg Config) (*Publisher, error) {
+	if cfg.Topic == "" {
+		cfg.Topic = defaultTopic
+	}
+	if cfg.QoS > 2 {
+		return nil, fmt.Errorf("mqtt: invalid QoS %d", cfg.QoS)
+	}
+	for level, qos := range cfg.LevelQoS {
+		if qos > 2 {
+			return nil, fmt.Errorf("mqtt: invalid QoS %d for level %s", qos, level)
+		}
+	}
+	if strings.ContainsAny(cfg.Topic, "+#") {
+		return nil, fmt.Errorf("mqtt: topic %q contains a wildcard", cfg.Topic)
+	}
+	if cfg.Encoder == nil {
+		cfg.Encoder = encoder.NewJSON(cfg.AppID, cfg.Env)
+	}
+	if cfg.MaxBuffered <= 0 {
+		cfg.MaxBuffered = defaultMaxBuffered
+	}
+	errs := report.New(cfg.ErrorHandler)
+	cfg.ErrorHandler = errs.Handle
+	p := &Publisher{cfg: cfg, client: client, errs: errs}
+	p.batcher = batch.New(batch.Config{
+		Size:         cfg.BatchSize,
+		Interval:     cfg.FlushInterval,
+		MaxBuffered:  cfg.MaxBuffered,
+		Retries:      cfg.Retries,
+		Backoff:      cfg.Backoff,
+		ErrorHandler: p.reportFlushError,
+	}, p.publish)
+	return p, nil
+}
+
+// ReportErrorsTo implements interfaces.ErrorReporter.
+func (p *Publisher) ReportErrorsTo(report func(error)) {
+	p.errs.ReportTo(report)
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	body, err := p.cfg.Encoder.Encode(data)
+	if err != nil {
+		p.cfg.ErrorHandler(fmt.Errorf("mqtt: encode: %w", err))
+		return
+	}
+	qos := p.cfg.QoS
+	if q, ok := p.cfg.LevelQoS[data.Level]; ok {
+		qos = q
+	}
+	if err := p.batcher.Add(&message{topic: p.topicFor(data), qos: qos, payload: body}); err != nil {
+		p.cfg.ErrorHandler(fmt.Errorf("mqtt: %w", err))
+	}
+}
+
+// topicFor expands Config.Topic for data.
+func (p *Publisher) topicFor(data *models.LogData) string {
+	appID, env, component := p.cfg.AppID, p.cfg.Env, ""
+	if data.Ctx != nil {
+		if v, ok := data.Ctx.Value(models.AppID).(string); ok && v != "" {
+			appID = v
+		}
+		if v, ok := data.Ctx.Value(models.EnvName).(string); ok && v != "" {
+			env = v
+		}
+	}
+	for _, f := range data.Fields {
+		if f != nil && f.Key == models.FieldComponentKey && f.Type == models.FieldTypeString {
+			component = f.String
+		}
+	}
+	return strings.NewReplacer(
+		"{app}", level(appID),
+		"{env}", level(env),
+		"{level}", level(data.Level.String()),
+		"{component}", level(component),
+	).Replace(p.cfg.Topic)
+}
+
+// level makes s a single topic level: separators, wildcards and control
+// characters become '_' and an empty value becomes "_".
+func level(s string) string {
+	if s == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '/' || r == '+' || r == '#' || r < ' ':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// Flush publishes everything buffered so far. It fails while the client is
+// offline; the records stay buffered.
+func (p *Publisher) Flush(ctx context.Context) error {
+	return p.batcher.Flush(ctx)
+}
+
+// Close flushes and stops the background goroutine. The client is owned by
+// the caller.
+func (p *Publisher) Close() error {
+	return p.batcher.Close()
+}
+
+// Dropped returns how many records were discarded because the offline
+// buffer was full.
+func (p *Publisher) Dropped() int64 {
+	return p.batcher.Dropped()
+}
+
+// HealthCheck returns the error of the last failed delivery to the broker
+// until a batch goes through again, and an error once the publisher is
+// closed.
+func (p *Publisher) HealthCheck(ctx context.Context) error {
+	return p.batcher.Err()
+}
+
+func (p *Publisher) publish(_ context.Context, msgs []*message) error {
+	if c, ok := p.client.(interface{ IsConnectionOpen() bool }); ok && !c.IsConnectionOpen() {
+		return ErrOffline
+	}
+	for _, m := range msgs {
+		if m.sent {
+			continue
+		}
+		if err := p.client.Publish(m.topic, m.qos, m.payload); err != nil {
+			return fmt.Errorf("mqtt: publish to %q: %w", m.topic, err)
+		}
+		m.sent = true
+	}
+	p.offline.Store(false)
+	return nil
+}
+
+// reportFlushError reports ErrOffline once per outage instead of on every
+// flush interval.
+func (p *Publisher) reportFlushError(err error) {
+	if errors.Is(err, ErrOffline) && p.offline.Swap(true) {
+		return
+	}
+	p.cfg.ErrorHandler(err)
+}
@@ -0,0 +1,136 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync"
+	"testing"
+)
+
+type published struct {
+	topic string
+	qos   byte
+}
+
+type fakeClient struct {
+	mu        sync.Mutex
+	online    bool
+	failAfter int
+	msgs      []published
+}
+
+func (c *fakeClient) Publish(topic string, qos byte, _ []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failAfter == 0 {
+		c.failAfter = -1
+		return errors.New("broker busy")
+	}
+	c.failAfter--
+	c.msgs = append(c.msgs, published{topic, qos})
+	return nil
+}
+
+func (c *fakeClient) IsConnectionOpen() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.online
+}
+
+func (c *fakeClient) set(online bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.online = online
+}
+
+func record(level models.LogLevel, component string) *models.LogData {
+	return &models.LogData{
+		Ctx:    context.Background(),
+		Msg:    "m",
+		Level:  level,
+		Fields: []*models.LogField{{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: component}},
+	}
+}
+
+func TestPublisher_TopicTemplateAndQoS(t *testing.T) {
+	c := &fakeClient{online: true, failAfter: -1}
+	pub, err := New(c, Config{
+		Topic:    "site/{app}/{component}/{level}",
+		QoS:      0,
+		LevelQoS: map[models.LogLevel]byte{models.ErrorLevel: 1},
+		AppID:    "pump",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pub.SendMsg(record(models.InfoLevel, "valve/a+b"))
+	pub.SendMsg(record(models.ErrorLevel, ""))
+	if err := pub.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	want := []published{{"site/pump/valve_a_b/info", 0}, {"site/pump/_/error", 1}}
+	if len(c.msgs) != 2 || c.msgs[0] != want[0] || c.msgs[1] != want[1] {
+		t.Errorf("expected %v, got %v", want, c.msgs)
+	}
+}
+
+func TestPublisher_BuffersWhileOffline(t *testing.T) {
+	c := &fakeClient{failAfter: -1}
+	var errs []error
+	pub, err := New(c, Config{ErrorHandler: func(err error) { errs = append(errs, err) }})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer pub.Close()
+	for i := 0; i < 3; i++ {
+		pub.SendMsg(record(models.WarnLevel, "x"))
+	}
+	if err := pub.Flush(context.Background()); !errors.Is(err, ErrOffline) {
+		t.Fatalf("expected ErrOffline while disconnected, got %v", err)
+	}
+
+	c.set(true)
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.msgs) != 3 || pub.Dropped() != 0 {
+		t.Errorf("expected the buffered records published after reconnecting, got %d", len(c.msgs))
+	}
+}
+
+func TestPublisher_DoesNotRepublishAfterPartialFailure(t *testing.T) {
+	c := &fakeClient{online: true, failAfter: 2}
+	pub, err := New(c, Config{ErrorHandler: func(error) {}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer pub.Close()
+	for i := 0; i < 4; i++ {
+		pub.SendMsg(record(models.InfoLevel, "x"))
+	}
+	_ = pub.Flush(context.Background())
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.msgs) != 4 {
+		t.Errorf("expected each record published exactly once, got %d", len(c.msgs))
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	c := &fakeClient{}
+	if _, err := New(c, Config{QoS: 3}); err == nil {
+		t.Error("expected error for QoS 3")
+	}
+	if _, err := New(c, Config{Topic: "logs/#"}); err == nil {
+		t.Error("expected error for a wildcard topic")
+	}
+}
@@ -0,0 +1,120 @@
+package glog
+
+import (
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+	"sync/atomic"
+)
+
+const nameSeparator = "."
+
+// Named returns a logger whose records carry its dotted name in
+// models.FieldLoggerKey. Names nest: logger.Named("http").Named("server")
+// is named "http.server". The service can set the minimum level per name
+// prefix with WithNamedLevels or SetNamedLevel.
+func (l *Logger) Named(name string) *Logger {
+	child := l.With()
+	switch {
+	case name == "":
+	case l.name == "":
+		child.name = name
+	default:
+		child.name = l.name + nameSeparator + name
+	}
+	return child
+}
+
+// WithNamedLevels sets the minimum level per logger name prefix, e.g.
+// {"": models.InfoLevel, "http": models.WarnLevel, "http.server":
+// models.DebugLevel}. A prefix matches whole name segments, so "http"
+// covers "http.server" but not "https". The longest matching prefix wins;
+// the empty prefix applies to every logger of the service, named or not.
+func WithNamedLevels(levels map[string]models.LogLevel) ServiceOption {
+	return func(ls *LoggerService) {
+		for prefix, level := range levels {
+			ls.SetNamedLevel(prefix, level)
+		}
+	}
+}
+
+// SetNamedLevel sets the minimum level for loggers named prefix or below
+// it, and can be called while the service runs.
+func (ls *LoggerService) SetNamedLevel(prefix string, level models.LogLevel) {
+	ls.namedMu.Lock()
+	defer ls.namedMu.Unlock()
+	setPrefixLevel(&ls.namedLevels, prefix, level)
+}
+
+// ClearNamedLevel removes the override set for prefix.
+func (ls *LoggerService) ClearNamedLevel(prefix string) {
+	ls.namedMu.Lock()
+	defer ls.namedMu.Unlock()
+	clearPrefixLevel(&ls.namedLevels, prefix)
+}
+
+// prefixLevels maps dotted prefixes to minimum levels. Writers copy the
+// map under namedMu and swap it in, so readers never lock.
+type prefixLevels = atomic.Pointer[map[string]models.LogLevel]
+
+func setPrefixLevel(p *prefixLevels, prefix string, level models.LogLevel) {
+	levels := make(map[string]models.LogLevel)
+	if old := p.Load(); old != nil {
+		for k, v := range *old {
+			levels[k] = v
+		}
+	}
+	levels[prefix] = level
+	p.Store(&levels)
+}
+
+func clearPrefixLevel(p *prefixLevels, prefix string) {
+	old := p.Load()
+	if old == nil {
+		return
+	}
+	levels := make(map[string]models.LogLevel, len(*old))
+	for k, v := range *old {
+		if k != prefix {
+			levels[k] = v
+		}
+	}
+	p.Store(&levels)
+}
+
+// matchPrefix returns the level of the longest prefix of name, in whole
+// segments, that has one; "" matches every name.
+func matchPrefix(levels map[string]models.LogLevel, name string) (models.LogLevel, bool) {
+	for {
+		if min, ok := levels[name]; ok {
+			return min, true
+		}
+		if name == "" {
+			return 0, false
+		}
+		if i := strings.LastIndex(name, nameSeparator); i >= 0 {
+			name = name[:i]
+		} else {
+			name = ""
+		}
+	}
+}
+
+// enabled reports whether the named level overrides let level through for
+// this logger.
+func (l *Logger) enabled(level models.LogLevel) bool {
+	if l.svc == nil {
+		return true
+	}
+	levels := l.svc.namedLevels.Load()
+	if levels == nil || len(*levels) == 0 {
+		return true
+	}
+	if min, ok := matchPrefix(*levels, l.name); ok {
+		return level >= min
+	}
+	return true
+}
+
+func (l *Logger) nameField() *models.LogField {
+	return &models.LogField{Key: models.FieldLoggerKey, Type: models.FieldTypeString, String: l.name}
+}
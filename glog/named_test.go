@@ -0,0 +1,78 @@
+package glog
+
+import (
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+	"time"
+)
+
+func loggerName(data *models.LogData) string {
+	for _, f := range data.Fields {
+		if f.Key == models.FieldLoggerKey {
+			return f.String
+		}
+	}
+	return ""
+}
+
+func TestLogger_Named(t *testing.T) {
+	logger, mock, service := setupTestLogger()
+	defer service.Stop()
+
+	ctx := context.Background()
+	server := logger.Named("http").Named("server")
+	server.Info(ctx, "listening")
+	server.Named("").Error(ctx, fmt.Errorf("accept failed"))
+	logger.Info(ctx, "root")
+	logs := waitForLogs(mock, 3, time.Second)
+	if len(logs) != 3 {
+		t.Fatalf("expected 3 logs, got %d", len(logs))
+	}
+
+	got := byMsg(logs)
+	for msg, want := range map[string]string{"listening": "http.server", "accept failed": "http.server", "root": ""} {
+		if got[msg] == nil || loggerName(got[msg]) != want {
+			t.Errorf("expected %q from logger %q, got %v", msg, want, got[msg])
+		}
+	}
+}
+
+func TestService_NamedLevels(t *testing.T) {
+	service := NewLoggerService(WithNamedLevels(map[string]models.LogLevel{
+		"":            models.WarnLevel,
+		"http":        models.ErrorLevel,
+		"http.server": models.DebugLevel,
+	}))
+	mock := &mockPublisher{}
+	service.AddLogger("mock", mock)
+	service.Start()
+	defer service.Stop()
+
+	ctx := context.Background()
+	root := service.NewLogger()
+	root.Info(ctx, "root info")
+	root.Warning(ctx, "root warning")
+	root.Named("http").Warning(ctx, "http warning")
+	root.Named("http").Named("client").Error(ctx, fmt.Errorf("client error"))
+	root.Named("http").Named("server").Debug(ctx, "server debug")
+	root.Named("https").Info(ctx, "https info")
+
+	service.SetNamedLevel("https", models.InfoLevel)
+	root.Named("https").Info(ctx, "https info again")
+	service.ClearNamedLevel("http.server")
+	root.Named("http.server").Warning(ctx, "server warning")
+
+	logs := waitForLogs(mock, 4, time.Second)
+	want := []string{"root warning", "client error", "server debug", "https info again"}
+	if len(logs) != len(want) {
+		t.Fatalf("expected %d logs, got %d", len(want), len(logs))
+	}
+	got := byMsg(logs)
+	for _, msg := range want {
+		if got[msg] == nil {
+			t.Errorf("expected %q to be logged, got %v", msg, got)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+// Package nats publishes records to NATS subjects derived from app, env and
+// level, either with core NATS or with JetStream for persistence. It does not
+// depend on a NATS client: wrap *nats.Conn or a JetStream context in Conn or
+// JetStream.
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/internal/report"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	defaultSubjectPrefix = "logs"
+	defaultMaxPending    = 1000
+)
+
+var ErrTooManyPending = errors.New("nats: too many unacknowledged messages")
+
+// Conn is the part of *nats.Conn used for core NATS. If it also has
+// FlushWithContext(context.Context) error, as *nats.Conn does, Flush calls it.
+type Conn interface {
+	Publish(subject string, data []byte) error
+}
+
+// JetStream publishes asynchronously and reports the acknowledgement (nil)
+// or failure to ack exactly once. An adapter for nats.JetStreamContext:
+//
+//	func (a adapter) PublishAsync(subject string, data []byte, ack func(error)) error {
+//		f, err := a.js.PublishAsync(subject, data)
+//		if err != nil {
+//			return err
+//		}
+//		go func() {
+//			select {
+//			case <-f.Ok():
+//				ack(nil)
+//			case err := <-f.Err():
+//				ack(err)
+//			}
+//		}()
+//		return nil
+//	}
+type JetStream interface {
+	PublishAsync(subject string, data []byte, ack func(error)) error
+}
+
+// Config configures a Publisher.
+type Config struct {
+	// SubjectPrefix starts every subject (default "logs"); records go to
+	// <prefix>.<app>.<env>.<level>.
+	SubjectPrefix string
+	// Subject overrides the subject of each record.
+	Subject func(*models.LogData) string
+	// AppID and Env are used when the record context has no models.AppID or
+	// models.EnvName.
+	AppID string
+	Env   string
+	// Encoder defaults to encoder.NewJSON(AppID, Env).
+	Encoder interfaces.Encoder
+	// MaxPending bounds JetStream messages awaiting an ack; further records
+	// are dropped. Default 1000.
+	MaxPending int
+	// ErrorHandler receives publish and ack errors. Once the publisher is
+	// added to a service, the service error handler receives them too. Without
+	// either, they are printed.
+	ErrorHandler func(error)
+}
+
+// Compile-time checks that Publisher implements interfaces.LogPublisher,
+// interfaces.HealthChecker and interfaces.ErrorReporter.
+var (
+	_ interfaces.LogPublisher  = (*Publisher)(nil)
+	_ interfaces.HealthChecker = (*Publisher)(nil)
+	_ interfaces.ErrorReporter = (*Publisher)(nil)
+)
+
+// Publisher sends each record as one NATS message.
+type Publisher struct {
+	cfg  Config
+	errs *report.Handler
+	conn Conn
+	js   JetStream
+
+	mu      sync.Mutex
+	pending int
+	idle    *sync.Cond
+	lastErr error
+	dropped atomic.Int64
+	failed  atomic.Int64
+}
+
+// New publishes with core NATS (at most once).
+func New(conn Conn, cfg Config) *Publisher {
+	return newPublisher(cfg, conn, nil)
+}
+
+// NewJetStream publishes to JetStream asynchronously; Flush waits for the
+// outstanding acks.
+func NewJetStream(js JetStream, cfg Config) *Publisher {
+	return newPublisher(cfg, nil, js)
+}
+
+func newPublisher(cfg Config, conn Conn, js JetStream) *Publisher {
+	if cfg.SubjectPrefix == "" {
+		cfg.SubjectPrefix = defaultSubjectPrefix
+	}
+	if cfg.Encoder == nil {
+		cfg.Encoder = encoder.NewJSON(cfg.AppID, cfg.Env)
+	}
+	if cfg.MaxPending <= 0 {
+		cfg.MaxPending = defaultMaxPending
+	}
+	errs := report.New(cfg.ErrorHandler)
+	cfg.ErrorHandler = errs.Handle
+	p := &Publisher{cfg: cfg, conn: conn, js: js, errs: errs}
+	p.idle = sync.NewCond(&p.mu)
+	return p
+}
+
+// ReportErrorsTo implements interfaces.ErrorReporter.
+func (p *Publisher) ReportErrorsTo(report func(error)) {
+	p.errs.ReportTo(report)
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	body, err := p.cfg.Encoder.Encode(data)
+	if err != nil {
+		p.cfg.ErrorHandler(fmt.Errorf("nats: encode: %w", err))
+		return
+	}
+	subject := p.subject(data)
+	if p.js == nil {
+		err := p.conn.Publish(subject, body)
+		p.setErr(err)
+		if err != nil {
+			p.failed.Add(1)
+			p.cfg.ErrorHandler(fmt.Errorf("nats: publish to %q: %w", subject, err))
+		}
+		return
+	}
+
+	p.mu.Lock()
+	if p.pending >= p.cfg.MaxPending {
+		p.mu.Unlock()
+		p.dropped.Add(1)
+		p.cfg.ErrorHandler(ErrTooManyPending)
+		return
+	}
+	p.pending++
+	p.mu.Unlock()
+
+	var once sync.Once
+	ack := func(err error) {
+		once.Do(func() {
+			p.setErr(err)
+			if err != nil {
+				p.failed.Add(1)
+				p.cfg.ErrorHandler(fmt.Errorf("nats: jetstream ack for %q: %w", subject, err))
+			}
+			p.mu.Lock()
+			p.pending--
+			if p.pending == 0 {
+				p.idle.Broadcast()
+			}
+			p.mu.Unlock()
+		})
+	}
+	if err := p.js.PublishAsync(subject, body, ack); err != nil {
+		ack(err)
+	}
+}
+
+// subject returns <prefix>.<app>.<env>.<level>, unless Config.Subject is set.
+func (p *Publisher) subject(data *models.LogData) string {
+	if p.cfg.Subject != nil {
+		return p.cfg.Subject(data)
+	}
+	appID, env := p.cfg.AppID, p.cfg.Env
+	if data.Ctx != nil {
+		if v, ok := data.Ctx.Value(models.AppID).(string); ok && v != "" {
+			appID = v
+		}
+		if v, ok := data.Ctx.Value(models.EnvName).(string); ok && v != "" {
+			env = v
+		}
+	}
+	return strings.Join([]string{p.cfg.SubjectPrefix, token(appID), token(env), token(data.Level.String())}, ".")
+}
+
+// token makes s a single subject token: separators, wildcards and
+// whitespace become '_' and an empty value becomes "_".
+func token(s string) string {
+	if s == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '.' || r == '*' || r == '>' || r <= ' ':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// Flush waits until every JetStream message is acknowledged, or flushes the
+// core NATS connection when it supports it.
+func (p *Publisher) Flush(ctx context.Context) error {
+	if p.js == nil {
+		if f, ok := p.conn.(interface{ FlushWithContext(context.Context) error }); ok {
+			return f.FlushWithContext(ctx)
+		}
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.mu.Lock()
+		for p.pending > 0 && ctx.Err() == nil {
+			p.idle.Wait()
+		}
+		p.mu.Unlock()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		pending := p.pending
+		p.idle.Broadcast()
+		p.mu.Unlock()
+		return fmt.Errorf("nats: %d messages still unacknowledged: %w", pending, ctx.Err())
+	}
+}
+
+// setErr records the outcome of the latest publish or ack for HealthCheck.
+func (p *Publisher) setErr(err error) {
+	p.mu.Lock()
+	p.lastErr = err
+	p.mu.Unlock()
+}
+
+// HealthCheck fails while a core NATS connection reports that it is not
+// connected, and otherwise returns the error of the last failed publish or
+// JetStream ack until a later one succeeds.
+func (p *Publisher) HealthCheck(ctx context.Context) error {
+	if c, ok := p.conn.(interface{ IsConnected() bool }); ok && !c.IsConnected() {
+		return errors.New("nats: not connected")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastErr
+}
+
+// Dropped returns how many records were discarded because MaxPending was
+// reached.
+func (p *Publisher) Dropped() int64 {
+	return p.dropped.Load()
+}
+
+// Failed returns how many publishes or JetStream acks failed.
+func (p *Publisher) Failed() int64 {
+	return p.failed.Load()
+}
@@ -0,0 +1,152 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeConn struct {
+	mu       sync.Mutex
+	subjects []string
+	flushed  bool
+}
+
+func (c *fakeConn) Publish(subject string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subjects = append(c.subjects, subject)
+	return nil
+}
+
+func (c *fakeConn) FlushWithContext(context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flushed = true
+	return nil
+}
+
+type fakeJetStream struct {
+	mu   sync.Mutex
+	acks []func(error)
+}
+
+func (j *fakeJetStream) PublishAsync(subject string, data []byte, ack func(error)) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.acks = append(j.acks, ack)
+	return nil
+}
+
+func (j *fakeJetStream) ackAll(err error) {
+	j.mu.Lock()
+	acks := j.acks
+	j.acks = nil
+	j.mu.Unlock()
+	for _, ack := range acks {
+		ack(err)
+	}
+}
+
+func TestPublisher_SubjectFromAppEnvLevel(t *testing.T) {
+	conn := &fakeConn{}
+	pub := New(conn, Config{AppID: "shop", Env: "prod"})
+	ctx := context.WithValue(context.Background(), models.EnvName, "eu.west")
+	pub.SendMsg(&models.LogData{Ctx: ctx, Msg: "hello", Level: models.WarnLevel})
+	pub.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "hello", Level: models.ErrorLevel})
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(conn.subjects) != 2 || conn.subjects[0] != "logs.shop.eu_west.warn" || conn.subjects[1] != "logs.shop.prod.error" {
+		t.Errorf("unexpected subjects %v", conn.subjects)
+	}
+	if !conn.flushed {
+		t.Error("expected Flush to flush the connection")
+	}
+}
+
+func TestPublisher_JetStreamFlushWaitsForAcks(t *testing.T) {
+	js := &fakeJetStream{}
+	var errs []error
+	var mu sync.Mutex
+	pub := NewJetStream(js, Config{AppID: "shop", Env: "prod", MaxPending: 2, ErrorHandler: func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}})
+	for i := 0; i < 3; i++ {
+		pub.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "persist me", Level: models.InfoLevel})
+	}
+	if pub.Dropped() != 1 {
+		t.Errorf("expected the third record dropped at MaxPending, got %d", pub.Dropped())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := pub.Flush(ctx); err == nil {
+		t.Error("expected Flush to time out while acks are outstanding")
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		js.ackAll(errors.New("no responders"))
+	}()
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pub.Failed() != 2 {
+		t.Errorf("expected 2 failed acks, got %d", pub.Failed())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 3 {
+		t.Errorf("expected the drop and both failures reported, got %v", errs)
+	}
+}
+
+type failingConn struct {
+	fakeConn
+	err       error
+	connected bool
+}
+
+func (c *failingConn) Publish(subject string, data []byte) error {
+	if c.err != nil {
+		return c.err
+	}
+	return c.fakeConn.Publish(subject, data)
+}
+
+func (c *failingConn) IsConnected() bool { return c.connected }
+
+func TestPublisher_HealthCheck(t *testing.T) {
+	conn := &failingConn{err: errors.New("no responders"), connected: true}
+	pub := New(conn, Config{ErrorHandler: func(error) {}})
+	ctx := context.Background()
+
+	pub.SendMsg(&models.LogData{Ctx: ctx, Msg: "x"})
+	if err := pub.HealthCheck(ctx); err == nil {
+		t.Error("expected unhealthy after a failed publish")
+	}
+	conn.err = nil
+	pub.SendMsg(&models.LogData{Ctx: ctx, Msg: "y"})
+	if err := pub.HealthCheck(ctx); err != nil {
+		t.Errorf("expected healthy after a successful publish, got %v", err)
+	}
+	conn.connected = false
+	if err := pub.HealthCheck(ctx); err == nil {
+		t.Error("expected unhealthy while disconnected")
+	}
+
+	js := &fakeJetStream{}
+	jsPub := NewJetStream(js, Config{ErrorHandler: func(error) {}})
+	jsPub.SendMsg(&models.LogData{Ctx: ctx, Msg: "z"})
+	js.ackAll(errors.New("timeout"))
+	if err := jsPub.HealthCheck(ctx); err == nil {
+		t.Error("expected unhealthy after a failed ack")
+	}
+}
@@ -0,0 +1,45 @@
+package glog
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+)
+
+// normalize gives every publisher the same view of edge inputs, whether the
+// record came from a Logger or was written to GetInputChan by a bridge:
+//
+//   - a nil Ctx becomes context.Background();
+//   - nil entries inside Fields are removed (a nil or empty Fields slice is
+//     passed on as is);
+//   - an empty Msg is passed on as is;
+//   - levels below DebugLevel become DebugLevel and levels above FatalLevel
+//     become ErrorLevel, so an unknown value never makes a backend panic or
+//     exit.
+//
+// A Fields slice with nil entries is replaced rather than compacted in place
+// because the sender may still hold it.
+func normalize(logData *models.LogData) {
+	if logData.Ctx == nil {
+		logData.Ctx = context.Background()
+	}
+	switch {
+	case logData.Level < models.DebugLevel:
+		logData.Level = models.DebugLevel
+	case logData.Level > models.FatalLevel:
+		logData.Level = models.ErrorLevel
+	}
+	for i, f := range logData.Fields {
+		if f != nil {
+			continue
+		}
+		fields := make([]*models.LogField, i, len(logData.Fields)-1)
+		copy(fields, logData.Fields[:i])
+		for _, rest := range logData.Fields[i+1:] {
+			if rest != nil {
+				fields = append(fields, rest)
+			}
+		}
+		logData.Fields = fields
+		return
+	}
+}
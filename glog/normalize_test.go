@@ -0,0 +1,48 @@
+package glog
+
+import (
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+	"time"
+)
+
+func TestPipeline_NormalizesEdgeInputs(t *testing.T) {
+	ls := NewLoggerService()
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+
+	valid := &models.LogField{Key: "k", Type: models.FieldTypeString, String: "v"}
+	senderFields := []*models.LogField{nil, valid, nil}
+	in := ls.GetInputChan()
+	in <- &models.LogData{Msg: "", Level: models.LogLevel(42), Fields: senderFields}
+	in <- &models.LogData{Msg: "below debug", Level: models.LogLevel(-5)}
+	in <- nil
+
+	logs := waitForLogs(mock, 2, 2*time.Second)
+	ls.Stop()
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(logs))
+	}
+	for _, log := range logs {
+		if log.Ctx == nil {
+			t.Error("expected nil Ctx to be replaced")
+		}
+		switch log.Msg {
+		case "":
+			if log.Level != models.ErrorLevel {
+				t.Errorf("expected unknown high level to become error, got %v", log.Level)
+			}
+			if len(log.Fields) != 1 || log.Fields[0] != valid {
+				t.Errorf("expected nil entries removed, got %+v", log.Fields)
+			}
+		case "below debug":
+			if log.Level != models.DebugLevel || log.Fields != nil {
+				t.Errorf("unexpected record %+v", log)
+			}
+		}
+	}
+	if senderFields[0] != nil || len(senderFields) != 3 {
+		t.Error("the sender's Fields slice must not be modified")
+	}
+}
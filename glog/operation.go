@@ -0,0 +1,115 @@
+package glog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	FieldOpNameKey     = "op_name"
+	FieldOpIDKey       = "op_id"
+	FieldOpParentKey   = "op_parent_id"
+	FieldOpDurationKey = "duration_ms"
+	FieldOpOutcomeKey  = "outcome"
+
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+type operationKey struct{}
+
+// Operation is a lightweight span for batch jobs and background work: Begin
+// emits a Debug start record, End emits the end record with duration and
+// outcome. Operations started from Operation.Context are linked to their
+// parent through FieldOpParentKey.
+type Operation struct {
+	logger   *Logger
+	ctx      context.Context
+	name     string
+	id       string
+	parentID string
+	start    time.Time
+	options  []models.Option
+	ended    atomic.Bool
+}
+
+// Begin starts an operation. Options are attached to both records.
+func (l *Logger) Begin(ctx context.Context, name string, options ...models.Option) *Operation {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	op := &Operation{
+		logger:  l,
+		name:    name,
+		id:      newOperationID(),
+		start:   time.Now(),
+		options: options,
+	}
+	if parent, ok := ctx.Value(operationKey{}).(*Operation); ok {
+		op.parentID = parent.id
+	}
+	op.ctx = context.WithValue(ctx, operationKey{}, op)
+
+	l.Debug(op.ctx, name+" started", op.fields()...)
+	return op
+}
+
+// Context returns a context carrying the operation, to be passed to nested
+// work so child operations and records can be correlated.
+func (op *Operation) Context() context.Context {
+	return op.ctx
+}
+
+func (op *Operation) ID() string {
+	return op.id
+}
+
+// End emits the end record: InfoLevel on success, ErrorLevel with the error
+// attached when err is non-nil. Only the first call has an effect, so it is
+// safe to defer End and also call it explicitly.
+func (op *Operation) End(err error, options ...models.Option) {
+	if !op.ended.CompareAndSwap(false, true) {
+		return
+	}
+	elapsed := time.Since(op.start)
+	opts := append(op.fields(), options...)
+	opts = append(opts, durationField(elapsed))
+
+	if err != nil {
+		opts = append(opts,
+			models.WithStringField(FieldOpOutcomeKey, OutcomeFailure),
+			models.WithStringField(models.FieldErrKey, err.Error()))
+		op.logger.logMsg(op.ctx, models.ErrorLevel, op.name+" failed", opts...)
+		return
+	}
+	opts = append(opts, models.WithStringField(FieldOpOutcomeKey, OutcomeSuccess))
+	op.logger.logMsg(op.ctx, models.InfoLevel, op.name+" finished", opts...)
+}
+
+func (op *Operation) fields() []models.Option {
+	opts := make([]models.Option, 0, len(op.options)+3)
+	opts = append(opts, op.options...)
+	opts = append(opts,
+		models.WithStringField(FieldOpNameKey, op.name),
+		models.WithStringField(FieldOpIDKey, op.id))
+	if op.parentID != "" {
+		opts = append(opts, models.WithStringField(FieldOpParentKey, op.parentID))
+	}
+	return opts
+}
+
+func newOperationID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// durationField is the duration_ms field of operations and canonical lines,
+// always a float of milliseconds.
+func durationField(d time.Duration) models.Option {
+	return models.WithFloatField(FieldOpDurationKey, float64(d)/float64(time.Millisecond))
+}
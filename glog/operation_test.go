@@ -0,0 +1,92 @@
+package glog
+
+import (
+	"context"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+	"time"
+)
+
+func fieldByKey(data *models.LogData, key string) *models.LogField {
+	for _, f := range data.Fields {
+		if f.Key == key {
+			return f
+		}
+	}
+	return nil
+}
+
+func TestOperation_StartAndEnd(t *testing.T) {
+	logger, mock, service := setupTestLogger()
+	defer service.Stop()
+
+	op := logger.Begin(context.Background(), "sync_users", models.WithComponent("jobs"))
+	op.End(nil)
+	op.End(errors.New("ignored second end"))
+
+	waitForLogs(mock, 2, time.Second)
+	time.Sleep(20 * time.Millisecond)
+	logs := mock.GetLogs()
+	if len(logs) != 2 {
+		t.Fatalf("expected start and end records, got %d", len(logs))
+	}
+
+	var start, end *models.LogData
+	for _, l := range logs {
+		switch l.Msg {
+		case "sync_users started":
+			start = l
+		case "sync_users finished":
+			end = l
+		}
+	}
+	if start == nil || end == nil {
+		t.Fatalf("unexpected records: %q, %q", logs[0].Msg, logs[1].Msg)
+	}
+	if start.Level != models.DebugLevel || end.Level != models.InfoLevel {
+		t.Errorf("unexpected levels: start %v end %v", start.Level, end.Level)
+	}
+	if fieldByKey(start, FieldOpIDKey).String != op.ID() || fieldByKey(end, FieldOpIDKey).String != op.ID() {
+		t.Error("expected both records to carry the operation id")
+	}
+	if f := fieldByKey(end, FieldOpOutcomeKey); f == nil || f.String != OutcomeSuccess {
+		t.Error("expected success outcome")
+	}
+	if fieldByKey(end, FieldOpDurationKey) == nil {
+		t.Error("expected duration field")
+	}
+	if componentOf(end) != "jobs" {
+		t.Errorf("expected options on end record, got component %q", componentOf(end))
+	}
+}
+
+func TestOperation_NestedFailure(t *testing.T) {
+	logger, mock, service := setupTestLogger()
+	defer service.Stop()
+
+	parent := logger.Begin(context.Background(), "import")
+	child := logger.Begin(parent.Context(), "import_batch")
+	child.End(errors.New("bad row"))
+	parent.End(nil)
+
+	logs := waitForLogs(mock, 4, time.Second)
+	var failed *models.LogData
+	for _, l := range logs {
+		if l.Msg == "import_batch failed" {
+			failed = l
+		}
+	}
+	if failed == nil {
+		t.Fatal("expected failure record for child operation")
+	}
+	if failed.Level != models.ErrorLevel {
+		t.Errorf("expected ErrorLevel, got %v", failed.Level)
+	}
+	if f := fieldByKey(failed, FieldOpParentKey); f == nil || f.String != parent.ID() {
+		t.Error("expected child to link to parent operation")
+	}
+	if f := fieldByKey(failed, models.FieldErrKey); f == nil || f.String != "bad row" {
+		t.Error("expected error field on failure record")
+	}
+}
@@ -0,0 +1,75 @@
+package glog
+
+import (
+	"bytes"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+)
+
+// bareSeq numbers records of loggers created with NewLogger from a bare
+// channel, which have no service counter.
+var bareSeq atomic.Uint64
+
+// WithGoroutineIDs stamps models.LogData.Goroutine with the ID of the
+// goroutine that made the logger call. Reading the ID costs about a
+// microsecond per record, so it is off by default.
+func WithGoroutineIDs() ServiceOption {
+	return func(ls *LoggerService) {
+		ls.goroutineIDs = true
+	}
+}
+
+// WithOrderingFields writes the sequence number, and the goroutine ID with
+// WithGoroutineIDs, as the models.FieldSeqKey and models.FieldGoroutineKey
+// fields of every record, so that publishers which only write fields still
+// let consumers restore the call order.
+func WithOrderingFields() ServiceOption {
+	return func(ls *LoggerService) {
+		ls.orderingFields = true
+	}
+}
+
+// stampOrder sets the sequence number of a record entering the pipeline
+// and, if enabled, the calling goroutine. A forwarded record gets a new
+// sequence number from the receiving service but keeps its goroutine.
+func (l *Logger) stampOrder(logData *models.LogData) {
+	if l.svc == nil {
+		logData.Seq = bareSeq.Add(1)
+		return
+	}
+	logData.Seq = l.svc.seq.Add(1)
+	if l.svc.goroutineIDs && logData.Goroutine == 0 {
+		logData.Goroutine = goroutineID()
+	}
+}
+
+// addOrderingFields runs in processLogData. Like fillBridgeDefaults it
+// replaces Fields instead of appending to it, because the caller may still
+// hold the slice.
+func (ls *LoggerService) addOrderingFields(logData *models.LogData) {
+	if !ls.orderingFields || logData.Seq == 0 {
+		return
+	}
+	fields := make([]*models.LogField, len(logData.Fields), len(logData.Fields)+2)
+	copy(fields, logData.Fields)
+	fields = append(fields, &models.LogField{Key: models.FieldSeqKey, Type: models.FieldTypeUint64, Uint64: logData.Seq})
+	if logData.Goroutine != 0 {
+		fields = append(fields, &models.LogField{Key: models.FieldGoroutineKey, Type: models.FieldTypeUint64, Uint64: logData.Goroutine})
+	}
+	logData.Fields = fields
+}
+
+// goroutineID parses the ID from the first line of the goroutine's stack,
+// "goroutine 42 [running]:".
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
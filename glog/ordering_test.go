@@ -0,0 +1,79 @@
+package glog
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLogger_SequenceNumbers(t *testing.T) {
+	logger, mock, service := setupTestLogger()
+	defer service.Stop()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				logger.Info(context.Background(), "tick")
+			}
+		}()
+	}
+	wg.Wait()
+	logs := waitForLogs(mock, 100, time.Second)
+	if len(logs) != 100 {
+		t.Fatalf("expected 100 logs, got %d", len(logs))
+	}
+
+	seqs := make([]int, len(logs))
+	for i, data := range logs {
+		seqs[i] = int(data.Seq)
+		if data.Goroutine != 0 {
+			t.Errorf("expected no goroutine ID without WithGoroutineIDs, got %d", data.Goroutine)
+		}
+	}
+	sort.Ints(seqs)
+	for i, seq := range seqs {
+		if seq != i+1 {
+			t.Fatalf("expected sequence numbers 1..100 without gaps, got %v", seqs)
+		}
+	}
+}
+
+func TestService_GoroutineIDsAndOrderingFields(t *testing.T) {
+	service := NewLoggerService(WithGoroutineIDs(), WithOrderingFields())
+	mock := &mockPublisher{}
+	service.AddLogger("mock", mock)
+	service.Start()
+	defer service.Stop()
+
+	logger := service.NewLogger()
+	logger.Info(context.Background(), "main")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		logger.Info(context.Background(), "other")
+	}()
+	<-done
+	logs := waitForLogs(mock, 2, time.Second)
+	if len(logs) != 2 {
+		t.Fatalf("expected 2 logs, got %d", len(logs))
+	}
+
+	got := byMsg(logs)
+	first, second := got["main"], got["other"]
+	if first == nil || second == nil || first.Goroutine == 0 || second.Goroutine == 0 || first.Goroutine == second.Goroutine {
+		t.Fatalf("expected distinct goroutine IDs, got %+v and %+v", first, second)
+	}
+	fields := map[string]uint64{}
+	for _, f := range first.Fields {
+		fields[f.Key] = f.Uint64
+	}
+	if fields[models.FieldSeqKey] != first.Seq || fields[models.FieldGoroutineKey] != first.Goroutine {
+		t.Errorf("expected seq and goroutine fields, got %v", fields)
+	}
+}
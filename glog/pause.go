@@ -0,0 +1,160 @@
+package glog
+
+import (
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync"
+	"time"
+)
+
+// pauseState is the maintenance window of one publisher. It is guarded by
+// its own mutex because PausePublisher and ResumePublisher run outside the
+// main worker.
+type pauseState struct {
+	mu          sync.Mutex
+	until       time.Time
+	bufferLimit int
+	buffer      []*models.LogData
+	dropped     int64
+}
+
+// PauseOption configures PausePublisher.
+type PauseOption func(*pauseState)
+
+// WithPauseBuffer keeps up to limit records in memory while the publisher is
+// paused and replays them on resume. Records beyond the limit are dropped.
+// Without it, traffic for a paused publisher is dropped.
+func WithPauseBuffer(limit int) PauseOption {
+	return func(p *pauseState) {
+		if limit > 0 {
+			p.bufferLimit = limit
+		}
+	}
+}
+
+// PausePublisher stops sending records to the publisher registered as
+// loggerID until the given time or until ResumePublisher is called, so a
+// backend under planned maintenance costs nothing. Pausing a paused
+// publisher replaces its window and keeps its buffer.
+func (ls *LoggerService) PausePublisher(loggerID string, until time.Time, opts ...PauseOption) error {
+	ls.mutex.RLock()
+	entry, ok := ls.loggers[loggerID]
+	ls.mutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("glogger: unknown publisher %q", loggerID)
+	}
+
+	state := &pauseState{until: until}
+	for _, opt := range opts {
+		opt(state)
+	}
+
+	entry.pauseMu.Lock()
+	defer entry.pauseMu.Unlock()
+	if entry.pause != nil {
+		state.buffer = entry.pause.buffer
+		state.dropped = entry.pause.dropped
+	}
+	entry.pause = state
+	return nil
+}
+
+// ResumePublisher ends the pause of loggerID and returns once the buffered
+// records have been delivered. The pause ends in queue order: records logged
+// before the call are buffered, and the buffer is delivered through the
+// workers before any record logged after it. It returns the number of
+// records dropped during the pause; on a stopped service, the records still
+// buffered count as dropped.
+func (ls *LoggerService) ResumePublisher(loggerID string) (dropped int64, err error) {
+	ls.mutex.RLock()
+	_, ok := ls.loggers[loggerID]
+	ls.mutex.RUnlock()
+	if !ok {
+		return 0, fmt.Errorf("glogger: unknown publisher %q", loggerID)
+	}
+
+	if ls.started.Load() {
+		done := make(chan int64, 1)
+		marker := resumeMarker{Context: context.Background(), loggerID: loggerID, done: done}
+		if ls.sendMarker(context.Background(), &models.LogData{Ctx: marker}) {
+			return <-done, nil
+		}
+	}
+
+	// Nothing is routed before Start or after Stop, so the buffer cannot be
+	// replayed.
+	_, state := ls.endPause(loggerID)
+	if state == nil {
+		return 0, nil
+	}
+	return state.dropped + int64(len(state.buffer)), nil
+}
+
+// resumeMarker is the context of the placeholder record ResumePublisher
+// queues. The main worker replays the buffer of loggerID when it reaches
+// the marker and sends the dropped count on done once the replay has been
+// delivered.
+type resumeMarker struct {
+	context.Context
+	loggerID string
+	done     chan int64
+}
+
+// resume ends the pause of m.loggerID from the main worker and dispatches the
+// buffered records as jobs.
+func (ls *LoggerService) resume(m resumeMarker) {
+	entry, state := ls.endPause(m.loggerID)
+	if state == nil {
+		m.done <- 0
+		return
+	}
+	jobs := make([]sendJob, 0, len(state.buffer))
+	for _, logData := range state.buffer {
+		jobs = append(jobs, entry.job(m.loggerID, logData))
+	}
+	ls.dispatch(jobs)
+	ls.inflight.Wait()
+	m.done <- state.dropped
+}
+
+// endPause clears the pause of the publisher currently registered as
+// loggerID and returns its state, or nil if it was not paused.
+func (ls *LoggerService) endPause(loggerID string) (*publisherEntry, *pauseState) {
+	ls.mutex.RLock()
+	entry, ok := ls.loggers[loggerID]
+	ls.mutex.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+	entry.pauseMu.Lock()
+	defer entry.pauseMu.Unlock()
+	state := entry.pause
+	entry.pause = nil
+	return entry, state
+}
+
+// pausedRecords is called by the main worker for every record. It reports
+// whether the entry is paused, buffering or dropping logData if so; a
+// record the entry does not accept is never buffered. Records buffered
+// during a window that has expired are returned for replay.
+func (e *publisherEntry) pausedRecords(logData *models.LogData, accepted bool, now time.Time) (paused bool, replay []*models.LogData) {
+	e.pauseMu.Lock()
+	defer e.pauseMu.Unlock()
+	state := e.pause
+	if state == nil {
+		return false, nil
+	}
+	if !now.Before(state.until) {
+		e.pause = nil
+		return false, state.buffer
+	}
+	switch {
+	case !accepted:
+	case len(state.buffer) < state.bufferLimit:
+		state.buffer = append(state.buffer, logData)
+	default:
+		state.dropped++
+	}
+	return true, nil
+}
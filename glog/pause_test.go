@@ -0,0 +1,137 @@
+package glog
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPausePublisher_BufferAndResume(t *testing.T) {
+	ls := NewLoggerService(WithBlockingSend(), WithNumWorkers(1))
+	paused, active := &mockPublisher{}, &mockPublisher{}
+	ls.AddLogger("paused", paused)
+	ls.AddLogger("active", active)
+	ls.Start()
+	logger := ls.NewLogger()
+
+	if err := ls.PausePublisher("paused", time.Now().Add(time.Hour), WithPauseBuffer(2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		logger.Info(context.Background(), "during maintenance")
+	}
+	if got := len(waitForLogs(active, 3, 2*time.Second)); got != 3 {
+		t.Fatalf("expected active publisher to receive 3 records, got %d", got)
+	}
+	if got := len(paused.GetLogs()); got != 0 {
+		t.Fatalf("expected paused publisher to receive nothing, got %d", got)
+	}
+
+	dropped, err := ls.ResumePublisher("paused")
+	if err != nil || dropped != 1 {
+		t.Errorf("expected 1 dropped record, got %d (%v)", dropped, err)
+	}
+	if got := len(paused.GetLogs()); got != 2 {
+		t.Errorf("expected 2 replayed records, got %d", got)
+	}
+
+	logger.Info(context.Background(), "after maintenance")
+	ls.Stop()
+	if got := len(paused.GetLogs()); got != 3 {
+		t.Errorf("expected resumed publisher to receive new records, got %d", got)
+	}
+}
+
+func TestPausePublisher_WindowExpires(t *testing.T) {
+	ls := NewLoggerService(WithBlockingSend())
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+	logger := ls.NewLogger()
+
+	_ = ls.PausePublisher("mock", time.Now().Add(50*time.Millisecond), WithPauseBuffer(10))
+	logger.Info(context.Background(), "buffered")
+	time.Sleep(60 * time.Millisecond)
+	logger.Info(context.Background(), "after window")
+	ls.Stop()
+
+	logs := mock.GetLogs()
+	if len(logs) != 2 {
+		t.Fatalf("expected buffered record replayed with the new one, got %d", len(logs))
+	}
+}
+
+func TestPausePublisher_UnknownID(t *testing.T) {
+	ls := NewLoggerService()
+	if err := ls.PausePublisher("missing", time.Now()); err == nil {
+		t.Error("expected error for unknown publisher")
+	}
+	if _, err := ls.ResumePublisher("missing"); err == nil {
+		t.Error("expected error for unknown publisher")
+	}
+}
+
+func TestResumePublisher_ReplaysBeforeNewRecords(t *testing.T) {
+	ls := NewLoggerService(WithBlockingSend(), WithNumWorkers(4))
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+	logger := ls.NewLogger()
+	ctx := context.Background()
+
+	_ = ls.PausePublisher("mock", time.Now().Add(time.Hour), WithPauseBuffer(100))
+	for i := 0; i < 20; i++ {
+		logger.Info(ctx, "before")
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			logger.Info(ctx, "during")
+		}
+	}()
+	dropped, err := ls.ResumePublisher("mock")
+	if err != nil || dropped != 0 {
+		t.Fatalf("expected nothing dropped, got %d (%v)", dropped, err)
+	}
+	<-done
+	for i := 0; i < 20; i++ {
+		logger.Info(ctx, "after")
+	}
+	ls.Stop()
+
+	logs := mock.GetLogs()
+	if len(logs) != 60 {
+		t.Fatalf("expected every record delivered exactly once, got %d", len(logs))
+	}
+	seenAfter := false
+	for _, l := range logs {
+		switch l.Msg {
+		case "after":
+			seenAfter = true
+		case "before":
+			if seenAfter {
+				t.Fatal("buffered records must be delivered before records logged after the resume")
+			}
+		}
+	}
+}
+
+func TestResumePublisher_AfterStop(t *testing.T) {
+	ls := NewLoggerService(WithBlockingSend())
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+
+	_ = ls.PausePublisher("mock", time.Now().Add(time.Hour), WithPauseBuffer(10))
+	ls.NewLogger().Info(context.Background(), "buffered")
+	ls.Stop()
+
+	dropped, err := ls.ResumePublisher("mock")
+	if err != nil || dropped != 1 {
+		t.Errorf("expected the undeliverable record to count as dropped, got %d (%v)", dropped, err)
+	}
+	if got := len(mock.GetLogs()); got != 0 {
+		t.Errorf("expected nothing delivered after Stop, got %d", got)
+	}
+}
@@ -0,0 +1,263 @@
+// Package postgres writes records into a PostgreSQL table with batched
+// multi-row inserts, keeping the fields as JSONB so they can be queried and
+// indexed with the usual jsonb operators.
+//
+// Like glog/sqlite, the package does not import a driver: open the database
+// with github.com/jackc/pgx/v5/stdlib, github.com/lib/pq or any other
+// database/sql driver and pass the *sql.DB to New.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/internal/batch"
+	"github.com/alexnobleburn/glogger/glog/internal/report"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTable   = "glog_records"
+	defaultRetries = 3
+	// maxParams is PostgreSQL's limit on bind parameters per statement.
+	maxParams = 65535
+)
+
+var columns = []string{"ts", "level", "component", "msg", "fields"}
+
+// tableNamePattern accepts an optionally schema-qualified identifier.
+var tableNamePattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*\.)?[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Config configures a Publisher.
+type Config struct {
+	// Table defaults to glog_records and may be schema-qualified.
+	Table string
+	// CreateTable creates the table and its indexes if they do not exist.
+	CreateTable bool
+	// BatchSize, FlushInterval and MaxBuffered control batching (defaults
+	// 500, 1s and 10000). BatchSize is capped so that one insert stays
+	// within PostgreSQL's bind parameter limit.
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxBuffered   int
+	// Retries for failed inserts (default 3), with exponential backoff
+	// starting at Backoff (default 100ms).
+	Retries int
+	Backoff time.Duration
+	// ErrorHandler receives insert errors. Once the publisher is added to a
+	// service, the service error handler receives them too. Without either,
+	// they are printed.
+	ErrorHandler func(error)
+}
+
+type row struct {
+	ts        time.Time
+	level     string
+	component string
+	msg       string
+	fields    string
+}
+
+// Compile-time checks that Publisher implements interfaces.ErrorPublisher,
+// interfaces.HealthChecker and interfaces.ErrorReporter.
+var (
+	_ interfaces.ErrorPublisher = (*Publisher)(nil)
+	_ interfaces.HealthChecker  = (*Publisher)(nil)
+	_ interfaces.ErrorReporter  = (*Publisher)(nil)
+)
+
+// Publisher buffers records and inserts them in batches, one statement per
+// batch.
+type Publisher struct {
+	db      *sql.DB
+	cfg     Config
+	errs    *report.Handler
+	batcher *batch.Batcher[*row]
+}
+
+// New validates cfg, creates the table if cfg.CreateTable is set and starts
+// the background flusher.
+func New(ctx context.Context, db *sql.DB, cfg Config) (*Publisher, error) {
+	if cfg.Table == "" {
+		cfg.Table = defaultTable
+	}
+	if !tableNamePattern.MatchString(cfg.Table) {
+		return nil, fmt.Errorf("glogger/postgres: invalid table name %q", cfg.Table)
+	}
+	if max := maxParams / len(columns); cfg.BatchSize <= 0 || cfg.BatchSize > max {
+		if cfg.BatchSize > max {
+			cfg.BatchSize = max
+		} else {
+			cfg.BatchSize = 500
+		}
+	}
+	if cfg.Retries == 0 {
+		cfg.Retries = defaultRetries
+	}
+	errs := report.New(cfg.ErrorHandler)
+	cfg.ErrorHandler = errs.Handle
+	p := &Publisher{db: db, cfg: cfg, errs: errs}
+	if cfg.CreateTable {
+		for _, stmt := range p.schema() {
+			if _, err := db.ExecContext(ctx, stmt); err != nil {
+				return nil, fmt.Errorf("glogger/postgres: create schema: %w", err)
+			}
+		}
+	}
+	p.batcher = batch.New(batch.Config{
+		Size:         cfg.BatchSize,
+		Interval:     cfg.FlushInterval,
+		MaxBuffered:  cfg.MaxBuffered,
+		Retries:      cfg.Retries,
+		Backoff:      cfg.Backoff,
+		ErrorHandler: cfg.ErrorHandler,
+	}, p.insert)
+	return p, nil
+}
+
+func (p *Publisher) schema() []string {
+	index := strings.ReplaceAll(p.cfg.Table, ".", "_")
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id BIGSERIAL PRIMARY KEY,
+	ts TIMESTAMPTZ NOT NULL,
+	level TEXT NOT NULL,
+	component TEXT NOT NULL DEFAULT '',
+	msg TEXT NOT NULL,
+	fields JSONB NOT NULL DEFAULT '{}'
+)`, p.cfg.Table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_ts_idx ON %s (ts)`, index, p.cfg.Table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_level_idx ON %s (level, ts)`, index, p.cfg.Table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_component_idx ON %s (component, ts)`, index, p.cfg.Table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_fields_idx ON %s USING GIN (fields)`, index, p.cfg.Table),
+	}
+}
+
+// ReportErrorsTo implements interfaces.ErrorReporter.
+func (p *Publisher) ReportErrorsTo(report func(error)) {
+	p.errs.ReportTo(report)
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	if err := p.TrySendMsg(data); err != nil {
+		p.cfg.ErrorHandler(err)
+	}
+}
+
+// TrySendMsg buffers data like SendMsg and returns the errors that happen
+// before the record is buffered, such as encoding errors, instead of
+// passing them to the error handler. Delivery errors are reported later,
+// from the background flush.
+func (p *Publisher) TrySendMsg(data *models.LogData) error {
+	r := &row{
+		ts:    data.TimeOr(time.Now()).UTC(),
+		level: data.Level.String(),
+		msg:   data.Msg,
+	}
+	fields := make(map[string]any, len(data.Fields))
+	for _, f := range data.Fields {
+		if f == nil {
+			continue
+		}
+		if f.Key == models.FieldComponentKey && f.Type == models.FieldTypeString {
+			r.component = f.String
+			continue
+		}
+		fields[f.Key] = fieldValue(f)
+	}
+	b, err := safejson.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("glogger/postgres: encode fields: %w", err)
+	}
+	r.fields = string(b)
+	if err := p.batcher.Add(r); err != nil {
+		return fmt.Errorf("glogger/postgres: %w", err)
+	}
+	return nil
+}
+
+// Flush inserts everything buffered so far.
+func (p *Publisher) Flush(ctx context.Context) error {
+	return p.batcher.Flush(ctx)
+}
+
+// Close flushes and stops the background goroutine. The database itself is
+// owned by the caller.
+func (p *Publisher) Close() error {
+	return p.batcher.Close()
+}
+
+// Dropped returns how many records were discarded because the buffer was
+// full.
+func (p *Publisher) Dropped() int64 {
+	return p.batcher.Dropped()
+}
+
+// HealthCheck returns the error of the last failed insert until an insert
+// succeeds again, and otherwise pings the database.
+func (p *Publisher) HealthCheck(ctx context.Context) error {
+	if err := p.batcher.Err(); err != nil {
+		return err
+	}
+	return p.db.PingContext(ctx)
+}
+
+func (p *Publisher) insert(ctx context.Context, rows []*row) error {
+	stmt, args := p.insertStatement(rows)
+	if _, err := p.db.ExecContext(ctx, stmt, args...); err != nil {
+		return fmt.Errorf("glogger/postgres: insert: %w", err)
+	}
+	return nil
+}
+
+// insertStatement builds one INSERT with a VALUES tuple per row.
+func (p *Publisher) insertStatement(rows []*row) (string, []any) {
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO ")
+	sb.WriteString(p.cfg.Table)
+	sb.WriteString(" (" + strings.Join(columns, ", ") + ") VALUES ")
+	args := make([]any, 0, len(rows)*len(columns))
+	for i, r := range rows {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		n := i * len(columns)
+		sb.WriteString("($" + strconv.Itoa(n+1) + ", $" + strconv.Itoa(n+2) + ", $" + strconv.Itoa(n+3) +
+			", $" + strconv.Itoa(n+4) + ", $" + strconv.Itoa(n+5) + "::jsonb)")
+		args = append(args, r.ts, r.level, r.component, r.msg, r.fields)
+	}
+	return sb.String(), args
+}
+
+func fieldValue(f *models.LogField) any {
+	switch f.Type {
+	case models.FieldTypeString:
+		return f.String
+	case models.FieldTypeInt:
+		return f.Integer
+	case models.FieldTypeFloat:
+		return f.Float
+	case models.FieldTypeBool:
+		return f.Bool
+	case models.FieldTypeInt64:
+		return f.Int64
+	case models.FieldTypeUint64:
+		return f.Uint64
+	case models.FieldTypeBinary:
+		return f.Binary
+	case models.FieldTypeDuration:
+		return f.Duration.String()
+	case models.FieldTypeTime:
+		return f.Time.Format(time.RFC3339Nano)
+	default:
+		b, _ := safejson.Marshal(f.Object)
+		return json.RawMessage(b)
+	}
+}
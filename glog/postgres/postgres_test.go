@@ -0,0 +1,190 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDriver records every statement executed through database/sql.
+type fakeDriver struct {
+	mu    sync.Mutex
+	execs []fakeExec
+	fail  int
+}
+
+type fakeExec struct {
+	query string
+	args  []driver.Value
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{d: c.d, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if s.d.fail > 0 {
+		s.d.fail--
+		return nil, errors.New("connection reset")
+	}
+	s.d.execs = append(s.d.execs, fakeExec{query: s.query, args: args})
+	return driver.RowsAffected(1), nil
+}
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+var registerOnce sync.Once
+var shared = &fakeDriver{}
+
+func openFake(t *testing.T) (*sql.DB, *fakeDriver) {
+	registerOnce.Do(func() { sql.Register("glogfake", shared) })
+	shared.mu.Lock()
+	shared.execs, shared.fail = nil, 0
+	shared.mu.Unlock()
+	db, err := sql.Open("glogfake", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, shared
+}
+
+func TestPublisher_BatchesRowsIntoOneInsert(t *testing.T) {
+	db, d := openFake(t)
+	pub, err := New(context.Background(), db, Config{Table: "logs.app", CreateTable: true, BatchSize: 10, Backoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		pub.SendMsg(&models.LogData{
+			Ctx:   context.Background(),
+			Msg:   "paid",
+			Level: models.WarnLevel,
+			Time:  ts,
+			Fields: []*models.LogField{
+				{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: "billing"},
+				{Key: "amount", Type: models.FieldTypeInt, Integer: 42},
+				{Key: "meta", Type: models.FieldTypeObject, Object: map[string]any{"k": "v"}},
+			},
+		})
+	}
+	if err := pub.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.execs) != 6 {
+		t.Fatalf("expected 5 schema statements and 1 insert, got %d", len(d.execs))
+	}
+	if !strings.Contains(d.execs[0].query, "CREATE TABLE IF NOT EXISTS logs.app") ||
+		!strings.Contains(d.execs[4].query, "logs_app_fields_idx ON logs.app USING GIN") {
+		t.Errorf("unexpected schema statements %q, %q", d.execs[0].query, d.execs[4].query)
+	}
+	insert := d.execs[5]
+	if !strings.HasPrefix(insert.query, "INSERT INTO logs.app (ts, level, component, msg, fields) VALUES ($1,") ||
+		!strings.HasSuffix(insert.query, "$15::jsonb)") {
+		t.Errorf("unexpected insert %q", insert.query)
+	}
+	if len(insert.args) != 15 {
+		t.Fatalf("expected 15 args, got %d", len(insert.args))
+	}
+	if got, ok := insert.args[0].(time.Time); !ok || !got.Equal(ts) {
+		t.Errorf("unexpected timestamp %v", insert.args[0])
+	}
+	if insert.args[1] != "warn" || insert.args[2] != "billing" || insert.args[3] != "paid" {
+		t.Errorf("unexpected columns %v", insert.args[:4])
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(insert.args[4].(string)), &fields); err != nil {
+		t.Fatalf("fields are not JSON: %v", err)
+	}
+	if fields["amount"] != float64(42) || fields["meta"].(map[string]any)["k"] != "v" || fields["component"] != nil {
+		t.Errorf("unexpected fields %v", fields)
+	}
+}
+
+func TestPublisher_RetriesFailedInsert(t *testing.T) {
+	db, d := openFake(t)
+	d.mu.Lock()
+	d.fail = 1
+	d.mu.Unlock()
+	pub, err := New(context.Background(), db, Config{Backoff: time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer pub.Close()
+	pub.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "hello", Level: models.InfoLevel})
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.execs) != 1 || pub.Dropped() != 0 {
+		t.Errorf("expected the insert to succeed on retry, got %d inserts, %d dropped", len(d.execs), pub.Dropped())
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	db, _ := openFake(t)
+	if _, err := New(context.Background(), db, Config{Table: "logs; DROP TABLE x"}); err == nil {
+		t.Error("expected error for invalid table name")
+	}
+	pub, err := New(context.Background(), db, Config{BatchSize: 1 << 20})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer pub.Close()
+	if pub.cfg.BatchSize*len(columns) > maxParams {
+		t.Errorf("expected batch size capped by the parameter limit, got %d", pub.cfg.BatchSize)
+	}
+}
+
+func TestPublisher_HealthCheck(t *testing.T) {
+	db, d := openFake(t)
+	d.mu.Lock()
+	d.fail = 2
+	d.mu.Unlock()
+	pub, err := New(context.Background(), db, Config{Retries: 1, Backoff: time.Millisecond, ErrorHandler: func(error) {}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer pub.Close()
+	ctx := context.Background()
+
+	pub.SendMsg(&models.LogData{Ctx: ctx, Msg: "hello", Level: models.InfoLevel})
+	_ = pub.Flush(ctx)
+	if err := pub.HealthCheck(ctx); err == nil {
+		t.Error("expected unhealthy after a failed insert")
+	}
+	if err := pub.Flush(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := pub.HealthCheck(ctx); err != nil {
+		t.Errorf("expected healthy after a successful insert, got %v", err)
+	}
+}
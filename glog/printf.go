@@ -0,0 +1,87 @@
+package glog
+
+import (
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+)
+
+// Infof logs fmt.Sprintf(format, args...) at info level. It and the other
+// printf-style methods ease migrating code written against the standard
+// library's log package or logrus. The message is only formatted once the
+// record passes section and canonical-line suppression. Arguments that are
+// models.Option values are applied as options instead of being formatted:
+//
+//	logger.Infof(ctx, "order %d placed", id, models.WithComponent("checkout"))
+func (l *Logger) Infof(ctx context.Context, format string, args ...any) {
+	l.logMsgf(ctx, models.InfoLevel, format, args)
+}
+
+func (l *Logger) Warningf(ctx context.Context, format string, args ...any) {
+	l.logMsgf(ctx, models.WarnLevel, format, args)
+}
+
+func (l *Logger) Debugf(ctx context.Context, format string, args ...any) {
+	l.logMsgf(ctx, models.DebugLevel, format, args)
+}
+
+// Errorf logs fmt.Errorf(format, args...), so %w wraps an error as it
+// does there.
+func (l *Logger) Errorf(ctx context.Context, format string, args ...any) {
+	component := func() string { return l.argsComponent(ctx, args) }
+	if l.skip(ctx, models.ErrorLevel, component, false) {
+		return
+	}
+	args, options := splitFormatArgs(args)
+	opts := l.applyOptions(options)
+	l.sendError(ctx, models.ErrorLevel, fmt.Errorf(format, args...), opts)
+}
+
+func (l *Logger) logMsgf(ctx context.Context, level models.LogLevel, format string, args []any) {
+	component := func() string { return l.argsComponent(ctx, args) }
+	if l.skip(ctx, level, component, true) {
+		return
+	}
+	args, options := splitFormatArgs(args)
+	l.sendMsg(ctx, level, fmt.Sprintf(format, args...), options)
+}
+
+// argsComponent resolves the component of a printf-style call without
+// modifying args.
+func (l *Logger) argsComponent(ctx context.Context, args []any) string {
+	var options []models.Option
+	for _, arg := range args {
+		if opt, ok := arg.(models.Option); ok {
+			options = append(options, opt)
+		}
+	}
+	return resolveComponent(ctx, l.applyOptions(options).GetComponent())
+}
+
+// splitFormatArgs separates models.Option values from the format
+// arguments. args may be the caller's own slice (Infof(format, args...)),
+// so the format arguments are copied rather than compacted in place.
+func splitFormatArgs(args []any) ([]any, []models.Option) {
+	var (
+		options    []models.Option
+		formatArgs []any
+	)
+	for i, arg := range args {
+		opt, ok := arg.(models.Option)
+		if !ok {
+			if formatArgs != nil {
+				formatArgs = append(formatArgs, arg)
+			}
+			continue
+		}
+		if formatArgs == nil {
+			formatArgs = make([]any, i, len(args)-1)
+			copy(formatArgs, args[:i])
+		}
+		options = append(options, opt)
+	}
+	if formatArgs == nil {
+		return args, nil
+	}
+	return formatArgs, options
+}
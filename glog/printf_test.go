@@ -0,0 +1,104 @@
+package glog
+
+import (
+	"context"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+	"time"
+)
+
+type countingStringer struct{ calls int }
+
+func (c *countingStringer) String() string {
+	c.calls++
+	return "formatted"
+}
+
+func TestLogger_Printf(t *testing.T) {
+	logger, mock, service := setupTestLogger()
+	defer service.Stop()
+
+	ctx := context.Background()
+	logger.Infof(ctx, "order %d placed", 7, models.WithComponent("checkout"), models.WithIntField("n", 2))
+	logger.Warningf(ctx, "100%% %s", "done")
+	logger.Debugf(ctx, "plain")
+	logs := waitForLogs(mock, 3, time.Second)
+	if len(logs) != 3 {
+		t.Fatalf("expected 3 logs, got %d", len(logs))
+	}
+
+	got := byMsg(logs)
+	for msg, level := range map[string]models.LogLevel{"order 7 placed": models.InfoLevel, "100% done": models.WarnLevel, "plain": models.DebugLevel} {
+		if got[msg] == nil || got[msg].Level != level {
+			t.Errorf("expected %q at %v, got %v", msg, level, got[msg])
+		}
+	}
+	if got["order 7 placed"] == nil {
+		t.FailNow()
+	}
+	fields := map[string]*models.LogField{}
+	for _, f := range got["order 7 placed"].Fields {
+		fields[f.Key] = f
+	}
+	if fields[models.FieldComponentKey] == nil || fields[models.FieldComponentKey].String != "checkout" || fields["n"] == nil || fields["n"].Integer != 2 {
+		t.Errorf("expected options to be applied, got %v", got["order 7 placed"].Fields)
+	}
+}
+
+func TestLogger_Errorf(t *testing.T) {
+	logger, mock, service := setupTestLogger()
+	defer service.Stop()
+
+	base := errors.New("connection refused")
+	logger.Errorf(context.Background(), "dial %s: %w", "db:5432", base, models.WithStackTrace())
+	logs := waitForLogs(mock, 1, time.Second)
+	if len(logs) != 1 {
+		t.Fatalf("expected 1 log, got %d", len(logs))
+	}
+	if logs[0].Msg != "dial db:5432: connection refused" || logs[0].Level != models.ErrorLevel {
+		t.Errorf("unexpected record %q at %v", logs[0].Msg, logs[0].Level)
+	}
+	var stack bool
+	for _, f := range logs[0].Fields {
+		stack = stack || f.Key == models.FieldFilenameKey
+	}
+	if !stack {
+		t.Error("expected WithStackTrace to add the stack")
+	}
+}
+
+func TestLogger_PrintfFormatsLazily(t *testing.T) {
+	logger, mock, service := setupTestLogger()
+	defer service.Stop()
+
+	arg := &countingStringer{}
+	ctx := Suppress(context.Background(), models.InfoLevel)
+	logger.Infof(ctx, "value %s", arg)
+	if n := EndSuppress(ctx, logger); n != 1 {
+		t.Errorf("expected 1 suppressed record, got %d", n)
+	}
+	if arg.calls != 0 {
+		t.Errorf("expected a suppressed record not to be formatted, got %d calls", arg.calls)
+	}
+	_ = waitForLogs(mock, 1, time.Second)
+}
+
+func TestLogger_PrintfKeepsCallerArgs(t *testing.T) {
+	logger, mock, service := setupTestLogger()
+	defer service.Stop()
+
+	component := models.WithComponent("checkout")
+	args := []any{component, 7, "eu"}
+	logger.Infof(context.Background(), "order %d in %s", args...)
+	logs := waitForLogs(mock, 1, time.Second)
+	if len(logs) != 1 || logs[0].Msg != "order 7 in eu" {
+		t.Fatalf("unexpected logs: %v", logs)
+	}
+	if args[1] != 7 || args[2] != "eu" {
+		t.Errorf("expected the caller's slice to be left alone, got %v", args)
+	}
+	if _, ok := args[0].(models.Option); !ok {
+		t.Errorf("expected the option to stay in the caller's slice, got %v", args[0])
+	}
+}
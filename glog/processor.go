@@ -0,0 +1,213 @@
+package glog
+
+import (
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Processor transforms a record before it reaches a publisher. Returning nil
+// drops the record for that publisher. Processors attached with
+// WithProcessors receive a private copy of the record and may modify it.
+type Processor func(*models.LogData) *models.LogData
+
+type publisherEntry struct {
+	publisher  interfaces.LogPublisher
+	processors []Processor
+	minLevel   *models.LogLevel
+	filters    []func(*models.LogData) bool
+	disabled   atomic.Bool
+	sampleRate atomic.Int64
+	sampled    atomic.Uint64
+	sampleMeta bool
+	pauseMu    sync.Mutex
+	pause      *pauseState
+}
+
+func (e *publisherEntry) job(loggerID string, logData *models.LogData) sendJob {
+	return sendJob{
+		loggerID:   loggerID,
+		logger:     e.publisher,
+		processors: e.processors,
+		logData:    logData,
+	}
+}
+
+// PublisherOption configures a single publisher registered with AddLogger.
+type PublisherOption func(*publisherEntry)
+
+// WithProcessors runs the processors, in order, on every record before it is
+// sent to this publisher only. Use it to keep full detail in a local sink
+// while redacting or sampling what leaves the host.
+func WithProcessors(processors ...Processor) PublisherOption {
+	return func(e *publisherEntry) {
+		e.processors = append(e.processors, processors...)
+	}
+}
+
+// WithMinLevel sends this publisher only records at level or above, e.g.
+// only errors to Sentry while a file gets everything.
+func WithMinLevel(level models.LogLevel) PublisherOption {
+	return func(e *publisherEntry) {
+		e.minLevel = &level
+	}
+}
+
+// WithFilter sends this publisher only the records keep returns true for.
+// Filters see the shared record before processors run and must not modify
+// it. A panicking filter is reported and the record not sent to this
+// publisher.
+func WithFilter(keep func(*models.LogData) bool) PublisherOption {
+	return func(e *publisherEntry) {
+		if keep != nil {
+			e.filters = append(e.filters, keep)
+		}
+	}
+}
+
+// HasComponent is a WithFilter predicate for records whose component is
+// component or nested below it, e.g. HasComponent("audit").
+func HasComponent(component string) func(*models.LogData) bool {
+	return func(data *models.LogData) bool {
+		for _, f := range data.Fields {
+			if f != nil && f.Key == models.FieldComponentKey && f.Type == models.FieldTypeString {
+				return f.String == component || strings.HasPrefix(f.String, component+componentSeparator)
+			}
+		}
+		return false
+	}
+}
+
+// WithSampleRate sends this publisher one record out of n below
+// ErrorLevel. Unlike SampleEvery it only copies the records it marks with
+// WithSampleMetadata, and SetSampleRate changes it at runtime.
+func WithSampleRate(n int, opts ...SampleOption) PublisherOption {
+	o := &sampleOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return func(e *publisherEntry) {
+		e.sampleRate.Store(int64(n))
+		e.sampleMeta = o.metadata
+	}
+}
+
+// accepts reports whether the entry is enabled and its level, filters and
+// sample rate let logData through. The record it returns is logData, or a
+// copy marked with the sample rate when the entry samples with metadata. A
+// panicking filter is reported to onPanic and the record rejected.
+func (e *publisherEntry) accepts(id string, logData *models.LogData, onPanic func(error)) (*models.LogData, bool) {
+	if e.disabled.Load() {
+		return logData, false
+	}
+	if e.minLevel != nil && logData.Level < *e.minLevel {
+		return logData, false
+	}
+	for i, keep := range e.filters {
+		if !runPublisherFilter(id, i, keep, logData, onPanic) {
+			return logData, false
+		}
+	}
+	if n := e.sampleRate.Load(); n > 1 && logData.Level < models.ErrorLevel && !logData.Internal {
+		if e.sampled.Add(1)%uint64(n) != 1 {
+			return logData, false
+		}
+		if e.sampleMeta {
+			// logData is shared with the other publishers.
+			logData = logData.Clone()
+			logData.MarkSampled(int(n))
+		}
+	}
+	return logData, true
+}
+
+func runProcessors(processors []Processor, logData *models.LogData) *models.LogData {
+	for _, p := range processors {
+		if logData = p(logData); logData == nil {
+			return nil
+		}
+	}
+	return logData
+}
+
+// RedactFields replaces the values of the given field keys with
+// "[REDACTED]".
+func RedactFields(keys ...string) Processor {
+	set := keySet(keys)
+	return func(logData *models.LogData) *models.LogData {
+		for i, f := range logData.Fields {
+			if f == nil {
+				continue
+			}
+			if _, ok := set[f.Key]; ok {
+				logData.Fields[i] = &models.LogField{Key: f.Key, Type: models.FieldTypeString, String: redactedValue}
+			}
+		}
+		return logData
+	}
+}
+
+// DropFields removes the given field keys.
+func DropFields(keys ...string) Processor {
+	set := keySet(keys)
+	return func(logData *models.LogData) *models.LogData {
+		kept := logData.Fields[:0]
+		for _, f := range logData.Fields {
+			if f == nil {
+				continue
+			}
+			if _, ok := set[f.Key]; !ok {
+				kept = append(kept, f)
+			}
+		}
+		logData.Fields = kept
+		return logData
+	}
+}
+
+type sampleOptions struct {
+	metadata bool
+}
+
+// SampleOption configures SampleEvery.
+type SampleOption func(*sampleOptions)
+
+// WithSampleMetadata adds sampled=true and sample_rate=n to the records that
+// survive sampling, like trace sampling metadata.
+func WithSampleMetadata() SampleOption {
+	return func(o *sampleOptions) {
+		o.metadata = true
+	}
+}
+
+// SampleEvery keeps one record out of n and drops the rest. Records at
+// ErrorLevel and above are always kept and never marked as sampled.
+func SampleEvery(n int, opts ...SampleOption) Processor {
+	o := &sampleOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	var counter atomic.Uint64
+	return func(logData *models.LogData) *models.LogData {
+		if n <= 1 || logData.Level >= models.ErrorLevel || logData.Internal {
+			return logData
+		}
+		if counter.Add(1)%uint64(n) != 1 {
+			return nil
+		}
+		if o.metadata {
+			logData.MarkSampled(n)
+		}
+		return logData
+	}
+}
+
+func keySet(keys []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[k] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,143 @@
+package glog
+
+import (
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+	"time"
+)
+
+func TestWithProcessors_PerPublisherChains(t *testing.T) {
+	loggerService := NewLoggerService()
+	local := &mockPublisher{logs: make([]*models.LogData, 0)}
+	remote := &mockPublisher{logs: make([]*models.LogData, 0)}
+	loggerService.AddLogger("local", local)
+	loggerService.AddLogger("remote", remote, WithProcessors(
+		RedactFields("email"),
+		DropFields("debug_dump"),
+	))
+	loggerService.Start()
+	logger := loggerService.NewLogger()
+
+	logger.Info(context.Background(), "signup",
+		models.WithStringField("email", "a@example.com"),
+		models.WithStringField("debug_dump", "..."),
+		models.WithIntField("plan", 2))
+	loggerService.Stop()
+
+	localLogs, remoteLogs := local.GetLogs(), remote.GetLogs()
+	if len(localLogs) != 1 || len(remoteLogs) != 1 {
+		t.Fatalf("expected one record per publisher, got %d/%d", len(localLogs), len(remoteLogs))
+	}
+
+	if f := fieldByKey(localLogs[0], "email"); f == nil || f.String != "a@example.com" {
+		t.Error("local publisher must see the unredacted record")
+	}
+	if f := fieldByKey(remoteLogs[0], "email"); f == nil || f.String != redactedValue {
+		t.Error("expected email to be redacted for remote publisher")
+	}
+	if fieldByKey(remoteLogs[0], "debug_dump") != nil {
+		t.Error("expected debug_dump to be dropped for remote publisher")
+	}
+	if fieldByKey(remoteLogs[0], "plan") == nil || fieldByKey(localLogs[0], "debug_dump") == nil {
+		t.Error("unrelated fields must be kept")
+	}
+}
+
+func TestWithProcessors_DropRecord(t *testing.T) {
+	loggerService := NewLoggerService(WithBlockingSend())
+	sampled := &mockPublisher{logs: make([]*models.LogData, 0)}
+	loggerService.AddLogger("sampled", sampled, WithProcessors(SampleEvery(10)))
+	loggerService.Start()
+	logger := loggerService.NewLogger()
+
+	for i := 0; i < 100; i++ {
+		logger.Info(context.Background(), fmt.Sprintf("message %d", i))
+	}
+	logger.Error(context.Background(), fmt.Errorf("always kept"))
+	loggerService.Stop()
+
+	logs := sampled.GetLogs()
+	if len(logs) != 11 {
+		t.Errorf("expected 10 sampled info records plus the error, got %d", len(logs))
+	}
+}
+
+func TestSampleEvery_Metadata(t *testing.T) {
+	sample := SampleEvery(5, WithSampleMetadata())
+
+	kept := sample(&models.LogData{Msg: "first", Level: models.InfoLevel})
+	if kept == nil {
+		t.Fatal("expected first record to be kept")
+	}
+	if f := fieldByKey(kept, models.FieldSampledKey); f == nil || !f.Bool {
+		t.Error("expected sampled=true")
+	}
+	if f := fieldByKey(kept, models.FieldSampleRateKey); f == nil || f.Integer != 5 {
+		t.Error("expected sample_rate=5")
+	}
+
+	errRecord := sample(&models.LogData{Msg: "boom", Level: models.ErrorLevel})
+	if fieldByKey(errRecord, models.FieldSampledKey) != nil {
+		t.Error("errors bypass sampling and must not be marked")
+	}
+}
+
+func TestWithMinLevelAndFilter_Routing(t *testing.T) {
+	ls := NewLoggerService()
+	file := &mockPublisher{}
+	sentry := &mockPublisher{}
+	kafka := &mockPublisher{}
+	ls.AddLogger("file", file)
+	ls.AddLogger("sentry", sentry, WithMinLevel(models.ErrorLevel))
+	ls.AddLogger("kafka", kafka, WithFilter(HasComponent("audit")))
+	ls.Start()
+
+	logger := ls.NewLogger()
+	ctx := context.Background()
+	logger.Info(ctx, "info")
+	logger.Error(ctx, fmt.Errorf("failure"))
+	logger.Info(ctx, "login", models.WithComponent("audit"))
+	logger.Info(PushComponent(ctx, "audit"), "export", models.WithComponent("csv"))
+	logger.Info(ctx, "not audit", models.WithComponent("auditor"))
+	if err := ls.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	ls.Stop()
+
+	if n := len(file.GetLogs()); n != 5 {
+		t.Errorf("expected every record in the file, got %d", n)
+	}
+	if logs := sentry.GetLogs(); len(logs) != 1 || logs[0].Msg != "failure" {
+		t.Errorf("expected only the error in sentry, got %d records", len(logs))
+	}
+	logs := byMsg(kafka.GetLogs())
+	if len(logs) != 2 || logs["login"] == nil || logs["export"] == nil {
+		t.Errorf("expected only audit records in kafka, got %v", logs)
+	}
+}
+
+func TestWithFilter_NotBufferedWhilePaused(t *testing.T) {
+	ls := NewLoggerService()
+	sentry := &mockPublisher{}
+	ls.AddLogger("sentry", sentry, WithMinLevel(models.ErrorLevel))
+	ls.Start()
+	defer ls.Stop()
+
+	if err := ls.PausePublisher("sentry", time.Now().Add(time.Minute), WithPauseBuffer(10)); err != nil {
+		t.Fatal(err)
+	}
+	logger := ls.NewLogger()
+	logger.Info(context.Background(), "info")
+	logger.Error(context.Background(), fmt.Errorf("failure"))
+	if err := ls.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ls.ResumePublisher("sentry"); err != nil {
+		t.Fatal(err)
+	}
+	if logs := sentry.GetLogs(); len(logs) != 1 || logs[0].Msg != "failure" {
+		t.Errorf("expected only the error replayed, got %d records", len(logs))
+	}
+}
@@ -0,0 +1,46 @@
+package glog
+
+import (
+	"github.com/alexnobleburn/glogger/glog/glogtest"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"testing"
+	"time"
+)
+
+func TestProperties_BlockingModeLosesNothing(t *testing.T) {
+	res := glogtest.RunProperties(t, glogtest.PropertyConfig{Producers: 8, RecordsPerProducer: 300},
+		func(collector interfaces.LogPublisher) (interfaces.Logger, func()) {
+			ls := NewLoggerService(WithBlockingSend(), WithInputBufferSize(4), WithJobBufferSize(4))
+			ls.AddLogger("collector", collector)
+			ls.Start()
+			return ls.NewLogger(), ls.Stop
+		})
+	res.AssertNoLoss(t)
+}
+
+func TestProperties_DropModeLossIsBounded(t *testing.T) {
+	res := glogtest.RunProperties(t, glogtest.PropertyConfig{Producers: 8, RecordsPerProducer: 300},
+		func(collector interfaces.LogPublisher) (interfaces.Logger, func()) {
+			ls := NewLoggerService(WithInputBufferSize(16), WithErrorHandler(func(error) {}))
+			ls.AddLogger("collector", collector)
+			ls.Start()
+			return ls.NewLogger(), ls.Stop
+		})
+	// Drops are allowed, duplicates never are.
+	res.AssertBoundedLoss(t, 1)
+}
+
+func TestProperties_SingleWorkerPreservesPerSourceOrder(t *testing.T) {
+	res := glogtest.RunProperties(t, glogtest.PropertyConfig{
+		Producers:          4,
+		RecordsPerProducer: 200,
+		MaxJitter:          50 * time.Microsecond,
+	}, func(collector interfaces.LogPublisher) (interfaces.Logger, func()) {
+		ls := NewLoggerService(WithBlockingSend(), WithNumWorkers(1))
+		ls.AddLogger("collector", collector)
+		ls.Start()
+		return ls.NewLogger(), ls.Stop
+	})
+	res.AssertNoLoss(t)
+	res.AssertPerSourceOrder(t)
+}
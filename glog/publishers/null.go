@@ -0,0 +1,71 @@
+package publishers
+
+import (
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync/atomic"
+)
+
+// Compile-time check that Null implements interfaces.LogPublisher.
+var _ interfaces.LogPublisher = (*Null)(nil)
+
+// Null discards every record and only counts them per level. It measures the
+// pipeline without any encoding or I/O, and keeps a service running when
+// output is disabled by configuration.
+type Null struct {
+	// counts is indexed by level, DebugLevel first.
+	counts [models.FatalLevel - models.DebugLevel + 1]atomic.Int64
+}
+
+func NewNull() *Null {
+	return &Null{}
+}
+
+func (n *Null) SendMsg(data *models.LogData) {
+	if data == nil {
+		return
+	}
+	// Out-of-range levels are counted the way the service normalizes them.
+	level := data.Level
+	switch {
+	case level < models.DebugLevel:
+		level = models.DebugLevel
+	case level > models.FatalLevel:
+		level = models.ErrorLevel
+	}
+	n.counts[level-models.DebugLevel].Add(1)
+}
+
+// Count returns how many records of level were received.
+func (n *Null) Count(level models.LogLevel) int64 {
+	if level < models.DebugLevel || level > models.FatalLevel {
+		return 0
+	}
+	return n.counts[level-models.DebugLevel].Load()
+}
+
+// Counts returns the records received per level name, including levels
+// with no records.
+func (n *Null) Counts() map[string]int64 {
+	m := make(map[string]int64, len(n.counts))
+	for i := range n.counts {
+		m[(models.DebugLevel + models.LogLevel(i)).String()] = n.counts[i].Load()
+	}
+	return m
+}
+
+// Total returns how many records were received.
+func (n *Null) Total() int64 {
+	var total int64
+	for i := range n.counts {
+		total += n.counts[i].Load()
+	}
+	return total
+}
+
+// Reset sets every counter back to zero, e.g. between benchmark runs.
+func (n *Null) Reset() {
+	for i := range n.counts {
+		n.counts[i].Store(0)
+	}
+}
@@ -0,0 +1,43 @@
+package publishers
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync"
+	"testing"
+)
+
+func TestNull_CountsPerLevel(t *testing.T) {
+	n := NewNull()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n.SendMsg(&models.LogData{Ctx: context.Background(), Level: models.InfoLevel})
+			n.SendMsg(&models.LogData{Ctx: context.Background(), Level: models.ErrorLevel})
+		}()
+	}
+	wg.Wait()
+	n.SendMsg(&models.LogData{Level: models.LogLevel(42)})
+	n.SendMsg(nil)
+
+	if n.Count(models.InfoLevel) != 10 {
+		t.Errorf("expected 10 info records, got %d", n.Count(models.InfoLevel))
+	}
+	if n.Count(models.ErrorLevel) != 11 {
+		t.Errorf("expected 11 error records including the out-of-range level, got %d", n.Count(models.ErrorLevel))
+	}
+	counts := n.Counts()
+	if len(counts) != 7 || counts["info"] != 10 || counts["debug"] != 0 {
+		t.Errorf("unexpected counts %v", counts)
+	}
+	if n.Total() != 21 {
+		t.Errorf("expected 21 records in total, got %d", n.Total())
+	}
+
+	n.Reset()
+	if n.Total() != 0 {
+		t.Errorf("expected zero after Reset, got %d", n.Total())
+	}
+}
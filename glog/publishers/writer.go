@@ -0,0 +1,187 @@
+// Package publishers holds generic publishers that combine an
+// interfaces.Encoder with a transport.
+package publishers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/internal/report"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"io"
+	"sync"
+	"time"
+)
+
+const defaultBufferSize = 4096
+
+// Compile-time checks that Writer implements interfaces.ErrorPublisher and
+// interfaces.ErrorReporter.
+var (
+	_ interfaces.ErrorPublisher = (*Writer)(nil)
+	_ interfaces.ErrorReporter  = (*Writer)(nil)
+)
+
+// Writer encodes each record and writes it to an io.Writer as one line.
+// Writes are serialized, so any destination (pipes, gzip writers, test
+// buffers, network connections) can be shared by the service workers.
+type Writer struct {
+	mu            sync.Mutex
+	w             io.Writer
+	bw            *bufio.Writer
+	enc           interfaces.Encoder
+	bufferSize    int
+	flushInterval time.Duration
+	onError       func(error)
+	errs          *report.Handler
+
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// WriterOption configures a Writer.
+type WriterOption func(*Writer)
+
+// WithBuffer buffers up to size bytes before writing to the destination.
+// Buffered lines are written by Flush, Close and, if set, the flush
+// interval.
+func WithBuffer(size int) WriterOption {
+	return func(wr *Writer) {
+		if size > 0 {
+			wr.bufferSize = size
+		}
+	}
+}
+
+// WithFlushInterval flushes the buffer periodically. It enables buffering
+// with a 4 KiB buffer unless WithBuffer is also given.
+func WithFlushInterval(d time.Duration) WriterOption {
+	return func(wr *Writer) {
+		if d > 0 {
+			wr.flushInterval = d
+		}
+	}
+}
+
+// WithErrorHandler receives encode, write and background flush errors. Once
+// the Writer is added to a service, the service error handler receives
+// them too. Without either, they are printed.
+func WithErrorHandler(handler func(error)) WriterOption {
+	return func(wr *Writer) {
+		if handler != nil {
+			wr.onError = handler
+		}
+	}
+}
+
+// NewWriter returns a publisher writing records encoded by enc to w. The
+// Writer does not close w.
+func NewWriter(w io.Writer, enc interfaces.Encoder, opts ...WriterOption) *Writer {
+	wr := &Writer{
+		w:    w,
+		enc:  enc,
+		done: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(wr)
+	}
+	wr.errs = report.New(wr.onError)
+	if wr.flushInterval > 0 && wr.bufferSize == 0 {
+		wr.bufferSize = defaultBufferSize
+	}
+	if wr.bufferSize > 0 {
+		wr.bw = bufio.NewWriterSize(w, wr.bufferSize)
+	}
+	if wr.flushInterval > 0 {
+		wr.wg.Add(1)
+		go wr.flushLoop()
+	}
+	return wr
+}
+
+func (wr *Writer) SendMsg(data *models.LogData) {
+	if err := wr.TrySendMsg(data); err != nil {
+		wr.errs.Handle(err)
+	}
+}
+
+// TrySendMsg writes data like SendMsg but returns encode and write errors
+// instead of passing them to the error handler.
+func (wr *Writer) TrySendMsg(data *models.LogData) error {
+	line, err := wr.enc.Encode(data)
+	if err != nil {
+		return fmt.Errorf("publishers: encode: %w", err)
+	}
+	if len(line) == 0 || line[len(line)-1] != '\n' {
+		line = append(line, '\n')
+	}
+
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	var dst io.Writer = wr.w
+	if wr.bw != nil {
+		dst = wr.bw
+	}
+	if _, err := dst.Write(line); err != nil {
+		return fmt.Errorf("publishers: write: %w", err)
+	}
+	return nil
+}
+
+// ReportErrorsTo implements interfaces.ErrorReporter.
+func (wr *Writer) ReportErrorsTo(report func(error)) {
+	wr.errs.ReportTo(report)
+}
+
+// Flush writes buffered lines and flushes the destination if it has a
+// Flush() error method (e.g. *gzip.Writer).
+func (wr *Writer) Flush(_ context.Context) error {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	return wr.flushLocked()
+}
+
+func (wr *Writer) flushLocked() error {
+	if wr.bw != nil {
+		if err := wr.bw.Flush(); err != nil {
+			return fmt.Errorf("publishers: flush: %w", err)
+		}
+	}
+	if f, ok := wr.w.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return fmt.Errorf("publishers: flush: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close stops the flush interval and flushes. It does not close the
+// destination.
+func (wr *Writer) Close() error {
+	wr.closeOnce.Do(func() {
+		close(wr.done)
+	})
+	wr.wg.Wait()
+	return wr.Flush(context.Background())
+}
+
+func (wr *Writer) flushLoop() {
+	defer wr.wg.Done()
+	ticker := time.NewTicker(wr.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-wr.done:
+			return
+		case <-ticker.C:
+			wr.mu.Lock()
+			err := wr.flushLocked()
+			wr.mu.Unlock()
+			if err != nil {
+				wr.errs.Handle(err)
+			}
+		}
+	}
+}
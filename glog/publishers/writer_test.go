@@ -0,0 +1,106 @@
+package publishers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type safeBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *safeBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func record(msg string) *models.LogData {
+	return &models.LogData{Ctx: context.Background(), Msg: msg, Level: models.InfoLevel}
+}
+
+func TestWriter_OneLinePerRecord(t *testing.T) {
+	var out safeBuffer
+	wr := NewWriter(&out, encoder.NewJSON("shop", "prod"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			wr.SendMsg(record(fmt.Sprintf("m%d", i)))
+		}(i)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 50 {
+		t.Fatalf("expected 50 lines, got %d", len(lines))
+	}
+	for _, l := range lines {
+		if !strings.HasPrefix(l, "{") || !strings.HasSuffix(l, "}") {
+			t.Errorf("interleaved or partial line %q", l)
+		}
+	}
+}
+
+func TestWriter_BufferedUntilFlush(t *testing.T) {
+	var out safeBuffer
+	wr := NewWriter(&out, encoder.NewJSON("shop", "prod"), WithBuffer(1<<16))
+	wr.SendMsg(record("buffered"))
+	if out.String() != "" {
+		t.Fatal("expected the line to stay buffered")
+	}
+	if err := wr.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), `"msg":"buffered"`) {
+		t.Errorf("expected the line after Flush, got %q", out.String())
+	}
+}
+
+func TestWriter_FlushInterval(t *testing.T) {
+	var out safeBuffer
+	wr := NewWriter(&out, encoder.NewJSON("shop", "prod"), WithFlushInterval(10*time.Millisecond))
+	defer wr.Close()
+	wr.SendMsg(record("ticked"))
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(out.String(), "ticked") {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the flush interval to write the line")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+type failingEncoder struct{}
+
+func (failingEncoder) Encode(*models.LogData) ([]byte, error) {
+	return nil, errors.New("boom")
+}
+
+func TestWriter_ReportsEncodeErrors(t *testing.T) {
+	var out safeBuffer
+	var errs []error
+	wr := NewWriter(&out, failingEncoder{}, WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	wr.SendMsg(record("lost"))
+	if len(errs) != 1 || out.String() != "" {
+		t.Errorf("expected one error and no output, got %v %q", errs, out.String())
+	}
+}
@@ -0,0 +1,247 @@
+// Package query defines the read-side API shared by glogger stores that keep
+// records around after publishing (the in-memory ring, the SQLite store).
+package query
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record is a stored log record together with the time it was stored.
+type Record struct {
+	Time time.Time
+	Data *models.LogData
+}
+
+// Source is implemented by every store that can be queried.
+type Source interface {
+	Query(filter Filter) *Iterator
+}
+
+// Filter selects records. Zero-valued criteria match everything.
+type Filter struct {
+	// Levels restricts the result to the listed levels. Use LevelsFrom for a
+	// minimum level.
+	Levels []models.LogLevel
+	Since  time.Time
+	Until  time.Time
+	// Component matches the component field exactly.
+	Component string
+	// MsgContains matches a substring of the message.
+	MsgContains string
+	// Fields matches the string representation of field values exactly.
+	// Array fields also match when one of their elements does.
+	// The value "*" only requires the field to be present.
+	Fields map[string]string
+	// Limit keeps only the newest Limit matches. Zero means no limit.
+	Limit int
+}
+
+// LevelsFrom returns every level from min up to FatalLevel.
+func LevelsFrom(min models.LogLevel) []models.LogLevel {
+	var levels []models.LogLevel
+	for l := min; l <= models.FatalLevel; l++ {
+		levels = append(levels, l)
+	}
+	return levels
+}
+
+func (f Filter) Match(rec Record) bool {
+	data := rec.Data
+	if data == nil {
+		return false
+	}
+	if len(f.Levels) > 0 && !containsLevel(f.Levels, data.Level) {
+		return false
+	}
+	if !f.Since.IsZero() && rec.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && rec.Time.After(f.Until) {
+		return false
+	}
+	if f.MsgContains != "" && !strings.Contains(data.Msg, f.MsgContains) {
+		return false
+	}
+	if f.Component != "" {
+		if c, ok := fieldValue(data, models.FieldComponentKey); !ok || c != f.Component {
+			return false
+		}
+	}
+	for key, want := range f.Fields {
+		if !fieldMatches(data, key, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldMatches reports whether the field key is present and equals want;
+// an array field matches when any element does. "*" matches any value.
+func fieldMatches(data *models.LogData, key, want string) bool {
+	for _, f := range data.Fields {
+		if f == nil || f.Key != key {
+			continue
+		}
+		if want == "*" || FieldString(f) == want {
+			return true
+		}
+		if f.Type == models.FieldTypeArray {
+			v := reflect.ValueOf(f.Object)
+			if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+				for i := 0; i < v.Len(); i++ {
+					if elementString(v.Index(i)) == want {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// elementString renders one array element: scalars with fmt, anything that
+// may nest (and so refer back to the array) as JSON.
+func elementString(v reflect.Value) string {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v.Interface())
+	}
+	b, _ := safejson.Marshal(v.Interface())
+	return string(b)
+}
+
+func containsLevel(levels []models.LogLevel, l models.LogLevel) bool {
+	for _, candidate := range levels {
+		if candidate == l {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldValue(data *models.LogData, key string) (string, bool) {
+	for _, f := range data.Fields {
+		if f != nil && f.Key == key {
+			return FieldString(f), true
+		}
+	}
+	return "", false
+}
+
+// FieldString renders a field value the way filters compare it.
+func FieldString(f *models.LogField) string {
+	switch f.Type {
+	case models.FieldTypeString:
+		return f.String
+	case models.FieldTypeInt:
+		return strconv.Itoa(f.Integer)
+	case models.FieldTypeFloat:
+		return strconv.FormatFloat(f.Float, 'g', -1, 64)
+	case models.FieldTypeBool:
+		return strconv.FormatBool(f.Bool)
+	case models.FieldTypeInt64:
+		return strconv.FormatInt(f.Int64, 10)
+	case models.FieldTypeUint64:
+		return strconv.FormatUint(f.Uint64, 10)
+	case models.FieldTypeBinary:
+		return base64.StdEncoding.EncodeToString(f.Binary)
+	case models.FieldTypeDuration:
+		return f.Duration.String()
+	case models.FieldTypeTime:
+		return f.Time.Format(time.RFC3339Nano)
+	default:
+		// Objects and arrays render as JSON; safejson stops at cycles.
+		b, _ := safejson.Marshal(f.Object)
+		return string(b)
+	}
+}
+
+// ParseFilter builds a Filter from URL query parameters:
+// level (minimum level name), since/until (RFC 3339), component, msg,
+// limit and field.<key>=<value>.
+func ParseFilter(values url.Values) (Filter, error) {
+	var f Filter
+	if v := values.Get("level"); v != "" {
+		level, err := models.ParseLevel(v)
+		if err != nil {
+			return f, err
+		}
+		f.Levels = LevelsFrom(level)
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &f.Since}, {"until", &f.Until}} {
+		if v := values.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return f, fmt.Errorf("query: invalid %s: %w", p.name, err)
+			}
+			*p.dst = t
+		}
+	}
+	if v := values.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return f, fmt.Errorf("query: invalid limit %q", v)
+		}
+		f.Limit = n
+	}
+	f.Component = values.Get("component")
+	f.MsgContains = values.Get("msg")
+	for key, vals := range values {
+		if name, ok := strings.CutPrefix(key, "field."); ok && len(vals) > 0 {
+			if f.Fields == nil {
+				f.Fields = make(map[string]string)
+			}
+			f.Fields[name] = vals[0]
+		}
+	}
+	return f, nil
+}
+
+// Iterator walks query results in chronological order.
+type Iterator struct {
+	records []Record
+	pos     int
+}
+
+func NewIterator(records []Record) *Iterator {
+	return &Iterator{records: records, pos: -1}
+}
+
+func (it *Iterator) Next() bool {
+	if it.pos+1 >= len(it.records) {
+		return false
+	}
+	it.pos++
+	return true
+}
+
+func (it *Iterator) Record() Record {
+	return it.records[it.pos]
+}
+
+// All drains the iterator into a slice.
+func (it *Iterator) All() []Record {
+	var res []Record
+	for it.Next() {
+		res = append(res, it.Record())
+	}
+	return res
+}
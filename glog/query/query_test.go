@@ -0,0 +1,82 @@
+package query
+
+import (
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net/url"
+	"testing"
+)
+
+func TestFilter_Match(t *testing.T) {
+	rec := Record{Data: &models.LogData{
+		Msg:   "payment failed",
+		Level: models.WarnLevel,
+		Fields: []*models.LogField{
+			nil,
+			{Key: "retry", Type: models.FieldTypeBool, Bool: true},
+			{Key: "tags", Type: models.FieldTypeArray, Object: []string{"eu", "card"}},
+		},
+	}}
+
+	if !(Filter{Fields: map[string]string{"retry": "true"}}).Match(rec) {
+		t.Error("expected bool field to match its string form")
+	}
+	if !(Filter{Fields: map[string]string{"retry": "*"}}).Match(rec) {
+		t.Error("expected wildcard to match present field")
+	}
+	if !(Filter{Fields: map[string]string{"tags": "card"}}).Match(rec) || !(Filter{Fields: map[string]string{"tags": `["eu","card"]`}}).Match(rec) {
+		t.Error("expected an array field to match an element and its JSON form")
+	}
+	if (Filter{Fields: map[string]string{"tags": "us"}}).Match(rec) {
+		t.Error("expected an array field not to match a missing element")
+	}
+	cyclic := map[string]any{"k": "v"}
+	cyclic["self"] = cyclic
+	list := []any{"a", nil}
+	list[1] = list
+	self := Record{Data: &models.LogData{Fields: []*models.LogField{
+		{Key: "o", Type: models.FieldTypeObject, Object: cyclic},
+		{Key: "l", Type: models.FieldTypeArray, Object: list},
+	}}}
+	if (Filter{Fields: map[string]string{"o": "x", "l": "x"}}).Match(self) {
+		t.Error("expected cyclic fields not to match an unrelated value")
+	}
+	if !(Filter{Fields: map[string]string{"l": "a"}}).Match(self) {
+		t.Error("expected a cyclic array field to match its scalar element")
+	}
+	if (Filter{Fields: map[string]string{"missing": "*"}}).Match(rec) {
+		t.Error("expected wildcard not to match absent field")
+	}
+	if (Filter{Component: "billing"}).Match(rec) {
+		t.Error("expected component filter not to match record without component")
+	}
+	if !(Filter{MsgContains: "payment"}).Match(rec) {
+		t.Error("expected message substring to match")
+	}
+	if (Filter{}).Match(Record{}) {
+		t.Error("expected record without data not to match")
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	values := url.Values{
+		"level":          {"warning"},
+		"component":      {"db"},
+		"since":          {"2024-05-01T10:00:00Z"},
+		"limit":          {"5"},
+		"field.order_id": {"42"},
+	}
+	f, err := ParseFilter(values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(f.Levels) == 0 || f.Levels[0] != models.WarnLevel {
+		t.Errorf("expected levels from warn, got %v", f.Levels)
+	}
+	if f.Component != "db" || f.Limit != 5 || f.Fields["order_id"] != "42" || f.Since.IsZero() {
+		t.Errorf("unexpected filter: %+v", f)
+	}
+
+	if _, err := ParseFilter(url.Values{"level": {"loud"}}); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
@@ -0,0 +1,260 @@
+// Package quota caps how much a single publisher may ship over a rolling
+// window, so a noisy deploy cannot turn into a surprise vendor bill.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWindow     = 24 * time.Hour
+	defaultBuckets    = 24
+	defaultWarnAt     = 0.8
+	defaultSampleRate = 10
+)
+
+// Policy decides what happens to traffic once usage crosses Config.WarnAt.
+type Policy int8
+
+const (
+	// PolicyDrop forwards everything until the quota is exhausted, then drops
+	// everything until usage falls back under the limit.
+	PolicyDrop Policy = iota
+	// PolicyShedLevels drops Debug once WarnAt is reached, Info halfway
+	// between WarnAt and the limit, Warning near the limit, and keeps Error
+	// and above until the quota is exhausted.
+	PolicyShedLevels
+	// PolicySample keeps one out of SampleRate records below ErrorLevel once
+	// WarnAt is reached.
+	PolicySample
+)
+
+type Config struct {
+	// MaxBytes is the byte budget per window (estimated with LogData.Size).
+	// Zero means unlimited.
+	MaxBytes int64
+	// MaxRecords is the record budget per window. Zero means unlimited.
+	MaxRecords int64
+	// Window is the rolling window length (default 24h).
+	Window time.Duration
+	// Buckets controls the window granularity (default 24).
+	Buckets int
+	// WarnAt is the usage ratio at which degradation starts and a warning is
+	// emitted (default 0.8).
+	WarnAt float64
+	Policy Policy
+	// SampleRate is used by PolicySample (default 10); 1 keeps every
+	// record.
+	SampleRate int
+	// SampleMetadata marks records kept by PolicySample with sampled=true
+	// and sample_rate=SampleRate.
+	SampleMetadata bool
+	// OnWarning is called once each time usage crosses WarnAt or the limit.
+	OnWarning func(Usage)
+}
+
+// Usage is a snapshot of the current window.
+type Usage struct {
+	Bytes      int64
+	Records    int64
+	MaxBytes   int64
+	MaxRecords int64
+	Dropped    int64
+}
+
+// Ratio returns the highest of the byte and record usage ratios.
+func (u Usage) Ratio() float64 {
+	var ratio float64
+	if u.MaxBytes > 0 {
+		ratio = float64(u.Bytes) / float64(u.MaxBytes)
+	}
+	if u.MaxRecords > 0 {
+		if r := float64(u.Records) / float64(u.MaxRecords); r > ratio {
+			ratio = r
+		}
+	}
+	return ratio
+}
+
+type bucket struct {
+	start   time.Time
+	bytes   int64
+	records int64
+}
+
+// Compile-time check that Publisher implements interfaces.LogPublisher.
+var _ interfaces.LogPublisher = (*Publisher)(nil)
+
+// Publisher wraps another publisher and enforces the quota in front of it.
+type Publisher struct {
+	next    interfaces.LogPublisher
+	cfg     Config
+	now     func() time.Time
+	mu      sync.Mutex
+	buckets []bucket
+	dropped int64
+	sampled int64
+	// warned holds the highest threshold already reported: 0 none, 1 WarnAt,
+	// 2 limit.
+	warned int
+}
+
+func New(next interfaces.LogPublisher, cfg Config) *Publisher {
+	if cfg.Window <= 0 {
+		cfg.Window = defaultWindow
+	}
+	if cfg.Buckets <= 0 {
+		cfg.Buckets = defaultBuckets
+	}
+	if cfg.WarnAt <= 0 || cfg.WarnAt > 1 {
+		cfg.WarnAt = defaultWarnAt
+	}
+	if cfg.SampleRate <= 0 {
+		cfg.SampleRate = defaultSampleRate
+	}
+	return &Publisher{
+		next:    next,
+		cfg:     cfg,
+		now:     time.Now,
+		buckets: make([]bucket, cfg.Buckets),
+	}
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	size := int64(data.Size())
+
+	p.mu.Lock()
+	usage := p.usageLocked()
+	ratio := usage.Ratio()
+	allowed, sampled := p.allow(data.Level, ratio)
+	if allowed {
+		b := p.currentBucketLocked()
+		b.bytes += size
+		b.records++
+		usage.Bytes += size
+		usage.Records++
+	} else {
+		p.dropped++
+	}
+	usage.Dropped = p.dropped
+	warning := p.checkWarningLocked(usage.Ratio())
+	p.mu.Unlock()
+
+	if warning != "" {
+		p.warn(data.Ctx, warning, usage)
+	}
+	if allowed {
+		if sampled && p.cfg.SampleMetadata {
+			data = data.Clone()
+			data.MarkSampled(p.cfg.SampleRate)
+		}
+		p.next.SendMsg(data)
+	}
+}
+
+// Usage returns the usage over the current rolling window.
+func (p *Publisher) Usage() Usage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	u := p.usageLocked()
+	u.Dropped = p.dropped
+	return u
+}
+
+// allow reports whether a record may pass and whether it passed through
+// sampling.
+func (p *Publisher) allow(level models.LogLevel, ratio float64) (allowed, sampled bool) {
+	if ratio >= 1 {
+		return false, false
+	}
+	if ratio < p.cfg.WarnAt {
+		return true, false
+	}
+
+	switch p.cfg.Policy {
+	case PolicyShedLevels:
+		step := (1 - p.cfg.WarnAt) / 3
+		switch {
+		case level <= models.DebugLevel:
+			return false, false
+		case level == models.InfoLevel:
+			return ratio < p.cfg.WarnAt+step, false
+		case level == models.WarnLevel:
+			return ratio < p.cfg.WarnAt+2*step, false
+		}
+		return true, false
+	case PolicySample:
+		// A rate of 1 keeps everything, as glog.SampleEvery does.
+		if level >= models.ErrorLevel || p.cfg.SampleRate <= 1 {
+			return true, false
+		}
+		p.sampled++
+		return p.sampled%int64(p.cfg.SampleRate) == 1, true
+	default:
+		return true, false
+	}
+}
+
+func (p *Publisher) checkWarningLocked(ratio float64) string {
+	switch {
+	case ratio >= 1 && p.warned < 2:
+		p.warned = 2
+		return "glogger: publisher quota exhausted, dropping messages"
+	case ratio >= p.cfg.WarnAt && p.warned < 1:
+		p.warned = 1
+		return fmt.Sprintf("glogger: publisher quota at %.0f%%, degrading output", ratio*100)
+	case ratio < p.cfg.WarnAt:
+		p.warned = 0
+	}
+	return ""
+}
+
+// warn reports the threshold crossing to OnWarning and through the wrapped
+// publisher itself, so the warning lands where the missing data would have.
+func (p *Publisher) warn(ctx context.Context, msg string, usage Usage) {
+	if p.cfg.OnWarning != nil {
+		p.cfg.OnWarning(usage)
+	}
+	p.next.SendMsg(&models.LogData{
+		Ctx:   ctx,
+		Msg:   msg,
+		Level: models.WarnLevel,
+		Fields: []*models.LogField{
+			{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: "glogger.quota"},
+			{Key: "quota_bytes", Type: models.FieldTypeInt, Integer: int(usage.Bytes)},
+			{Key: "quota_records", Type: models.FieldTypeInt, Integer: int(usage.Records)},
+			{Key: "quota_dropped", Type: models.FieldTypeInt, Integer: int(usage.Dropped)},
+		},
+	})
+}
+
+func (p *Publisher) bucketWidth() time.Duration {
+	return p.cfg.Window / time.Duration(p.cfg.Buckets)
+}
+
+func (p *Publisher) currentBucketLocked() *bucket {
+	width := p.bucketWidth()
+	start := p.now().Truncate(width)
+	b := &p.buckets[int(start.UnixNano()/int64(width))%len(p.buckets)]
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+	return b
+}
+
+func (p *Publisher) usageLocked() Usage {
+	u := Usage{MaxBytes: p.cfg.MaxBytes, MaxRecords: p.cfg.MaxRecords}
+	oldest := p.now().Add(-p.cfg.Window)
+	for _, b := range p.buckets {
+		if b.start.After(oldest) {
+			u.Bytes += b.bytes
+			u.Records += b.records
+		}
+	}
+	return u
+}
@@ -0,0 +1,179 @@
+package quota
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingPublisher struct {
+	mu   sync.Mutex
+	logs []*models.LogData
+}
+
+func (r *recordingPublisher) SendMsg(data *models.LogData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, data)
+}
+
+func (r *recordingPublisher) count(level models.LogLevel, msg string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, l := range r.logs {
+		if l.Level == level && (msg == "" || l.Msg == msg) {
+			n++
+		}
+	}
+	return n
+}
+
+func newTestPublisher(cfg Config) (*Publisher, *recordingPublisher, *time.Time) {
+	next := &recordingPublisher{}
+	p := New(next, cfg)
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	return p, next, &now
+}
+
+func record(level models.LogLevel) *models.LogData {
+	return &models.LogData{Ctx: context.Background(), Msg: "msg", Level: level}
+}
+
+func TestPublisher_DropsWhenExhausted(t *testing.T) {
+	p, next, _ := newTestPublisher(Config{MaxRecords: 10})
+
+	for i := 0; i < 15; i++ {
+		p.SendMsg(record(models.InfoLevel))
+	}
+
+	if got := next.count(models.InfoLevel, "msg"); got != 10 {
+		t.Errorf("expected 10 forwarded records, got %d", got)
+	}
+	if got := p.Usage().Dropped; got != 5 {
+		t.Errorf("expected 5 dropped records, got %d", got)
+	}
+	if got := next.count(models.WarnLevel, ""); got != 2 {
+		t.Errorf("expected warning and exhaustion records, got %d", got)
+	}
+}
+
+func TestPublisher_ShedLevelsKeepsErrors(t *testing.T) {
+	var warnings []Usage
+	p, next, _ := newTestPublisher(Config{
+		MaxRecords: 100,
+		Policy:     PolicyShedLevels,
+		OnWarning:  func(u Usage) { warnings = append(warnings, u) },
+	})
+
+	for i := 0; i < 80; i++ {
+		p.SendMsg(record(models.InfoLevel))
+	}
+	p.SendMsg(record(models.DebugLevel))
+	p.SendMsg(record(models.ErrorLevel))
+
+	if got := next.count(models.DebugLevel, "msg"); got != 0 {
+		t.Errorf("expected debug to be shed above WarnAt, got %d", got)
+	}
+	if got := next.count(models.ErrorLevel, "msg"); got != 1 {
+		t.Errorf("expected error to be kept, got %d", got)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("expected one warning callback, got %d", len(warnings))
+	}
+}
+
+func TestPublisher_RollingWindowRecovers(t *testing.T) {
+	p, next, now := newTestPublisher(Config{MaxRecords: 5, Window: time.Hour, Buckets: 4})
+
+	for i := 0; i < 10; i++ {
+		p.SendMsg(record(models.InfoLevel))
+	}
+	*now = now.Add(2 * time.Hour)
+	p.SendMsg(record(models.InfoLevel))
+
+	if got := next.count(models.InfoLevel, "msg"); got != 6 {
+		t.Errorf("expected quota to reset after the window, got %d forwarded", got)
+	}
+	if got := p.Usage().Records; got != 1 {
+		t.Errorf("expected 1 record in the new window, got %d", got)
+	}
+}
+
+func TestPublisher_ByteQuota(t *testing.T) {
+	p, next, _ := newTestPublisher(Config{MaxBytes: 1000})
+
+	for i := 0; i < 20; i++ {
+		p.SendMsg(record(models.InfoLevel))
+	}
+
+	forwarded := next.count(models.InfoLevel, "msg")
+	if forwarded == 0 || forwarded == 20 {
+		t.Errorf("expected byte quota to cut traffic partway, got %d forwarded", forwarded)
+	}
+}
+
+func TestPublisher_SampleMetadata(t *testing.T) {
+	p, next, _ := newTestPublisher(Config{MaxRecords: 100, WarnAt: 0.1, Policy: PolicySample, SampleRate: 4, SampleMetadata: true})
+
+	shared := make([]*models.LogData, 0, 30)
+	for i := 0; i < 30; i++ {
+		data := record(models.InfoLevel)
+		shared = append(shared, data)
+		p.SendMsg(data)
+	}
+
+	next.mu.Lock()
+	defer next.mu.Unlock()
+	marked := 0
+	for _, l := range next.logs {
+		for _, f := range l.Fields {
+			if f.Key == models.FieldSampleRateKey && f.Integer == 4 {
+				marked++
+			}
+		}
+	}
+	if marked == 0 {
+		t.Error("expected sampled survivors to carry sample_rate")
+	}
+	for _, data := range shared {
+		if len(data.Fields) != 0 {
+			t.Fatal("quota must not modify the shared record")
+		}
+	}
+}
+
+func TestPublisher_SampleRateOneKeepsAll(t *testing.T) {
+	p, next, _ := newTestPublisher(Config{MaxRecords: 100, WarnAt: 0.1, Policy: PolicySample, SampleRate: 1, SampleMetadata: true})
+
+	for i := 0; i < 30; i++ {
+		p.SendMsg(record(models.InfoLevel))
+	}
+
+	if got := next.count(models.InfoLevel, "msg"); got != 30 {
+		t.Errorf("expected every record kept at rate 1, got %d", got)
+	}
+	if got := p.Usage().Dropped; got != 0 {
+		t.Errorf("expected nothing dropped, got %d", got)
+	}
+}
+
+func TestPublisher_CyclicObjectField(t *testing.T) {
+	p, next, _ := newTestPublisher(Config{MaxBytes: 1 << 20})
+
+	cyclic := map[string]any{"k": "v"}
+	cyclic["self"] = cyclic
+	data := record(models.InfoLevel)
+	data.Fields = []*models.LogField{{Key: "o", Type: models.FieldTypeObject, Object: cyclic}}
+	p.SendMsg(data)
+
+	if got := next.count(models.InfoLevel, "msg"); got != 1 {
+		t.Errorf("expected the record forwarded, got %d", got)
+	}
+	if got := p.Usage().Bytes; got <= 0 {
+		t.Errorf("expected the record accounted, got %d bytes", got)
+	}
+}
@@ -0,0 +1,186 @@
+package glog
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRateLimitSummaryInterval = 10 * time.Second
+	rateLimitedMessage              = "rate limited records"
+)
+
+// rateLimiter holds one token bucket per limited level. Records at a level
+// without a bucket are never limited.
+type rateLimiter struct {
+	mu       sync.Mutex
+	buckets  map[models.LogLevel]*tokenBucket
+	interval time.Duration
+	// pending counts the records dropped since the last summary, total
+	// those dropped since the service was created.
+	pending map[models.LogLevel]int64
+	total   map[models.LogLevel]int64
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// WithRateLimit allows at most perSecond records at level, with bursts of
+// up to burst records, e.g. WithRateLimit(models.ErrorLevel, 100, 200) to
+// keep an error storm from flooding the publishers. Records over the limit
+// are dropped before they are queued and counted; every summary interval
+// (see WithRateLimitSummary) one Warning record reports how many were
+// dropped. Call it once per level to limit; a burst below one allows one
+// second's worth of records.
+func WithRateLimit(level models.LogLevel, perSecond float64, burst int) ServiceOption {
+	return func(ls *LoggerService) {
+		if perSecond <= 0 {
+			return
+		}
+		rl := ls.rateLimiter()
+		b := float64(burst)
+		if b < 1 {
+			b = perSecond
+			if b < 1 {
+				b = 1
+			}
+		}
+		rl.buckets[level] = &tokenBucket{rate: perSecond, burst: b, tokens: b}
+	}
+}
+
+// WithRateLimitSummary sets how often the records dropped by WithRateLimit
+// are reported, 10 seconds by default. The last summary is logged by Stop.
+func WithRateLimitSummary(interval time.Duration) ServiceOption {
+	return func(ls *LoggerService) {
+		if interval > 0 {
+			ls.rateLimiter().interval = interval
+		}
+	}
+}
+
+func (ls *LoggerService) rateLimiter() *rateLimiter {
+	if ls.rateLimit == nil {
+		ls.rateLimit = &rateLimiter{
+			buckets:  make(map[models.LogLevel]*tokenBucket),
+			interval: defaultRateLimitSummaryInterval,
+			pending:  make(map[models.LogLevel]int64),
+			total:    make(map[models.LogLevel]int64),
+		}
+	}
+	return ls.rateLimit
+}
+
+// allow takes a token for a record at level, counting the record if there
+// is none.
+func (rl *rateLimiter) allow(level models.LogLevel, now time.Time) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	b, ok := rl.buckets[level]
+	if !ok {
+		return true
+	}
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true
+	}
+	rl.pending[level]++
+	rl.total[level]++
+	return false
+}
+
+// takePending returns and resets the counts since the last summary.
+func (rl *rateLimiter) takePending() map[models.LogLevel]int64 {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if len(rl.pending) == 0 {
+		return nil
+	}
+	pending := rl.pending
+	rl.pending = make(map[models.LogLevel]int64)
+	return pending
+}
+
+func (rl *rateLimiter) stats() map[string]int64 {
+	if rl == nil {
+		return nil
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	res := make(map[string]int64, len(rl.buckets))
+	for level := range rl.buckets {
+		res[level.String()] = rl.total[level]
+	}
+	return res
+}
+
+// startRateLimitSummary logs a summary every interval until
+// stopRateLimitSummary.
+func (ls *LoggerService) startRateLimitSummary() {
+	rl := ls.rateLimit
+	if rl == nil {
+		return
+	}
+	rl.done = make(chan struct{})
+	rl.wg.Add(1)
+	go func() {
+		defer rl.wg.Done()
+		ticker := time.NewTicker(rl.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ls.logRateLimitSummary()
+			case <-rl.done:
+				return
+			}
+		}
+	}()
+}
+
+// stopRateLimitSummary stops the summaries and logs the last one, before
+// Stop closes the input.
+func (ls *LoggerService) stopRateLimitSummary() {
+	rl := ls.rateLimit
+	if rl == nil || rl.done == nil {
+		return
+	}
+	close(rl.done)
+	rl.wg.Wait()
+	ls.logRateLimitSummary()
+}
+
+// logRateLimitSummary logs one Warning record with the total dropped in
+// FieldCanonicalSuppressedKey and the count per level in fields named
+// "rate_limited_<level>". The record itself is not rate limited.
+func (ls *LoggerService) logRateLimitSummary() {
+	pending := ls.rateLimit.takePending()
+	if len(pending) == 0 {
+		return
+	}
+	var total int64
+	var opts []models.Option
+	for level := models.DebugLevel; level <= models.FatalLevel; level++ {
+		if n := pending[level]; n > 0 {
+			total += n
+			opts = append(opts, models.WithIntField("rate_limited_"+level.String(), int(n)))
+		}
+	}
+	opts = append(opts, models.WithIntField(FieldCanonicalSuppressedKey, int(total)))
+	ls.NewLogger().Internal().Warning(context.Background(), rateLimitedMessage, opts...)
+}
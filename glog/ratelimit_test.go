@@ -0,0 +1,88 @@
+package glog
+
+import (
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_TokenBucket(t *testing.T) {
+	ls := NewLoggerService(WithRateLimit(models.ErrorLevel, 10, 5))
+	rl := ls.rateLimit
+	now := time.Now()
+	allowed := 0
+	for i := 0; i < 20; i++ {
+		if rl.allow(models.ErrorLevel, now) {
+			allowed++
+		}
+	}
+	if allowed != 5 {
+		t.Errorf("expected the burst of 5 allowed, got %d", allowed)
+	}
+	if !rl.allow(models.ErrorLevel, now.Add(100*time.Millisecond)) {
+		t.Error("expected a token refilled after 100ms at 10/s")
+	}
+	if rl.allow(models.ErrorLevel, now.Add(100*time.Millisecond)) {
+		t.Error("expected only one token refilled")
+	}
+	if !rl.allow(models.InfoLevel, now) {
+		t.Error("expected levels without a limit to pass")
+	}
+	if got := ls.Stats().RateLimited["error"]; got != 16 {
+		t.Errorf("expected 16 records counted, got %d", got)
+	}
+}
+
+func TestRateLimit_SummaryOnStop(t *testing.T) {
+	ls := NewLoggerService(
+		WithRateLimit(models.ErrorLevel, 1, 2),
+		WithRateLimitSummary(time.Hour),
+	)
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+
+	logger := ls.NewLogger()
+	for i := 0; i < 10; i++ {
+		logger.Error(context.Background(), fmt.Errorf("storm %d", i))
+	}
+	logger.Info(context.Background(), "not limited")
+	ls.Stop()
+
+	logs := byMsg(mock.GetLogs())
+	if len(logs) != 4 || logs["not limited"] == nil {
+		t.Fatalf("expected 2 errors, the info and a summary, got %v", logs)
+	}
+	summary := logs[rateLimitedMessage]
+	if summary == nil || summary.Level != models.WarnLevel {
+		t.Fatalf("expected a warning summary, got %+v", summary)
+	}
+	fields := map[string]int{}
+	for _, f := range summary.Fields {
+		fields[f.Key] = int(f.Integer)
+	}
+	if fields["rate_limited_error"] != 8 || fields[FieldCanonicalSuppressedKey] != 8 {
+		t.Errorf("unexpected summary fields %v", fields)
+	}
+}
+
+func TestRateLimit_PeriodicSummary(t *testing.T) {
+	ls := NewLoggerService(
+		WithRateLimit(models.WarnLevel, 1, 1),
+		WithRateLimitSummary(10*time.Millisecond),
+	)
+	mock := &mockPublisher{}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+	defer ls.Stop()
+
+	logger := ls.NewLogger()
+	logger.Warning(context.Background(), "first")
+	logger.Warning(context.Background(), "second")
+	logs := waitForLogs(mock, 2, time.Second)
+	if len(logs) != 2 || byMsg(logs)[rateLimitedMessage] == nil {
+		t.Errorf("expected the first warning and a summary, got %v", byMsg(logs))
+	}
+}
@@ -0,0 +1,151 @@
+package glog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"reflect"
+)
+
+// ErrReconfigureAfterStop is returned by Reconfigure once the service is
+// stopped. The routing is left unchanged and the caller still owns the
+// publishers it passed.
+var ErrReconfigureAfterStop = errors.New("glogger: reconfigure after Stop")
+
+// Routing is the complete set of levels and publishers Reconfigure applies.
+type Routing struct {
+	// NamedLevels replaces the named overrides, the service level being
+	// the empty prefix.
+	NamedLevels map[string]models.LogLevel
+	// ComponentLevels replaces the component overrides.
+	ComponentLevels map[string]models.LogLevel
+	Publishers      []RoutedPublisher
+}
+
+// RoutedPublisher is one publisher of a Routing.
+type RoutedPublisher struct {
+	ID        string
+	Publisher interfaces.LogPublisher
+	Options   []PublisherOption
+}
+
+// Reconfigure replaces the levels and publishers of the service in one
+// step: a record is routed either by the old routing or by the new one.
+// Publishers that are new to the service are started. Publishers that are
+// no longer part of it are stopped once the records already dispatched to
+// them are delivered, so reconfiguring drops nothing in flight.
+//
+// A publisher kept under the same ID keeps its pause and buffer; runtime
+// changes made with SetPublisherEnabled and SetSampleRate are reset to
+// the new options.
+func (ls *LoggerService) Reconfigure(ctx context.Context, routing Routing) error {
+	if ls.stopped.Load() {
+		return ErrReconfigureAfterStop
+	}
+	entries := make(map[string]*publisherEntry, len(routing.Publishers))
+	for _, p := range routing.Publishers {
+		if p.Publisher == nil {
+			return fmt.Errorf("%w: %q", ErrNilPublisher, p.ID)
+		}
+		if _, dup := entries[p.ID]; dup {
+			return fmt.Errorf("glogger: duplicate publisher %q", p.ID)
+		}
+		entry := &publisherEntry{publisher: p.Publisher}
+		for _, opt := range p.Options {
+			opt(entry)
+		}
+		entries[p.ID] = entry
+	}
+	for id, entry := range entries {
+		ls.reportErrors(id, entry.publisher)
+	}
+
+	old := ls.swapRouting(entries, routing)
+	if !ls.started.Load() {
+		// Publishers are started, and stopped, with the service.
+		return nil
+	}
+	for id, entry := range entries {
+		if s, ok := entry.publisher.(interfaces.Starter); ok && !hasPublisher(old, id, entry.publisher) {
+			ls.startPublisher(id, s)
+		}
+	}
+
+	// Records dispatched under the old routing may still be on their way
+	// to the removed publishers.
+	err := ls.Flush(ctx)
+	for id, entry := range old {
+		if hasPublisher(entries, id, entry.publisher) {
+			continue
+		}
+		if f, ok := entry.publisher.(interfaces.Flusher); ok {
+			if ferr := f.Flush(ctx); ferr != nil {
+				err = errors.Join(err, fmt.Errorf("glogger: flush publisher %q: %w", id, ferr))
+			}
+		}
+		if serr := StopPublisher(ctx, entry.publisher); serr != nil {
+			err = errors.Join(err, fmt.Errorf("glogger: stop publisher %q: %w", id, serr))
+		}
+	}
+	return err
+}
+
+// swapRouting installs entries and the levels of routing and returns the
+// previous entries. A publisher kept under the same ID hands its pause over
+// to its new entry.
+func (ls *LoggerService) swapRouting(entries map[string]*publisherEntry, routing Routing) map[string]*publisherEntry {
+	ls.namedMu.Lock()
+	defer ls.namedMu.Unlock()
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+	old := ls.loggers
+	for id, entry := range entries {
+		if prev, ok := old[id]; ok && SamePublisher(prev.publisher, entry.publisher) {
+			prev.pauseMu.Lock()
+			entry.pause = prev.pause
+			prev.pause = nil
+			prev.pauseMu.Unlock()
+		}
+	}
+	ls.loggers = entries
+	replaceLevels(&ls.namedLevels, routing.NamedLevels)
+	replaceLevels(&ls.componentLevels, routing.ComponentLevels)
+	return old
+}
+
+// SamePublisher reports whether b, registered under the same ID as a, is
+// the same publisher. Values of types that cannot be compared, such as
+// func types, cannot be told apart and are taken to be the same when their
+// types match.
+func SamePublisher(a, b interfaces.LogPublisher) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb {
+		return false
+	}
+	return ta == nil || !ta.Comparable() || a == b
+}
+
+// hasPublisher reports whether entries hold p, the publisher registered
+// under id elsewhere. A comparable publisher may have moved to another ID.
+func hasPublisher(entries map[string]*publisherEntry, id string, p interfaces.LogPublisher) bool {
+	if t := reflect.TypeOf(p); t == nil || !t.Comparable() {
+		e, ok := entries[id]
+		return ok && SamePublisher(e.publisher, p)
+	}
+	for _, e := range entries {
+		if SamePublisher(e.publisher, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func replaceLevels(p *prefixLevels, levels map[string]models.LogLevel) {
+	res := make(map[string]models.LogLevel, len(levels))
+	for k, v := range levels {
+		res[k] = v
+	}
+	p.Store(&res)
+}
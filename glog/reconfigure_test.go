@@ -0,0 +1,127 @@
+package glog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReconfigure_DrainsRemovedPublishers(t *testing.T) {
+	var events []string
+	old := &lifecyclePublisher{events: &events, name: "old"}
+	old.sendFunc = func(*models.LogData) { time.Sleep(5 * time.Millisecond) }
+	kept := &lifecyclePublisher{events: &events, name: "kept"}
+	ls := NewLoggerService(WithNamedLevels(map[string]models.LogLevel{"http": models.ErrorLevel}))
+	ls.AddLogger("old", old)
+	ls.AddLogger("kept", kept)
+	ls.Start()
+	defer ls.Stop()
+
+	logger := ls.NewLogger()
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		logger.Info(ctx, fmt.Sprintf("before %d", i))
+	}
+	added := &lifecyclePublisher{events: &events, name: "new"}
+	err := ls.Reconfigure(ctx, Routing{
+		NamedLevels:     map[string]models.LogLevel{"": models.InfoLevel},
+		ComponentLevels: map[string]models.LogLevel{"db": models.WarnLevel},
+		Publishers: []RoutedPublisher{
+			{ID: "kept", Publisher: kept, Options: []PublisherOption{WithMinLevel(models.WarnLevel)}},
+			{ID: "new", Publisher: added},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each record is routed by the old routing or by the new one, and the
+	// old publisher is stopped only after its records are delivered.
+	delivered := len(old.GetLogs())
+
+	logger.Info(ctx, "after")
+	logger.Named("http").Info(ctx, "named override cleared")
+	logger.Debug(ctx, "below the new level")
+	if err := ls.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(old.GetLogs()); n != delivered {
+		t.Errorf("expected nothing sent to the removed publisher after Reconfigure, got %d more", n-delivered)
+	}
+	if logs := byMsg(kept.GetLogs()); logs["after"] != nil {
+		t.Error("expected the kept publisher filtered by its new level")
+	}
+	logs := byMsg(added.GetLogs())
+	if logs["after"] == nil || logs["named override cleared"] == nil || logs["below the new level"] != nil {
+		t.Errorf("expected the new publisher to get the records after, got %v", logs)
+	}
+	if n := delivered + len(logs) - 2; n != 5 {
+		t.Errorf("expected every record before Reconfigure delivered once, got %d", n)
+	}
+	if _, ok := ls.NamedLevels()["http"]; ok || ls.ComponentLevels()["db"] != models.WarnLevel {
+		t.Errorf("expected the levels replaced, got %v and %v", ls.NamedLevels(), ls.ComponentLevels())
+	}
+
+	got := strings.Join(events, ",")
+	if !strings.Contains(got, "new start") || !strings.Contains(got, "old flush,old stop") ||
+		strings.Count(got, "kept start") != 1 || strings.Contains(got, "kept stop") {
+		t.Errorf("unexpected lifecycle %q", got)
+	}
+}
+
+func TestReconfigure_AfterStop(t *testing.T) {
+	ls := NewLoggerService()
+	ls.Start()
+	ls.Stop()
+	err := ls.Reconfigure(context.Background(), Routing{Publishers: []RoutedPublisher{{ID: "a", Publisher: &mockPublisher{}}}})
+	if !errors.Is(err, ErrReconfigureAfterStop) {
+		t.Errorf("expected ErrReconfigureAfterStop, got %v", err)
+	}
+}
+
+type pubFunc func(*models.LogData)
+
+func (f pubFunc) SendMsg(data *models.LogData) { f(data) }
+
+func TestReconfigure_FuncPublisher(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	pub := pubFunc(func(data *models.LogData) {
+		mu.Lock()
+		got = append(got, data.Msg)
+		mu.Unlock()
+	})
+	ls := NewLoggerService()
+	ls.AddLogger("func", pub)
+	ls.Start()
+
+	ctx := context.Background()
+	routing := Routing{Publishers: []RoutedPublisher{{ID: "func", Publisher: pub}, {ID: "other", Publisher: pubFunc(func(*models.LogData) {})}}}
+	if err := ls.Reconfigure(ctx, routing); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.Reconfigure(ctx, routing); err != nil {
+		t.Fatal(err)
+	}
+	ls.NewLogger().Info(ctx, "after")
+
+	done := make(chan struct{})
+	go func() {
+		ls.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop did not return after Reconfigure")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 1 || got[0] != "after" {
+		t.Errorf("expected the func publisher to keep receiving records, got %q", got)
+	}
+}
@@ -0,0 +1,69 @@
+package glog
+
+import (
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"sort"
+	"sync"
+)
+
+// PublisherFactory creates a publisher from its configuration.
+type PublisherFactory func(spec PublisherSpec) (interfaces.LogPublisher, error)
+
+// PublisherSpec is what a PublisherFactory gets: the publisher ID, the
+// service identity and the publisher's own settings.
+type PublisherSpec struct {
+	ID    string
+	AppID string
+	Env   string
+	// Decode decodes the publisher's settings into v, usually a pointer to
+	// a struct with json tags. It rejects unknown keys. Nil means the
+	// publisher has no settings.
+	Decode func(v any) error
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]PublisherFactory)
+)
+
+// RegisterPublisher makes a publisher type available by name, e.g. to
+// glog/config. Packages usually call it from init. It panics if name is
+// already registered or factory is nil.
+func RegisterPublisher(name string, factory PublisherFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if factory == nil {
+		panic("glogger: RegisterPublisher factory is nil")
+	}
+	if _, dup := registry[name]; dup {
+		panic(fmt.Sprintf("glogger: RegisterPublisher called twice for %q", name))
+	}
+	registry[name] = factory
+}
+
+// NewPublisher creates a publisher of the registered type name.
+func NewPublisher(name string, spec PublisherSpec) (interfaces.LogPublisher, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("glogger: unknown publisher type %q (registered: %v)", name, RegisteredPublishers())
+	}
+	if spec.Decode == nil {
+		spec.Decode = func(any) error { return nil }
+	}
+	return factory(spec)
+}
+
+// RegisteredPublishers returns the registered type names, sorted.
+func RegisteredPublishers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,38 @@
+package glog
+
+import (
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"strings"
+	"testing"
+)
+
+func TestRegisterPublisher(t *testing.T) {
+	mock := &mockPublisher{}
+	RegisterPublisher("registry-test", func(spec PublisherSpec) (interfaces.LogPublisher, error) {
+		var settings struct{}
+		return mock, spec.Decode(&settings)
+	})
+
+	p, err := NewPublisher("registry-test", PublisherSpec{ID: "x"})
+	if err != nil || p != mock {
+		t.Fatalf("expected the registered publisher, got %v, %v", p, err)
+	}
+	found := false
+	for _, name := range RegisteredPublishers() {
+		found = found || name == "registry-test"
+	}
+	if !found {
+		t.Errorf("expected registry-test in %v", RegisteredPublishers())
+	}
+
+	if _, err := NewPublisher("registry-missing", PublisherSpec{}); err == nil || !strings.Contains(err.Error(), "registry-test") {
+		t.Errorf("expected an unknown type error listing the registered types, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a duplicate registration to panic")
+		}
+	}()
+	RegisterPublisher("registry-test", func(PublisherSpec) (interfaces.LogPublisher, error) { return nil, nil })
+}
@@ -0,0 +1,130 @@
+package remote
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"io"
+)
+
+// StreamMethod is the full name of the LogCollector.Stream method of
+// log.proto.
+const StreamMethod = "/glogger.remote.v1.LogCollector/Stream"
+
+// MaxRecordSize is the largest encoded record the publisher sends. It
+// matches the default message limit of a gRPC server.
+const MaxRecordSize = 4 << 20
+
+// ErrRecordTooLarge is reported for a record over MaxRecordSize. The record
+// is dropped instead of buffered.
+var ErrRecordTooLarge = errors.New("remote: record too large")
+
+// StreamDesc describes LogCollector.Stream for grpc.ClientConn.NewStream and
+// grpc.ServiceDesc.
+var StreamDesc = grpc.StreamDesc{
+	StreamName:    "Stream",
+	ServerStreams: true,
+	ClientStreams: true,
+}
+
+// Codec is a gRPC codec for messages that are already encoded, such as the
+// output of Marshal and MarshalAck. It sends and receives *[]byte values
+// as they are. Its name is "proto", so peers using generated stubs see a
+// regular protobuf stream.
+type Codec struct{}
+
+func (Codec) Marshal(v any) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("remote: cannot encode %T", v)
+	}
+	return *b, nil
+}
+
+func (Codec) Unmarshal(data []byte, v any) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("remote: cannot decode into %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (Codec) Name() string {
+	return "proto"
+}
+
+// MarshalAck encodes a StreamAck message.
+func MarshalAck(received int64) []byte {
+	if received == 0 {
+		return nil
+	}
+	// Field 1, varint.
+	return binary.AppendUvarint([]byte{0x08}, uint64(received))
+}
+
+// UnmarshalAck decodes a StreamAck message.
+func UnmarshalAck(b []byte) (int64, error) {
+	var received int64
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 || tag&7 != 0 {
+			return 0, errors.New("remote: malformed ack")
+		}
+		v, m := binary.Uvarint(b[n:])
+		if m <= 0 {
+			return 0, errors.New("remote: malformed ack")
+		}
+		if tag>>3 == 1 {
+			received = int64(v)
+		}
+		b = b[n+m:]
+	}
+	return received, nil
+}
+
+// Collector returns a Dialer that opens LogCollector streams on conn, for
+// example a *grpc.ClientConn from grpc.NewClient. The caller owns conn and
+// closes it after the publisher.
+func Collector(conn grpc.ClientConnInterface) Dialer {
+	return func(ctx context.Context) (Stream, error) {
+		// The stream outlives the flush that opened it.
+		ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		s, err := conn.NewStream(ctx, &StreamDesc, StreamMethod, grpc.ForceCodec(Codec{}))
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		return &grpcStream{s: s, cancel: cancel}, nil
+	}
+}
+
+type grpcStream struct {
+	s      grpc.ClientStream
+	cancel context.CancelFunc
+}
+
+func (g *grpcStream) Send(record []byte) error {
+	return g.s.SendMsg(&record)
+}
+
+func (g *grpcStream) Recv() (int64, error) {
+	var msg []byte
+	if err := g.s.RecvMsg(&msg); err != nil {
+		if err == io.EOF {
+			// The collector ended the stream before acknowledging.
+			return 0, io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	return UnmarshalAck(msg)
+}
+
+// Close cancels the stream. It is safe to call while Send or Recv is
+// blocked.
+func (g *grpcStream) Close() error {
+	g.cancel()
+	return nil
+}
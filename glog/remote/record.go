@@ -0,0 +1,15 @@
+package remote
+
+import (
+	"github.com/alexnobleburn/glogger/glog/models"
+)
+
+// Marshal encodes data for the collector; see models.ToProto.
+func Marshal(data *models.LogData, appID, env string) []byte {
+	return models.ToProto(data, appID, env)
+}
+
+// Unmarshal decodes a record sent by a Publisher; see models.FromProto.
+func Unmarshal(b []byte) (*models.LogData, error) {
+	return models.FromProto(b)
+}
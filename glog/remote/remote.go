@@ -0,0 +1,213 @@
+// Package remote streams records to a central collector such as glog/agent,
+// so that a process can run glogger as a thin client and leave delivery to
+// the collector.
+//
+// The transport is the gRPC LogCollector service of log.proto: records are
+// sent on a bidirectional stream as LogData messages of
+// glog/models/log.proto, encoded with models.ToProto, and the collector
+// acknowledges them as it takes them in. Collector opens such streams on a
+// grpc.ClientConn; to use another transport, implement Stream and pass its
+// Dialer to New.
+package remote
+
+import (
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/internal/batch"
+	"github.com/alexnobleburn/glogger/glog/internal/report"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRetries = 3
+	defaultTimeout = 10 * time.Second
+)
+
+// Stream carries encoded LogData messages to the collector.
+type Stream interface {
+	Send(record []byte) error
+	// Recv waits for the next acknowledgement and returns how many records
+	// the collector has received on the stream so far.
+	Recv() (int64, error)
+	// Close ends the stream. It may be called while Send or Recv is
+	// blocked, and must unblock them.
+	Close() error
+}
+
+// Dialer opens a new Stream. The publisher calls it on the first send and
+// again after a send fails.
+type Dialer func(ctx context.Context) (Stream, error)
+
+// Config configures a Publisher.
+type Config struct {
+	// AppID and Env are sent for records whose context has no models.AppID
+	// or models.EnvName.
+	AppID string
+	Env   string
+	// BatchSize, FlushInterval and MaxBuffered control batching (defaults
+	// 100, 1s and 10000). Records stay buffered while the collector is
+	// unreachable, up to MaxBuffered.
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxBuffered   int
+	// Retries per flush (default 3), each on a fresh stream, with
+	// exponential backoff starting at Backoff (default 100ms).
+	Retries int
+	Backoff time.Duration
+	// Timeout bounds sending a batch and waiting for its acknowledgement
+	// (default 10s). The stream is abandoned when it expires.
+	Timeout time.Duration
+	// ErrorHandler receives dial and send errors. Once the publisher is added
+	// to a service, the service error handler receives them too. Without
+	// either, they are printed.
+	ErrorHandler func(error)
+}
+
+// Compile-time checks that Publisher implements interfaces.LogPublisher,
+// interfaces.HealthChecker and interfaces.ErrorReporter.
+var (
+	_ interfaces.LogPublisher  = (*Publisher)(nil)
+	_ interfaces.HealthChecker = (*Publisher)(nil)
+	_ interfaces.ErrorReporter = (*Publisher)(nil)
+)
+
+// Publisher encodes records and streams them in batches. A batch counts as
+// delivered once the collector has acknowledged all of its records. When the
+// stream fails part-way, the records acknowledged so far are done and only
+// the rest is sent again on a new stream.
+type Publisher struct {
+	cfg     Config
+	errs    *report.Handler
+	dial    Dialer
+	batcher *batch.Batcher[[]byte]
+
+	mu     sync.Mutex
+	stream Stream
+	// Records sent and acknowledged on stream.
+	sent, acked int64
+}
+
+func New(dial Dialer, cfg Config) *Publisher {
+	if cfg.Retries == 0 {
+		cfg.Retries = defaultRetries
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	errs := report.New(cfg.ErrorHandler)
+	cfg.ErrorHandler = errs.Handle
+	p := &Publisher{cfg: cfg, dial: dial, errs: errs}
+	p.batcher = batch.New(batch.Config{
+		Size:         cfg.BatchSize,
+		Interval:     cfg.FlushInterval,
+		MaxBuffered:  cfg.MaxBuffered,
+		Retries:      cfg.Retries,
+		Backoff:      cfg.Backoff,
+		ErrorHandler: cfg.ErrorHandler,
+	}, p.send)
+	return p
+}
+
+// ReportErrorsTo implements interfaces.ErrorReporter.
+func (p *Publisher) ReportErrorsTo(report func(error)) {
+	p.errs.ReportTo(report)
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	record := Marshal(data, p.cfg.AppID, p.cfg.Env)
+	if len(record) > MaxRecordSize {
+		p.cfg.ErrorHandler(fmt.Errorf("%w: %d bytes exceeds %d", ErrRecordTooLarge, len(record), MaxRecordSize))
+		return
+	}
+	if err := p.batcher.Add(record); err != nil {
+		p.cfg.ErrorHandler(fmt.Errorf("remote: %w", err))
+	}
+}
+
+// Flush sends everything buffered so far.
+func (p *Publisher) Flush(ctx context.Context) error {
+	return p.batcher.Flush(ctx)
+}
+
+// Close flushes, stops the background goroutine and closes the stream.
+func (p *Publisher) Close() error {
+	err := p.batcher.Close()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stream != nil {
+		if cerr := p.stream.Close(); err == nil {
+			err = cerr
+		}
+		p.stream = nil
+	}
+	return err
+}
+
+// Dropped returns how many records were discarded because the buffer was
+// full.
+func (p *Publisher) Dropped() int64 {
+	return p.batcher.Dropped()
+}
+
+// HealthCheck returns the error of the last failed delivery to the collector
+// until a batch goes through again, and an error once the publisher is
+// closed.
+func (p *Publisher) HealthCheck(ctx context.Context) error {
+	return p.batcher.Err()
+}
+
+func (p *Publisher) send(ctx context.Context, records [][]byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stream == nil {
+		s, err := p.dial(ctx)
+		if err != nil {
+			return fmt.Errorf("remote: dial: %w", err)
+		}
+		p.stream, p.sent, p.acked = s, 0, 0
+	}
+	stream := p.stream
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() { _ = stream.Close() })
+	defer stop()
+
+	// Earlier batches were acknowledged in full.
+	base := p.sent
+	for _, r := range records {
+		if err := stream.Send(r); err != nil {
+			return p.fail(base, fmt.Errorf("remote: send: %w", err))
+		}
+		p.sent++
+	}
+	for p.acked < p.sent {
+		n, err := stream.Recv()
+		if err != nil {
+			return p.fail(base, fmt.Errorf("remote: ack: %w", err))
+		}
+		p.acked = n
+	}
+	return nil
+}
+
+// fail closes the stream after err and reports how much of the batch that
+// started at base was acknowledged before it failed.
+func (p *Publisher) fail(base int64, err error) error {
+	// Acknowledgements already on the way still count.
+	for p.acked < p.sent {
+		n, rerr := p.stream.Recv()
+		if rerr != nil {
+			break
+		}
+		p.acked = n
+	}
+	_ = p.stream.Close()
+	p.stream = nil
+	if done := p.acked - base; done > 0 {
+		return batch.Partial(int(done), err)
+	}
+	return err
+}
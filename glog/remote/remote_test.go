@@ -0,0 +1,173 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAck_RoundTrip(t *testing.T) {
+	for _, n := range []int64{0, 1, 300, 1 << 40} {
+		got, err := UnmarshalAck(MarshalAck(n))
+		if err != nil || got != n {
+			t.Errorf("expected %d, got %d, %v", n, got, err)
+		}
+	}
+	if _, err := UnmarshalAck([]byte{0x08}); err == nil {
+		t.Error("expected an error for a truncated ack")
+	}
+}
+
+type sliceStream struct {
+	records [][]byte
+}
+
+func (s *sliceStream) Send(record []byte) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *sliceStream) Recv() (int64, error) { return int64(len(s.records)), nil }
+func (s *sliceStream) Close() error         { return nil }
+
+func TestPublisher_DropsOnlyOversizedRecord(t *testing.T) {
+	stream := &sliceStream{}
+	var errs []error
+	pub := New(func(context.Context) (Stream, error) { return stream, nil }, Config{ErrorHandler: func(err error) { errs = append(errs, err) }})
+	ctx := context.Background()
+	pub.SendMsg(&models.LogData{Ctx: ctx, Msg: "before"})
+	pub.SendMsg(&models.LogData{Ctx: ctx, Msg: strings.Repeat("x", MaxRecordSize)})
+	pub.SendMsg(&models.LogData{Ctx: ctx, Msg: "after"})
+	if err := pub.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, r := range stream.records {
+		data, _ := Unmarshal(r)
+		got = append(got, data.Msg)
+	}
+	if len(got) != 2 || got[0] != "before" || got[1] != "after" {
+		t.Errorf("expected the records around the oversized one delivered, got %q", got)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], ErrRecordTooLarge) {
+		t.Errorf("expected the oversized record reported, got %v", errs)
+	}
+}
+
+// collector serves LogCollector.Stream. The first stream acknowledges
+// failAfter records and then fails.
+type collector struct {
+	failAfter int
+
+	mu      sync.Mutex
+	msgs    []string
+	streams int
+}
+
+func (c *collector) stream(_ any, ss grpc.ServerStream) error {
+	c.mu.Lock()
+	c.streams++
+	first := c.streams == 1
+	c.mu.Unlock()
+
+	var received int64
+	for {
+		var msg []byte
+		if err := ss.RecvMsg(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if first && received == int64(c.failAfter) {
+			return status.Error(codes.Unavailable, "collector restarting")
+		}
+		data, err := Unmarshal(msg)
+		if err != nil {
+			return err
+		}
+		c.mu.Lock()
+		c.msgs = append(c.msgs, data.Msg)
+		c.mu.Unlock()
+		received++
+		ack := MarshalAck(received)
+		if err := ss.SendMsg(&ack); err != nil {
+			return err
+		}
+	}
+}
+
+// serveCollector serves handler as LogCollector.Stream and returns a
+// connection to it.
+func serveCollector(t *testing.T, handler grpc.StreamHandler) *grpc.ClientConn {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := grpc.NewServer(grpc.ForceServerCodec(Codec{}))
+	desc := StreamDesc
+	desc.Handler = handler
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "glogger.remote.v1.LogCollector",
+		Streams:     []grpc.StreamDesc{desc},
+	}, nil)
+	go func() { _ = s.Serve(ln) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestPublisher_ResendsOnlyUnacknowledged(t *testing.T) {
+	c := &collector{failAfter: 2}
+	pub := New(Collector(serveCollector(t, c.stream)), Config{Backoff: 10 * time.Millisecond, ErrorHandler: func(error) {}})
+	for _, msg := range []string{"a", "b", "c", "d", "e"} {
+		pub.SendMsg(&models.LogData{Ctx: context.Background(), Msg: msg})
+	}
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := pub.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if got := strings.Join(c.msgs, ""); got != "abcde" {
+		t.Errorf("expected every record delivered once, got %q", got)
+	}
+	if c.streams != 2 {
+		t.Errorf("expected a second stream, got %d", c.streams)
+	}
+}
+
+func TestPublisher_TimesOutWithoutAck(t *testing.T) {
+	conn := serveCollector(t, func(_ any, ss grpc.ServerStream) error {
+		<-ss.Context().Done()
+		return nil
+	})
+	pub := New(Collector(conn), Config{Retries: 1, Backoff: time.Millisecond, Timeout: 20 * time.Millisecond, ErrorHandler: func(error) {}})
+	defer pub.Close()
+	pub.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "unacknowledged"})
+	if err := pub.Flush(context.Background()); err == nil {
+		t.Fatal("expected the flush to fail without an acknowledgement")
+	}
+	if pub.HealthCheck(context.Background()) == nil {
+		t.Error("expected the failure reported by HealthCheck")
+	}
+}
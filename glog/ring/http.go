@@ -0,0 +1,72 @@
+package ring
+
+import (
+	"bytes"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/query"
+	"net/http"
+)
+
+// Handler serves the buffered records, oldest first, filtered by the query
+// parameters of query.ParseFilter:
+//
+//	mux.Handle("/debug/logs", buf.Handler())
+//	// GET /debug/logs?level=warn&component=billing&limit=50
+//	// GET /debug/logs?format=text for one console line per record
+//
+// The response is a JSON array in the layout of encoder.NewJSON(appID, env).
+// It exposes every record held, so mount it behind authentication.
+func (b *Buffer) Handler(appID, env string) http.Handler {
+	jsonEnc := encoder.NewJSON(appID, env)
+	textEnc := encoder.NewConsole()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter, err := query.ParseFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		records := b.Query(filter).All()
+
+		var (
+			enc  interfaces.Encoder = jsonEnc
+			text                    = r.URL.Query().Get("format") == "text"
+			buf  bytes.Buffer
+		)
+		if text {
+			enc = textEnc
+		} else {
+			buf.WriteByte('[')
+		}
+		n := 0
+		for _, rec := range records {
+			// Encode with the time the buffer recorded, not the time of the
+			// request.
+			data := *rec.Data
+			data.Time = rec.Time
+			line, err := enc.Encode(&data)
+			if err != nil {
+				continue
+			}
+			line = bytes.TrimRight(line, "\n")
+			if text {
+				buf.Write(line)
+				buf.WriteByte('\n')
+				continue
+			}
+			if n > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(line)
+			n++
+		}
+		if text {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		} else {
+			buf.WriteByte(']')
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write(buf.Bytes())
+	})
+}
@@ -0,0 +1,78 @@
+package ring
+
+import (
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler_JSON(t *testing.T) {
+	b := New(10)
+	b.SendMsg(newRecord(models.InfoLevel, "started", "api"))
+	b.SendMsg(newRecord(models.ErrorLevel, "db down", "db"))
+	b.SendMsg(newRecord(models.WarnLevel, "slow query", "db"))
+	b.SendMsg(newRecord(models.ErrorLevel, "timeout", "api"))
+
+	srv := httptest.NewServer(b.Handler("my-app", "prod"))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/debug/logs?level=warn&component=db")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	var recs []map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&recs); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(recs) != 2 || recs[0]["msg"] != "db down" || recs[1]["msg"] != "slow query" {
+		t.Fatalf("expected [db down, slow query], got %v", recs)
+	}
+	if recs[0]["service_name"] != "my-app" || recs[0]["env"] != "prod" {
+		t.Errorf("expected app and env in output, got %v", recs[0])
+	}
+}
+
+func TestHandler_TextAndLimit(t *testing.T) {
+	b := New(10)
+	for _, msg := range []string{"one", "two", "three"} {
+		b.SendMsg(newRecord(models.InfoLevel, msg, "api"))
+	}
+	srv := httptest.NewServer(b.Handler("", ""))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?format=text&limit=2")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "[api] two") || !strings.HasSuffix(lines[1], "[api] three") {
+		t.Errorf("expected the last two records as console lines, got %q", body)
+	}
+
+	resp, err = http.Get(srv.URL + "?level=loud")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown level, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandler_Empty(t *testing.T) {
+	rec := httptest.NewRecorder()
+	New(5).Handler("", "").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/logs", nil))
+	if got := strings.TrimSpace(rec.Body.String()); got != "[]" {
+		t.Errorf("expected an empty array, got %q", got)
+	}
+}
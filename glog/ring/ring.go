@@ -0,0 +1,91 @@
+// Package ring keeps the most recent log records in memory so they can be
+// inspected without any external backend.
+package ring
+
+import (
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/query"
+	"sync"
+	"time"
+)
+
+const defaultSize = 1000
+
+// Compile-time checks that Buffer is both a publisher and a query source.
+var (
+	_ interfaces.LogPublisher = (*Buffer)(nil)
+	_ query.Source            = (*Buffer)(nil)
+)
+
+// Buffer is a publisher holding the last N records.
+type Buffer struct {
+	mu      sync.RWMutex
+	records []query.Record
+	next    int
+	full    bool
+	now     func() time.Time
+}
+
+// New creates a Buffer keeping the last size records (default 1000).
+func New(size int) *Buffer {
+	if size <= 0 {
+		size = defaultSize
+	}
+	return &Buffer{
+		records: make([]query.Record, size),
+		now:     time.Now,
+	}
+}
+
+func (b *Buffer) SendMsg(data *models.LogData) {
+	rec := query.Record{Time: data.TimeOr(b.now()), Data: data}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.records[b.next] = rec
+	b.next++
+	if b.next == len(b.records) {
+		b.next = 0
+		b.full = true
+	}
+}
+
+// Len returns the number of records currently held.
+func (b *Buffer) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.full {
+		return len(b.records)
+	}
+	return b.next
+}
+
+// Query returns the matching records in chronological order.
+func (b *Buffer) Query(filter query.Filter) *query.Iterator {
+	b.mu.RLock()
+	var res []query.Record
+	b.each(func(rec query.Record) {
+		if filter.Match(rec) {
+			res = append(res, rec)
+		}
+	})
+	b.mu.RUnlock()
+
+	if filter.Limit > 0 && len(res) > filter.Limit {
+		res = res[len(res)-filter.Limit:]
+	}
+	return query.NewIterator(res)
+}
+
+// each visits records oldest first. The caller must hold the lock.
+func (b *Buffer) each(fn func(query.Record)) {
+	if b.full {
+		for _, rec := range b.records[b.next:] {
+			fn(rec)
+		}
+	}
+	for _, rec := range b.records[:b.next] {
+		fn(rec)
+	}
+}
@@ -0,0 +1,83 @@
+package ring
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/glogtest"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/query"
+	"testing"
+	"time"
+)
+
+func newRecord(level models.LogLevel, msg, component string) *models.LogData {
+	data := &models.LogData{Ctx: context.Background(), Msg: msg, Level: level}
+	if component != "" {
+		data.Fields = append(data.Fields,
+			&models.LogField{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: component})
+	}
+	return data
+}
+
+func TestBuffer_KeepsLastN(t *testing.T) {
+	b := New(3)
+	for _, msg := range []string{"1", "2", "3", "4", "5"} {
+		b.SendMsg(newRecord(models.InfoLevel, msg, ""))
+	}
+
+	if b.Len() != 3 {
+		t.Fatalf("expected 3 records, got %d", b.Len())
+	}
+	recs := b.Query(query.Filter{}).All()
+	var msgs []string
+	for _, r := range recs {
+		msgs = append(msgs, r.Data.Msg)
+	}
+	if len(msgs) != 3 || msgs[0] != "3" || msgs[2] != "5" {
+		t.Errorf("expected [3 4 5] in order, got %v", msgs)
+	}
+}
+
+func TestBuffer_QueryFilters(t *testing.T) {
+	b := New(10)
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	i := 0
+	b.now = func() time.Time {
+		i++
+		return base.Add(time.Duration(i) * time.Minute)
+	}
+
+	b.SendMsg(newRecord(models.DebugLevel, "cache miss", "cache"))
+	b.SendMsg(newRecord(models.ErrorLevel, "db down", "db"))
+	errWithField := newRecord(models.ErrorLevel, "payment failed", "billing")
+	errWithField.Fields = append(errWithField.Fields,
+		&models.LogField{Key: "order_id", Type: models.FieldTypeInt, Integer: 42})
+	b.SendMsg(errWithField)
+	b.SendMsg(newRecord(models.InfoLevel, "ok", "billing"))
+
+	errors := b.Query(query.Filter{Levels: query.LevelsFrom(models.ErrorLevel)}).All()
+	if len(errors) != 2 {
+		t.Errorf("expected 2 errors, got %d", len(errors))
+	}
+
+	billing := b.Query(query.Filter{Component: "billing", Fields: map[string]string{"order_id": "42"}}).All()
+	if len(billing) != 1 || billing[0].Data.Msg != "payment failed" {
+		t.Errorf("unexpected component/field match: %+v", billing)
+	}
+
+	window := b.Query(query.Filter{Since: base.Add(2 * time.Minute), Until: base.Add(3 * time.Minute)}).All()
+	if len(window) != 2 {
+		t.Errorf("expected 2 records in time range, got %d", len(window))
+	}
+
+	latest := b.Query(query.Filter{Limit: 1}).All()
+	if len(latest) != 1 || latest[0].Data.Msg != "ok" {
+		t.Errorf("expected newest record with limit 1, got %+v", latest)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	glogtest.RunPublisherConformance(t, func(t testing.TB) interfaces.LogPublisher {
+		return New(100)
+	})
+}
@@ -0,0 +1,95 @@
+package glog
+
+import (
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"time"
+)
+
+// PublisherState is the runtime routing state of one publisher.
+type PublisherState struct {
+	Enabled    bool   `json:"enabled"`
+	Paused     bool   `json:"paused"`
+	SampleRate int    `json:"sample_rate,omitempty"`
+	MinLevel   string `json:"min_level,omitempty"`
+}
+
+// PublisherStates returns the state of every registered publisher, keyed by
+// ID.
+func (ls *LoggerService) PublisherStates() map[string]PublisherState {
+	ls.mutex.RLock()
+	defer ls.mutex.RUnlock()
+	now := time.Now()
+	states := make(map[string]PublisherState, len(ls.loggers))
+	for id, entry := range ls.loggers {
+		state := PublisherState{
+			Enabled:    !entry.disabled.Load(),
+			SampleRate: int(entry.sampleRate.Load()),
+		}
+		if entry.minLevel != nil {
+			state.MinLevel = entry.minLevel.String()
+		}
+		entry.pauseMu.Lock()
+		state.Paused = entry.pause != nil && now.Before(entry.pause.until)
+		entry.pauseMu.Unlock()
+		states[id] = state
+	}
+	return states
+}
+
+// SetPublisherEnabled stops or resumes sending records to the publisher
+// registered as loggerID. Records sent while it is disabled are dropped,
+// not buffered; use PausePublisher to buffer them.
+func (ls *LoggerService) SetPublisherEnabled(loggerID string, enabled bool) error {
+	entry, err := ls.entry(loggerID)
+	if err != nil {
+		return err
+	}
+	entry.disabled.Store(!enabled)
+	return nil
+}
+
+// SetSampleRate changes the sample rate of the publisher registered as
+// loggerID, see WithSampleRate. A rate of 0 or 1 sends every record.
+func (ls *LoggerService) SetSampleRate(loggerID string, n int) error {
+	if n < 0 {
+		return fmt.Errorf("glogger: invalid sample rate %d", n)
+	}
+	entry, err := ls.entry(loggerID)
+	if err != nil {
+		return err
+	}
+	entry.sampleRate.Store(int64(n))
+	return nil
+}
+
+// NamedLevels returns a copy of the named level overrides, including the
+// service level under "".
+func (ls *LoggerService) NamedLevels() map[string]models.LogLevel {
+	return copyLevels(ls.namedLevels.Load())
+}
+
+// ComponentLevels returns a copy of the component level overrides.
+func (ls *LoggerService) ComponentLevels() map[string]models.LogLevel {
+	return copyLevels(ls.componentLevels.Load())
+}
+
+func copyLevels(levels *map[string]models.LogLevel) map[string]models.LogLevel {
+	res := make(map[string]models.LogLevel)
+	if levels != nil {
+		for k, v := range *levels {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+func (ls *LoggerService) entry(loggerID string) (*publisherEntry, error) {
+	ls.mutex.RLock()
+	defer ls.mutex.RUnlock()
+	entry, ok := ls.loggers[loggerID]
+	if !ok {
+		return nil, fmt.Errorf("glogger: unknown publisher %q", loggerID)
+	}
+	return entry, nil
+}
@@ -0,0 +1,104 @@
+package glog
+
+import (
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+)
+
+func TestSetPublisherEnabledAndSampleRate(t *testing.T) {
+	ls := NewLoggerService()
+	file := &mockPublisher{}
+	kafka := &mockPublisher{}
+	ls.AddLogger("file", file, WithSampleRate(5))
+	ls.AddLogger("kafka", kafka, WithMinLevel(models.WarnLevel))
+	ls.Start()
+	defer ls.Stop()
+
+	if err := ls.SetPublisherEnabled("kafka", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.SetPublisherEnabled("missing", false); err == nil {
+		t.Error("expected an error for an unknown publisher")
+	}
+	if err := ls.SetSampleRate("file", -1); err == nil {
+		t.Error("expected an error for a negative rate")
+	}
+
+	logger := ls.NewLogger()
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		logger.Info(ctx, fmt.Sprintf("info %d", i))
+	}
+	logger.Error(ctx, fmt.Errorf("failure"))
+	if err := ls.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(file.GetLogs()); n != 3 {
+		t.Errorf("expected 2 sampled records and the error, got %d", n)
+	}
+	if n := len(kafka.GetLogs()); n != 0 {
+		t.Errorf("expected nothing sent to the disabled publisher, got %d", n)
+	}
+
+	want := PublisherState{Enabled: false, MinLevel: "warn"}
+	if got := ls.PublisherStates()["kafka"]; got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	_ = ls.SetPublisherEnabled("kafka", true)
+	_ = ls.SetSampleRate("file", 0)
+	logger.Warning(ctx, "warning")
+	if err := ls.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(kafka.GetLogs()); n != 1 {
+		t.Errorf("expected the re-enabled publisher to get the warning, got %d", n)
+	}
+	if n := len(file.GetLogs()); n != 4 {
+		t.Errorf("expected every record once the rate is cleared, got %d", n)
+	}
+}
+
+func TestWithSampleRate_Metadata(t *testing.T) {
+	ls := NewLoggerService(WithBlockingSend())
+	sampled := &mockPublisher{}
+	full := &mockPublisher{}
+	ls.AddLogger("sampled", sampled, WithSampleRate(4, WithSampleMetadata()))
+	ls.AddLogger("full", full)
+	ls.Start()
+	defer ls.Stop()
+
+	logger := ls.NewLogger()
+	ctx := context.Background()
+	for i := 0; i < 8; i++ {
+		logger.Info(ctx, fmt.Sprintf("info %d", i))
+	}
+	logger.Error(ctx, fmt.Errorf("failure"))
+	if err := ls.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	logs := sampled.GetLogs()
+	if len(logs) != 3 {
+		t.Fatalf("expected 2 sampled records and the error, got %d", len(logs))
+	}
+	for _, l := range logs {
+		f := fieldByKey(l, models.FieldSampleRateKey)
+		if l.Level == models.ErrorLevel {
+			if f != nil {
+				t.Error("records kept regardless of sampling must not be marked")
+			}
+			continue
+		}
+		if f == nil || f.Integer != 4 || fieldByKey(l, models.FieldSampledKey) == nil {
+			t.Errorf("expected %q to carry sampled and sample_rate=4", l.Msg)
+		}
+	}
+	for _, l := range full.GetLogs() {
+		if fieldByKey(l, models.FieldSampledKey) != nil {
+			t.Fatalf("marking must not leak into the shared record, got %q marked", l.Msg)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+// Package s3 archives records as gzip-compressed NDJSON objects in S3 or any
+// S3-compatible store. Records are collected into one object until it
+// reaches a size or age threshold, then uploaded under a key built from a
+// template such as "logs/{app}/{date}/{uuid}.json.gz".
+package s3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/internal/batch"
+	"github.com/alexnobleburn/glogger/glog/internal/report"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultKeyTemplate = "logs/{app}/{date}/{uuid}.json.gz"
+	defaultMaxBytes    = 8 << 20
+	defaultMaxAge      = 5 * time.Minute
+	defaultMaxPending  = 100
+	defaultRetries     = 3
+)
+
+// Config configures a Publisher.
+type Config struct {
+	// KeyTemplate names each object (default
+	// "logs/{app}/{date}/{uuid}.json.gz"). It accepts {app}, {env},
+	// {date} (2006-01-02), {hour} (15), {timestamp} (20060102T150405Z) and
+	// {uuid}; times are those of the object's first record, in UTC.
+	KeyTemplate string
+	// AppID and Env fill {app} and {env} and are used by the default
+	// encoder when the record context has no models.AppID or models.EnvName.
+	AppID string
+	Env   string
+	// Encoder writes one line per record. Defaults to
+	// encoder.NewJSON(AppID, Env).
+	Encoder interfaces.Encoder
+	// MaxBytes uploads the object once this much uncompressed NDJSON is
+	// buffered (default 8 MiB).
+	MaxBytes int
+	// MaxAge uploads the object this long after its first record (default
+	// 5m), so quiet services still archive regularly.
+	MaxAge time.Duration
+	// MaxPending bounds the finished objects kept while uploads fail
+	// (default 100); the oldest are dropped first.
+	MaxPending int
+	// Retries for failed uploads (default 3), with exponential backoff
+	// starting at Backoff (default 100ms).
+	Retries int
+	Backoff time.Duration
+	// ErrorHandler receives encoding and upload errors. Once the publisher is
+	// added to a service, the service error handler receives them too. Without
+	// either, they are printed.
+	ErrorHandler func(error)
+}
+
+type object struct {
+	key  string
+	body []byte
+}
+
+// Compile-time checks that Publisher implements interfaces.LogPublisher and
+// interfaces.ErrorReporter.
+var (
+	_ interfaces.LogPublisher  = (*Publisher)(nil)
+	_ interfaces.ErrorReporter = (*Publisher)(nil)
+)
+
+// Publisher compresses records into the current object as they arrive and
+// uploads finished objects from a background goroutine.
+type Publisher struct {
+	cfg      Config
+	errs     *report.Handler
+	uploader Uploader
+	batcher  *batch.Batcher[*object]
+	now      func() time.Time
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	gz      *gzip.Writer
+	size    int
+	first   time.Time
+	timer   *time.Timer
+	current int
+}
+
+func New(uploader Uploader, cfg Config) *Publisher {
+	if cfg.KeyTemplate == "" {
+		cfg.KeyTemplate = defaultKeyTemplate
+	}
+	if cfg.Encoder == nil {
+		cfg.Encoder = encoder.NewJSON(cfg.AppID, cfg.Env)
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = defaultMaxBytes
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = defaultMaxAge
+	}
+	if cfg.MaxPending <= 0 {
+		cfg.MaxPending = defaultMaxPending
+	}
+	if cfg.Retries == 0 {
+		cfg.Retries = defaultRetries
+	}
+	errs := report.New(cfg.ErrorHandler)
+	cfg.ErrorHandler = errs.Handle
+	p := &Publisher{cfg: cfg, uploader: uploader, now: time.Now, errs: errs}
+	p.batcher = batch.New(batch.Config{
+		Size:         1,
+		Interval:     time.Second,
+		MaxBuffered:  cfg.MaxPending,
+		Retries:      cfg.Retries,
+		Backoff:      cfg.Backoff,
+		ErrorHandler: cfg.ErrorHandler,
+	}, p.upload)
+	return p
+}
+
+// ReportErrorsTo implements interfaces.ErrorReporter.
+func (p *Publisher) ReportErrorsTo(report func(error)) {
+	p.errs.ReportTo(report)
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	line, err := p.cfg.Encoder.Encode(data)
+	if err != nil {
+		p.cfg.ErrorHandler(fmt.Errorf("s3: encode: %w", err))
+		return
+	}
+	line = append(bytes.TrimRight(line, "\n"), '\n')
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.gz == nil {
+		p.gz = gzip.NewWriter(&p.buf)
+		p.first = data.TimeOr(p.now())
+		generation := p.current
+		p.timer = time.AfterFunc(p.cfg.MaxAge, func() { p.rotateIf(generation) })
+	}
+	if _, err := p.gz.Write(line); err != nil {
+		p.cfg.ErrorHandler(fmt.Errorf("s3: compress: %w", err))
+		return
+	}
+	p.size += len(line)
+	if p.size >= p.cfg.MaxBytes {
+		p.rotateLocked()
+	}
+}
+
+// rotateIf finishes the object started in generation, unless it was already
+// finished by size or Flush.
+func (p *Publisher) rotateIf(generation int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.current == generation {
+		p.rotateLocked()
+	}
+}
+
+// rotateLocked finishes the current object and queues it for upload.
+func (p *Publisher) rotateLocked() {
+	if p.gz == nil {
+		return
+	}
+	p.timer.Stop()
+	if err := p.gz.Close(); err != nil {
+		p.cfg.ErrorHandler(fmt.Errorf("s3: compress: %w", err))
+	}
+	obj := &object{key: p.key(p.first), body: append([]byte(nil), p.buf.Bytes()...)}
+	p.buf.Reset()
+	p.gz, p.size, p.timer = nil, 0, nil
+	p.current++
+	if err := p.batcher.Add(obj); err != nil {
+		p.cfg.ErrorHandler(fmt.Errorf("s3: %w", err))
+	}
+}
+
+func (p *Publisher) key(t time.Time) string {
+	t = t.UTC()
+	return strings.NewReplacer(
+		"{app}", p.cfg.AppID,
+		"{env}", p.cfg.Env,
+		"{date}", t.Format("2006-01-02"),
+		"{hour}", t.Format("15"),
+		"{timestamp}", t.Format("20060102T150405Z"),
+		"{uuid}", newUUID(),
+	).Replace(p.cfg.KeyTemplate)
+}
+
+// Flush finishes the current object and uploads everything pending.
+func (p *Publisher) Flush(ctx context.Context) error {
+	p.mu.Lock()
+	p.rotateLocked()
+	p.mu.Unlock()
+	return p.batcher.Flush(ctx)
+}
+
+// Close uploads the current object and stops the background goroutine.
+func (p *Publisher) Close() error {
+	p.mu.Lock()
+	p.rotateLocked()
+	p.mu.Unlock()
+	return p.batcher.Close()
+}
+
+// Dropped returns how many finished objects were discarded because
+// MaxPending was exceeded or the store rejected them.
+func (p *Publisher) Dropped() int64 {
+	return p.batcher.Dropped()
+}
+
+func (p *Publisher) upload(ctx context.Context, objects []*object) error {
+	for _, obj := range objects {
+		err := p.uploader.Upload(ctx, obj.key, obj.body, "application/x-ndjson", "gzip")
+		if err == nil {
+			continue
+		}
+		// Client errors other than timeouts and throttling will not go away
+		// on retry.
+		var status *StatusError
+		if errors.As(err, &status) && status.Code/100 == 4 &&
+			status.Code != http.StatusRequestTimeout && status.Code != http.StatusTooManyRequests {
+			return batch.Permanent(fmt.Errorf("s3: upload %s: %w", obj.key, err))
+		}
+		return fmt.Errorf("s3: upload %s: %w", obj.key, err)
+	}
+	return nil
+}
+
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
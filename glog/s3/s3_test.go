@@ -0,0 +1,126 @@
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	keys    []string
+	fail    int
+}
+
+func (m *memStore) Upload(_ context.Context, key string, body []byte, contentType, contentEncoding string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fail > 0 {
+		m.fail--
+		return &StatusError{Code: 503, Status: "503 Service Unavailable"}
+	}
+	if m.objects == nil {
+		m.objects = make(map[string][]byte)
+	}
+	m.objects[key] = body
+	m.keys = append(m.keys, key)
+	return nil
+}
+
+func (m *memStore) lines(t *testing.T, key string) []map[string]any {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(m.objects[key]))
+	if err != nil {
+		t.Fatalf("object %s is not gzip: %v", key, err)
+	}
+	var out []map[string]any
+	sc := bufio.NewScanner(zr)
+	for sc.Scan() {
+		var rec map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatalf("object %s has a non-JSON line: %v", key, err)
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+func record(msg string, ts time.Time) *models.LogData {
+	return &models.LogData{Ctx: context.Background(), Msg: msg, Level: models.InfoLevel, Time: ts}
+}
+
+func TestPublisher_RotatesBySizeAndNamesByTemplate(t *testing.T) {
+	store := &memStore{}
+	pub := New(store, Config{AppID: "shop", KeyTemplate: "logs/{app}/{date}/{hour}/{uuid}.json.gz", MaxBytes: 200, Backoff: time.Millisecond})
+	ts := time.Date(2024, 5, 1, 13, 30, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		pub.SendMsg(record("order placed", ts))
+	}
+	if err := pub.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.keys) < 2 {
+		t.Fatalf("expected the size threshold to split objects, got %d", len(store.keys))
+	}
+	keyPattern := regexp.MustCompile(`^logs/shop/2024-05-01/13/[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}\.json\.gz$`)
+	total := 0
+	for _, k := range store.keys {
+		if !keyPattern.MatchString(k) {
+			t.Errorf("unexpected key %q", k)
+		}
+		for _, rec := range store.lines(t, k) {
+			total++
+			if rec["msg"] != "order placed" {
+				t.Errorf("unexpected record %v", rec)
+			}
+		}
+	}
+	if total != 6 {
+		t.Errorf("expected 6 records across objects, got %d", total)
+	}
+}
+
+func TestPublisher_RotatesByAge(t *testing.T) {
+	store := &memStore{}
+	pub := New(store, Config{MaxAge: 20 * time.Millisecond})
+	defer pub.Close()
+	pub.SendMsg(record("quiet", time.Now()))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		_ = pub.batcher.Flush(context.Background())
+		store.mu.Lock()
+		n := len(store.keys)
+		store.mu.Unlock()
+		if n == 1 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("expected the object uploaded after MaxAge")
+}
+
+func TestPublisher_RetriesUploads(t *testing.T) {
+	store := &memStore{fail: 2}
+	pub := New(store, Config{Backoff: time.Millisecond})
+	pub.SendMsg(record("kept", time.Now()))
+	if err := pub.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.keys) != 1 || pub.Dropped() != 0 {
+		t.Errorf("expected the object uploaded after retries, got %d uploads, %d dropped", len(store.keys), pub.Dropped())
+	}
+}
@@ -0,0 +1,388 @@
+// Package safejson encodes FieldTypeObject values for JSON-producing
+// publishers. Values that cannot be encoded (channels, funcs, complex
+// numbers, cyclic structures) never make a publisher fail, hang or emit
+// partial output: the offending part is replaced by a placeholder string and
+// the problem is reported to the caller.
+package safejson
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxDepth bounds how many levels of nested maps, slices and structs
+// Marshal follows, so that an entity graph with back-references cannot blow
+// up the output.
+const DefaultMaxDepth = 16
+
+// UnserializableError describes a value that was replaced by a placeholder.
+type UnserializableError struct {
+	Type string
+	Err  error
+}
+
+func (e *UnserializableError) Error() string {
+	return fmt.Sprintf("safejson: cannot encode %s: %v", e.Type, e.Err)
+}
+
+func (e *UnserializableError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	ErrCycle    = errors.New("cycle detected")
+	ErrMaxDepth = errors.New("max depth exceeded")
+	ErrType     = errors.New("unsupported type")
+)
+
+// Placeholder is the string written in place of an unserializable value.
+func Placeholder(v any) string {
+	return fmt.Sprintf("<unserializable: %T>", v)
+}
+
+type options struct {
+	maxDepth int
+}
+
+// Option configures Marshal.
+type Option func(*options)
+
+// WithMaxDepth overrides DefaultMaxDepth. Values nested deeper are replaced
+// by a "<max depth: T>" placeholder.
+func WithMaxDepth(depth int) Option {
+	return func(o *options) {
+		if depth > 0 {
+			o.maxDepth = depth
+		}
+	}
+}
+
+// Marshal encodes v as JSON. The result is always valid JSON: parts of v that
+// cannot be encoded are replaced by placeholders ("<unserializable: T>",
+// "<cycle: T>", "<max depth: T>") and reported in the returned error, which
+// wraps one *UnserializableError per problem.
+func Marshal(v any, opts ...Option) ([]byte, error) {
+	o := options{maxDepth: DefaultMaxDepth}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	e := &encoder{opts: o, visiting: make(map[visitKey]bool)}
+	e.encode(reflect.ValueOf(v), 0)
+	return e.buf.Bytes(), errors.Join(e.errs...)
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+type visitKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+type encoder struct {
+	buf      bytes.Buffer
+	opts     options
+	errs     []error
+	visiting map[visitKey]bool
+}
+
+func (e *encoder) placeholder(kind string, t reflect.Type, err error) {
+	name := "nil"
+	if t != nil {
+		name = t.String()
+	}
+	e.errs = append(e.errs, &UnserializableError{Type: name, Err: err})
+	e.string(fmt.Sprintf("<%s: %s>", kind, name))
+}
+
+func (e *encoder) encode(v reflect.Value, depth int) {
+	if !v.IsValid() {
+		e.buf.WriteString("null")
+		return
+	}
+	if e.marshaler(v) {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		e.buf.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.buf.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			e.placeholder("unserializable", v.Type(), ErrType)
+			return
+		}
+		bits := 64
+		if v.Kind() == reflect.Float32 {
+			bits = 32
+		}
+		e.buf.WriteString(strconv.FormatFloat(f, 'g', -1, bits))
+	case reflect.String:
+		e.string(v.String())
+	case reflect.Interface:
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return
+		}
+		e.encode(v.Elem(), depth)
+	case reflect.Pointer:
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return
+		}
+		if e.enter(v, 0) {
+			defer e.leave(v, 0)
+			e.encode(v.Elem(), depth)
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return
+		}
+		if !e.tooDeep(v, depth) && e.enter(v, 0) {
+			defer e.leave(v, 0)
+			e.encodeMap(v, depth)
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.string(base64.StdEncoding.EncodeToString(v.Bytes()))
+			return
+		}
+		if !e.tooDeep(v, depth) && e.enter(v, v.Len()) {
+			defer e.leave(v, v.Len())
+			e.encodeArray(v, depth)
+		}
+	case reflect.Array:
+		if !e.tooDeep(v, depth) {
+			e.encodeArray(v, depth)
+		}
+	case reflect.Struct:
+		if !e.tooDeep(v, depth) {
+			e.encodeStruct(v, depth)
+		}
+	default:
+		e.placeholder("unserializable", v.Type(), ErrType)
+	}
+}
+
+// tooDeep writes a placeholder for a container nested maxDepth levels deep.
+func (e *encoder) tooDeep(v reflect.Value, depth int) bool {
+	if depth < e.opts.maxDepth {
+		return false
+	}
+	e.placeholder("max depth", v.Type(), ErrMaxDepth)
+	return true
+}
+
+// marshaler encodes values implementing json.Marshaler or
+// encoding.TextMarshaler the way encoding/json would.
+func (e *encoder) marshaler(v reflect.Value) bool {
+	if !v.CanInterface() {
+		return false
+	}
+	t := v.Type()
+	if v.Kind() != reflect.Pointer && v.CanAddr() && reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		v = v.Addr()
+	} else if !t.Implements(jsonMarshalerType) && !t.Implements(textMarshalerType) {
+		return false
+	}
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		e.buf.WriteString("null")
+		return true
+	}
+	if m, ok := v.Interface().(json.Marshaler); ok {
+		b, err := m.MarshalJSON()
+		var compact bytes.Buffer
+		if err == nil {
+			err = json.Compact(&compact, b)
+		}
+		if err != nil {
+			e.placeholder("unserializable", t, err)
+			return true
+		}
+		e.buf.Write(compact.Bytes())
+		return true
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		if err != nil {
+			e.placeholder("unserializable", t, err)
+			return true
+		}
+		e.string(string(b))
+		return true
+	}
+	return false
+}
+
+// enter marks a reference as being encoded on the current path. It returns
+// false, after writing a cycle placeholder, when the reference is already on
+// the path.
+func (e *encoder) enter(v reflect.Value, length int) bool {
+	key := visitKey{ptr: v.Pointer(), typ: v.Type(), len: length}
+	if e.visiting[key] {
+		e.placeholder("cycle", v.Type(), ErrCycle)
+		return false
+	}
+	e.visiting[key] = true
+	return true
+}
+
+func (e *encoder) leave(v reflect.Value, length int) {
+	delete(e.visiting, visitKey{ptr: v.Pointer(), typ: v.Type(), len: length})
+}
+
+func (e *encoder) encodeArray(v reflect.Value, depth int) {
+	e.buf.WriteByte('[')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		e.encode(v.Index(i), depth+1)
+	}
+	e.buf.WriteByte(']')
+}
+
+func (e *encoder) encodeMap(v reflect.Value, depth int) {
+	type entry struct {
+		key string
+		val reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, ok := mapKey(iter.Key())
+		if !ok {
+			e.placeholder("unserializable", v.Type(), ErrType)
+			return
+		}
+		entries = append(entries, entry{key: key, val: iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	e.buf.WriteByte('{')
+	for i, en := range entries {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		e.string(en.key)
+		e.buf.WriteByte(':')
+		e.encode(en.val, depth+1)
+	}
+	e.buf.WriteByte('}')
+}
+
+func mapKey(k reflect.Value) (string, bool) {
+	if k.Kind() == reflect.String {
+		return k.String(), true
+	}
+	if m, ok := k.Interface().(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		return string(b), err == nil
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), true
+	}
+	return "", false
+}
+
+func (e *encoder) encodeStruct(v reflect.Value, depth int) {
+	e.buf.WriteByte('{')
+	first := true
+	e.structFields(v, depth, &first)
+	e.buf.WriteByte('}')
+}
+
+// structFields writes the exported fields of v, honouring json tags and
+// inlining untagged embedded structs. Name conflicts between embedded
+// structs are not resolved.
+func (e *encoder) structFields(v reflect.Value, depth int, first *bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, tagOpts, _ := strings.Cut(tag, ",")
+		fv := v.Field(i)
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				e.structFields(fv, depth, first)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if strings.Contains(","+tagOpts+",", ",omitempty,") && isEmpty(fv) {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		if !*first {
+			e.buf.WriteByte(',')
+		}
+		*first = false
+		e.string(name)
+		e.buf.WriteByte(':')
+		e.encode(fv, depth+1)
+	}
+}
+
+// isEmpty mirrors the omitempty rules of encoding/json.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// string writes s as a JSON string without HTML escaping, matching zap's
+// encoder.
+func (e *encoder) string(s string) {
+	enc := json.NewEncoder(&e.buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	e.buf.Truncate(e.buf.Len() - 1)
+}
@@ -0,0 +1,117 @@
+package safejson
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshal_MatchesEncodingJSON(t *testing.T) {
+	type Embedded struct {
+		Region string `json:"region"`
+	}
+	type payload struct {
+		Embedded
+		ID      int               `json:"id"`
+		Name    string            `json:"name,omitempty"`
+		Skipped string            `json:"-"`
+		Tags    []string          `json:"tags"`
+		Attrs   map[string]any    `json:"attrs"`
+		When    time.Time         `json:"when"`
+		Raw     []byte            `json:"raw"`
+		Ptr     *int              `json:"ptr"`
+		ByID    map[int]string    `json:"by_id"`
+		Nested  struct{ OK bool } `json:"nested"`
+		private int
+	}
+	v := payload{
+		Embedded: Embedded{Region: "eu"},
+		ID:       7,
+		Tags:     []string{"a", "<b>"},
+		Attrs:    map[string]any{"z": 1.5, "a": nil},
+		When:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Raw:      []byte("hi"),
+		ByID:     map[int]string{2: "two", 1: "one"},
+	}
+
+	got, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var gotAny, wantAny any
+	want, _ := json.Marshal(v)
+	_ = json.Unmarshal(want, &wantAny)
+	if err := json.Unmarshal(got, &gotAny); err != nil {
+		t.Fatalf("invalid JSON %s: %v", got, err)
+	}
+	gotJSON, _ := json.Marshal(gotAny)
+	wantJSON, _ := json.Marshal(wantAny)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("expected %s, got %s", wantJSON, gotJSON)
+	}
+	if !strings.Contains(string(got), `"<b>"`) {
+		t.Errorf("expected no HTML escaping, got %s", got)
+	}
+}
+
+func TestMarshal_Unserializable(t *testing.T) {
+	b, err := Marshal(map[string]any{"ch": make(chan int), "fn": func() {}, "ok": 1})
+	var uerr *UnserializableError
+	if !errors.As(err, &uerr) || !errors.Is(err, ErrType) {
+		t.Fatalf("expected UnserializableError, got %v", err)
+	}
+	want := `{"ch":"<unserializable: chan int>","fn":"<unserializable: func()>","ok":1}`
+	if string(b) != want {
+		t.Errorf("expected %s, got %s", want, b)
+	}
+}
+
+type node struct {
+	Name     string  `json:"name"`
+	Parent   *node   `json:"parent,omitempty"`
+	Children []*node `json:"children,omitempty"`
+}
+
+func TestMarshal_Cycle(t *testing.T) {
+	root := &node{Name: "order"}
+	child := &node{Name: "line", Parent: root}
+	root.Children = []*node{child}
+
+	b, err := Marshal(root)
+	if !errors.Is(err, ErrCycle) {
+		t.Fatalf("expected ErrCycle, got %v", err)
+	}
+	want := `{"name":"order","children":[{"name":"line","parent":"<cycle: *safejson.node>"}]}`
+	if string(b) != want {
+		t.Errorf("expected %s, got %s", want, b)
+	}
+}
+
+func TestMarshal_SharedReferenceIsNotACycle(t *testing.T) {
+	shared := &node{Name: "shared"}
+	b, err := Marshal([]*node{shared, shared})
+	if err != nil {
+		t.Fatalf("unexpected error: %v (%s)", err, b)
+	}
+}
+
+func TestMarshal_MaxDepth(t *testing.T) {
+	deep := map[string]any{}
+	current := deep
+	for i := 0; i < 10; i++ {
+		next := map[string]any{}
+		current["next"] = next
+		current = next
+	}
+
+	b, err := Marshal(deep, WithMaxDepth(3))
+	if !errors.Is(err, ErrMaxDepth) {
+		t.Fatalf("expected ErrMaxDepth, got %v", err)
+	}
+	want := `{"next":{"next":{"next":"<max depth: map[string]interface {}>"}}}`
+	if string(b) != want {
+		t.Errorf("expected %s, got %s", want, b)
+	}
+}
@@ -0,0 +1,146 @@
+// Package sanitize keeps user-controlled data from breaking the
+// one-record-per-line guarantee of newline-delimited sinks (files, sockets,
+// NDJSON streams).
+//
+// JSON encoders already escape control characters inside string values, so
+// only plain-text layouts and raw writers need this package. The policy is
+// explicit: every line break or other control character found in user data is
+// handled according to the chosen Policy, and a record written through
+// LineWriter is always terminated by exactly one '\n'.
+package sanitize
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Policy int8
+
+const (
+	// PolicyEscape renders control characters as Go-style escapes:
+	// "\n" becomes `\n`, "\r" becomes `\r`, "\t" becomes `\t` and anything
+	// else becomes `\u00XX`. The original data stays recoverable.
+	PolicyEscape Policy = iota
+	// PolicyReplace turns every control character into a single space.
+	PolicyReplace
+	// PolicyStrip removes control characters entirely.
+	PolicyStrip
+)
+
+func (p Policy) String() string {
+	switch p {
+	case PolicyEscape:
+		return "escape"
+	case PolicyReplace:
+		return "replace"
+	case PolicyStrip:
+		return "strip"
+	default:
+		return fmt.Sprintf("Policy(%d)", p)
+	}
+}
+
+// String applies the policy to s. Strings without control characters are
+// returned unchanged without allocating.
+func String(s string, policy Policy) string {
+	if !needsSanitize(s) {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	for _, r := range s {
+		if !isControl(r) {
+			b.WriteRune(r)
+			continue
+		}
+		switch policy {
+		case PolicyReplace:
+			b.WriteByte(' ')
+		case PolicyStrip:
+		default:
+			writeEscaped(&b, r)
+		}
+	}
+	return b.String()
+}
+
+func needsSanitize(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c < 0x20 || c == 0x7f {
+				return true
+			}
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if isControl(r) {
+			return true
+		}
+		i += size - 1
+	}
+	return false
+}
+
+// isControl reports whether r can terminate or visually split a line.
+// U+2028 and U+2029 are included because several log viewers treat them as
+// line breaks.
+func isControl(r rune) bool {
+	return unicode.IsControl(r) || r == '\u2028' || r == '\u2029'
+}
+
+func writeEscaped(b *strings.Builder, r rune) {
+	switch r {
+	case '\n':
+		b.WriteString(`\n`)
+	case '\r':
+		b.WriteString(`\r`)
+	case '\t':
+		b.WriteString(`\t`)
+	default:
+		fmt.Fprintf(b, `\u%04x`, r)
+	}
+}
+
+// LineWriter wraps a newline-delimited sink. Each Write call is treated as one
+// record: a single trailing "\n" (or "\r\n") is kept as the record terminator,
+// every other control character is handled according to the policy, and a
+// terminator is added when missing.
+type LineWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	policy Policy
+}
+
+func NewLineWriter(w io.Writer, policy Policy) *LineWriter {
+	return &LineWriter{w: w, policy: policy}
+}
+
+func (lw *LineWriter) Write(p []byte) (int, error) {
+	record := bytes.TrimSuffix(p, []byte("\n"))
+	record = bytes.TrimSuffix(record, []byte("\r"))
+
+	line := String(string(record), lw.policy) + "\n"
+
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if _, err := io.WriteString(lw.w, line); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Sync flushes the underlying writer when it supports it, so LineWriter can
+// be handed to zap as a WriteSyncer.
+func (lw *LineWriter) Sync() error {
+	if s, ok := lw.w.(interface{ Sync() error }); ok {
+		return s.Sync()
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package sanitize
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestString_Policies(t *testing.T) {
+	input := "user login\n{\"level\":\"error\",\"msg\":\"forged\"}\r\tend\x00"
+
+	escaped := String(input, PolicyEscape)
+	if strings.ContainsAny(escaped, "\n\r\t\x00") {
+		t.Errorf("escape policy left control characters: %q", escaped)
+	}
+	if !strings.Contains(escaped, `\n{`) || !strings.Contains(escaped, `\u0000`) {
+		t.Errorf("unexpected escaped output: %q", escaped)
+	}
+
+	replaced := String(input, PolicyReplace)
+	if replaced != "user login {\"level\":\"error\",\"msg\":\"forged\"}  end " {
+		t.Errorf("unexpected replaced output: %q", replaced)
+	}
+
+	stripped := String(input, PolicyStrip)
+	if stripped != "user login{\"level\":\"error\",\"msg\":\"forged\"}end" {
+		t.Errorf("unexpected stripped output: %q", stripped)
+	}
+}
+
+func TestString_UnicodeLineSeparators(t *testing.T) {
+	got := String("a\u2028b\u2029c", PolicyEscape)
+	if got != `a\u2028b\u2029c` {
+		t.Errorf("expected unicode separators to be escaped, got %q", got)
+	}
+}
+
+func TestString_CleanInputUnchanged(t *testing.T) {
+	input := "plain message with ünïcödé"
+	if got := String(input, PolicyEscape); got != input {
+		t.Errorf("expected %q unchanged, got %q", input, got)
+	}
+}
+
+func TestLineWriter_OneRecordPerWrite(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewLineWriter(&buf, PolicyEscape)
+
+	if _, err := w.Write([]byte("first\nfake second line\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := w.Write([]byte("no terminator")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+	if lines[0] != `first\nfake second line` {
+		t.Errorf("unexpected first line: %q", lines[0])
+	}
+	if lines[1] != "no terminator" {
+		t.Errorf("unexpected second line: %q", lines[1])
+	}
+}
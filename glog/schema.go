@@ -0,0 +1,43 @@
+package glog
+
+import (
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net/http"
+)
+
+// Schemas returns the effective output schema of every registered publisher
+// that implements interfaces.SchemaDescriber, keyed by publisher ID.
+// Processors attached with WithProcessors are not reflected.
+func (ls *LoggerService) Schemas() map[string]*models.Schema {
+	ls.mutex.RLock()
+	defer ls.mutex.RUnlock()
+	schemas := make(map[string]*models.Schema)
+	for id, entry := range ls.loggers {
+		if d, ok := entry.publisher.(interfaces.SchemaDescriber); ok {
+			schemas[id] = d.Schema()
+		}
+	}
+	return schemas
+}
+
+// SchemaHandler serves Schemas as JSON. With ?publisher=<id> it serves the
+// schema of that publisher alone, or 404 if it has none.
+func (ls *LoggerService) SchemaHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body any = ls.Schemas()
+		if id := r.URL.Query().Get("publisher"); id != "" {
+			s, ok := ls.Schemas()[id]
+			if !ok {
+				http.Error(w, "no schema for publisher "+id, http.StatusNotFound)
+				return
+			}
+			body = s
+		}
+		w.Header().Set("Content-Type", "application/schema+json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(body)
+	})
+}
@@ -0,0 +1,75 @@
+package glog
+
+import (
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/zap"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSchemas_PerPublisher(t *testing.T) {
+	ls := NewLoggerService()
+	ls.AddLogger("stdout", zap.NewZapLoggerWithWriter("shop", "prod", io.Discard,
+		zap.WithOutputKeys(models.OutputKeys{Message: "message", Level: "severity"}),
+		zap.WithLevelEncoder(models.SyslogSeverityEncoder),
+		zap.WithFlatLayout()))
+	ls.AddLogger("mock", &mockPublisher{})
+
+	schemas := ls.Schemas()
+	if len(schemas) != 1 {
+		t.Fatalf("expected only the describing publisher, got %d", len(schemas))
+	}
+	s := schemas["stdout"]
+	if s.Profile != models.ProfileFlat || !s.AdditionalProperties {
+		t.Errorf("expected an open flat schema, got %+v", s)
+	}
+	severity := s.Properties["severity"]
+	if severity == nil || severity.Type != "integer" || len(severity.Enum) != 7 {
+		t.Errorf("expected integer severities, got %+v", severity)
+	}
+	if s.Properties["message"] == nil || s.Properties["msg"] != nil {
+		t.Error("expected renamed message key")
+	}
+	if s.Properties[models.FieldComponentKey] == nil {
+		t.Error("expected well-known fields at the top level")
+	}
+}
+
+func TestSchemaHandler(t *testing.T) {
+	ls := NewLoggerService()
+	ls.AddLogger("json", &encodedPublisher{enc: encoder.NewJSON("shop", "prod")})
+
+	rec := httptest.NewRecorder()
+	ls.SchemaHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/schema?publisher=json", nil))
+	var s models.Schema
+	if err := json.Unmarshal(rec.Body.Bytes(), &s); err != nil {
+		t.Fatalf("invalid JSON %s: %v", rec.Body.String(), err)
+	}
+	if s.Schema != models.JSONSchemaDraft || s.Profile != models.ProfileNested {
+		t.Errorf("unexpected schema %+v", s)
+	}
+	payload := s.Properties["payload"]
+	if payload == nil || payload.Properties[models.FieldFilenameKey] == nil {
+		t.Error("expected well-known fields under payload")
+	}
+
+	rec = httptest.NewRecorder()
+	ls.SchemaHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/schema?publisher=missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+}
+
+type encodedPublisher struct {
+	enc *encoder.JSON
+}
+
+func (p *encodedPublisher) SendMsg(*models.LogData) {}
+
+func (p *encodedPublisher) Schema() *models.Schema {
+	return p.enc.Schema()
+}
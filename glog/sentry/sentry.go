@@ -0,0 +1,403 @@
+// Package sentry forwards error-level records to Sentry's store API, one
+// event per record, with the stack captured in models.FieldFilenameKey turned
+// into Sentry stack frames.
+package sentry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/internal/batch"
+	"github.com/alexnobleburn/glogger/glog/internal/report"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetries = 3
+	clientName     = "glogger/1.0"
+)
+
+// Config configures a Publisher.
+type Config struct {
+	// DSN is the project DSN, e.g. https://<key>@o0.ingest.sentry.io/<project>.
+	DSN string
+	// AppID and Env are used when the record context has no models.AppID or
+	// models.EnvName; Env becomes the Sentry environment.
+	AppID string
+	Env   string
+	// Release is attached to every event.
+	Release string
+	// MinLevel is the lowest level forwarded. Defaults to models.ErrorLevel;
+	// WarnLevel is the lowest accepted value.
+	MinLevel models.LogLevel
+	// TagKeys lists string fields sent as Sentry tags instead of extras, so
+	// that they can be searched and grouped on. The component is always a
+	// tag.
+	TagKeys []string
+	// MaxBuffered bounds the events kept while Sentry is unreachable
+	// (default 10000).
+	MaxBuffered int
+	// Retries for 429 and 5xx responses (default 3), with exponential
+	// backoff starting at Backoff (default 100ms).
+	Retries int
+	Backoff time.Duration
+	// Client defaults to an http.Client with a 10s timeout.
+	Client *http.Client
+	// ErrorHandler receives DSN and delivery errors. Once the publisher is
+	// added to a service, the service error handler receives them too. Without
+	// either, they are printed.
+	ErrorHandler func(error)
+}
+
+// Compile-time checks that Publisher implements interfaces.LogPublisher and
+// interfaces.ErrorReporter.
+var (
+	_ interfaces.LogPublisher  = (*Publisher)(nil)
+	_ interfaces.ErrorReporter = (*Publisher)(nil)
+)
+
+// Publisher drops records below Config.MinLevel and sends the rest as Sentry
+// events. Fields become extras, except the component and Config.TagKeys
+// which become tags.
+type Publisher struct {
+	cfg      Config
+	errs     *report.Handler
+	storeURL string
+	auth     string
+	tags     map[string]bool
+	batcher  *batch.Batcher[*event]
+}
+
+// New parses cfg.DSN and returns a Publisher. An invalid DSN is reported to
+// the error handler and yields a Publisher that discards everything, so that
+// a misconfigured Sentry never takes the service down.
+func New(cfg Config) *Publisher {
+	if cfg.MinLevel < models.WarnLevel {
+		cfg.MinLevel = models.ErrorLevel
+	}
+	if cfg.Retries == 0 {
+		cfg.Retries = defaultRetries
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	errs := report.New(cfg.ErrorHandler)
+	cfg.ErrorHandler = errs.Handle
+	p := &Publisher{cfg: cfg, tags: make(map[string]bool, len(cfg.TagKeys)), errs: errs}
+	for _, k := range cfg.TagKeys {
+		p.tags[k] = true
+	}
+	storeURL, key, err := parseDSN(cfg.DSN)
+	if err != nil {
+		cfg.ErrorHandler(err)
+		return p
+	}
+	p.storeURL = storeURL
+	p.auth = fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", clientName, key)
+	// The store endpoint takes one event per request; batches of one keep a
+	// retry from re-sending events that were already accepted.
+	p.batcher = batch.New(batch.Config{
+		Size:         1,
+		MaxBuffered:  cfg.MaxBuffered,
+		Retries:      cfg.Retries,
+		Backoff:      cfg.Backoff,
+		ErrorHandler: cfg.ErrorHandler,
+	}, p.send)
+	return p
+}
+
+// parseDSN returns the store endpoint and public key of dsn.
+func parseDSN(dsn string) (string, string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", fmt.Errorf("sentry: invalid DSN: %w", err)
+	}
+	key := u.User.Username()
+	path := strings.TrimSuffix(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	if u.Scheme == "" || u.Host == "" || key == "" || i < 0 || path[i+1:] == "" {
+		return "", "", fmt.Errorf("sentry: invalid DSN %q", dsn)
+	}
+	store := fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, path[:i], path[i+1:])
+	return store, key, nil
+}
+
+// ReportErrorsTo implements interfaces.ErrorReporter.
+func (p *Publisher) ReportErrorsTo(report func(error)) {
+	p.errs.ReportTo(report)
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	if p.batcher == nil || data.Level < p.cfg.MinLevel {
+		return
+	}
+	if err := p.batcher.Add(p.event(data)); err != nil {
+		p.cfg.ErrorHandler(fmt.Errorf("sentry: %w", err))
+	}
+}
+
+// Flush sends everything buffered so far.
+func (p *Publisher) Flush(ctx context.Context) error {
+	if p.batcher == nil {
+		return nil
+	}
+	return p.batcher.Flush(ctx)
+}
+
+// Close flushes and stops the background goroutine.
+func (p *Publisher) Close() error {
+	if p.batcher == nil {
+		return nil
+	}
+	return p.batcher.Close()
+}
+
+// Dropped returns how many events were discarded because the buffer was
+// full or Sentry rejected them.
+func (p *Publisher) Dropped() int64 {
+	if p.batcher == nil {
+		return 0
+	}
+	return p.batcher.Dropped()
+}
+
+type event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Logger      string            `json:"logger,omitempty"`
+	Platform    string            `json:"platform"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Message     *message          `json:"message,omitempty"`
+	Exception   *exceptions       `json:"exception,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+}
+
+type message struct {
+	Formatted string `json:"formatted"`
+}
+
+type exceptions struct {
+	Values []exception `json:"values"`
+}
+
+type exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *stacktrace `json:"stacktrace,omitempty"`
+}
+
+type stacktrace struct {
+	Frames []Frame `json:"frames"`
+}
+
+// Frame is a Sentry stack frame.
+type Frame struct {
+	Function string `json:"function,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	AbsPath  string `json:"abs_path,omitempty"`
+	Lineno   int    `json:"lineno,omitempty"`
+	InApp    bool   `json:"in_app"`
+}
+
+func (p *Publisher) event(data *models.LogData) *event {
+	ev := &event{
+		EventID:     newEventID(),
+		Timestamp:   data.TimeOr(time.Now()).UTC().Format(time.RFC3339Nano),
+		Level:       sentryLevel(data.Level),
+		Platform:    "go",
+		ServerName:  p.cfg.AppID,
+		Release:     p.cfg.Release,
+		Environment: p.cfg.Env,
+		Message:     &message{Formatted: data.Msg},
+	}
+	if data.Ctx != nil {
+		if appID, ok := data.Ctx.Value(models.AppID).(string); ok && appID != "" {
+			ev.ServerName = appID
+		}
+		if env, ok := data.Ctx.Value(models.EnvName).(string); ok && env != "" {
+			ev.Environment = env
+		}
+	}
+
+	var frames []Frame
+	for _, f := range data.Fields {
+		if f == nil {
+			continue
+		}
+		switch {
+		case f.Key == models.FieldFilenameKey && f.Type == models.FieldTypeString:
+			frames = ParseFrames(f.String)
+		case f.Key == models.FieldComponentKey && f.Type == models.FieldTypeString:
+			ev.Logger = f.String
+			ev.tag(f.Key, f.String)
+		case p.tags[f.Key] && f.Type == models.FieldTypeString:
+			ev.tag(f.Key, f.String)
+		default:
+			if ev.Extra == nil {
+				ev.Extra = make(map[string]any)
+			}
+			ev.Extra[f.Key] = fieldValue(f)
+		}
+	}
+	if len(frames) > 0 {
+		ev.Message = nil
+		ev.Exception = &exceptions{Values: []exception{{
+			Type:       "error",
+			Value:      data.Msg,
+			Stacktrace: &stacktrace{Frames: frames},
+		}}}
+	}
+	return ev
+}
+
+func (ev *event) tag(key, value string) {
+	if ev.Tags == nil {
+		ev.Tags = make(map[string]string)
+	}
+	ev.Tags[key] = value
+}
+
+func fieldValue(f *models.LogField) any {
+	switch f.Type {
+	case models.FieldTypeString:
+		return f.String
+	case models.FieldTypeInt:
+		return f.Integer
+	case models.FieldTypeFloat:
+		return f.Float
+	case models.FieldTypeBool:
+		return f.Bool
+	case models.FieldTypeInt64:
+		return f.Int64
+	case models.FieldTypeUint64:
+		return f.Uint64
+	case models.FieldTypeBinary:
+		return base64.StdEncoding.EncodeToString(f.Binary)
+	case models.FieldTypeDuration:
+		return f.Duration.String()
+	case models.FieldTypeTime:
+		return f.Time.Format(time.RFC3339Nano)
+	default:
+		b, _ := safejson.Marshal(f.Object)
+		return json.RawMessage(b)
+	}
+}
+
+// ParseFrames converts the stack written by Logger.Error into Sentry frames.
+// The logger writes frames innermost first, each as "function\n\tfile:line",
+// joined by " <- "; Sentry expects them outermost first. Frames outside the
+// runtime and the standard library are marked in-app.
+func ParseFrames(stack string) []Frame {
+	if stack == "" {
+		return nil
+	}
+	parts := strings.Split(stack, " <- ")
+	frames := make([]Frame, 0, len(parts))
+	for i := len(parts) - 1; i >= 0; i-- {
+		fn, loc, ok := strings.Cut(strings.TrimSpace(parts[i]), "\n\t")
+		if !ok {
+			fn, loc = "", fn
+		}
+		fr := Frame{Function: fn}
+		if j := strings.LastIndexByte(loc, ':'); j >= 0 {
+			if n, err := strconv.Atoi(loc[j+1:]); err == nil {
+				fr.Lineno = n
+				loc = loc[:j]
+			}
+		}
+		fr.AbsPath = loc
+		fr.Filename = loc
+		if j := strings.LastIndexByte(loc, '/'); j >= 0 {
+			fr.Filename = loc[j+1:]
+		}
+		fr.InApp = inApp(fn)
+		frames = append(frames, fr)
+	}
+	return frames
+}
+
+// inApp reports whether fn belongs to the main package or a module outside
+// the standard library, whose import paths start with a domain.
+func inApp(fn string) bool {
+	if fn == "" {
+		return false
+	}
+	pkg := fn
+	slash := strings.LastIndexByte(pkg, '/')
+	if dot := strings.IndexByte(pkg[slash+1:], '.'); dot >= 0 {
+		pkg = pkg[:slash+1+dot]
+	}
+	if pkg == "main" {
+		return true
+	}
+	first, _, _ := strings.Cut(pkg, "/")
+	return strings.Contains(first, ".")
+}
+
+func sentryLevel(level models.LogLevel) string {
+	switch {
+	case level <= models.DebugLevel:
+		return "debug"
+	case level == models.InfoLevel:
+		return "info"
+	case level == models.WarnLevel:
+		return "warning"
+	case level == models.ErrorLevel:
+		return "error"
+	default:
+		return "fatal"
+	}
+}
+
+func newEventID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func (p *Publisher) send(ctx context.Context, events []*event) error {
+	for _, ev := range events {
+		body, err := json.Marshal(ev)
+		if err != nil {
+			return batch.Permanent(fmt.Errorf("sentry: marshal event: %w", err))
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.storeURL, bytes.NewReader(body))
+		if err != nil {
+			return batch.Permanent(fmt.Errorf("sentry: build request: %w", err))
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Sentry-Auth", p.auth)
+
+		resp, err := p.cfg.Client.Do(req)
+		if err != nil {
+			return fmt.Errorf("sentry: send: %w", err)
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		if resp.StatusCode/100 == 2 {
+			continue
+		}
+		err = fmt.Errorf("sentry: send: %s: %s", resp.Status, bytes.TrimSpace(msg))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return err
+		}
+		return batch.Permanent(err)
+	}
+	return nil
+}
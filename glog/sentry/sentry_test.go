@@ -0,0 +1,139 @@
+package sentry
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type fakeSentry struct {
+	mu     sync.Mutex
+	events []event
+	auth   []string
+	paths  []string
+}
+
+func (f *fakeSentry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ev event
+	if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.events = append(f.events, ev)
+	f.auth = append(f.auth, r.Header.Get("X-Sentry-Auth"))
+	f.paths = append(f.paths, r.URL.Path)
+	w.WriteHeader(http.StatusOK)
+}
+
+func dsnFor(srv *httptest.Server) string {
+	return strings.Replace(srv.URL, "http://", "http://public@", 1) + "/42"
+}
+
+func TestPublisher_ForwardsErrorsOnly(t *testing.T) {
+	sentry := &fakeSentry{}
+	srv := httptest.NewServer(sentry)
+	defer srv.Close()
+
+	pub := New(Config{DSN: dsnFor(srv), AppID: "shop", Env: "prod", Release: "1.2.3", TagKeys: []string{"tenant"}})
+	defer pub.Close()
+	pub.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "just info", Level: models.InfoLevel})
+	pub.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "a warning", Level: models.WarnLevel})
+	pub.SendMsg(&models.LogData{
+		Ctx:   context.Background(),
+		Msg:   "payment failed",
+		Level: models.ErrorLevel,
+		Fields: []*models.LogField{
+			{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: "payments"},
+			{Key: "tenant", Type: models.FieldTypeString, String: "acme"},
+			{Key: "amount", Type: models.FieldTypeInt, Integer: 42},
+			{Key: models.FieldFilenameKey, Type: models.FieldTypeString,
+				String: "github.com/acme/shop/pay.Charge\n\t/src/shop/pay/charge.go:17 <- main.main\n\t/src/shop/main.go:9 <- runtime.main\n\t/usr/lib/go/src/runtime/proc.go:250"},
+		},
+	})
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sentry.mu.Lock()
+	defer sentry.mu.Unlock()
+	if len(sentry.events) != 1 {
+		t.Fatalf("expected only the error to be forwarded, got %d events", len(sentry.events))
+	}
+	if sentry.paths[0] != "/api/42/store/" || !strings.Contains(sentry.auth[0], "sentry_key=public") {
+		t.Errorf("unexpected endpoint %q or auth %q", sentry.paths[0], sentry.auth[0])
+	}
+	ev := sentry.events[0]
+	if ev.Level != "error" || ev.Environment != "prod" || ev.Release != "1.2.3" || ev.Logger != "payments" {
+		t.Errorf("unexpected event header %+v", ev)
+	}
+	if ev.Tags["component"] != "payments" || ev.Tags["tenant"] != "acme" {
+		t.Errorf("unexpected tags %v", ev.Tags)
+	}
+	if ev.Extra["amount"] != float64(42) || ev.Extra[models.FieldFilenameKey] != nil {
+		t.Errorf("unexpected extras %v", ev.Extra)
+	}
+	if ev.Exception == nil || len(ev.Exception.Values) != 1 {
+		t.Fatalf("expected an exception with a stack trace, got %+v", ev.Exception)
+	}
+	frames := ev.Exception.Values[0].Stacktrace.Frames
+	if len(frames) != 3 {
+		t.Fatalf("expected 3 frames, got %d", len(frames))
+	}
+	if frames[0].Function != "runtime.main" || frames[0].InApp {
+		t.Errorf("expected outermost runtime frame first, got %+v", frames[0])
+	}
+	last := frames[2]
+	if last.Function != "github.com/acme/shop/pay.Charge" || last.Filename != "charge.go" || last.Lineno != 17 || !last.InApp {
+		t.Errorf("unexpected innermost frame %+v", last)
+	}
+	if !frames[1].InApp {
+		t.Error("expected main package frame to be in-app")
+	}
+}
+
+func TestPublisher_InvalidDSN(t *testing.T) {
+	var errs []error
+	pub := New(Config{DSN: "not a dsn", ErrorHandler: func(err error) { errs = append(errs, err) }})
+	pub.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "boom", Level: models.ErrorLevel})
+	if err := pub.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected the DSN error to be reported once, got %v", errs)
+	}
+}
+
+func TestPublisher_RetriesServerErrors(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	pub := New(Config{DSN: dsnFor(srv), Backoff: 1})
+	defer pub.Close()
+	pub.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "boom", Level: models.FatalLevel})
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 2 || pub.Dropped() != 0 {
+		t.Errorf("expected one retry and no drops, got %d calls, %d dropped", calls, pub.Dropped())
+	}
+}
@@ -1,6 +1,8 @@
 package glog
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/alexnobleburn/glogger/glog/interfaces"
 	"github.com/alexnobleburn/glogger/glog/models"
@@ -10,12 +12,18 @@ import (
 )
 
 const (
-	defaultInputBufferSize = 100
-	defaultJobBufferSize   = 1000
-	defaultNumWorkers      = 4
-	defaultSendTimeout     = 100 * time.Millisecond
+	defaultInputBufferSize  = 100
+	defaultJobBufferSize    = 1000
+	defaultNumWorkers       = 4
+	defaultSendTimeout      = 100 * time.Millisecond
+	defaultFlushTimeout     = 5 * time.Second
+	defaultLifecycleTimeout = 5 * time.Second
 )
 
+// ErrDeliveryFailed wraps errors returned by interfaces.ErrorPublisher and
+// reported by interfaces.ErrorReporter.
+var ErrDeliveryFailed = errors.New("glogger: delivery failed")
+
 // defaultErrorHandler writes errors to stderr-style output.
 var defaultErrorHandler = func(err error) {
 	fmt.Println(err)
@@ -56,6 +64,15 @@ func WithSendTimeout(d time.Duration) ServiceOption {
 	}
 }
 
+// WithBlockingSend makes loggers wait for room in the input buffer instead of
+// dropping messages when it is full. Use it when losing logs is worse than
+// slowing down the caller.
+func WithBlockingSend() ServiceOption {
+	return func(ls *LoggerService) {
+		ls.blockingSend = true
+	}
+}
+
 func WithErrorHandler(handler func(error)) ServiceOption {
 	return func(ls *LoggerService) {
 		if handler != nil {
@@ -65,26 +82,81 @@ func WithErrorHandler(handler func(error)) ServiceOption {
 }
 
 type LoggerService struct {
-	inputCh        chan *models.LogData
-	jobCh          chan sendJob
+	inputCh         chan *models.LogData
+	jobCh           chan sendJob
 	inputBufferSize int
 	jobBufferSize   int
-	numWorkers     int
-	sendTimeout    time.Duration
-	errorHandler   func(error)
-	mutex          sync.RWMutex
-	loggers        map[string]interfaces.LogPublisher
-	wg             sync.WaitGroup
-	mainWg         sync.WaitGroup
-	stopped        atomic.Bool
-	stopOnce       sync.Once
+	numWorkers      int
+	sendTimeout     time.Duration
+	blockingSend    bool
+	development     bool
+	errorHandler    func(error)
+	mutex           sync.RWMutex
+	loggers         map[string]*publisherEntry
+	wg              sync.WaitGroup
+	mainWg          sync.WaitGroup
+	inflight        sync.WaitGroup
+	started         atomic.Bool
+	stopped         atomic.Bool
+	stopOnce        sync.Once
+	stats           serviceStats
+	env             atomic.Pointer[envOverrides]
+	bridgeDefaults  BridgeDefaults
+	shed            *shedPolicy
+	rateLimit       *rateLimiter
+	dedup           *dedupState
+	namedMu         sync.Mutex
+	namedLevels     prefixLevels
+	componentLevels prefixLevels
+	seq             atomic.Uint64
+	goroutineIDs    bool
+	orderingFields  bool
+	extractors      []ContextExtractor
+	interceptors    []Processor
+	filters         []interfaces.Filter
+	hooksMu         sync.Mutex
+	stopHooks       []*stopHook
+	shutdownOnce    sync.Once
+}
+
+type serviceStats struct {
+	processed atomic.Int64
+	dropped   atomic.Int64
+	timeouts  atomic.Int64
+	panics    atomic.Int64
+	failed    atomic.Int64
+	misuse    atomic.Int64
+	filtered  atomic.Int64
+	// stopReported keeps log-after-Stop from flooding the error handler.
+	stopReported atomic.Bool
+}
+
+// ServiceStats is a snapshot of the pipeline counters.
+type ServiceStats struct {
+	Publishers  int   `json:"publishers"`
+	QueuedInput int   `json:"queued_input"`
+	QueuedJobs  int   `json:"queued_jobs"`
+	Processed   int64 `json:"processed"`
+	Dropped     int64 `json:"dropped"`
+	Timeouts    int64 `json:"timeouts"`
+	Panics      int64 `json:"panics"`
+	// Failed counts deliveries an interfaces.ErrorPublisher or
+	// interfaces.ErrorReporter reported as failed.
+	Failed int64 `json:"failed"`
+	Misuse int64 `json:"misuse"`
+	// Filtered counts records dropped by the filters of WithFilters.
+	Filtered int64 `json:"filtered"`
+	// Shed counts records dropped per level by WithShedByLevel.
+	Shed map[string]int64 `json:"shed,omitempty"`
+	// RateLimited counts records dropped per level by WithRateLimit.
+	RateLimited map[string]int64 `json:"rate_limited,omitempty"`
 }
 
 func NewLoggerService(opts ...ServiceOption) *LoggerService {
 	ls := &LoggerService{
 		inputBufferSize: defaultInputBufferSize,
 		jobBufferSize:   defaultJobBufferSize,
-		loggers:         make(map[string]interfaces.LogPublisher),
+		loggers:         make(map[string]*publisherEntry),
 		numWorkers:      defaultNumWorkers,
 		sendTimeout:     defaultSendTimeout,
 		errorHandler:    defaultErrorHandler,
@@ -97,10 +169,28 @@ func NewLoggerService(opts ...ServiceOption) *LoggerService {
 	return ls
 }
 
-func (ls *LoggerService) AddLogger(loggerID string, logger interfaces.LogPublisher) {
+// AddLogger registers a publisher under loggerID, replacing any publisher
+// previously registered with the same ID. A nil publisher is reported as
+// misuse and ignored.
+func (ls *LoggerService) AddLogger(loggerID string, logger interfaces.LogPublisher, opts ...PublisherOption) {
+	if logger == nil {
+		ls.misuse(fmt.Errorf("%w: %q", ErrNilPublisher, loggerID))
+		return
+	}
+	if sp, ok := logger.(*servicePublisher); ok {
+		logger = &servicePublisher{target: sp.target, source: ls}
+	}
+	entry := &publisherEntry{publisher: logger}
+	for _, opt := range opts {
+		opt(entry)
+	}
+	ls.reportErrors(loggerID, logger)
 	ls.mutex.Lock()
-	defer ls.mutex.Unlock()
-	ls.loggers[loggerID] = logger
+	ls.loggers[loggerID] = entry
+	ls.mutex.Unlock()
+	if s, ok := logger.(interfaces.Starter); ok && ls.started.Load() {
+		ls.startPublisher(loggerID, s)
+	}
 }
 
 func (ls *LoggerService) RemoveLogger(loggerID string) {
@@ -117,11 +207,38 @@ func (ls *LoggerService) GetInputChan() chan<- *models.LogData {
 func (ls *LoggerService) NewLogger() *Logger {
 	return &Logger{
 		logChan: ls.inputCh,
-		stopped: &ls.stopped,
+		svc:     ls,
+	}
+}
+
+// Stats returns the current pipeline counters. Dropped counts messages
+// discarded by loggers created with NewLogger because the input buffer was
+// full.
+func (ls *LoggerService) Stats() ServiceStats {
+	ls.mutex.RLock()
+	publishers := len(ls.loggers)
+	ls.mutex.RUnlock()
+	return ServiceStats{
+		Publishers:  publishers,
+		QueuedInput: len(ls.inputCh),
+		QueuedJobs:  len(ls.jobCh),
+		Processed:   ls.stats.processed.Load(),
+		Dropped:     ls.stats.dropped.Load(),
+		Timeouts:    ls.stats.timeouts.Load(),
+		Panics:      ls.stats.panics.Load(),
+		Failed:      ls.stats.failed.Load(),
+		Misuse:      ls.stats.misuse.Load(),
+		Filtered:    ls.stats.filtered.Load(),
+		Shed:        ls.shed.stats(),
+		RateLimited: ls.rateLimit.stats(),
 	}
 }
 
 func (ls *LoggerService) Start() {
+	ls.ApplyEnv()
+	ls.started.Store(true)
+	ls.startPublishers()
+	ls.startRateLimitSummary()
 	ls.mainWg.Add(1)
 	go ls.runMainWorker()
 
@@ -133,19 +250,119 @@ func (ls *LoggerService) Start() {
 
 func (ls *LoggerService) Stop() {
 	ls.stopOnce.Do(func() {
+		// Hooks that log run while the input is still open.
+		ls.runStopHooks(true)
+		ls.stopRateLimitSummary()
 		ls.stopped.Store(true)
 		close(ls.inputCh)
 	})
 
 	ls.mainWg.Wait()
 	ls.wg.Wait()
+	ls.shutdownOnce.Do(ls.shutdown)
+}
+
+// shutdown runs once the pipeline has drained: it flushes the publishers,
+// runs the stop hooks not registered with WithHookBeforeDrain and then
+// stops the publishers.
+func (ls *LoggerService) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultFlushTimeout)
+	defer cancel()
+	if err := ls.flushPublishers(ctx); err != nil {
+		ls.errorHandler(err)
+	}
+	ls.runStopHooks(false)
+	ls.stopPublishers()
+}
+
+// Flush blocks until every record queued before the call has been handed
+// to the publishers, then flushes the publishers that implement
+// interfaces.Flusher. Records held back by a paused publisher stay
+// buffered. Flush returns ctx.Err() if ctx is done first; after Stop it
+// returns nil, since Stop has already drained and flushed the pipeline.
+func (ls *LoggerService) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	if !ls.sendMarker(ctx, &models.LogData{Ctx: flushMarker{Context: ctx, done: done}}) {
+		if ls.stopped.Load() {
+			return nil
+		}
+		return ctx.Err()
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return ls.flushPublishers(ctx)
+}
+
+// flushMarker is the context of the placeholder record Flush queues. The
+// main worker closes done once every job dispatched before it has finished.
+type flushMarker struct {
+	context.Context
+	done chan struct{}
+}
+
+// sendMarker queues data behind the records already waiting. It reports
+// false if the service is stopped or ctx is done first.
+func (ls *LoggerService) sendMarker(ctx context.Context, data *models.LogData) (sent bool) {
+	if ls.stopped.Load() {
+		return false
+	}
+	// Stop may close the channel while we wait for room.
+	defer func() {
+		if recover() != nil {
+			sent = false
+		}
+	}()
+	select {
+	case ls.inputCh <- data:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// flushPublishers flushes publishers that buffer records, such as the Kafka
+// publisher.
+func (ls *LoggerService) flushPublishers(ctx context.Context) error {
+	var errs []error
+	for id, f := range publishersOf[interfaces.Flusher](ls) {
+		if err := f.Flush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("glogger: flush publisher %q: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func (ls *LoggerService) runMainWorker() {
 	defer ls.mainWg.Done()
 	defer close(ls.jobCh)
-	for logData := range ls.inputCh {
-		ls.processLogData(logData)
+	for {
+		select {
+		case logData, ok := <-ls.inputCh:
+			if !ok {
+				ls.releaseRepeats()
+				return
+			}
+			if logData != nil {
+				switch m := logData.Ctx.(type) {
+				case flushMarker:
+					ls.releaseRepeats()
+					ls.inflight.Wait()
+					close(m.done)
+					continue
+				case resumeMarker:
+					ls.releaseRepeats()
+					ls.resume(m)
+					continue
+				}
+			}
+			ls.processLogData(logData)
+		case <-ls.dedup.timerC():
+			ls.dedup.timer = nil
+			ls.releaseRepeats()
+		}
 	}
 }
 
@@ -153,6 +370,32 @@ func (ls *LoggerService) processLogData(logData *models.LogData) {
 	if logData == nil {
 		return
 	}
+	ls.stats.processed.Add(1)
+	normalize(logData)
+	extractFields(logData, ls.extractors, ls.errorHandler)
+	ls.fillBridgeDefaults(logData)
+	if !ls.allowed(logData) {
+		return
+	}
+	if logData = ls.intercept(logData); logData == nil {
+		return
+	}
+	if ls.hold(logData) {
+		return
+	}
+	ls.route(logData)
+}
+
+// route hands logData to the publishers that accept it.
+func (ls *LoggerService) route(logData *models.LogData) {
+	ls.addOrderingFields(logData)
+
+	env := ls.env.Load()
+	if env != nil && env.stdout != nil {
+		ls.inflight.Add(1)
+		ls.jobCh <- sendJob{loggerID: forcedStdoutID, logger: env.stdout, logData: logData}
+		return
+	}
 
 	ls.mutex.RLock()
 	if len(ls.loggers) == 0 {
@@ -161,20 +404,33 @@ func (ls *LoggerService) processLogData(logData *models.LogData) {
 		return
 	}
 
+	now := time.Now()
 	jobs := make([]sendJob, 0, len(ls.loggers))
-	for id, logger := range ls.loggers {
-		if logger == nil {
+	for id, entry := range ls.loggers {
+		if entry.publisher == nil {
 			ls.errorHandler(fmt.Errorf("glogger: logger with ID %q is nil, skipping", id))
 			continue
 		}
-		jobs = append(jobs, sendJob{
-			loggerID: id,
-			logger:   logger,
-			logData:  logData,
-		})
+		if env != nil && env.disabled[id] {
+			continue
+		}
+		data, accepted := entry.accepts(id, logData, ls.filterPanic)
+		paused, replay := entry.pausedRecords(data, accepted, now)
+		for _, buffered := range replay {
+			jobs = append(jobs, entry.job(id, buffered))
+		}
+		if paused || !accepted {
+			continue
+		}
+		jobs = append(jobs, entry.job(id, data))
 	}
 	ls.mutex.RUnlock()
+	ls.dispatch(jobs)
+}
 
+// dispatch hands jobs to the workers, tracking them for Flush.
+func (ls *LoggerService) dispatch(jobs []sendJob) {
+	ls.inflight.Add(len(jobs))
 	for _, job := range jobs {
 		ls.jobCh <- job
 	}
@@ -184,6 +440,7 @@ func (ls *LoggerService) runWorker() {
 	defer ls.wg.Done()
 	for job := range ls.jobCh {
 		ls.processJob(job)
+		ls.inflight.Done()
 	}
 }
 
@@ -193,10 +450,17 @@ func (ls *LoggerService) processJob(job sendJob) {
 		defer close(doneCh)
 		defer func() {
 			if r := recover(); r != nil {
+				ls.stats.panics.Add(1)
 				ls.errorHandler(fmt.Errorf("glogger: panic in publisher %q: %v", job.loggerID, r))
 			}
 		}()
-		job.logger.SendMsg(job.logData)
+		logData := job.logData
+		if len(job.processors) > 0 {
+			if logData = runProcessors(job.processors, logData.Clone()); logData == nil {
+				return
+			}
+		}
+		ls.send(job.loggerID, job.logger, logData)
 	}()
 
 	timer := time.NewTimer(ls.sendTimeout)
@@ -205,6 +469,7 @@ func (ls *LoggerService) processJob(job sendJob) {
 	select {
 	case <-doneCh:
 	case <-timer.C:
+		ls.stats.timeouts.Add(1)
 		ls.errorHandler(fmt.Errorf(
 			"glogger: timeout sending to publisher %q after %v, message: %q",
 			job.loggerID, ls.sendTimeout, job.logData.Msg,
@@ -212,8 +477,36 @@ func (ls *LoggerService) processJob(job sendJob) {
 	}
 }
 
+// send delivers logData and reports failures of publishers that return
+// them.
+func (ls *LoggerService) send(loggerID string, publisher interfaces.LogPublisher, logData *models.LogData) {
+	ep, ok := publisher.(interfaces.ErrorPublisher)
+	if !ok {
+		publisher.SendMsg(logData)
+		return
+	}
+	if err := ep.TrySendMsg(logData); err != nil {
+		ls.deliveryFailed(loggerID, err)
+	}
+}
+
+// deliveryFailed counts and reports a delivery error of a publisher.
+func (ls *LoggerService) deliveryFailed(loggerID string, err error) {
+	ls.stats.failed.Add(1)
+	ls.errorHandler(fmt.Errorf("%w: publisher %q: %w", ErrDeliveryFailed, loggerID, err))
+}
+
+// reportErrors has an interfaces.ErrorReporter report its background
+// delivery errors to the service.
+func (ls *LoggerService) reportErrors(loggerID string, publisher interfaces.LogPublisher) {
+	if r, ok := publisher.(interfaces.ErrorReporter); ok {
+		r.ReportErrorsTo(func(err error) { ls.deliveryFailed(loggerID, err) })
+	}
+}
+
 type sendJob struct {
-	loggerID string
-	logger   interfaces.LogPublisher
-	logData  *models.LogData
+	loggerID   string
+	logger     interfaces.LogPublisher
+	processors []Processor
+	logData    *models.LogData
 }
@@ -0,0 +1,154 @@
+// Package shard spreads records over several publishers by a hash of the
+// component (or another field), so that a high-volume host writes N files in
+// parallel instead of contending on one, and downstream shippers can ingest
+// each file independently. Records with the same key always land in the same
+// shard, which keeps a component's records in order within its file.
+package shard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"hash/fnv"
+	"io"
+	"os"
+	"sync"
+)
+
+// Compile-time check that Publisher implements interfaces.LogPublisher.
+var _ interfaces.LogPublisher = (*Publisher)(nil)
+
+// Publisher forwards each record to exactly one of its shards.
+type Publisher struct {
+	shards  []interfaces.LogPublisher
+	key     func(*models.LogData) string
+	closers []io.Closer
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Option configures a Publisher.
+type Option func(*Publisher)
+
+// WithKeyField shards by the string field key (e.g. "tenant") instead of the
+// component.
+func WithKeyField(key string) Option {
+	return func(p *Publisher) {
+		p.key = fieldKey(key)
+	}
+}
+
+// WithKeyFunc shards by an arbitrary key derived from the record.
+func WithKeyFunc(fn func(*models.LogData) string) Option {
+	return func(p *Publisher) {
+		if fn != nil {
+			p.key = fn
+		}
+	}
+}
+
+// New shards over the given publishers. Records without a key all go to the
+// same shard.
+func New(shards []interfaces.LogPublisher, opts ...Option) (*Publisher, error) {
+	if len(shards) == 0 {
+		return nil, errors.New("shard: no shards")
+	}
+	for i, s := range shards {
+		if s == nil {
+			return nil, fmt.Errorf("shard: shard %d is nil", i)
+		}
+	}
+	p := &Publisher{shards: shards, key: fieldKey(models.FieldComponentKey)}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+// OpenFiles opens n files named by pattern, which must contain one %d verb
+// for the shard index (e.g. "/var/log/app/app-%d.log"), and shards over
+// publishers created by newPublisher, typically
+//
+//	func(w io.Writer) interfaces.LogPublisher { return zap.NewZapLoggerWithWriter(appID, env, w) }
+//
+// Files are opened for appending and closed by Close.
+func OpenFiles(pattern string, n int, newPublisher func(io.Writer) interfaces.LogPublisher, opts ...Option) (*Publisher, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("shard: invalid shard count %d", n)
+	}
+	shards := make([]interfaces.LogPublisher, 0, n)
+	closers := make([]io.Closer, 0, n)
+	for i := 0; i < n; i++ {
+		f, err := os.OpenFile(fmt.Sprintf(pattern, i), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			for _, c := range closers {
+				_ = c.Close()
+			}
+			return nil, fmt.Errorf("shard: %w", err)
+		}
+		closers = append(closers, f)
+		shards = append(shards, newPublisher(f))
+	}
+	p, err := New(shards, opts...)
+	if err != nil {
+		for _, c := range closers {
+			_ = c.Close()
+		}
+		return nil, err
+	}
+	p.closers = closers
+	return p, nil
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	p.shards[p.index(p.key(data))].SendMsg(data)
+}
+
+func (p *Publisher) index(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(p.shards)))
+}
+
+// Flush flushes every shard that supports it.
+func (p *Publisher) Flush(ctx context.Context) error {
+	var errs []error
+	for _, s := range p.shards {
+		if f, ok := s.(interface{ Flush(context.Context) error }); ok {
+			errs = append(errs, f.Flush(ctx))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every shard that supports it, then the files opened by
+// OpenFiles. Later calls return the result of the first.
+func (p *Publisher) Close() error {
+	p.closeOnce.Do(func() {
+		var errs []error
+		for _, s := range p.shards {
+			if c, ok := s.(io.Closer); ok {
+				errs = append(errs, c.Close())
+			}
+		}
+		for _, c := range p.closers {
+			errs = append(errs, c.Close())
+		}
+		p.closeErr = errors.Join(errs...)
+	})
+	return p.closeErr
+}
+
+func fieldKey(key string) func(*models.LogData) string {
+	return func(data *models.LogData) string {
+		for _, f := range data.Fields {
+			if f != nil && f.Key == key && f.Type == models.FieldTypeString {
+				return f.String
+			}
+		}
+		return ""
+	}
+}
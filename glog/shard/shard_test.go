@@ -0,0 +1,138 @@
+package shard
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recorder struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (r *recorder) SendMsg(data *models.LogData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, data.Msg)
+}
+
+func record(msg, component string) *models.LogData {
+	return &models.LogData{
+		Ctx: context.Background(),
+		Msg: msg,
+		Fields: []*models.LogField{
+			{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: component},
+			{Key: "tenant", Type: models.FieldTypeString, String: "t-" + component},
+		},
+	}
+}
+
+func TestPublisher_SameKeySameShard(t *testing.T) {
+	shards := []*recorder{{}, {}, {}, {}}
+	pubs := make([]interfaces.LogPublisher, len(shards))
+	for i, s := range shards {
+		pubs[i] = s
+	}
+	p, err := New(pubs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		p.SendMsg(record(fmt.Sprintf("c%d", i%10), fmt.Sprintf("c%d", i%10)))
+	}
+
+	total, used := 0, 0
+	for i, s := range shards {
+		total += len(s.msgs)
+		if len(s.msgs) > 0 {
+			used++
+		}
+		for _, m := range s.msgs {
+			if p.index(m) != i {
+				t.Errorf("record of component %s delivered to shard %d", m, i)
+			}
+		}
+	}
+	if total != 100 {
+		t.Errorf("expected every record delivered once, got %d", total)
+	}
+	if used < 2 {
+		t.Errorf("expected records spread over several shards, got %d", used)
+	}
+}
+
+func TestPublisher_KeyField(t *testing.T) {
+	a, b := &recorder{}, &recorder{}
+	p, err := New([]interfaces.LogPublisher{a, b}, WithKeyField("tenant"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := record("hello", "x")
+	p.SendMsg(data)
+	want := []*recorder{a, b}[p.index("t-x")]
+	if len(want.msgs) != 1 {
+		t.Error("expected the record in the shard of its tenant")
+	}
+}
+
+func TestNew_RejectsEmptyAndNilShards(t *testing.T) {
+	if _, err := New(nil); err == nil {
+		t.Error("expected error for no shards")
+	}
+	if _, err := New([]interfaces.LogPublisher{&recorder{}, nil}); err == nil {
+		t.Error("expected error for nil shard")
+	}
+}
+
+type lineWriter struct {
+	w io.Writer
+}
+
+func (l *lineWriter) SendMsg(data *models.LogData) {
+	fmt.Fprintln(l.w, data.Msg)
+}
+
+func TestOpenFiles(t *testing.T) {
+	dir := t.TempDir()
+	p, err := OpenFiles(filepath.Join(dir, "app-%d.log"), 3, func(w io.Writer) interfaces.LogPublisher {
+		return &lineWriter{w: w}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 30; i++ {
+		c := fmt.Sprintf("c%d", i%6)
+		p.SendMsg(record(c, c))
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	total := 0
+	for i := 0; i < 3; i++ {
+		f, err := os.Open(filepath.Join(dir, fmt.Sprintf("app-%d.log", i)))
+		if err != nil {
+			t.Fatalf("expected shard file %d: %v", i, err)
+		}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			total++
+			if p.index(strings.TrimSpace(sc.Text())) != i {
+				t.Errorf("record %q written to the wrong file %d", sc.Text(), i)
+			}
+		}
+		f.Close()
+	}
+	if total != 30 {
+		t.Errorf("expected 30 lines across files, got %d", total)
+	}
+}
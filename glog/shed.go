@@ -0,0 +1,84 @@
+package glog
+
+import (
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync/atomic"
+)
+
+const (
+	defaultShedDebugAt   = 0.5
+	defaultShedInfoAt    = 0.75
+	defaultShedWarningAt = 0.9
+)
+
+// shedPolicy drops low-level records first as the input buffer fills up:
+// Debug from debugAt, Info from infoAt and Warning from warningAt (fractions
+// of the buffer capacity). Error and above are never shed.
+type shedPolicy struct {
+	debugAt   float64
+	infoAt    float64
+	warningAt float64
+	shed      [3]atomic.Int64
+}
+
+// WithShedByLevel makes loggers shed records by level when the input buffer
+// saturates instead of treating a Debug and an Error line alike: Debug is
+// dropped once the buffer is half full, Info at 75% and Warning at 90%.
+// Error and above are never shed; they wait for room even without
+// WithBlockingSend. Shed records are counted per level in Stats.
+func WithShedByLevel() ServiceOption {
+	return WithShedThresholds(defaultShedDebugAt, defaultShedInfoAt, defaultShedWarningAt)
+}
+
+// WithShedThresholds is WithShedByLevel with custom buffer fill fractions
+// (0 < x <= 1) at which Debug, Info and Warning records start being shed.
+// Out-of-range values keep the defaults.
+func WithShedThresholds(debug, info, warning float64) ServiceOption {
+	return func(ls *LoggerService) {
+		ls.shed = &shedPolicy{
+			debugAt:   shedThreshold(debug, defaultShedDebugAt),
+			infoAt:    shedThreshold(info, defaultShedInfoAt),
+			warningAt: shedThreshold(warning, defaultShedWarningAt),
+		}
+	}
+}
+
+func shedThreshold(v, def float64) float64 {
+	if v <= 0 || v > 1 {
+		return def
+	}
+	return v
+}
+
+// drop reports whether a record at level should be shed at the given buffer
+// fill, counting it if so.
+func (p *shedPolicy) drop(level models.LogLevel, queued, capacity int) bool {
+	var threshold float64
+	var idx int
+	switch {
+	case level <= models.DebugLevel:
+		threshold, idx = p.debugAt, 0
+	case level == models.InfoLevel:
+		threshold, idx = p.infoAt, 1
+	case level == models.WarnLevel:
+		threshold, idx = p.warningAt, 2
+	default:
+		return false
+	}
+	if capacity == 0 || float64(queued)/float64(capacity) < threshold {
+		return false
+	}
+	p.shed[idx].Add(1)
+	return true
+}
+
+func (p *shedPolicy) stats() map[string]int64 {
+	if p == nil {
+		return nil
+	}
+	return map[string]int64{
+		models.DebugLevel.String(): p.shed[0].Load(),
+		models.InfoLevel.String():  p.shed[1].Load(),
+		models.WarnLevel.String():  p.shed[2].Load(),
+	}
+}
@@ -0,0 +1,71 @@
+package glog
+
+import (
+	"context"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+	"time"
+)
+
+func TestShedByLevel_DropsLowLevelsFirst(t *testing.T) {
+	ls := NewLoggerService(WithInputBufferSize(10), WithShedByLevel())
+	mock := &mockPublisher{logs: make([]*models.LogData, 0)}
+	ls.AddLogger("mock", mock)
+	logger := ls.NewLogger()
+	ctx := context.Background()
+
+	// The service is not started yet, so the buffer only fills up.
+	for i := 0; i < 5; i++ {
+		logger.Info(ctx, "info")
+	}
+	logger.Debug(ctx, "debug shed at 50%")
+	for i := 0; i < 4; i++ {
+		logger.Info(ctx, "info until 75%")
+	}
+	logger.Warning(ctx, "warning kept at 80%")
+	logger.Warning(ctx, "warning shed at 90%")
+	logger.Error(ctx, errors.New("error kept at 90%"))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		logger.Error(ctx, errors.New("error waits for room"))
+	}()
+	select {
+	case <-done:
+		t.Fatal("an error must wait for room instead of being dropped")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	ls.Start()
+	<-done
+	ls.Stop()
+
+	stats := ls.Stats()
+	if stats.Shed["debug"] != 1 || stats.Shed["info"] != 1 || stats.Shed["warn"] != 1 {
+		t.Errorf("unexpected shed counters %v", stats.Shed)
+	}
+	if stats.Dropped != 0 {
+		t.Errorf("expected shedding instead of uniform drops, got %d dropped", stats.Dropped)
+	}
+	errorsSeen := 0
+	for _, data := range mock.GetLogs() {
+		if data.Level == models.ErrorLevel {
+			errorsSeen++
+		}
+	}
+	if errorsSeen != 2 || len(mock.GetLogs()) != 11 {
+		t.Errorf("expected 11 records with both errors, got %d with %d errors", len(mock.GetLogs()), errorsSeen)
+	}
+}
+
+func TestShedThresholds_Defaults(t *testing.T) {
+	ls := NewLoggerService(WithShedThresholds(0, 2, 0.95))
+	if ls.shed.debugAt != defaultShedDebugAt || ls.shed.infoAt != defaultShedInfoAt || ls.shed.warningAt != 0.95 {
+		t.Errorf("unexpected thresholds %+v", ls.shed)
+	}
+	if NewLoggerService().Stats().Shed != nil {
+		t.Error("expected no shed counters without the policy")
+	}
+}
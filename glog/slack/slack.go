@@ -0,0 +1,320 @@
+// Package slack posts error records to a Slack incoming webhook as Block Kit
+// attachments, holding back repeats of the same error so that an error
+// storm does not flood the channel.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/internal/batch"
+	"github.com/alexnobleburn/glogger/glog/internal/report"
+	"github.com/alexnobleburn/glogger/glog/internal/throttle"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultRetries = 3
+	defaultWindow  = 5 * time.Minute
+	// Block Kit limits.
+	maxFields    = 10
+	maxFieldText = 2000
+	maxStackText = 2900
+)
+
+// Config configures a Publisher.
+type Config struct {
+	// WebhookURL is the incoming webhook of the channel.
+	WebhookURL string
+	// Username and IconEmoji override the webhook's defaults.
+	Username  string
+	IconEmoji string
+	// MinLevel is the lowest level posted. Defaults to models.ErrorLevel;
+	// WarnLevel is the lowest accepted value.
+	MinLevel models.LogLevel
+	// AppID and Env head each alert when the record context has no
+	// models.AppID or models.EnvName.
+	AppID string
+	Env   string
+	// Window holds back records with the same level, component and message
+	// for this long after one is posted (default 5m). The next post after
+	// the window says how many were held back. A negative value posts
+	// every record.
+	Window time.Duration
+	// MaxBuffered bounds the alerts kept while Slack is unreachable
+	// (default 10000).
+	MaxBuffered int
+	// Retries for 429 and 5xx responses (default 3), with exponential
+	// backoff starting at Backoff (default 100ms).
+	Retries int
+	Backoff time.Duration
+	// Client defaults to an http.Client with a 10s timeout.
+	Client *http.Client
+	// ErrorHandler receives delivery errors. Once the publisher is added to a
+	// service, the service error handler receives them too. Without either,
+	// they are printed.
+	ErrorHandler func(error)
+}
+
+// Compile-time checks that Publisher implements interfaces.LogPublisher and
+// interfaces.ErrorReporter.
+var (
+	_ interfaces.LogPublisher  = (*Publisher)(nil)
+	_ interfaces.ErrorReporter = (*Publisher)(nil)
+)
+
+// Publisher posts one message per allowed record from a background
+// goroutine.
+type Publisher struct {
+	cfg      Config
+	errs     *report.Handler
+	throttle *throttle.Throttle
+	batcher  *batch.Batcher[*payload]
+}
+
+func New(cfg Config) *Publisher {
+	if cfg.MinLevel < models.WarnLevel {
+		cfg.MinLevel = models.ErrorLevel
+	}
+	if cfg.Window == 0 {
+		cfg.Window = defaultWindow
+	}
+	if cfg.Retries == 0 {
+		cfg.Retries = defaultRetries
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	errs := report.New(cfg.ErrorHandler)
+	cfg.ErrorHandler = errs.Handle
+	p := &Publisher{cfg: cfg, throttle: throttle.New(cfg.Window), errs: errs}
+	p.batcher = batch.New(batch.Config{
+		Size:         1,
+		MaxBuffered:  cfg.MaxBuffered,
+		Retries:      cfg.Retries,
+		Backoff:      cfg.Backoff,
+		ErrorHandler: cfg.ErrorHandler,
+	}, p.post)
+	return p
+}
+
+// ReportErrorsTo implements interfaces.ErrorReporter.
+func (p *Publisher) ReportErrorsTo(report func(error)) {
+	p.errs.ReportTo(report)
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	if data.Level < p.cfg.MinLevel {
+		return
+	}
+	ok, suppressed := p.throttle.Allow(data.Level.String() + "\x00" + component(data) + "\x00" + data.Msg)
+	if !ok {
+		return
+	}
+	if err := p.batcher.Add(p.payload(data, suppressed)); err != nil {
+		p.cfg.ErrorHandler(fmt.Errorf("slack: %w", err))
+	}
+}
+
+// Flush posts everything buffered so far.
+func (p *Publisher) Flush(ctx context.Context) error {
+	return p.batcher.Flush(ctx)
+}
+
+// Close flushes and stops the background goroutine.
+func (p *Publisher) Close() error {
+	return p.batcher.Close()
+}
+
+// Dropped returns how many alerts were discarded because the buffer was
+// full or Slack rejected them. Records held back by the window are not
+// counted.
+func (p *Publisher) Dropped() int64 {
+	return p.batcher.Dropped()
+}
+
+type payload struct {
+	Text        string       `json:"text"`
+	Username    string       `json:"username,omitempty"`
+	IconEmoji   string       `json:"icon_emoji,omitempty"`
+	Attachments []attachment `json:"attachments"`
+}
+
+type attachment struct {
+	Color  string  `json:"color"`
+	Blocks []block `json:"blocks"`
+}
+
+type block struct {
+	Type     string `json:"type"`
+	Text     *text  `json:"text,omitempty"`
+	Fields   []text `json:"fields,omitempty"`
+	Elements []text `json:"elements,omitempty"`
+}
+
+type text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func mrkdwn(s string) text {
+	return text{Type: "mrkdwn", Text: s}
+}
+
+func (p *Publisher) payload(data *models.LogData, suppressed int) *payload {
+	appID, env := p.cfg.AppID, p.cfg.Env
+	if data.Ctx != nil {
+		if v, ok := data.Ctx.Value(models.AppID).(string); ok && v != "" {
+			appID = v
+		}
+		if v, ok := data.Ctx.Value(models.EnvName).(string); ok && v != "" {
+			env = v
+		}
+	}
+	level := strings.ToUpper(data.Level.String())
+	title := level
+	if appID != "" {
+		title += " in " + appID
+		if env != "" {
+			title += " (" + env + ")"
+		}
+	}
+
+	blocks := []block{
+		{Type: "section", Text: ptr(mrkdwn("*" + escape(title) + "*\n" + escape(data.Msg)))},
+	}
+	var fields []text
+	var stack string
+	for _, f := range data.Fields {
+		if f == nil {
+			continue
+		}
+		if f.Key == models.FieldFilenameKey && f.Type == models.FieldTypeString {
+			stack = f.String
+			continue
+		}
+		if len(fields) < maxFields {
+			fields = append(fields, mrkdwn(truncate("*"+escape(f.Key)+"*\n"+escape(fieldText(f)), maxFieldText)))
+		}
+	}
+	if len(fields) > 0 {
+		blocks = append(blocks, block{Type: "section", Fields: fields})
+	}
+	if stack != "" {
+		blocks = append(blocks, block{Type: "section", Text: ptr(mrkdwn("```" + truncate(escape(stack), maxStackText) + "```"))})
+	}
+	footer := data.TimeOr(time.Now()).UTC().Format(time.RFC3339)
+	if suppressed > 0 {
+		footer += " · " + strconv.Itoa(suppressed) + " similar held back"
+	}
+	blocks = append(blocks, block{Type: "context", Elements: []text{mrkdwn(footer)}})
+
+	return &payload{
+		Text:        level + ": " + data.Msg,
+		Username:    p.cfg.Username,
+		IconEmoji:   p.cfg.IconEmoji,
+		Attachments: []attachment{{Color: color(data.Level), Blocks: blocks}},
+	}
+}
+
+func ptr(t text) *text {
+	return &t
+}
+
+func color(level models.LogLevel) string {
+	if level == models.WarnLevel {
+		return "#e8a317"
+	}
+	return "#d00000"
+}
+
+// escape escapes the characters Slack treats as control sequences.
+func escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	n -= len("…")
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
+
+func component(data *models.LogData) string {
+	for _, f := range data.Fields {
+		if f != nil && f.Key == models.FieldComponentKey && f.Type == models.FieldTypeString {
+			return f.String
+		}
+	}
+	return ""
+}
+
+func fieldText(f *models.LogField) string {
+	switch f.Type {
+	case models.FieldTypeString:
+		return f.String
+	case models.FieldTypeInt:
+		return strconv.Itoa(f.Integer)
+	case models.FieldTypeFloat:
+		return strconv.FormatFloat(f.Float, 'g', -1, 64)
+	case models.FieldTypeBool:
+		return strconv.FormatBool(f.Bool)
+	case models.FieldTypeInt64:
+		return strconv.FormatInt(f.Int64, 10)
+	case models.FieldTypeUint64:
+		return strconv.FormatUint(f.Uint64, 10)
+	case models.FieldTypeBinary:
+		return base64.StdEncoding.EncodeToString(f.Binary)
+	case models.FieldTypeDuration:
+		return f.Duration.String()
+	case models.FieldTypeTime:
+		return f.Time.Format(time.RFC3339Nano)
+	default:
+		b, _ := safejson.Marshal(f.Object)
+		return string(b)
+	}
+}
+
+func (p *Publisher) post(ctx context.Context, payloads []*payload) error {
+	for _, pl := range payloads {
+		body, err := json.Marshal(pl)
+		if err != nil {
+			return batch.Permanent(fmt.Errorf("slack: marshal: %w", err))
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.WebhookURL, bytes.NewReader(body))
+		if err != nil {
+			return batch.Permanent(fmt.Errorf("slack: build request: %w", err))
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := p.cfg.Client.Do(req)
+		if err != nil {
+			return fmt.Errorf("slack: post: %w", err)
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		if resp.StatusCode/100 == 2 {
+			continue
+		}
+		err = fmt.Errorf("slack: post: %s: %s", resp.Status, bytes.TrimSpace(msg))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return err
+		}
+		return batch.Permanent(err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type fakeSlack struct {
+	mu    sync.Mutex
+	posts []payload
+}
+
+func (f *fakeSlack) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var p payload
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.posts = append(f.posts, p)
+	_, _ = w.Write([]byte("ok"))
+}
+
+func errRecord(msg string) *models.LogData {
+	return &models.LogData{
+		Ctx:   context.Background(),
+		Msg:   msg,
+		Level: models.ErrorLevel,
+		Fields: []*models.LogField{
+			{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: "billing"},
+			{Key: "order_id", Type: models.FieldTypeInt, Integer: 42},
+			{Key: models.FieldFilenameKey, Type: models.FieldTypeString, String: "main.charge\n\t/src/main.go:10"},
+		},
+	}
+}
+
+func TestPublisher_PostsErrorsAsBlocks(t *testing.T) {
+	slack := &fakeSlack{}
+	srv := httptest.NewServer(slack)
+	defer srv.Close()
+
+	pub := New(Config{WebhookURL: srv.URL, AppID: "shop", Env: "prod", Username: "glogger"})
+	pub.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "just info", Level: models.InfoLevel})
+	pub.SendMsg(errRecord("charge <failed>"))
+	if err := pub.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	slack.mu.Lock()
+	defer slack.mu.Unlock()
+	if len(slack.posts) != 1 {
+		t.Fatalf("expected only the error posted, got %d", len(slack.posts))
+	}
+	p := slack.posts[0]
+	if p.Text != "ERROR: charge <failed>" || p.Username != "glogger" || len(p.Attachments) != 1 {
+		t.Fatalf("unexpected payload %+v", p)
+	}
+	blocks := p.Attachments[0].Blocks
+	if len(blocks) != 4 {
+		t.Fatalf("expected header, fields, stack and context blocks, got %d", len(blocks))
+	}
+	if blocks[0].Text.Text != "*ERROR in shop (prod)*\ncharge &lt;failed&gt;" {
+		t.Errorf("unexpected header %q", blocks[0].Text.Text)
+	}
+	if len(blocks[1].Fields) != 2 || blocks[1].Fields[1].Text != "*order_id*\n42" {
+		t.Errorf("unexpected fields %+v", blocks[1].Fields)
+	}
+	if !strings.Contains(blocks[2].Text.Text, "main.go:10") {
+		t.Errorf("expected the stack in a code block, got %q", blocks[2].Text.Text)
+	}
+}
+
+func TestPublisher_HoldsBackRepeats(t *testing.T) {
+	slack := &fakeSlack{}
+	srv := httptest.NewServer(slack)
+	defer srv.Close()
+
+	pub := New(Config{WebhookURL: srv.URL})
+	for i := 0; i < 50; i++ {
+		pub.SendMsg(errRecord("db down"))
+	}
+	pub.SendMsg(errRecord("cache down"))
+	if err := pub.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	slack.mu.Lock()
+	defer slack.mu.Unlock()
+	if len(slack.posts) != 2 {
+		t.Errorf("expected one post per distinct error, got %d", len(slack.posts))
+	}
+}
+
+func TestTruncate_KeepsRunesWhole(t *testing.T) {
+	got := truncate(strings.Repeat("é", 10), 8)
+	if !strings.HasSuffix(got, "…") || len(got) > 8 || !strings.HasPrefix(got, "éé") {
+		t.Errorf("unexpected truncation %q", got)
+	}
+}
@@ -0,0 +1,161 @@
+// Package slog forwards records to a log/slog Handler, so that glogger's
+// pipeline can feed any handler from the standard library ecosystem
+// (slog.NewJSONHandler, OpenTelemetry bridges, sampling handlers, ...).
+//
+// Fields become attributes at the top level; wrap the handler with
+// WithGroup to nest them. The record context is passed to the handler, so
+// handlers that read trace IDs from the context keep working.
+package slog
+
+import (
+	"context"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"log/slog"
+	"time"
+)
+
+// Levels above Error have no slog equivalent. They are mapped above
+// slog.LevelError in steps of 4, the spacing slog uses between its own
+// levels, so a handler prints them as "ERROR+4", "ERROR+8" and "ERROR+12".
+const (
+	LevelDPanic = slog.LevelError + 4
+	LevelPanic  = slog.LevelError + 8
+	LevelFatal  = slog.LevelError + 12
+)
+
+// Compile-time check that Publisher implements interfaces.LogPublisher.
+var _ interfaces.LogPublisher = (*Publisher)(nil)
+
+type Publisher struct {
+	handler slog.Handler
+	appID   string
+	env     string
+	onError func(error)
+}
+
+// Option configures a Publisher.
+type Option func(*Publisher)
+
+// WithService adds service_name and env attributes, like the other JSON
+// publishers. models.AppID and models.EnvName in the record context take
+// precedence.
+func WithService(appID, env string) Option {
+	return func(p *Publisher) {
+		p.appID = appID
+		p.env = env
+	}
+}
+
+// WithErrorHandler receives errors returned by the handler. Defaults to
+// fmt.Println.
+func WithErrorHandler(handler func(error)) Option {
+	return func(p *Publisher) {
+		if handler != nil {
+			p.onError = handler
+		}
+	}
+}
+
+func New(handler slog.Handler, opts ...Option) *Publisher {
+	p := &Publisher{
+		handler: handler,
+		onError: func(err error) { fmt.Println(err) },
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	ctx := data.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	level := Level(data.Level)
+	if !p.handler.Enabled(ctx, level) {
+		return
+	}
+
+	rec := slog.NewRecord(data.TimeOr(time.Now()), level, data.Msg, 0)
+	appID, ok := ctx.Value(models.AppID).(string)
+	if !ok || appID == "" {
+		appID = p.appID
+	}
+	env, ok := ctx.Value(models.EnvName).(string)
+	if !ok || env == "" {
+		env = p.env
+	}
+	if appID != "" {
+		rec.AddAttrs(slog.String("service_name", appID))
+	}
+	if env != "" {
+		rec.AddAttrs(slog.String("env", env))
+	}
+	for _, f := range data.Fields {
+		if f != nil {
+			rec.AddAttrs(attr(f))
+		}
+	}
+	if err := p.handler.Handle(ctx, rec); err != nil {
+		p.onError(fmt.Errorf("glogger/slog: %w", err))
+	}
+}
+
+// Level maps a glogger level to a slog level. Out-of-range levels map to
+// slog.LevelInfo.
+func Level(level models.LogLevel) slog.Level {
+	switch level {
+	case models.DebugLevel:
+		return slog.LevelDebug
+	case models.InfoLevel:
+		return slog.LevelInfo
+	case models.WarnLevel:
+		return slog.LevelWarn
+	case models.ErrorLevel:
+		return slog.LevelError
+	case models.DPanicLevel:
+		return LevelDPanic
+	case models.PanicLevel:
+		return LevelPanic
+	case models.FatalLevel:
+		return LevelFatal
+	}
+	return slog.LevelInfo
+}
+
+func attr(f *models.LogField) slog.Attr {
+	switch f.Type {
+	case models.FieldTypeString:
+		return slog.String(f.Key, f.String)
+	case models.FieldTypeInt:
+		return slog.Int(f.Key, f.Integer)
+	case models.FieldTypeFloat:
+		return slog.Float64(f.Key, f.Float)
+	case models.FieldTypeBool:
+		return slog.Bool(f.Key, f.Bool)
+	case models.FieldTypeInt64:
+		return slog.Int64(f.Key, f.Int64)
+	case models.FieldTypeUint64:
+		return slog.Uint64(f.Key, f.Uint64)
+	case models.FieldTypeBinary:
+		return slog.Any(f.Key, f.Binary)
+	case models.FieldTypeDuration:
+		return slog.Duration(f.Key, f.Duration)
+	case models.FieldTypeTime:
+		return slog.Time(f.Key, f.Time)
+	default:
+		if ns, ok := f.Object.(models.Namespace); ok {
+			attrs := make([]any, 0, len(ns))
+			for _, child := range ns {
+				if child != nil {
+					attrs = append(attrs, attr(child))
+				}
+			}
+			return slog.Group(f.Key, attrs...)
+		}
+		return slog.Any(f.Key, f.Object)
+	}
+}
@@ -0,0 +1,107 @@
+package slog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/glogtest"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPublisher_JSONHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+	p := New(h.WithGroup("payload"), WithService("my-app", "test"))
+
+	p.SendMsg(&models.LogData{Ctx: context.Background(), Level: models.DebugLevel, Msg: "filtered out"})
+	ctx := context.WithValue(context.Background(), models.EnvName, "prod")
+	p.SendMsg(&models.LogData{
+		Ctx:   ctx,
+		Level: models.WarnLevel,
+		Msg:   "slow request",
+		Time:  time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Fields: []*models.LogField{
+			{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: "http"},
+			{Key: "ms", Type: models.FieldTypeInt, Integer: 1200},
+			{Key: "cached", Type: models.FieldTypeBool, Bool: false},
+			{Key: "user", Type: models.FieldTypeObject, Object: map[string]string{"id": "u1"}},
+		},
+	})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected one line, got %q", buf.String())
+	}
+	var rec struct {
+		Time    time.Time
+		Level   string
+		Msg     string
+		Payload map[string]any
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatalf("invalid JSON %q: %v", lines[0], err)
+	}
+	if rec.Level != "WARN" || rec.Msg != "slow request" || !rec.Time.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected record %+v", rec)
+	}
+	if rec.Payload["service_name"] != "my-app" || rec.Payload["env"] != "prod" || rec.Payload["component"] != "http" ||
+		rec.Payload["ms"] != float64(1200) || rec.Payload["cached"] != false {
+		t.Errorf("unexpected attributes %v", rec.Payload)
+	}
+	if user, _ := rec.Payload["user"].(map[string]any); user["id"] != "u1" {
+		t.Errorf("expected the object field as JSON, got %v", rec.Payload["user"])
+	}
+}
+
+func TestLevel(t *testing.T) {
+	var buf bytes.Buffer
+	p := New(slog.NewTextHandler(&buf, nil))
+	for _, level := range []models.LogLevel{models.ErrorLevel, models.DPanicLevel, models.FatalLevel, models.LogLevel(42)} {
+		p.SendMsg(&models.LogData{Ctx: context.Background(), Level: level, Msg: "m"})
+	}
+	out := buf.String()
+	for _, want := range []string{"level=ERROR ", "level=ERROR+4 ", "level=ERROR+12 ", "level=INFO "} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+}
+
+type failingHandler struct{ slog.Handler }
+
+func (failingHandler) Handle(context.Context, slog.Record) error { return errors.New("disk full") }
+
+func TestPublisher_HandlerError(t *testing.T) {
+	var got error
+	p := New(failingHandler{slog.NewTextHandler(io.Discard, nil)}, WithErrorHandler(func(err error) { got = err }))
+	p.SendMsg(&models.LogData{Ctx: context.Background(), Level: models.InfoLevel, Msg: "m"})
+	if got == nil || !strings.Contains(got.Error(), "disk full") {
+		t.Errorf("expected the handler error reported, got %v", got)
+	}
+}
+
+func TestPublisher_Conformance(t *testing.T) {
+	glogtest.RunPublisherConformance(t, func(t testing.TB) interfaces.LogPublisher {
+		return New(slog.NewJSONHandler(io.Discard, nil), WithService("test-app", "test"))
+	})
+}
+
+func TestPublisher_NamespaceAsGroup(t *testing.T) {
+	var buf bytes.Buffer
+	p := New(slog.NewTextHandler(&buf, nil))
+
+	opts := &models.Options{}
+	models.WithNamespace("http", models.WithStringField("method", "GET"), models.WithIntField("status", 200))(opts)
+	p.SendMsg(&models.LogData{Ctx: context.Background(), Level: models.InfoLevel, Msg: "request", Fields: opts.GetFields()})
+
+	if out := buf.String(); !strings.Contains(out, "http.method=GET http.status=200") {
+		t.Errorf("expected the namespace as an slog group, got %q", out)
+	}
+}
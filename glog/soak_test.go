@@ -0,0 +1,34 @@
+package glog
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/glogtest"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"testing"
+	"time"
+)
+
+func TestSoak_ShortRunHasNoLeaks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("soak test skipped in short mode")
+	}
+	report, err := glogtest.Soak(context.Background(), glogtest.SoakConfig{
+		Duration:       300 * time.Millisecond,
+		SampleInterval: 50 * time.Millisecond,
+		Rate:           2000,
+	}, func(collector interfaces.LogPublisher) (interfaces.Logger, func()) {
+		ls := NewLoggerService(WithErrorHandler(func(error) {}))
+		ls.AddLogger("discard", collector)
+		ls.Start()
+		return ls.NewLogger(), ls.Stop
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Failed() {
+		t.Errorf("unexpected leaks: %v", report.Leaks)
+	}
+	if len(report.Samples) == 0 || report.After.Sent == 0 {
+		t.Errorf("expected samples and traffic, got %+v", report)
+	}
+}
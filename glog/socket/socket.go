@@ -0,0 +1,226 @@
+// Package socket ships records as newline-delimited JSON over TCP, unix
+// sockets or UDP, the input format of logstash's tcp/udp inputs with the
+// json_lines codec and of vector's socket source.
+package socket
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/internal/batch"
+	"github.com/alexnobleburn/glogger/glog/internal/report"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRetries = 3
+	defaultTimeout = 10 * time.Second
+	// maxDatagram is the largest UDP payload; longer lines are dropped
+	// rather than truncated into invalid JSON.
+	maxDatagram = 65507
+)
+
+// Config configures a Publisher.
+type Config struct {
+	// Network is "tcp", "udp" or "unix" (default "tcp").
+	Network string
+	// Address is host:port, or the socket path for "unix".
+	Address string
+	// TLS enables TLS for "tcp".
+	TLS *tls.Config
+	// AppID and Env are used by the default encoder when the record context
+	// has no models.AppID or models.EnvName.
+	AppID string
+	Env   string
+	// Encoder writes one line per record. Defaults to
+	// encoder.NewJSON(AppID, Env).
+	Encoder interfaces.Encoder
+	// BatchSize, FlushInterval and MaxBuffered control batching (defaults
+	// 100, 1s and 10000). Lines stay buffered while the listener is
+	// unreachable, up to MaxBuffered.
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxBuffered   int
+	// Retries per flush (default 3), each on a fresh connection, with
+	// exponential backoff starting at Backoff (default 100ms).
+	Retries int
+	Backoff time.Duration
+	// Timeout bounds dialing and each batch write (default 10s).
+	Timeout time.Duration
+	// ErrorHandler receives encoding, dial and write errors. Once the
+	// publisher is added to a service, the service error handler receives them
+	// too. Without either, they are printed.
+	ErrorHandler func(error)
+}
+
+// Compile-time checks that Publisher implements interfaces.ErrorPublisher,
+// interfaces.HealthChecker and interfaces.ErrorReporter.
+var (
+	_ interfaces.ErrorPublisher = (*Publisher)(nil)
+	_ interfaces.HealthChecker  = (*Publisher)(nil)
+	_ interfaces.ErrorReporter  = (*Publisher)(nil)
+)
+
+// Publisher writes each record as one line. Over TCP and unix sockets a
+// batch is written in one go on a persistent connection, which is re-dialed
+// after a write error; over UDP every line is its own datagram.
+type Publisher struct {
+	cfg     Config
+	errs    *report.Handler
+	batcher *batch.Batcher[[]byte]
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func New(cfg Config) *Publisher {
+	if cfg.Network == "" {
+		cfg.Network = "tcp"
+	}
+	if cfg.Encoder == nil {
+		cfg.Encoder = encoder.NewJSON(cfg.AppID, cfg.Env)
+	}
+	if cfg.Retries == 0 {
+		cfg.Retries = defaultRetries
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	errs := report.New(cfg.ErrorHandler)
+	cfg.ErrorHandler = errs.Handle
+	p := &Publisher{cfg: cfg, errs: errs}
+	p.batcher = batch.New(batch.Config{
+		Size:         cfg.BatchSize,
+		Interval:     cfg.FlushInterval,
+		MaxBuffered:  cfg.MaxBuffered,
+		Retries:      cfg.Retries,
+		Backoff:      cfg.Backoff,
+		ErrorHandler: cfg.ErrorHandler,
+	}, p.write)
+	return p
+}
+
+// ReportErrorsTo implements interfaces.ErrorReporter.
+func (p *Publisher) ReportErrorsTo(report func(error)) {
+	p.errs.ReportTo(report)
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	if err := p.TrySendMsg(data); err != nil {
+		p.cfg.ErrorHandler(err)
+	}
+}
+
+// TrySendMsg buffers data like SendMsg and returns the errors that happen
+// before the record is buffered, such as encoding errors, instead of
+// passing them to the error handler. Delivery errors are reported later,
+// from the background flush.
+func (p *Publisher) TrySendMsg(data *models.LogData) error {
+	line, err := p.cfg.Encoder.Encode(data)
+	if err != nil {
+		return fmt.Errorf("socket: encode: %w", err)
+	}
+	line = append(bytes.TrimRight(line, "\n"), '\n')
+	if p.datagram() && len(line) > maxDatagram {
+		return fmt.Errorf("socket: record of %d bytes exceeds the UDP datagram limit", len(line))
+	}
+	if err := p.batcher.Add(line); err != nil {
+		return fmt.Errorf("socket: %w", err)
+	}
+	return nil
+}
+
+// Flush writes everything buffered so far.
+func (p *Publisher) Flush(ctx context.Context) error {
+	return p.batcher.Flush(ctx)
+}
+
+// Close flushes, stops the background goroutine and closes the connection.
+func (p *Publisher) Close() error {
+	err := p.batcher.Close()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn != nil {
+		if cerr := p.conn.Close(); err == nil {
+			err = cerr
+		}
+		p.conn = nil
+	}
+	return err
+}
+
+// Dropped returns how many records were discarded because the buffer was
+// full.
+func (p *Publisher) Dropped() int64 {
+	return p.batcher.Dropped()
+}
+
+// HealthCheck returns the error of the last failed delivery to the listener
+// until a batch goes through again, and an error once the publisher is
+// closed.
+func (p *Publisher) HealthCheck(ctx context.Context) error {
+	return p.batcher.Err()
+}
+
+func (p *Publisher) datagram() bool {
+	switch p.cfg.Network {
+	case "udp", "udp4", "udp6", "unixgram":
+		return true
+	}
+	return false
+}
+
+func (p *Publisher) dial(ctx context.Context) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+	if p.cfg.TLS != nil && !p.datagram() {
+		d := &tls.Dialer{Config: p.cfg.TLS}
+		return d.DialContext(ctx, p.cfg.Network, p.cfg.Address)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, p.cfg.Network, p.cfg.Address)
+}
+
+func (p *Publisher) write(ctx context.Context, lines [][]byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		conn, err := p.dial(ctx)
+		if err != nil {
+			return fmt.Errorf("socket: dial %s %s: %w", p.cfg.Network, p.cfg.Address, err)
+		}
+		p.conn = conn
+	}
+	_ = p.conn.SetWriteDeadline(time.Now().Add(p.cfg.Timeout))
+	var err error
+	if p.datagram() {
+		for _, line := range lines {
+			if _, err = p.conn.Write(line); err != nil {
+				break
+			}
+		}
+	} else {
+		w := bufio.NewWriterSize(p.conn, 32<<10)
+		for _, line := range lines {
+			if _, err = w.Write(line); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = w.Flush()
+		}
+	}
+	if err != nil {
+		_ = p.conn.Close()
+		p.conn = nil
+		return fmt.Errorf("socket: write: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package socket
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type lineServer struct {
+	mu    sync.Mutex
+	lines []map[string]any
+	conns int
+}
+
+func (s *lineServer) serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns++
+		s.mu.Unlock()
+		go func() {
+			defer conn.Close()
+			sc := bufio.NewScanner(conn)
+			for sc.Scan() {
+				var rec map[string]any
+				if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+					continue
+				}
+				s.mu.Lock()
+				s.lines = append(s.lines, rec)
+				s.mu.Unlock()
+			}
+		}()
+	}
+}
+
+func (s *lineServer) wait(n int) []map[string]any {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		got := len(s.lines)
+		s.mu.Unlock()
+		if got >= n {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]any(nil), s.lines...)
+}
+
+func record(msg string) *models.LogData {
+	return &models.LogData{Ctx: context.Background(), Msg: msg, Level: models.InfoLevel}
+}
+
+func TestPublisher_TCPWritesJSONLines(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer ln.Close()
+	srv := &lineServer{}
+	go srv.serve(ln)
+
+	pub := New(Config{Address: ln.Addr().String(), AppID: "shop", Env: "prod"})
+	for _, msg := range []string{"one", "two", "three"} {
+		pub.SendMsg(record(msg))
+	}
+	if err := pub.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := srv.wait(3)
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(lines))
+	}
+	if lines[0]["service_name"] != "shop" || lines[0]["msg"] != "one" {
+		t.Errorf("unexpected line %v", lines[0])
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.conns != 1 {
+		t.Errorf("expected a single persistent connection, got %d", srv.conns)
+	}
+}
+
+func TestPublisher_BuffersUntilListenerIsUp(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	pub := New(Config{Address: addr, Retries: -1, ErrorHandler: func(error) {}})
+	defer pub.Close()
+	pub.SendMsg(record("early"))
+	if err := pub.Flush(context.Background()); err == nil {
+		t.Fatal("expected flush to fail while the listener is down")
+	}
+
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("could not re-listen on %s: %v", addr, err)
+	}
+	defer ln.Close()
+	srv := &lineServer{}
+	go srv.serve(ln)
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lines := srv.wait(1); len(lines) != 1 || lines[0]["msg"] != "early" {
+		t.Errorf("expected the buffered record delivered, got %v", lines)
+	}
+}
+
+func TestPublisher_UDPSendsOneDatagramPerRecord(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer pc.Close()
+
+	pub := New(Config{Network: "udp", Address: pc.LocalAddr().String()})
+	pub.SendMsg(record("a"))
+	pub.SendMsg(record("b"))
+	if err := pub.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	buf := make([]byte, maxDatagram)
+	_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for _, want := range []string{"a", "b"} {
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var rec map[string]any
+		if err := json.Unmarshal(buf[:n], &rec); err != nil || rec["msg"] != want {
+			t.Errorf("expected datagram %q as one JSON line, got %q", want, buf[:n])
+		}
+	}
+}
+
+func TestPublisher_TLS(t *testing.T) {
+	// Borrow the test certificate of an httptest TLS server.
+	hs := httptest.NewTLSServer(http.NotFoundHandler())
+	defer hs.Close()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", hs.TLS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer ln.Close()
+	srv := &lineServer{}
+	go srv.serve(ln)
+
+	clientTLS := hs.Client().Transport.(*http.Transport).TLSClientConfig
+	pub := New(Config{Address: ln.Addr().String(), TLS: clientTLS})
+	pub.SendMsg(record("secure"))
+	if err := pub.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lines := srv.wait(1); len(lines) != 1 || lines[0]["msg"] != "secure" {
+		t.Errorf("expected the record over TLS, got %v", lines)
+	}
+}
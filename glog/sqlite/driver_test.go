@@ -0,0 +1,126 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fakeDB is a recording database/sql driver. It keeps inserted rows in memory
+// and answers every SELECT with all of them, newest first, so that the
+// store's decoding, filtering and limits can be checked without SQLite.
+type fakeDB struct {
+	mu      sync.Mutex
+	execs   []fakeExec
+	rows    [][]driver.Value
+	execErr error
+}
+
+type fakeExec struct {
+	stmt string
+	args []driver.Value
+}
+
+func newFakeDB() (*fakeDB, *sql.DB) {
+	f := &fakeDB{}
+	return f, sql.OpenDB(f)
+}
+
+// statements returns the executed statements starting with prefix.
+func (f *fakeDB) statements(prefix string) []fakeExec {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var res []fakeExec
+	for _, e := range f.execs {
+		if strings.HasPrefix(e.stmt, prefix) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return fakeDriver{f} }
+
+type fakeDriver struct{ db *fakeDB }
+
+func (d fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{db: d.db}, nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake: prepared statements are not supported")
+}
+func (c *fakeConn) Close() error { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fake: transactions are not supported")
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, stmt string, args []driver.NamedValue) (driver.Result, error) {
+	f := c.db
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	f.execs = append(f.execs, fakeExec{stmt: stmt, args: values})
+	if f.execErr != nil {
+		return nil, f.execErr
+	}
+	switch {
+	case strings.HasPrefix(stmt, "INSERT"):
+		// ts, level, component, msg, fields
+		f.rows = append(f.rows, values)
+	case strings.HasPrefix(stmt, "DELETE"):
+		cutoff := values[0].(int64)
+		kept := f.rows[:0]
+		for _, r := range f.rows {
+			if r[0].(int64) >= cutoff {
+				kept = append(kept, r)
+			}
+		}
+		f.rows = kept
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, stmt string, args []driver.NamedValue) (driver.Rows, error) {
+	f := c.db
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	f.execs = append(f.execs, fakeExec{stmt: stmt, args: values})
+
+	rows := make([][]driver.Value, 0, len(f.rows))
+	for _, r := range f.rows {
+		rows = append(rows, []driver.Value{r[0], r[1], r[3], r[4]})
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i][0].(int64) > rows[j][0].(int64) })
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+	pos  int
+}
+
+func (r *fakeRows) Columns() []string { return []string{"ts", "level", "msg", "fields"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.pos])
+	r.pos++
+	return nil
+}
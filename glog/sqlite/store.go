@@ -0,0 +1,362 @@
+// Package sqlite stores log records in a local SQLite database with
+// retention, giving single-binary tools a queryable log history.
+//
+// The package does not import a driver: open the database with the driver of
+// your choice (modernc.org/sqlite, github.com/mattn/go-sqlite3, ...) and pass
+// the *sql.DB to New.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/query"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTable         = "glog_records"
+	defaultPruneInterval = time.Minute
+)
+
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type Option func(*Store)
+
+func WithTable(name string) Option {
+	return func(s *Store) {
+		if name != "" {
+			s.table = name
+		}
+	}
+}
+
+// WithRetention deletes records older than d. Zero keeps everything.
+func WithRetention(d time.Duration) Option {
+	return func(s *Store) {
+		if d > 0 {
+			s.retention = d
+		}
+	}
+}
+
+func WithPruneInterval(d time.Duration) Option {
+	return func(s *Store) {
+		if d > 0 {
+			s.pruneInterval = d
+		}
+	}
+}
+
+func WithErrorHandler(handler func(error)) Option {
+	return func(s *Store) {
+		if handler != nil {
+			s.errorHandler = handler
+		}
+	}
+}
+
+// Compile-time checks that Store is both a publisher and a query source.
+var (
+	_ interfaces.LogPublisher = (*Store)(nil)
+	_ query.Source            = (*Store)(nil)
+)
+
+type Store struct {
+	db            *sql.DB
+	table         string
+	retention     time.Duration
+	pruneInterval time.Duration
+	errorHandler  func(error)
+	now           func() time.Time
+	stopCh        chan struct{}
+	wg            sync.WaitGroup
+	closeOnce     sync.Once
+}
+
+// New creates the table and indexes if needed and starts retention pruning.
+func New(ctx context.Context, db *sql.DB, opts ...Option) (*Store, error) {
+	s := &Store{
+		db:            db,
+		table:         defaultTable,
+		pruneInterval: defaultPruneInterval,
+		errorHandler:  func(err error) { fmt.Println(err) },
+		now:           time.Now,
+		stopCh:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if !tableNamePattern.MatchString(s.table) {
+		return nil, fmt.Errorf("glogger/sqlite: invalid table name %q", s.table)
+	}
+	for _, stmt := range s.schema() {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("glogger/sqlite: create schema: %w", err)
+		}
+	}
+	if s.retention > 0 {
+		s.wg.Add(1)
+		go s.runPruner()
+	}
+	return s, nil
+}
+
+func (s *Store) schema() []string {
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	ts INTEGER NOT NULL,
+	level INTEGER NOT NULL,
+	component TEXT NOT NULL DEFAULT '',
+	msg TEXT NOT NULL,
+	fields TEXT NOT NULL DEFAULT '[]'
+)`, s.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_ts_idx ON %s (ts)`, s.table, s.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_level_idx ON %s (level, ts)`, s.table, s.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_component_idx ON %s (component, ts)`, s.table, s.table),
+	}
+}
+
+func (s *Store) SendMsg(data *models.LogData) {
+	fields, err := encodeFields(data.Fields)
+	if err != nil {
+		s.errorHandler(fmt.Errorf("glogger/sqlite: encode fields: %w", err))
+		return
+	}
+	_, err = s.db.Exec(
+		fmt.Sprintf(`INSERT INTO %s (ts, level, component, msg, fields) VALUES (?, ?, ?, ?, ?)`, s.table),
+		data.TimeOr(s.now()).UnixNano(), int(data.Level), component(data), data.Msg, fields,
+	)
+	if err != nil {
+		s.errorHandler(fmt.Errorf("glogger/sqlite: insert: %w", err))
+	}
+}
+
+// Query returns matching records in chronological order. Errors are reported
+// through the error handler and yield an empty result.
+func (s *Store) Query(filter query.Filter) *query.Iterator {
+	stmt, args := s.selectStatement(filter)
+	rows, err := s.db.Query(stmt, args...)
+	if err != nil {
+		s.errorHandler(fmt.Errorf("glogger/sqlite: query: %w", err))
+		return query.NewIterator(nil)
+	}
+	defer rows.Close()
+
+	var res []query.Record
+	for rows.Next() {
+		var (
+			ts     int64
+			level  int
+			msg    string
+			fields string
+		)
+		if err := rows.Scan(&ts, &level, &msg, &fields); err != nil {
+			s.errorHandler(fmt.Errorf("glogger/sqlite: scan: %w", err))
+			return query.NewIterator(nil)
+		}
+		decoded, err := decodeFields(fields)
+		if err != nil {
+			s.errorHandler(fmt.Errorf("glogger/sqlite: decode fields: %w", err))
+			continue
+		}
+		rec := query.Record{
+			Time: time.Unix(0, ts),
+			Data: &models.LogData{Ctx: context.Background(), Msg: msg, Level: models.LogLevel(level), Fields: decoded},
+		}
+		if filter.Match(rec) {
+			res = append(res, rec)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		s.errorHandler(fmt.Errorf("glogger/sqlite: rows: %w", err))
+	}
+
+	// Rows come newest first so that LIMIT keeps the latest records.
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	if filter.Limit > 0 && len(res) > filter.Limit {
+		res = res[len(res)-filter.Limit:]
+	}
+	return query.NewIterator(res)
+}
+
+// selectStatement pushes the indexed criteria down to SQLite. Field and
+// message matchers are re-checked in Go by Filter.Match.
+func (s *Store) selectStatement(filter query.Filter) (string, []any) {
+	var (
+		where []string
+		args  []any
+	)
+	if len(filter.Levels) > 0 {
+		placeholders := make([]string, len(filter.Levels))
+		for i, l := range filter.Levels {
+			placeholders[i] = "?"
+			args = append(args, int(l))
+		}
+		where = append(where, "level IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, "ts >= ?")
+		args = append(args, filter.Since.UnixNano())
+	}
+	if !filter.Until.IsZero() {
+		where = append(where, "ts <= ?")
+		args = append(args, filter.Until.UnixNano())
+	}
+	if filter.Component != "" {
+		where = append(where, "component = ?")
+		args = append(args, filter.Component)
+	}
+	if filter.MsgContains != "" {
+		where = append(where, "instr(msg, ?) > 0")
+		args = append(args, filter.MsgContains)
+	}
+
+	stmt := fmt.Sprintf("SELECT ts, level, msg, fields FROM %s", s.table)
+	if len(where) > 0 {
+		stmt += " WHERE " + strings.Join(where, " AND ")
+	}
+	stmt += " ORDER BY ts DESC, id DESC"
+	if filter.Limit > 0 && len(filter.Fields) == 0 {
+		stmt += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+	return stmt, args
+}
+
+// Prune deletes records older than the retention period.
+func (s *Store) Prune(ctx context.Context) error {
+	if s.retention <= 0 {
+		return nil
+	}
+	cutoff := s.now().Add(-s.retention).UnixNano()
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE ts < ?", s.table), cutoff)
+	return err
+}
+
+func (s *Store) runPruner() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.pruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Prune(context.Background()); err != nil {
+				s.errorHandler(fmt.Errorf("glogger/sqlite: prune: %w", err))
+			}
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// Close stops retention pruning. The database itself is owned by the caller.
+func (s *Store) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+	return nil
+}
+
+func component(data *models.LogData) string {
+	for _, f := range data.Fields {
+		if f != nil && f.Key == models.FieldComponentKey {
+			return f.String
+		}
+	}
+	return ""
+}
+
+type storedField struct {
+	Key   string           `json:"key"`
+	Type  models.FieldType `json:"type"`
+	Value json.RawMessage  `json:"value"`
+}
+
+func encodeFields(fields []*models.LogField) (string, error) {
+	stored := make([]storedField, 0, len(fields))
+	for _, f := range fields {
+		if f == nil {
+			continue
+		}
+		var v any
+		switch f.Type {
+		case models.FieldTypeString:
+			v = f.String
+		case models.FieldTypeInt:
+			v = f.Integer
+		case models.FieldTypeFloat:
+			v = f.Float
+		case models.FieldTypeBool:
+			v = f.Bool
+		case models.FieldTypeInt64:
+			v = f.Int64
+		case models.FieldTypeUint64:
+			v = f.Uint64
+		case models.FieldTypeBinary:
+			v = f.Binary
+		case models.FieldTypeDuration:
+			v = f.Duration
+		case models.FieldTypeTime:
+			v = f.Time
+		default:
+			v = f.Object
+		}
+		// safejson always returns valid JSON, with placeholders for cycles,
+		// excessive depth and values it cannot encode.
+		raw, _ := safejson.Marshal(v)
+		stored = append(stored, storedField{Key: f.Key, Type: f.Type, Value: raw})
+	}
+	b, err := safejson.Marshal(stored)
+	return string(b), err
+}
+
+func decodeFields(raw string) ([]*models.LogField, error) {
+	var stored []storedField
+	if err := json.Unmarshal([]byte(raw), &stored); err != nil {
+		return nil, err
+	}
+	fields := make([]*models.LogField, 0, len(stored))
+	for _, sf := range stored {
+		f := &models.LogField{Key: sf.Key, Type: sf.Type}
+		var err error
+		switch sf.Type {
+		case models.FieldTypeString:
+			err = json.Unmarshal(sf.Value, &f.String)
+		case models.FieldTypeInt:
+			err = json.Unmarshal(sf.Value, &f.Integer)
+		case models.FieldTypeFloat:
+			err = json.Unmarshal(sf.Value, &f.Float)
+		case models.FieldTypeBool:
+			err = json.Unmarshal(sf.Value, &f.Bool)
+		case models.FieldTypeInt64:
+			err = json.Unmarshal(sf.Value, &f.Int64)
+		case models.FieldTypeUint64:
+			err = json.Unmarshal(sf.Value, &f.Uint64)
+		case models.FieldTypeBinary:
+			err = json.Unmarshal(sf.Value, &f.Binary)
+		case models.FieldTypeDuration:
+			err = json.Unmarshal(sf.Value, &f.Duration)
+		case models.FieldTypeTime:
+			err = json.Unmarshal(sf.Value, &f.Time)
+		default:
+			err = json.Unmarshal(sf.Value, &f.Object)
+		}
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
@@ -0,0 +1,226 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/query"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFields_RoundTrip(t *testing.T) {
+	in := []*models.LogField{
+		{Key: "s", Type: models.FieldTypeString, String: "value"},
+		nil,
+		{Key: "i", Type: models.FieldTypeInt, Integer: 42},
+		{Key: "f", Type: models.FieldTypeFloat, Float: 2.5},
+		{Key: "b", Type: models.FieldTypeBool, Bool: true},
+		{Key: "o", Type: models.FieldTypeObject, Object: map[string]any{"k": "v"}},
+	}
+
+	raw, err := encodeFields(in)
+	if err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+	out, err := decodeFields(raw)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+
+	if len(out) != 5 {
+		t.Fatalf("expected 5 fields (nil skipped), got %d", len(out))
+	}
+	if out[0].String != "value" || out[1].Integer != 42 || out[2].Float != 2.5 || !out[3].Bool {
+		t.Errorf("unexpected scalar values: %+v %+v %+v %+v", out[0], out[1], out[2], out[3])
+	}
+	if obj, ok := out[4].Object.(map[string]any); !ok || obj["k"] != "v" {
+		t.Errorf("unexpected object value: %#v", out[4].Object)
+	}
+}
+
+func TestStore_SelectStatement(t *testing.T) {
+	s := &Store{table: defaultTable}
+	since := time.Unix(100, 0)
+
+	stmt, args := s.selectStatement(query.Filter{
+		Levels:    []models.LogLevel{models.ErrorLevel, models.FatalLevel},
+		Since:     since,
+		Component: "db",
+		Limit:     10,
+	})
+
+	want := "SELECT ts, level, msg, fields FROM glog_records WHERE level IN (?, ?) AND ts >= ? AND component = ? ORDER BY ts DESC, id DESC LIMIT ?"
+	if stmt != want {
+		t.Errorf("unexpected statement:\n got %s\nwant %s", stmt, want)
+	}
+	if len(args) != 5 || args[2] != since.UnixNano() || args[4] != 10 {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	stmt, _ = s.selectStatement(query.Filter{Limit: 10, Fields: map[string]string{"k": "v"}})
+	if strings.Contains(stmt, "LIMIT") {
+		t.Error("limit must be applied after field matching")
+	}
+}
+
+func TestStore_Schema(t *testing.T) {
+	s := &Store{table: "app_logs"}
+	schema := s.schema()
+	if len(schema) != 4 || !strings.Contains(schema[0], "CREATE TABLE IF NOT EXISTS app_logs") {
+		t.Errorf("unexpected schema: %v", schema)
+	}
+}
+
+type node struct {
+	Name   string
+	Parent *node
+}
+
+func TestFields_CyclicObject(t *testing.T) {
+	n := &node{Name: "root"}
+	n.Parent = n
+	raw, err := encodeFields([]*models.LogField{{Key: "entity", Type: models.FieldTypeObject, Object: n}})
+	if err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+	if !strings.Contains(raw, "<cycle:") {
+		t.Errorf("expected a cycle placeholder, got %s", raw)
+	}
+	if _, err := decodeFields(raw); err != nil {
+		t.Errorf("expected the encoded fields to decode, got %v", err)
+	}
+}
+
+func newFakeStore(t *testing.T, opts ...Option) (*Store, *fakeDB, *[]error) {
+	t.Helper()
+	fake, db := newFakeDB()
+	var errs []error
+	opts = append(opts, WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	s, err := New(context.Background(), db, opts...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() {
+		s.Close()
+		db.Close()
+	})
+	return s, fake, &errs
+}
+
+func newRecord(ts time.Time, level models.LogLevel, msg string, fields ...*models.LogField) *models.LogData {
+	return &models.LogData{Ctx: context.Background(), Time: ts, Level: level, Msg: msg, Fields: fields}
+}
+
+func TestStore_New_CreatesSchema(t *testing.T) {
+	_, fake, _ := newFakeStore(t, WithTable("app_logs"))
+
+	if got := len(fake.statements("CREATE TABLE IF NOT EXISTS app_logs")); got != 1 {
+		t.Errorf("expected the table to be created once, got %d", got)
+	}
+	if got := len(fake.statements("CREATE INDEX")); got != 3 {
+		t.Errorf("expected 3 indexes, got %d", got)
+	}
+}
+
+func TestStore_SendMsg_Inserts(t *testing.T) {
+	s, fake, errs := newFakeStore(t)
+	base := time.Unix(1000, 0)
+
+	for i := 0; i < 3; i++ {
+		s.SendMsg(newRecord(base.Add(time.Duration(i)*time.Second), models.InfoLevel, "msg",
+			&models.LogField{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: "db"},
+			&models.LogField{Key: "attempt", Type: models.FieldTypeInt, Integer: i},
+		))
+	}
+
+	inserts := fake.statements("INSERT INTO glog_records")
+	if len(inserts) != 3 {
+		t.Fatalf("expected one insert per record, got %d", len(inserts))
+	}
+	args := inserts[2].args
+	if args[0] != base.Add(2*time.Second).UnixNano() || args[1] != int64(models.InfoLevel) || args[2] != "db" || args[3] != "msg" {
+		t.Errorf("unexpected insert args: %v", args)
+	}
+	if !strings.Contains(args[4].(string), `"key":"attempt"`) {
+		t.Errorf("expected encoded fields, got %v", args[4])
+	}
+	if len(*errs) != 0 {
+		t.Errorf("unexpected errors: %v", *errs)
+	}
+}
+
+func TestStore_SendMsg_ReportsInsertError(t *testing.T) {
+	s, fake, errs := newFakeStore(t)
+	fake.execErr = errors.New("disk full")
+
+	s.SendMsg(newRecord(time.Unix(1000, 0), models.InfoLevel, "msg"))
+
+	if len(*errs) != 1 || !strings.Contains((*errs)[0].Error(), "insert: disk full") {
+		t.Errorf("expected the insert error to be reported, got %v", *errs)
+	}
+}
+
+func TestStore_Query(t *testing.T) {
+	s, fake, errs := newFakeStore(t)
+	base := time.Unix(1000, 0)
+	for i := 0; i < 5; i++ {
+		s.SendMsg(newRecord(base.Add(time.Duration(i)*time.Second), models.InfoLevel, fmt.Sprintf("msg %d", i),
+			&models.LogField{Key: "user", Type: models.FieldTypeString, String: []string{"alice", "bob"}[i%2]},
+		))
+	}
+
+	recs := s.Query(query.Filter{Limit: 2}).All()
+	if len(recs) != 2 || recs[0].Data.Msg != "msg 3" || recs[1].Data.Msg != "msg 4" {
+		t.Fatalf("expected the latest records in chronological order, got %v", recs)
+	}
+	if !recs[1].Time.Equal(base.Add(4*time.Second)) || recs[1].Data.Level != models.InfoLevel {
+		t.Errorf("unexpected scanned record: %+v", recs[1])
+	}
+	if stmt := fake.statements("SELECT"); len(stmt) != 1 || stmt[0].args[0] != int64(2) {
+		t.Errorf("expected the limit to be pushed down, got %v", stmt)
+	}
+
+	recs = s.Query(query.Filter{Fields: map[string]string{"user": "alice"}, Limit: 2}).All()
+	if len(recs) != 2 || recs[0].Data.Msg != "msg 2" || recs[1].Data.Msg != "msg 4" {
+		t.Errorf("expected field matching before the limit, got %v", recs)
+	}
+	if len(*errs) != 0 {
+		t.Errorf("unexpected errors: %v", *errs)
+	}
+}
+
+func TestStore_Prune(t *testing.T) {
+	s, fake, _ := newFakeStore(t, WithRetention(time.Hour))
+	now := time.Unix(10000, 0)
+	s.now = func() time.Time { return now }
+
+	s.SendMsg(newRecord(now.Add(-2*time.Hour), models.InfoLevel, "old"))
+	s.SendMsg(newRecord(now.Add(-time.Minute), models.InfoLevel, "recent"))
+
+	if err := s.Prune(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deletes := fake.statements("DELETE FROM glog_records WHERE ts < ?")
+	if len(deletes) != 1 || deletes[0].args[0] != now.Add(-time.Hour).UnixNano() {
+		t.Fatalf("unexpected deletes: %v", deletes)
+	}
+	recs := s.Query(query.Filter{}).All()
+	if len(recs) != 1 || recs[0].Data.Msg != "recent" {
+		t.Errorf("expected only the recent record to survive, got %v", recs)
+	}
+}
+
+func TestStore_PruneWithoutRetention(t *testing.T) {
+	s, fake, _ := newFakeStore(t)
+
+	if err := s.Prune(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := len(fake.statements("DELETE")); got != 0 {
+		t.Errorf("expected no deletes without retention, got %d", got)
+	}
+}
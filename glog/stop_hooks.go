@@ -0,0 +1,115 @@
+package glog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type stopHook struct {
+	name        string
+	fn          func(context.Context)
+	order       int
+	timeout     time.Duration
+	seq         int
+	beforeDrain bool
+}
+
+// StopHookOption configures a hook registered with OnStop.
+type StopHookOption func(*stopHook)
+
+// WithHookName names the hook in timeout and panic reports.
+func WithHookName(name string) StopHookOption {
+	return func(h *stopHook) {
+		h.name = name
+	}
+}
+
+// WithHookOrder runs hooks with a lower order first. Hooks with the same
+// order run in registration order. The default order is 0.
+func WithHookOrder(order int) StopHookOption {
+	return func(h *stopHook) {
+		h.order = order
+	}
+}
+
+// WithHookTimeout bounds the hook (default 5s). The hook's context is
+// cancelled at the deadline and Stop moves on to the next hook.
+func WithHookTimeout(d time.Duration) StopHookOption {
+	return func(h *stopHook) {
+		if d > 0 {
+			h.timeout = d
+		}
+	}
+}
+
+// WithHookBeforeDrain runs the hook at the start of Stop, while loggers
+// still accept records, instead of after the pipeline has drained. Records
+// the hook logs, such as a shutdown audit record, are delivered and flushed
+// like any other. These hooks run before all others, ordered among
+// themselves by WithHookOrder.
+func WithHookBeforeDrain() StopHookOption {
+	return func(h *stopHook) {
+		h.beforeDrain = true
+	}
+}
+
+// OnStop registers fn to run once during Stop, after the pipeline has
+// drained and publishers have been flushed, e.g. to upload a final spool.
+// Loggers no longer accept records at that point, so hooks write to their
+// destinations directly; a hook that logs through glog needs
+// WithHookBeforeDrain. A hook that panics or exceeds its timeout is
+// reported to the error handler.
+func (ls *LoggerService) OnStop(fn func(ctx context.Context), opts ...StopHookOption) {
+	if fn == nil {
+		return
+	}
+	ls.hooksMu.Lock()
+	defer ls.hooksMu.Unlock()
+	h := &stopHook{fn: fn, timeout: defaultFlushTimeout, seq: len(ls.stopHooks)}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.name == "" {
+		h.name = fmt.Sprintf("#%d", h.seq)
+	}
+	ls.stopHooks = append(ls.stopHooks, h)
+}
+
+// runStopHooks runs the hooks registered with or without
+// WithHookBeforeDrain.
+func (ls *LoggerService) runStopHooks(beforeDrain bool) {
+	ls.hooksMu.Lock()
+	var hooks []*stopHook
+	for _, h := range ls.stopHooks {
+		if h.beforeDrain == beforeDrain {
+			hooks = append(hooks, h)
+		}
+	}
+	ls.hooksMu.Unlock()
+	sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].order < hooks[j].order })
+	for _, h := range hooks {
+		ls.runStopHook(h)
+	}
+}
+
+func (ls *LoggerService) runStopHook(h *stopHook) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			if r := recover(); r != nil {
+				ls.errorHandler(fmt.Errorf("glogger: panic in stop hook %s: %v", h.name, r))
+			}
+		}()
+		h.fn(ctx)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		ls.errorHandler(fmt.Errorf("glogger: stop hook %s did not finish within %v", h.name, h.timeout))
+	}
+}
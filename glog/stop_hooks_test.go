@@ -0,0 +1,94 @@
+package glog
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOnStop_RunsAfterDrainInOrder(t *testing.T) {
+	ls := NewLoggerService(WithBlockingSend())
+	mock := &mockPublisher{logs: make([]*models.LogData, 0)}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+
+	var order []string
+	ls.OnStop(func(ctx context.Context) {
+		if n := len(mock.GetLogs()); n != 3 {
+			t.Errorf("expected the pipeline drained before hooks, got %d records", n)
+		}
+		order = append(order, "audit")
+	}, WithHookOrder(1))
+	ls.OnStop(func(ctx context.Context) { order = append(order, "spool") })
+	ls.OnStop(func(ctx context.Context) { order = append(order, "metrics") })
+
+	logger := ls.NewLogger()
+	for i := 0; i < 3; i++ {
+		logger.Info(context.Background(), "work")
+	}
+	ls.Stop()
+	ls.Stop()
+
+	if strings.Join(order, ",") != "spool,metrics,audit" {
+		t.Errorf("unexpected hook order %v", order)
+	}
+}
+
+func TestOnStop_BeforeDrainHooksCanLog(t *testing.T) {
+	ls := NewLoggerService(WithBlockingSend())
+	mock := &mockPublisher{logs: make([]*models.LogData, 0)}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+	logger := ls.NewLogger()
+
+	var order []string
+	ls.OnStop(func(ctx context.Context) { order = append(order, "spool") })
+	ls.OnStop(func(ctx context.Context) {
+		logger.Info(ctx, "shutting down")
+		order = append(order, "audit")
+	}, WithHookBeforeDrain())
+	ls.Stop()
+
+	if strings.Join(order, ",") != "audit,spool" {
+		t.Errorf("unexpected hook order %v", order)
+	}
+	logs := mock.GetLogs()
+	if len(logs) != 1 || logs[0].Msg != "shutting down" {
+		t.Errorf("expected the record logged by the hook delivered, got %+v", logs)
+	}
+}
+
+func TestOnStop_TimeoutAndPanicAreReported(t *testing.T) {
+	var mu sync.Mutex
+	var errs []string
+	ls := NewLoggerService(WithErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err.Error())
+	}))
+	ls.AddLogger("mock", &mockPublisher{logs: make([]*models.LogData, 0)})
+	ls.Start()
+
+	ran := false
+	ls.OnStop(func(ctx context.Context) { <-ctx.Done(); time.Sleep(time.Second) },
+		WithHookName("upload"), WithHookTimeout(20*time.Millisecond))
+	ls.OnStop(func(ctx context.Context) { panic("boom") })
+	ls.OnStop(func(ctx context.Context) { ran = true })
+
+	start := time.Now()
+	ls.Stop()
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("expected Stop not to wait for a hook past its timeout")
+	}
+	if !ran {
+		t.Error("expected hooks after a failing one to run")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 2 || !strings.Contains(errs[0], `stop hook upload`) || !strings.Contains(errs[1], "panic") {
+		t.Errorf("unexpected errors %v", errs)
+	}
+}
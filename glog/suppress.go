@@ -0,0 +1,106 @@
+package glog
+
+import (
+	"context"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync"
+)
+
+const suppressedSectionMessage = "suppressed records"
+
+type suppressKey struct{}
+
+// suppression is a quiet section: records at its levels logged with its
+// context are counted instead of written.
+type suppression struct {
+	mu     sync.Mutex
+	levels map[models.LogLevel]bool
+	counts map[models.LogLevel]int
+	ended  bool
+}
+
+// Suppress returns a context under which records at the given levels (all
+// levels if none are given) logged by a glog Logger are dropped, e.g. to
+// silence expected errors during startup probes or chaos tests without
+// changing global levels. EndSuppress reports how many were dropped. Nested
+// sections suppress the levels of the enclosing ones as well.
+func Suppress(ctx context.Context, levels ...models.LogLevel) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s := &suppression{levels: make(map[models.LogLevel]bool), counts: make(map[models.LogLevel]int)}
+	if len(levels) == 0 {
+		for l := models.DebugLevel; l <= models.FatalLevel; l++ {
+			s.levels[l] = true
+		}
+	}
+	for _, l := range levels {
+		s.levels[l] = true
+	}
+	if parent := suppressionFrom(ctx); parent != nil {
+		parent.mu.Lock()
+		for l := range parent.levels {
+			s.levels[l] = true
+		}
+		parent.mu.Unlock()
+	}
+	return context.WithValue(ctx, suppressKey{}, s)
+}
+
+// EndSuppress ends the quiet section of ctx and, if anything was dropped,
+// logs one Info record with the total in FieldCanonicalSuppressedKey and
+// the count per level in fields named "suppressed_<level>". It returns the
+// total. Records logged with ctx afterwards are written again; only the
+// first call reports.
+func EndSuppress(ctx context.Context, logger interfaces.Logger, options ...models.Option) int {
+	s := suppressionFrom(ctx)
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return 0
+	}
+	s.ended = true
+	total := 0
+	opts := append([]models.Option{}, options...)
+	for l := models.DebugLevel; l <= models.FatalLevel; l++ {
+		if n := s.counts[l]; n > 0 {
+			total += n
+			opts = append(opts, models.WithIntField("suppressed_"+l.String(), n))
+		}
+	}
+	s.mu.Unlock()
+
+	if total > 0 && logger != nil {
+		opts = append(opts, models.WithIntField(FieldCanonicalSuppressedKey, total))
+		logger.Info(ctx, suppressedSectionMessage, opts...)
+	}
+	return total
+}
+
+func suppressionFrom(ctx context.Context) *suppression {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(suppressKey{}).(*suppression)
+	return s
+}
+
+// suppressedBySection reports whether a record at level falls in a quiet
+// section of ctx, counting it if so.
+func suppressedBySection(ctx context.Context, level models.LogLevel) bool {
+	s := suppressionFrom(ctx)
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended || !s.levels[level] {
+		return false
+	}
+	s.counts[level]++
+	return true
+}
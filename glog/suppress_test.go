@@ -0,0 +1,72 @@
+package glog
+
+import (
+	"context"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"testing"
+)
+
+func TestSuppress_DropsMatchingLevelsAndReports(t *testing.T) {
+	ls := NewLoggerService(WithBlockingSend())
+	mock := &mockPublisher{logs: make([]*models.LogData, 0)}
+	ls.AddLogger("mock", mock)
+	ls.Start()
+	logger := ls.NewLogger()
+
+	ctx := Suppress(context.Background(), models.ErrorLevel, models.WarnLevel)
+	logger.Error(ctx, errors.New("probe refused"))
+	logger.Error(ctx, errors.New("probe refused"))
+	logger.Warning(ctx, "retrying")
+	logger.Info(ctx, "still visible")
+	logger.Error(context.Background(), errors.New("outside the section"))
+	if n := EndSuppress(ctx, logger); n != 3 {
+		t.Errorf("expected 3 suppressed records, got %d", n)
+	}
+	logger.Error(ctx, errors.New("after the section"))
+	if n := EndSuppress(ctx, logger); n != 0 {
+		t.Errorf("expected only the first EndSuppress to report, got %d", n)
+	}
+	ls.Stop()
+
+	logs := mock.GetLogs()
+	if len(logs) != 4 {
+		t.Fatalf("expected 4 records, got %d", len(logs))
+	}
+	var summary *models.LogData
+	for _, data := range logs {
+		switch data.Msg {
+		case "probe refused", "retrying":
+			t.Errorf("record %q should have been suppressed", data.Msg)
+		case suppressedSectionMessage:
+			summary = data
+		}
+	}
+	if summary == nil {
+		t.Fatal("expected a summary record")
+	}
+	if f := fieldByKey(summary, FieldCanonicalSuppressedKey); f == nil || f.Integer != 3 {
+		t.Errorf("expected total of 3, got %+v", f)
+	}
+	if f := fieldByKey(summary, "suppressed_error"); f == nil || f.Integer != 2 {
+		t.Errorf("expected 2 suppressed errors, got %+v", f)
+	}
+}
+
+func TestSuppress_AllLevelsAndNesting(t *testing.T) {
+	outer := Suppress(context.Background(), models.DebugLevel)
+	inner := Suppress(outer, models.InfoLevel)
+	if !suppressedBySection(inner, models.DebugLevel) || !suppressedBySection(inner, models.InfoLevel) {
+		t.Error("nested sections must suppress the enclosing levels too")
+	}
+	if suppressedBySection(outer, models.InfoLevel) {
+		t.Error("the inner section must not widen the outer one")
+	}
+	all := Suppress(context.Background())
+	if !suppressedBySection(all, models.FatalLevel) || !suppressedBySection(all, models.DebugLevel) {
+		t.Error("expected every level suppressed when none are given")
+	}
+	if EndSuppress(context.Background(), nil) != 0 {
+		t.Error("expected no-op without a section")
+	}
+}
@@ -0,0 +1,368 @@
+// Package telegram sends high-severity records to Telegram chats through a
+// bot, routed per component, with repeats and bursts held back so that an
+// incident produces a readable handful of messages.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/internal/batch"
+	"github.com/alexnobleburn/glogger/glog/internal/report"
+	"github.com/alexnobleburn/glogger/glog/internal/throttle"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultAPIURL       = "https://api.telegram.org"
+	defaultRetries      = 3
+	defaultWindow       = 5 * time.Minute
+	defaultMaxPerMinute = 20
+	maxFields           = 10
+	maxMessage          = 4096
+	maxStack            = 1500
+)
+
+// Config configures a Publisher.
+type Config struct {
+	// Token is the bot token from @BotFather.
+	Token string
+	// ChatID receives records whose component has no route.
+	ChatID string
+	// Routes sends records of a component to another chat, e.g.
+	// {"billing": "-1001234567890"}.
+	Routes map[string]string
+	// MinLevel is the lowest level sent. Defaults to models.ErrorLevel;
+	// WarnLevel is the lowest accepted value.
+	MinLevel models.LogLevel
+	// AppID and Env head each message when the record context has no
+	// models.AppID or models.EnvName.
+	AppID string
+	Env   string
+	// Window holds back records with the same level, component and message
+	// for this long after one is sent (default 5m). A negative value sends
+	// every record.
+	Window time.Duration
+	// MaxPerMinute caps the messages sent to one chat per minute (default
+	// 20, Telegram's limit for groups). The next message after a capped
+	// minute says how many were held back.
+	MaxPerMinute int
+	// APIURL defaults to https://api.telegram.org.
+	APIURL string
+	// MaxBuffered bounds the messages kept while Telegram is unreachable
+	// (default 10000).
+	MaxBuffered int
+	// Retries for 429 and 5xx responses (default 3), with exponential
+	// backoff starting at Backoff (default 100ms).
+	Retries int
+	Backoff time.Duration
+	// Client defaults to an http.Client with a 10s timeout.
+	Client *http.Client
+	// ErrorHandler receives delivery errors. Once the publisher is added to a
+	// service, the service error handler receives them too. Without either,
+	// they are printed.
+	ErrorHandler func(error)
+}
+
+type message struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+}
+
+// Compile-time checks that Publisher implements interfaces.LogPublisher and
+// interfaces.ErrorReporter.
+var (
+	_ interfaces.LogPublisher  = (*Publisher)(nil)
+	_ interfaces.ErrorReporter = (*Publisher)(nil)
+)
+
+// Publisher sends one message per allowed record from a background
+// goroutine.
+type Publisher struct {
+	cfg      Config
+	errs     *report.Handler
+	url      string
+	throttle *throttle.Throttle
+	batcher  *batch.Batcher[*message]
+	now      func() time.Time
+
+	mu    sync.Mutex
+	chats map[string]*chatRate
+}
+
+// chatRate counts the messages sent to a chat in the current minute.
+type chatRate struct {
+	start    time.Time
+	sent     int
+	heldBack int
+}
+
+func New(cfg Config) *Publisher {
+	if cfg.MinLevel < models.WarnLevel {
+		cfg.MinLevel = models.ErrorLevel
+	}
+	if cfg.Window == 0 {
+		cfg.Window = defaultWindow
+	}
+	if cfg.MaxPerMinute <= 0 {
+		cfg.MaxPerMinute = defaultMaxPerMinute
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = defaultAPIURL
+	}
+	if cfg.Retries == 0 {
+		cfg.Retries = defaultRetries
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	errs := report.New(cfg.ErrorHandler)
+	cfg.ErrorHandler = errs.Handle
+	p := &Publisher{
+		cfg:      cfg,
+		url:      strings.TrimSuffix(cfg.APIURL, "/") + "/bot" + cfg.Token + "/sendMessage",
+		throttle: throttle.New(cfg.Window),
+		now:      time.Now,
+		chats:    make(map[string]*chatRate),
+		errs:     errs,
+	}
+	p.batcher = batch.New(batch.Config{
+		Size:         1,
+		MaxBuffered:  cfg.MaxBuffered,
+		Retries:      cfg.Retries,
+		Backoff:      cfg.Backoff,
+		ErrorHandler: cfg.ErrorHandler,
+	}, p.send)
+	return p
+}
+
+// ReportErrorsTo implements interfaces.ErrorReporter.
+func (p *Publisher) ReportErrorsTo(report func(error)) {
+	p.errs.ReportTo(report)
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	if data.Level < p.cfg.MinLevel {
+		return
+	}
+	comp := component(data)
+	chat := p.cfg.ChatID
+	if c, ok := p.cfg.Routes[comp]; ok {
+		chat = c
+	}
+	if chat == "" {
+		return
+	}
+	ok, repeats := p.throttle.Allow(data.Level.String() + "\x00" + comp + "\x00" + data.Msg)
+	if !ok {
+		return
+	}
+	ok, burst := p.allowChat(chat)
+	if !ok {
+		return
+	}
+	msg := &message{
+		ChatID:                chat,
+		Text:                  p.text(data, comp, repeats, burst),
+		ParseMode:             "HTML",
+		DisableWebPagePreview: true,
+	}
+	if err := p.batcher.Add(msg); err != nil {
+		p.cfg.ErrorHandler(fmt.Errorf("telegram: %w", err))
+	}
+}
+
+// allowChat applies MaxPerMinute to chat and returns how many messages the
+// previous capped minute held back.
+func (p *Publisher) allowChat(chat string) (bool, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	r, ok := p.chats[chat]
+	if !ok {
+		r = &chatRate{start: now}
+		p.chats[chat] = r
+	}
+	if now.Sub(r.start) >= time.Minute {
+		r.start, r.sent = now, 0
+	}
+	if r.sent >= p.cfg.MaxPerMinute {
+		r.heldBack++
+		return false, 0
+	}
+	r.sent++
+	held := r.heldBack
+	r.heldBack = 0
+	return true, held
+}
+
+func (p *Publisher) text(data *models.LogData, comp string, repeats, burst int) string {
+	appID, env := p.cfg.AppID, p.cfg.Env
+	if data.Ctx != nil {
+		if v, ok := data.Ctx.Value(models.AppID).(string); ok && v != "" {
+			appID = v
+		}
+		if v, ok := data.Ctx.Value(models.EnvName).(string); ok && v != "" {
+			env = v
+		}
+	}
+	var b strings.Builder
+	b.WriteString("<b>" + strings.ToUpper(data.Level.String()) + "</b>")
+	if appID != "" {
+		b.WriteString(" in " + html.EscapeString(appID))
+		if env != "" {
+			b.WriteString(" (" + html.EscapeString(env) + ")")
+		}
+	}
+	if comp != "" {
+		b.WriteString(" · <code>" + html.EscapeString(comp) + "</code>")
+	}
+	b.WriteString("\n" + html.EscapeString(data.Msg) + "\n")
+
+	var stack string
+	n := 0
+	for _, f := range data.Fields {
+		if f == nil || f.Key == models.FieldComponentKey {
+			continue
+		}
+		if f.Key == models.FieldFilenameKey && f.Type == models.FieldTypeString {
+			stack = f.String
+			continue
+		}
+		if n == maxFields {
+			continue
+		}
+		n++
+		b.WriteString("\n<b>" + html.EscapeString(f.Key) + "</b>: " + html.EscapeString(fieldText(f)))
+	}
+	if stack != "" {
+		b.WriteString("\n<pre>" + html.EscapeString(truncate(stack, maxStack)) + "</pre>")
+	}
+	if repeats > 0 {
+		b.WriteString("\n<i>" + strconv.Itoa(repeats) + " repeats held back</i>")
+	}
+	if burst > 0 {
+		b.WriteString("\n<i>" + strconv.Itoa(burst) + " other alerts held back by the rate limit</i>")
+	}
+	// Cut the plain-text part rather than risk splitting an HTML entity or
+	// tag; the fields are the first to go.
+	text := b.String()
+	if len(text) > maxMessage {
+		text = truncate(html.EscapeString(data.Msg), maxMessage)
+	}
+	return text
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	n -= len("…")
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
+
+func component(data *models.LogData) string {
+	for _, f := range data.Fields {
+		if f != nil && f.Key == models.FieldComponentKey && f.Type == models.FieldTypeString {
+			return f.String
+		}
+	}
+	return ""
+}
+
+func fieldText(f *models.LogField) string {
+	switch f.Type {
+	case models.FieldTypeString:
+		return f.String
+	case models.FieldTypeInt:
+		return strconv.Itoa(f.Integer)
+	case models.FieldTypeFloat:
+		return strconv.FormatFloat(f.Float, 'g', -1, 64)
+	case models.FieldTypeBool:
+		return strconv.FormatBool(f.Bool)
+	case models.FieldTypeInt64:
+		return strconv.FormatInt(f.Int64, 10)
+	case models.FieldTypeUint64:
+		return strconv.FormatUint(f.Uint64, 10)
+	case models.FieldTypeBinary:
+		return base64.StdEncoding.EncodeToString(f.Binary)
+	case models.FieldTypeDuration:
+		return f.Duration.String()
+	case models.FieldTypeTime:
+		return f.Time.Format(time.RFC3339Nano)
+	default:
+		b, _ := safejson.Marshal(f.Object)
+		return string(b)
+	}
+}
+
+// Flush sends everything buffered so far.
+func (p *Publisher) Flush(ctx context.Context) error {
+	return p.batcher.Flush(ctx)
+}
+
+// Close flushes and stops the background goroutine.
+func (p *Publisher) Close() error {
+	return p.batcher.Close()
+}
+
+// Dropped returns how many messages were discarded because the buffer was
+// full or Telegram rejected them. Records held back by throttling are not
+// counted.
+func (p *Publisher) Dropped() int64 {
+	return p.batcher.Dropped()
+}
+
+func (p *Publisher) send(ctx context.Context, msgs []*message) error {
+	for _, m := range msgs {
+		body, err := json.Marshal(m)
+		if err != nil {
+			return batch.Permanent(fmt.Errorf("telegram: marshal: %w", err))
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+		if err != nil {
+			return batch.Permanent(fmt.Errorf("telegram: build request: %w", err))
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := p.cfg.Client.Do(req)
+		if err != nil {
+			// The URL holds the bot token; keep it out of the error.
+			var uerr *url.Error
+			if errors.As(err, &uerr) {
+				err = uerr.Err
+			}
+			return fmt.Errorf("telegram: send to chat %s: %w", m.ChatID, err)
+		}
+		desc, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		if resp.StatusCode/100 == 2 {
+			continue
+		}
+		err = fmt.Errorf("telegram: send to chat %s: %s: %s", m.ChatID, resp.Status, bytes.TrimSpace(desc))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return err
+		}
+		return batch.Permanent(err)
+	}
+	return nil
+}
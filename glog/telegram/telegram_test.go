@@ -0,0 +1,120 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeBot struct {
+	mu    sync.Mutex
+	paths []string
+	msgs  []message
+}
+
+func (f *fakeBot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var m message
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.paths = append(f.paths, r.URL.Path)
+	f.msgs = append(f.msgs, m)
+	_, _ = w.Write([]byte(`{"ok":true}`))
+}
+
+func record(msg, component string) *models.LogData {
+	return &models.LogData{
+		Ctx:   context.Background(),
+		Msg:   msg,
+		Level: models.ErrorLevel,
+		Fields: []*models.LogField{
+			{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: component},
+			{Key: "order_id", Type: models.FieldTypeInt, Integer: 42},
+		},
+	}
+}
+
+func TestPublisher_RoutesByComponent(t *testing.T) {
+	bot := &fakeBot{}
+	srv := httptest.NewServer(bot)
+	defer srv.Close()
+
+	pub := New(Config{
+		Token:  "123:abc",
+		ChatID: "ops",
+		Routes: map[string]string{"billing": "billing-team"},
+		AppID:  "shop",
+		APIURL: srv.URL,
+	})
+	pub.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "warn only", Level: models.WarnLevel})
+	pub.SendMsg(record("charge <failed>", "billing"))
+	pub.SendMsg(record("disk full", "storage"))
+	if err := pub.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	bot.mu.Lock()
+	defer bot.mu.Unlock()
+	if len(bot.msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(bot.msgs))
+	}
+	if bot.paths[0] != "/bot123:abc/sendMessage" {
+		t.Errorf("unexpected path %q", bot.paths[0])
+	}
+	byChat := map[string]message{}
+	for _, m := range bot.msgs {
+		byChat[m.ChatID] = m
+	}
+	billing, ok := byChat["billing-team"]
+	if !ok || byChat["ops"].Text == "" {
+		t.Fatalf("expected one message per chat, got %v", bot.msgs)
+	}
+	if billing.ParseMode != "HTML" ||
+		!strings.Contains(billing.Text, "<b>ERROR</b> in shop · <code>billing</code>") ||
+		!strings.Contains(billing.Text, "charge &lt;failed&gt;") ||
+		!strings.Contains(billing.Text, "<b>order_id</b>: 42") {
+		t.Errorf("unexpected text %q", billing.Text)
+	}
+}
+
+func TestPublisher_ThrottlesRepeatsAndBursts(t *testing.T) {
+	bot := &fakeBot{}
+	srv := httptest.NewServer(bot)
+	defer srv.Close()
+
+	now := time.Unix(0, 0)
+	pub := New(Config{Token: "t", ChatID: "ops", APIURL: srv.URL, MaxPerMinute: 3})
+	pub.now = func() time.Time { return now }
+	for i := 0; i < 10; i++ {
+		pub.SendMsg(record("same", "db"))
+	}
+	for i := 0; i < 5; i++ {
+		pub.SendMsg(record(fmt.Sprintf("distinct %d", i), "db"))
+	}
+	now = now.Add(time.Minute)
+	pub.SendMsg(record("after the burst", "db"))
+	if err := pub.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	bot.mu.Lock()
+	defer bot.mu.Unlock()
+	// "same" once, two distinct messages up to the cap, then one after the
+	// minute that reports the three held back.
+	if len(bot.msgs) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(bot.msgs))
+	}
+	if last := bot.msgs[3].Text; !strings.Contains(last, "3 other alerts held back") {
+		t.Errorf("expected the held-back count in the next message, got %q", last)
+	}
+}
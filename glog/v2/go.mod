@@ -0,0 +1,9 @@
+module github.com/alexnobleburn/glogger/glog/v2
+
+go 1.21
+
+require github.com/alexnobleburn/glogger v0.0.0
+
+require github.com/pkg/errors v0.9.1 // indirect
+
+replace github.com/alexnobleburn/glogger => ../..
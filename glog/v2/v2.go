@@ -0,0 +1,262 @@
+// Package v2 is the next major API surface of glogger. It hides the
+// channel plumbing of the v1 glog package behind Service and Logger, and its
+// Publisher reports errors and supports Flush and Close.
+//
+// v2 is a module of its own, github.com/alexnobleburn/glogger/glog/v2,
+// versioned with glog/v2.x.y tags. It requires the root module and runs on
+// top of the v1 pipeline, so both can be used side by side while code
+// migrates: FromV1 and ToV1 adapt publishers in either direction and Wrap
+// puts a v2 Service in front of an existing glog.LoggerService.
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync"
+	"time"
+)
+
+// Publisher delivers records to a backend.
+type Publisher interface {
+	Publish(ctx context.Context, data *models.LogData) error
+	Flush(ctx context.Context) error
+	Close() error
+}
+
+// Logger is the v2 logging front end. It is the v1 glog.Logger, so loggers
+// can be passed between code on either version. It satisfies
+// interfaces.Logger.
+type Logger = glog.Logger
+
+type config struct {
+	serviceOpts  []glog.ServiceOption
+	errorHandler func(error)
+}
+
+// Option configures a Service.
+type Option func(*config)
+
+func WithBufferSize(size int) Option {
+	return func(c *config) {
+		c.serviceOpts = append(c.serviceOpts, glog.WithInputBufferSize(size), glog.WithJobBufferSize(size*10))
+	}
+}
+
+func WithWorkers(n int) Option {
+	return func(c *config) {
+		c.serviceOpts = append(c.serviceOpts, glog.WithNumWorkers(n))
+	}
+}
+
+func WithSendTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.serviceOpts = append(c.serviceOpts, glog.WithSendTimeout(d))
+	}
+}
+
+func WithBlocking() Option {
+	return func(c *config) {
+		c.serviceOpts = append(c.serviceOpts, glog.WithBlockingSend())
+	}
+}
+
+func WithDevelopment() Option {
+	return func(c *config) {
+		c.serviceOpts = append(c.serviceOpts, glog.WithDevelopment())
+	}
+}
+
+// WithErrorHandler receives pipeline errors and errors returned by publishers.
+func WithErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		if handler != nil {
+			c.errorHandler = handler
+		}
+	}
+}
+
+// Service owns the pipeline and the publishers registered with it.
+type Service struct {
+	svc          *glog.LoggerService
+	owned        bool
+	errorHandler func(error)
+	mu           sync.Mutex
+	publishers   map[string]Publisher
+	closeOnce    sync.Once
+	closeErr     error
+}
+
+// New creates and starts a Service.
+func New(opts ...Option) *Service {
+	c := &config{errorHandler: func(err error) { fmt.Println(err) }}
+	for _, opt := range opts {
+		opt(c)
+	}
+	svc := glog.NewLoggerService(append(c.serviceOpts, glog.WithErrorHandler(c.errorHandler))...)
+	s := &Service{svc: svc, owned: true, errorHandler: c.errorHandler, publishers: make(map[string]Publisher)}
+	svc.Start()
+	return s
+}
+
+// Wrap returns a Service backed by an existing, already started v1 service.
+// The caller keeps ownership of svc: Close never stops it. Close only
+// detaches, flushes and closes the publishers added through the returned
+// Service; publishers added directly to svc are left alone. Only
+// WithErrorHandler applies, the other options configure the v1 service.
+func Wrap(svc *glog.LoggerService, opts ...Option) *Service {
+	c := &config{errorHandler: func(err error) { fmt.Println(err) }}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return &Service{svc: svc, errorHandler: c.errorHandler, publishers: make(map[string]Publisher)}
+}
+
+// Add registers p under id, replacing any publisher with the same id.
+func (s *Service) Add(id string, p Publisher, opts ...glog.PublisherOption) {
+	s.mu.Lock()
+	s.publishers[id] = p
+	s.mu.Unlock()
+	s.svc.AddLogger(id, ToV1(id, p, s.errorHandler), opts...)
+}
+
+// Remove unregisters the publisher with the given id without closing it.
+func (s *Service) Remove(id string) {
+	s.mu.Lock()
+	delete(s.publishers, id)
+	s.mu.Unlock()
+	s.svc.RemoveLogger(id)
+}
+
+func (s *Service) Logger() *Logger {
+	return s.svc.NewLogger()
+}
+
+func (s *Service) Stats() glog.ServiceStats {
+	return s.svc.Stats()
+}
+
+// Flush flushes every registered publisher.
+func (s *Service) Flush(ctx context.Context) error {
+	var errs []error
+	for id, p := range s.snapshot() {
+		if err := p.Flush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("glogger: flush %q: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close drains the pipeline, then flushes and closes every registered
+// publisher. A Service created by New stops its pipeline; one created by Wrap
+// removes its publishers from the wrapped service and leaves it running.
+// It is safe to call more than once.
+func (s *Service) Close(ctx context.Context) error {
+	s.closeOnce.Do(func() {
+		var errs []error
+		if s.owned {
+			s.svc.Stop()
+		} else if err := s.detach(ctx); err != nil {
+			errs = append(errs, err)
+		}
+		if err := s.Flush(ctx); err != nil {
+			errs = append(errs, err)
+		}
+		for id, p := range s.snapshot() {
+			if err := p.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("glogger: close %q: %w", id, err))
+			}
+		}
+		s.closeErr = errors.Join(errs...)
+	})
+	return s.closeErr
+}
+
+// detach delivers the records queued so far, removes the publishers added
+// through s from the wrapped service and waits for the deliveries that were
+// already dispatched to them.
+func (s *Service) detach(ctx context.Context) error {
+	if err := s.svc.Flush(ctx); err != nil {
+		return err
+	}
+	for id := range s.snapshot() {
+		s.svc.RemoveLogger(id)
+	}
+	return s.svc.Flush(ctx)
+}
+
+func (s *Service) snapshot() map[string]Publisher {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]Publisher, len(s.publishers))
+	for id, p := range s.publishers {
+		out[id] = p
+	}
+	return out
+}
+
+// FromV1 adapts a v1 publisher. Publish never fails; Flush and Close call
+// the publisher's Flush(ctx) error, Sync() error or Close() error methods
+// when it has them.
+func FromV1(p interfaces.LogPublisher) Publisher {
+	return &v1Publisher{p: p}
+}
+
+type v1Publisher struct {
+	p interfaces.LogPublisher
+}
+
+func (a *v1Publisher) Publish(_ context.Context, data *models.LogData) error {
+	a.p.SendMsg(data)
+	return nil
+}
+
+func (a *v1Publisher) Flush(ctx context.Context) error {
+	switch f := a.p.(type) {
+	case interface{ Flush(context.Context) error }:
+		return f.Flush(ctx)
+	case interface{ Sync() error }:
+		return f.Sync()
+	}
+	return nil
+}
+
+func (a *v1Publisher) Close() error {
+	if c, ok := a.p.(interface{ Close() error }); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// ToV1 adapts a v2 publisher to the v1 pipeline. Errors returned by Publish
+// are passed to onError, tagged with id.
+func ToV1(id string, p Publisher, onError func(error)) interfaces.LogPublisher {
+	if v1, ok := p.(*v1Publisher); ok {
+		return v1.p
+	}
+	return &v2Publisher{id: id, p: p, onError: onError}
+}
+
+type v2Publisher struct {
+	id      string
+	p       Publisher
+	onError func(error)
+}
+
+func (a *v2Publisher) SendMsg(data *models.LogData) {
+	if err := a.TrySendMsg(data); err != nil && a.onError != nil {
+		a.onError(fmt.Errorf("glogger: publisher %q: %w", a.id, err))
+	}
+}
+
+// TrySendMsg lets the v1 service report Publish errors itself.
+func (a *v2Publisher) TrySendMsg(data *models.LogData) error {
+	ctx := data.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return a.p.Publish(ctx, data)
+}
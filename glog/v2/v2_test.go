@@ -0,0 +1,141 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"github.com/alexnobleburn/glogger/glog"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"sync"
+	"testing"
+)
+
+type recordingPublisher struct {
+	mu      sync.Mutex
+	msgs    []string
+	fail    error
+	flushed int
+	closed  int
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, data *models.LogData) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.msgs = append(p.msgs, data.Msg)
+	return p.fail
+}
+
+func (p *recordingPublisher) Flush(context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.flushed++
+	return nil
+}
+
+func (p *recordingPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed++
+	return nil
+}
+
+type v1Recorder struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (p *v1Recorder) SendMsg(data *models.LogData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.msgs = append(p.msgs, data.Msg)
+}
+
+func TestService_PublishFlushClose(t *testing.T) {
+	var mu sync.Mutex
+	var handled []error
+	s := New(WithBlocking(), WithErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, err)
+	}))
+	ok := &recordingPublisher{}
+	failing := &recordingPublisher{fail: errors.New("backend down")}
+	s.Add("ok", ok)
+	s.Add("failing", failing)
+
+	s.Logger().Info(context.Background(), "hello")
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("second close should be a no-op, got %v", err)
+	}
+
+	if len(ok.msgs) != 1 || ok.flushed != 1 || ok.closed != 1 {
+		t.Errorf("expected one publish, flush and close, got %+v", ok)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handled) != 1 || !errors.Is(handled[0], failing.fail) {
+		t.Errorf("expected publish error to reach the handler, got %v", handled)
+	}
+}
+
+func TestWrap_MixedPublishers(t *testing.T) {
+	v1svc := glog.NewLoggerService(glog.WithBlockingSend())
+	legacy := &v1Recorder{}
+	v1svc.AddLogger("legacy", legacy)
+	v1svc.Start()
+
+	s := Wrap(v1svc)
+	modern := &recordingPublisher{}
+	s.Add("modern", modern)
+	s.Add("adapted", FromV1(&v1Recorder{}))
+
+	v1svc.NewLogger().Info(context.Background(), "from v1 logger")
+	s.Logger().Info(context.Background(), "from v2 logger")
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+
+	if len(legacy.msgs) != 2 || len(modern.msgs) != 2 {
+		t.Errorf("expected both publishers to see both records, got %d and %d", len(legacy.msgs), len(modern.msgs))
+	}
+	if modern.closed != 1 {
+		t.Error("expected publisher added through v2 to be closed")
+	}
+}
+
+func TestWrap_CloseLeavesServiceRunning(t *testing.T) {
+	v1svc := glog.NewLoggerService(glog.WithBlockingSend())
+	legacy := &v1Recorder{}
+	v1svc.AddLogger("legacy", legacy)
+	v1svc.Start()
+	defer v1svc.Stop()
+
+	s := Wrap(v1svc)
+	modern := &recordingPublisher{}
+	s.Add("modern", modern)
+	s.Logger().Info(context.Background(), "before close")
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+
+	v1svc.NewLogger().Info(context.Background(), "after close")
+	if err := v1svc.Flush(context.Background()); err != nil {
+		t.Fatalf("expected the wrapped service to keep running, got %v", err)
+	}
+
+	legacy.mu.Lock()
+	defer legacy.mu.Unlock()
+	if len(legacy.msgs) != 2 {
+		t.Errorf("expected the caller's publisher to keep receiving records, got %v", legacy.msgs)
+	}
+	modern.mu.Lock()
+	defer modern.mu.Unlock()
+	if len(modern.msgs) != 1 || modern.closed != 1 {
+		t.Errorf("expected the v2 publisher to be detached and closed, got %+v", modern)
+	}
+	if got := v1svc.Stats().Publishers; got != 1 {
+		t.Errorf("expected only the caller's publisher to remain, got %d", got)
+	}
+}
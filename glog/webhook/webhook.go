@@ -0,0 +1,229 @@
+// Package webhook POSTs batches of records as a JSON array to an arbitrary
+// HTTP endpoint, covering in-house collectors that accept plain JSON without
+// a dedicated publisher.
+package webhook
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/internal/batch"
+	"github.com/alexnobleburn/glogger/glog/internal/report"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"io"
+	"net/http"
+	"time"
+)
+
+const defaultRetries = 3
+
+// Config configures a Publisher.
+type Config struct {
+	// URL is the endpoint that receives the batches.
+	URL string
+	// Method defaults to POST.
+	Method string
+	// Headers are set on every request, e.g. an API key header.
+	Headers map[string]string
+	// BearerToken sets "Authorization: Bearer <token>". Username and
+	// Password set basic auth instead.
+	BearerToken string
+	Username    string
+	Password    string
+	// Gzip compresses request bodies and sets Content-Encoding: gzip.
+	Gzip bool
+	// AppID and Env are used by the default encoder when the record context
+	// has no models.AppID or models.EnvName.
+	AppID string
+	Env   string
+	// Encoder turns a record into one array element and must produce JSON.
+	// Defaults to encoder.NewJSON(AppID, Env).
+	Encoder interfaces.Encoder
+	// BatchSize, FlushInterval and MaxBuffered control batching (defaults
+	// 100, 1s and 10000).
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxBuffered   int
+	// Retries for 408, 429 and 5xx responses and network errors (default
+	// 3), with exponential backoff starting at Backoff (default 100ms).
+	Retries int
+	Backoff time.Duration
+	// Client defaults to an http.Client with a 10s timeout.
+	Client *http.Client
+	// ErrorHandler receives encoding and delivery errors. Once the publisher
+	// is added to a service, the service error handler receives them too.
+	// Without either, they are printed.
+	ErrorHandler func(error)
+}
+
+// Compile-time checks that Publisher implements interfaces.ErrorPublisher,
+// interfaces.HealthChecker and interfaces.ErrorReporter.
+var (
+	_ interfaces.ErrorPublisher = (*Publisher)(nil)
+	_ interfaces.HealthChecker  = (*Publisher)(nil)
+	_ interfaces.ErrorReporter  = (*Publisher)(nil)
+)
+
+// Publisher sends each batch as one request whose body is a JSON array of
+// encoded records.
+type Publisher struct {
+	cfg     Config
+	errs    *report.Handler
+	batcher *batch.Batcher[json.RawMessage]
+}
+
+func New(cfg Config) *Publisher {
+	if cfg.Method == "" {
+		cfg.Method = http.MethodPost
+	}
+	if cfg.Encoder == nil {
+		cfg.Encoder = encoder.NewJSON(cfg.AppID, cfg.Env)
+	}
+	if cfg.Retries == 0 {
+		cfg.Retries = defaultRetries
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	errs := report.New(cfg.ErrorHandler)
+	cfg.ErrorHandler = errs.Handle
+	p := &Publisher{cfg: cfg, errs: errs}
+	p.batcher = batch.New(batch.Config{
+		Size:         cfg.BatchSize,
+		Interval:     cfg.FlushInterval,
+		MaxBuffered:  cfg.MaxBuffered,
+		Retries:      cfg.Retries,
+		Backoff:      cfg.Backoff,
+		ErrorHandler: cfg.ErrorHandler,
+	}, p.send)
+	return p
+}
+
+// ReportErrorsTo implements interfaces.ErrorReporter.
+func (p *Publisher) ReportErrorsTo(report func(error)) {
+	p.errs.ReportTo(report)
+}
+
+func (p *Publisher) SendMsg(data *models.LogData) {
+	if err := p.TrySendMsg(data); err != nil {
+		p.cfg.ErrorHandler(err)
+	}
+}
+
+// TrySendMsg buffers data like SendMsg and returns the errors that happen
+// before the record is buffered, such as encoding errors, instead of
+// passing them to the error handler. Delivery errors are reported later,
+// from the background flush.
+func (p *Publisher) TrySendMsg(data *models.LogData) error {
+	b, err := p.cfg.Encoder.Encode(data)
+	if err != nil {
+		return fmt.Errorf("webhook: encode: %w", err)
+	}
+	b = bytes.TrimSpace(b)
+	if !json.Valid(b) {
+		return fmt.Errorf("webhook: encoder produced invalid JSON: %.64q", b)
+	}
+	if err := p.batcher.Add(json.RawMessage(b)); err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	return nil
+}
+
+// Flush sends everything buffered so far.
+func (p *Publisher) Flush(ctx context.Context) error {
+	return p.batcher.Flush(ctx)
+}
+
+// Close flushes and stops the background goroutine.
+func (p *Publisher) Close() error {
+	return p.batcher.Close()
+}
+
+// Dropped returns how many records were discarded because the buffer was
+// full or the endpoint rejected them.
+func (p *Publisher) Dropped() int64 {
+	return p.batcher.Dropped()
+}
+
+// HealthCheck returns the error of the last failed delivery to the endpoint
+// until a batch goes through again, and an error once the publisher is
+// closed.
+func (p *Publisher) HealthCheck(ctx context.Context) error {
+	return p.batcher.Err()
+}
+
+func (p *Publisher) send(ctx context.Context, entries []json.RawMessage) error {
+	body, err := p.body(entries)
+	if err != nil {
+		return batch.Permanent(fmt.Errorf("webhook: build body: %w", err))
+	}
+	req, err := http.NewRequestWithContext(ctx, p.cfg.Method, p.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return batch.Permanent(fmt.Errorf("webhook: build request: %w", err))
+	}
+	for k, v := range p.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.cfg.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	switch {
+	case p.cfg.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+p.cfg.BearerToken)
+	case p.cfg.Username != "":
+		req.SetBasicAuth(p.cfg.Username, p.cfg.Password)
+	}
+
+	resp, err := p.cfg.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: send: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("webhook: send: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	if resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+	return batch.Permanent(err)
+}
+
+func (p *Publisher) body(entries []json.RawMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var zw *gzip.Writer
+	if p.cfg.Gzip {
+		zw = gzip.NewWriter(&buf)
+		w = zw
+	}
+	if _, err := w.Write([]byte{'['}); err != nil {
+		return nil, err
+	}
+	for i, e := range entries {
+		if i > 0 {
+			if _, err := w.Write([]byte{','}); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := w.Write(e); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := w.Write([]byte{']'}); err != nil {
+		return nil, err
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
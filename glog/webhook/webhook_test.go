@@ -0,0 +1,155 @@
+package webhook
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/encoder"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type collector struct {
+	mu       sync.Mutex
+	batches  [][]map[string]any
+	headers  []http.Header
+	statuses []int
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := http.StatusOK
+	if len(c.statuses) > 0 {
+		status, c.statuses = c.statuses[0], c.statuses[1:]
+	}
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+		return
+	}
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body = zr
+	}
+	var batch []map[string]any
+	if err := json.NewDecoder(body).Decode(&batch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.batches = append(c.batches, batch)
+	c.headers = append(c.headers, r.Header.Clone())
+	w.WriteHeader(status)
+}
+
+func TestPublisher_PostsGzippedBatches(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	pub := New(Config{
+		URL:         srv.URL,
+		Headers:     map[string]string{"X-Api-Key": "k1"},
+		BearerToken: "secret",
+		Gzip:        true,
+		AppID:       "shop",
+		Env:         "prod",
+		BatchSize:   2,
+	})
+	for _, msg := range []string{"one", "two", "three"} {
+		pub.SendMsg(&models.LogData{Ctx: context.Background(), Msg: msg, Level: models.InfoLevel})
+	}
+	if err := pub.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	total := 0
+	for _, b := range c.batches {
+		total += len(b)
+		for _, rec := range b {
+			if rec["service_name"] != "shop" {
+				t.Errorf("expected encoded records, got %v", rec)
+			}
+		}
+	}
+	if total != 3 || len(c.batches) < 2 {
+		t.Fatalf("expected 3 records in at least 2 batches, got %d in %d", total, len(c.batches))
+	}
+	h := c.headers[0]
+	if h.Get("Authorization") != "Bearer secret" || h.Get("X-Api-Key") != "k1" || h.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected headers %v", h)
+	}
+}
+
+func TestPublisher_RetriesAndDropsPermanentFailures(t *testing.T) {
+	c := &collector{statuses: []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusBadRequest}}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	pub := New(Config{URL: srv.URL, Username: "u", Password: "p", Backoff: time.Millisecond, ErrorHandler: func(error) {}})
+	defer pub.Close()
+
+	pub.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "retried", Level: models.WarnLevel})
+	if err := pub.Flush(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pub.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "rejected", Level: models.WarnLevel})
+	_ = pub.Flush(context.Background())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.batches) != 1 || c.batches[0][0]["msg"] != "retried" {
+		t.Errorf("expected the first batch delivered after a retry, got %v", c.batches)
+	}
+	if pub.Dropped() != 1 {
+		t.Errorf("expected the rejected batch dropped, got %d", pub.Dropped())
+	}
+}
+
+func TestPublisher_RejectsNonJSONEncoder(t *testing.T) {
+	var errs []error
+	pub := New(Config{URL: "http://127.0.0.1:0", Encoder: encoder.NewLogfmt("a", "b"), ErrorHandler: func(err error) { errs = append(errs, err) }})
+	pub.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "x", Level: models.InfoLevel})
+	if len(errs) != 1 {
+		t.Errorf("expected the invalid entry reported, got %v", errs)
+	}
+	_ = pub.Close()
+}
+
+func TestPublisher_HealthCheckFollowsDeliveries(t *testing.T) {
+	c := &collector{statuses: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	pub := New(Config{URL: srv.URL, Retries: 1, Backoff: time.Millisecond, ErrorHandler: func(error) {}})
+	ctx := context.Background()
+	if err := pub.HealthCheck(ctx); err != nil {
+		t.Fatalf("expected healthy before any delivery, got %v", err)
+	}
+	pub.SendMsg(&models.LogData{Ctx: ctx, Msg: "first"})
+	_ = pub.Flush(ctx)
+	if err := pub.HealthCheck(ctx); err == nil {
+		t.Error("expected unhealthy after a failed delivery")
+	}
+	if err := pub.Flush(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := pub.HealthCheck(ctx); err != nil {
+		t.Errorf("expected healthy after a successful delivery, got %v", err)
+	}
+	_ = pub.Close()
+	if err := pub.HealthCheck(ctx); err == nil {
+		t.Error("expected unhealthy once closed")
+	}
+}
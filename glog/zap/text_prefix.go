@@ -0,0 +1,87 @@
+package zap
+
+import (
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/sanitize"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"time"
+)
+
+const (
+	prefixLevelWidth     = 5
+	prefixComponentWidth = 9
+)
+
+var prefixPool = buffer.NewPool()
+
+// WithTextPrefix writes each record as a fixed-width text prefix followed by
+// the JSON fields on the same line, for grep-based tooling:
+//
+//	2024-05-01T10:00:00Z WARN  payments  card declined {"service_name":"shop","env":"prod","payload":{...}}
+//
+// The message is escaped so a record always stays on one line. The level
+// column uses the WithLevelEncoder vocabulary when it yields strings.
+func WithTextPrefix() Option {
+	return func(l *Logger) {
+		l.textPrefix = true
+	}
+}
+
+// prefixEncoder renders the timestamp, level, component and message as text
+// and delegates the remaining fields to a JSON encoder configured without
+// those keys.
+type prefixEncoder struct {
+	zapcore.Encoder
+	level models.LevelEncoder
+}
+
+func newPrefixEncoder(config zapcore.EncoderConfig, level models.LevelEncoder) zapcore.Encoder {
+	config.MessageKey = zapcore.OmitKey
+	config.LevelKey = zapcore.OmitKey
+	config.TimeKey = zapcore.OmitKey
+	if level == nil {
+		level = models.UppercaseLevelEncoder
+	}
+	return &prefixEncoder{Encoder: zapcore.NewJSONEncoder(config), level: level}
+}
+
+func (e *prefixEncoder) Clone() zapcore.Encoder {
+	return &prefixEncoder{Encoder: e.Encoder.Clone(), level: e.level}
+}
+
+func (e *prefixEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	js, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer js.Free()
+
+	component := "-"
+	for _, f := range fields {
+		if f.Key == models.FieldComponentKey && f.Type == zapcore.StringType && f.String != "" {
+			component = f.String
+		}
+	}
+
+	buf := prefixPool.Get()
+	buf.AppendString(ent.Time.UTC().Format(time.RFC3339))
+	buf.AppendByte(' ')
+	buf.AppendString(pad(fmt.Sprint(e.level(models.LogLevel(ent.Level))), prefixLevelWidth))
+	buf.AppendByte(' ')
+	buf.AppendString(pad(sanitize.String(component, sanitize.PolicyEscape), prefixComponentWidth))
+	buf.AppendByte(' ')
+	buf.AppendString(sanitize.String(ent.Message, sanitize.PolicyEscape))
+	buf.AppendByte(' ')
+	buf.AppendBytes(js.Bytes())
+	return buf, nil
+}
+
+func pad(s string, width int) string {
+	if len(s) >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-len(s))
+}
@@ -2,7 +2,10 @@ package zap
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/safejson"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"io"
@@ -19,29 +22,126 @@ type Logger struct {
 	zl    *zap.Logger
 	appID string
 	env   string
+	// onError receives encoding problems; by default they are written as a
+	// warning record through zl.
+	onError func(error)
+	// objectOpts configure safejson for object fields.
+	objectOpts   []safejson.Option
+	levelEncoder models.LevelEncoder
+	keys         models.OutputKeys
+	flat         bool
+	textPrefix   bool
 }
 
-func NewZapLogger(appID, env string) *Logger {
-	return newLogger(appID, env, os.Stdout)
+// Option configures a Logger.
+type Option func(*Logger)
+
+// WithErrorHandler receives encoding problems such as unserializable object
+// fields instead of the default internal warning record.
+func WithErrorHandler(handler func(error)) Option {
+	return func(l *Logger) {
+		if handler != nil {
+			l.onError = handler
+		}
+	}
 }
 
-// NewZapLoggerWithWriter creates a Logger that writes to the given writer (useful for tests).
-func NewZapLoggerWithWriter(appID, env string, w io.Writer) *Logger {
-	return newLogger(appID, env, zapcore.AddSync(w))
+// WithMaxObjectDepth limits how many levels of nested maps, slices and
+// structs are written for object fields (default safejson.DefaultMaxDepth).
+func WithMaxObjectDepth(depth int) Option {
+	return func(l *Logger) {
+		l.objectOpts = append(l.objectOpts, safejson.WithMaxDepth(depth))
+	}
 }
 
-func newLogger(appID, env string, ws zapcore.WriteSyncer) *Logger {
-	config := getEncoderConfig()
-	core := zapcore.NewCore(zapcore.NewJSONEncoder(config), ws, getAllLevelFunc())
-	zapLogger := zap.New(zapcore.NewTee(core))
+// WithLevelEncoder renders the level field in the backend's vocabulary, e.g.
+// models.StackdriverSeverityEncoder or models.SyslogSeverityEncoder.
+func WithLevelEncoder(enc models.LevelEncoder) Option {
+	return func(l *Logger) {
+		l.levelEncoder = enc
+	}
+}
 
-	return &Logger{
-		zl:    zapLogger,
+// WithOutputKeys renames the message, level and timestamp keys, e.g. to
+// "message" and "severity" for legacy consumers; use models.OmitKey to leave
+// one out.
+func WithOutputKeys(keys models.OutputKeys) Option {
+	return func(l *Logger) {
+		l.keys = keys.WithDefaults()
+	}
+}
+
+// WithFlatLayout writes fields at the top level instead of under "payload".
+// A field whose key collides with a top-level key is written as
+// "fields.<key>".
+func WithFlatLayout() Option {
+	return func(l *Logger) {
+		l.flat = true
+	}
+}
+
+func NewZapLogger(appID, env string, opts ...Option) *Logger {
+	return newLogger(appID, env, os.Stdout, opts)
+}
+
+// NewZapLoggerWithWriter creates a Logger that writes to the given writer (useful for tests).
+func NewZapLoggerWithWriter(appID, env string, w io.Writer, opts ...Option) *Logger {
+	return newLogger(appID, env, zapcore.AddSync(w), opts)
+}
+
+func newLogger(appID, env string, ws zapcore.WriteSyncer, opts []Option) *Logger {
+	l := &Logger{
 		appID: appID,
 		env:   env,
+		keys:  models.DefaultOutputKeys(),
+	}
+	l.onError = l.warnInternal
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	config := getEncoderConfig()
+	config.MessageKey = zapKey(l.keys.Message)
+	config.LevelKey = zapKey(l.keys.Level)
+	config.TimeKey = zapKey(l.keys.Timestamp)
+	if l.levelEncoder != nil {
+		config.EncodeLevel = zapLevelEncoder(l.levelEncoder)
+	}
+	enc := zapcore.NewJSONEncoder(config)
+	if l.textPrefix {
+		enc = newPrefixEncoder(config, l.levelEncoder)
+	}
+	core := zapcore.NewCore(enc, ws, getAllLevelFunc())
+	l.zl = zap.New(zapcore.NewTee(core))
+	return l
+}
+
+func zapKey(key string) string {
+	if key == models.OmitKey {
+		return zapcore.OmitKey
+	}
+	return key
+}
+
+// zapLevelEncoder relies on zapcore levels sharing the numbering of
+// models.LogLevel.
+func zapLevelEncoder(enc models.LevelEncoder) zapcore.LevelEncoder {
+	return func(level zapcore.Level, pae zapcore.PrimitiveArrayEncoder) {
+		switch v := enc(models.LogLevel(level)).(type) {
+		case int:
+			pae.AppendInt(v)
+		case string:
+			pae.AppendString(v)
+		default:
+			pae.AppendString(fmt.Sprint(v))
+		}
 	}
 }
 
+func (l *Logger) warnInternal(err error) {
+	l.zl.Warn("glogger: field encoding problem", zap.String("error", err.Error()))
+}
+
 func (l *Logger) SendMsg(logData *models.LogData) {
 	ctx := logData.Ctx
 	if ctx == nil {
@@ -64,46 +164,113 @@ func (l *Logger) SendMsg(logData *models.LogData) {
 	resFields := l.getPayloadFields(logData)
 	fields = append(fields, resFields...)
 
-	switch logData.Level {
-	case models.ErrorLevel:
-		l.zl.Error(logData.Msg, fields...)
-	case models.WarnLevel:
-		l.zl.Warn(logData.Msg, fields...)
-	case models.InfoLevel:
-		l.zl.Info(logData.Msg, fields...)
-	case models.DebugLevel:
-		l.zl.Debug(logData.Msg, fields...)
-	case models.DPanicLevel:
-		l.zl.DPanic(logData.Msg, fields...)
-	case models.PanicLevel:
-		l.zl.Panic(logData.Msg, fields...)
-	case models.FatalLevel:
-		l.zl.Fatal(logData.Msg, fields...)
-	default:
-		l.zl.Info(logData.Msg, fields...)
+	level := zapcore.InfoLevel
+	if logData.Level >= models.DebugLevel && logData.Level <= models.FatalLevel {
+		level = zapcore.Level(logData.Level)
+	}
+	// Check instead of l.zl.Info etc. so that an explicit event time can
+	// replace the entry time; Panic and Fatal still panic and exit on Write.
+	if ce := l.zl.Check(level, logData.Msg); ce != nil {
+		if !logData.Time.IsZero() {
+			ce.Time = logData.Time
+		}
+		ce.Write(fields...)
 	}
 }
 
 func (l *Logger) getPayloadFields(logData *models.LogData) []zap.Field {
 	var resFields []zap.Field
-	resFields = append(resFields, zap.Namespace("payload"))
+	if !l.flat {
+		resFields = append(resFields, zap.Namespace("payload"))
+	}
 	for _, f := range logData.Fields {
-		switch f.Type {
-		case models.FieldTypeInt:
-			resFields = append(resFields, zap.Int(f.Key, f.Integer))
-		case models.FieldTypeString:
-			resFields = append(resFields, zap.String(f.Key, f.String))
-		case models.FieldTypeFloat:
-			resFields = append(resFields, zap.Float64(f.Key, f.Float))
-		case models.FieldTypeObject:
-			resFields = append(resFields, zap.Any(f.Key, f.Object))
-		case models.FieldTypeBool:
-			resFields = append(resFields, zap.Bool(f.Key, f.Bool))
+		if f == nil {
+			continue
+		}
+		key := f.Key
+		if l.flat {
+			key = l.keys.FlatFieldKey(key)
 		}
+		resFields = append(resFields, l.field(key, f))
 	}
 	return resFields
 }
 
+// field converts one record field; unknown types are skipped.
+func (l *Logger) field(key string, f *models.LogField) zap.Field {
+	switch f.Type {
+	case models.FieldTypeInt:
+		return zap.Int(key, f.Integer)
+	case models.FieldTypeString:
+		return zap.String(key, f.String)
+	case models.FieldTypeFloat:
+		return zap.Float64(key, f.Float)
+	case models.FieldTypeArray:
+		return l.arrayField(key, f.Object)
+	case models.FieldTypeObject:
+		return l.objectField(key, f.Object)
+	case models.FieldTypeBool:
+		return zap.Bool(key, f.Bool)
+	case models.FieldTypeInt64:
+		return zap.Int64(key, f.Int64)
+	case models.FieldTypeUint64:
+		return zap.Uint64(key, f.Uint64)
+	case models.FieldTypeBinary:
+		return zap.Binary(key, f.Binary)
+	case models.FieldTypeDuration:
+		return zap.Duration(key, f.Duration)
+	case models.FieldTypeTime:
+		return zap.Time(key, f.Time)
+	}
+	return zap.Skip()
+}
+
+// arrayField uses zap's typed array fields for the slices the options
+// produce and objectField for anything else.
+func (l *Logger) arrayField(key string, value any) zap.Field {
+	switch v := value.(type) {
+	case []string:
+		return zap.Strings(key, v)
+	case []int:
+		return zap.Ints(key, v)
+	}
+	return l.objectField(key, value)
+}
+
+// objectField keeps zap's native handling for values that know how to encode
+// themselves and goes through safejson for everything else, so that an
+// unserializable value becomes a placeholder instead of an encoder error.
+func (l *Logger) objectField(key string, value any) zap.Field {
+	if ns, ok := value.(models.Namespace); ok {
+		return zap.Object(key, namespace{l: l, fields: ns})
+	}
+	switch value.(type) {
+	case nil, error, zapcore.ObjectMarshaler, zapcore.ArrayMarshaler:
+		return zap.Any(key, value)
+	}
+	b, err := safejson.Marshal(value, l.objectOpts...)
+	if err != nil {
+		l.onError(fmt.Errorf("field %q: %w", key, err))
+	}
+	return zap.Reflect(key, json.RawMessage(b))
+}
+
+// namespace writes a models.Namespace with the same zap types as top-level
+// fields.
+type namespace struct {
+	l      *Logger
+	fields models.Namespace
+}
+
+func (n namespace) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range n.fields {
+		if f != nil {
+			n.l.field(f.Key, f).AddTo(enc)
+		}
+	}
+	return nil
+}
+
 func getEncoderConfig() zapcore.EncoderConfig {
 	config := zap.NewProductionEncoderConfig()
 	config.TimeKey = timeTag
@@ -114,3 +281,17 @@ func getEncoderConfig() zapcore.EncoderConfig {
 func getAllLevelFunc() zap.LevelEnablerFunc {
 	return func(l zapcore.Level) bool { return true }
 }
+
+// Schema describes the records this Logger writes. With WithTextPrefix the
+// schema covers the JSON after the text prefix.
+func (l *Logger) Schema() *models.Schema {
+	keys := l.keys
+	if l.textPrefix {
+		keys = models.OutputKeys{Message: models.OmitKey, Level: models.OmitKey, Timestamp: models.OmitKey}
+	}
+	s := models.RecordSchema("glogger zap publisher", keys, l.flat, l.levelEncoder)
+	if l.textPrefix {
+		s.Profile = models.ProfileTextPrefix
+	}
+	return s
+}
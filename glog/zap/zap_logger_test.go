@@ -1,10 +1,17 @@
 package zap
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/glogtest"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
 	"github.com/alexnobleburn/glogger/glog/models"
 	"io"
+	"math"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewZapLogger(t *testing.T) {
@@ -238,3 +245,293 @@ func BenchmarkZapLogger_SendMsg_WithFields(b *testing.B) {
 		logger.SendMsg(logData)
 	}
 }
+
+func TestZapLogger_SendMsg_NewlineInjection(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewZapLoggerWithWriter("test-app", "test", &buf)
+
+	forged := "\n{\"level\":\"error\",\"msg\":\"forged\"}\n"
+	logData := &models.LogData{
+		Ctx:   context.Background(),
+		Msg:   "login failed" + forged,
+		Level: models.InfoLevel,
+		Fields: []*models.LogField{
+			{Key: "user" + forged, Type: models.FieldTypeString, String: "bob" + forged},
+			{Key: "object", Type: models.FieldTypeObject, Object: map[string]string{"k": forged}},
+		},
+	}
+
+	logger.SendMsg(logData)
+
+	out := buf.String()
+	if strings.Count(out, "\n") != 1 || !strings.HasSuffix(out, "\n") {
+		t.Fatalf("expected exactly one terminated line, got %q", out)
+	}
+}
+
+func TestZapLogger_SendMsg_DurationAndTime(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewZapLoggerWithWriter("test-app", "test", &buf)
+
+	logger.SendMsg(&models.LogData{
+		Ctx:   context.Background(),
+		Msg:   "request done",
+		Level: models.InfoLevel,
+		Fields: []*models.LogField{
+			{Key: "took", Type: models.FieldTypeDuration, Duration: 1500 * time.Millisecond},
+			{Key: "started", Type: models.FieldTypeTime, Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		},
+	})
+
+	out := buf.String()
+	if !strings.Contains(out, `"took":1.5`) || !strings.Contains(out, `"started":"2024-05-01T12:00:00Z"`) {
+		t.Errorf("expected zap's duration and time encoding, got %s", out)
+	}
+}
+
+func TestZapLogger_SendMsg_Int64AndBinary(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewZapLoggerWithWriter("test-app", "test", &buf)
+
+	logger.SendMsg(&models.LogData{
+		Ctx:   context.Background(),
+		Msg:   "upload",
+		Level: models.InfoLevel,
+		Fields: []*models.LogField{
+			{Key: "id", Type: models.FieldTypeInt64, Int64: math.MinInt64},
+			{Key: "size", Type: models.FieldTypeUint64, Uint64: math.MaxUint64},
+			{Key: "digest", Type: models.FieldTypeBinary, Binary: []byte("hi")},
+		},
+	})
+
+	out := buf.String()
+	for _, want := range []string{`"id":-9223372036854775808`, `"size":18446744073709551615`, `"digest":"aGk="`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in %s", want, out)
+		}
+	}
+}
+
+func TestZapLogger_SendMsg_Arrays(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewZapLoggerWithWriter("test-app", "test", &buf)
+
+	logger.SendMsg(&models.LogData{
+		Ctx:   context.Background(),
+		Msg:   "tagged",
+		Level: models.InfoLevel,
+		Fields: []*models.LogField{
+			{Key: "tags", Type: models.FieldTypeArray, Object: []string{"eu", "card"}},
+			{Key: "ids", Type: models.FieldTypeArray, Object: []int{1, 2}},
+		},
+	})
+
+	out := buf.String()
+	for _, want := range []string{`"tags":["eu","card"]`, `"ids":[1,2]`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in %s", want, out)
+		}
+	}
+}
+
+func TestZapLogger_SendMsg_Namespace(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewZapLoggerWithWriter("test-app", "test", &buf)
+
+	opts := &models.Options{}
+	models.WithNamespace("http",
+		models.WithStringField("method", "GET"),
+		models.WithNamespace("response", models.WithDurationField("took", 1500*time.Millisecond)),
+	)(opts)
+	logger.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "request", Level: models.InfoLevel, Fields: opts.GetFields()})
+
+	if want := `"http":{"method":"GET","response":{"took":1.5}}`; !strings.Contains(buf.String(), want) {
+		t.Errorf("expected %s with zap's field types, got %s", want, buf.String())
+	}
+}
+
+func TestZapLogger_Conformance(t *testing.T) {
+	glogtest.RunPublisherConformance(t, func(t testing.TB) interfaces.LogPublisher {
+		return NewZapLoggerWithWriter("test-app", "test", io.Discard)
+	})
+}
+
+func TestZapLogger_UnserializableObject(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewZapLoggerWithWriter("test-app", "test", &buf)
+
+	logger.SendMsg(&models.LogData{
+		Ctx:   context.Background(),
+		Msg:   "with channel",
+		Level: models.InfoLevel,
+		Fields: []*models.LogField{
+			{Key: "ch", Type: models.FieldTypeObject, Object: make(chan int)},
+			{Key: "ok", Type: models.FieldTypeObject, Object: map[string]int{"n": 1}},
+		},
+	})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected warning and record lines, got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], `"level":"warn"`) || !strings.Contains(lines[0], `field \"ch\"`) {
+		t.Errorf("expected internal warning naming the field, got %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"ch":"<unserializable: chan int>"`) || !strings.Contains(lines[1], `"ok":{"n":1}`) {
+		t.Errorf("expected placeholder and encoded object, got %s", lines[1])
+	}
+}
+
+func TestZapLogger_WithErrorHandler(t *testing.T) {
+	var got error
+	logger := NewZapLoggerWithWriter("test-app", "test", io.Discard, WithErrorHandler(func(err error) { got = err }))
+
+	logger.SendMsg(&models.LogData{
+		Ctx:    context.Background(),
+		Msg:    "with func",
+		Level:  models.InfoLevel,
+		Fields: []*models.LogField{{Key: "fn", Type: models.FieldTypeObject, Object: func() {}}},
+	})
+
+	if got == nil {
+		t.Error("expected encoding problem to reach the error handler")
+	}
+}
+
+type entity struct {
+	ID     int       `json:"id"`
+	Owner  *entity   `json:"owner,omitempty"`
+	Assets []*entity `json:"assets,omitempty"`
+}
+
+func TestZapLogger_CyclicObject(t *testing.T) {
+	var buf bytes.Buffer
+	var problems []error
+	logger := NewZapLoggerWithWriter("test-app", "test", &buf,
+		WithErrorHandler(func(err error) { problems = append(problems, err) }))
+
+	user := &entity{ID: 1}
+	user.Assets = []*entity{{ID: 2, Owner: user}}
+	logger.SendMsg(&models.LogData{
+		Ctx:    context.Background(),
+		Msg:    "orm entity",
+		Level:  models.InfoLevel,
+		Fields: []*models.LogField{{Key: "user", Type: models.FieldTypeObject, Object: user}},
+	})
+
+	if !strings.Contains(buf.String(), `"user":{"id":1,"assets":[{"id":2,"owner":"<cycle: *zap.entity>"}]}`) {
+		t.Errorf("expected cycle placeholder, got %s", buf.String())
+	}
+	if len(problems) != 1 {
+		t.Errorf("expected one reported problem, got %v", problems)
+	}
+}
+
+func TestZapLogger_WithLevelEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewZapLoggerWithWriter("test-app", "test", &buf, WithLevelEncoder(models.StackdriverSeverityEncoder))
+	logger.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "m", Level: models.WarnLevel})
+	if !strings.Contains(buf.String(), `"level":"WARNING"`) {
+		t.Errorf("expected Stackdriver severity, got %s", buf.String())
+	}
+
+	buf.Reset()
+	logger = NewZapLoggerWithWriter("test-app", "test", &buf, WithLevelEncoder(models.SyslogSeverityEncoder))
+	logger.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "m", Level: models.ErrorLevel})
+	if !strings.Contains(buf.String(), `"level":3`) {
+		t.Errorf("expected numeric syslog severity, got %s", buf.String())
+	}
+
+	buf.Reset()
+	names := models.LevelNames(map[models.LogLevel]string{models.WarnLevel: "WARNING"}, models.UppercaseLevelEncoder)
+	logger = NewZapLoggerWithWriter("test-app", "test", &buf, WithLevelEncoder(names))
+	logger.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "m", Level: models.InfoLevel})
+	if !strings.Contains(buf.String(), `"level":"INFO"`) {
+		t.Errorf("expected fallback encoder, got %s", buf.String())
+	}
+}
+
+func TestZapLogger_OutputKeysAndFlatLayout(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewZapLoggerWithWriter("test-app", "test", &buf,
+		WithOutputKeys(models.OutputKeys{Message: "message", Level: "severity", Timestamp: models.OmitKey}),
+		WithFlatLayout())
+
+	logger.SendMsg(&models.LogData{
+		Ctx:   context.Background(),
+		Msg:   "legacy",
+		Level: models.InfoLevel,
+		Fields: []*models.LogField{
+			{Key: "order_id", Type: models.FieldTypeString, String: "o-1"},
+			{Key: "message", Type: models.FieldTypeString, String: "collides"},
+		},
+	})
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buf.String(), err)
+	}
+	if got["message"] != "legacy" || got["severity"] != "info" {
+		t.Errorf("expected renamed keys, got %v", got)
+	}
+	if _, ok := got["timestamp"]; ok {
+		t.Error("expected timestamp to be omitted")
+	}
+	if got["order_id"] != "o-1" || got["fields.message"] != "collides" {
+		t.Errorf("expected flat fields with collision prefix, got %v", got)
+	}
+	if _, ok := got["payload"]; ok {
+		t.Error("flat layout must not write a payload object")
+	}
+}
+
+func TestZapLogger_WithTextPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewZapLoggerWithWriter("shop", "prod", &buf, WithTextPrefix())
+
+	logger.SendMsg(&models.LogData{
+		Ctx:   context.Background(),
+		Msg:   "card declined\nforged line",
+		Level: models.WarnLevel,
+		Fields: []*models.LogField{
+			{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: "payments"},
+			{Key: "amount", Type: models.FieldTypeInt, Integer: 42},
+		},
+	})
+
+	line := buf.String()
+	if strings.Count(line, "\n") != 1 {
+		t.Fatalf("expected a single line, got %q", line)
+	}
+	parts := strings.SplitN(line, " ", 2)
+	if _, err := time.Parse(time.RFC3339, parts[0]); err != nil {
+		t.Errorf("expected RFC3339 timestamp prefix, got %q", parts[0])
+	}
+	if !strings.HasPrefix(parts[1], `WARN  payments  card declined\nforged line {`) {
+		t.Errorf("unexpected prefix: %q", parts[1])
+	}
+	js := line[strings.Index(line, "{"):]
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(js), &fields); err != nil {
+		t.Fatalf("expected JSON fields after the prefix, got %q: %v", js, err)
+	}
+	if _, ok := fields["msg"]; ok {
+		t.Error("message must only appear in the prefix")
+	}
+}
+
+func TestZapLogger_SendMsg_ExplicitTimestamp(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewZapLoggerWithWriter("test-app", "test", &buf)
+	eventTime := time.Date(2023, 3, 14, 15, 9, 26, 0, time.UTC)
+
+	logger.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "backfilled", Level: models.WarnLevel, Time: eventTime})
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buf.String(), err)
+	}
+	if got["timestamp"] != "2023-03-14T15:09:26Z" || got["level"] != "warn" {
+		t.Errorf("expected the event time and level to be kept, got %v", got)
+	}
+}
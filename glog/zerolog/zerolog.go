@@ -0,0 +1,204 @@
+// Package zerolog writes records in zerolog's JSON format, so that outputs
+// built for zerolog (zerolog.ConsoleWriter, zerolog.MultiLevelWriter,
+// diode writers, log shippers parsing zerolog lines) can sit behind the
+// glogger pipeline unchanged:
+//
+//	{"level":"info","service_name":"my-app","env":"prod","user":"bob","time":"2024-05-01T12:00:00Z","message":"login"}
+//
+// Fields are written at the top level, as zerolog does. The package writes
+// the format itself rather than depending on zerolog, so any io.Writer
+// works as the output.
+package zerolog
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"github.com/alexnobleburn/glogger/glog/safejson"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// keys are zerolog's default field names. Fields with these names (or
+// service_name and env) are written as "fields.<key>".
+var keys = models.OutputKeys{Message: "message", Level: "level", Timestamp: "time"}
+
+type Logger struct {
+	mu sync.Mutex
+	w  io.Writer
+
+	appID      string
+	env        string
+	timeFormat string
+	onError    func(error)
+	objectOpts []safejson.Option
+	exit       func(int)
+}
+
+// Option configures a Logger.
+type Option func(*Logger)
+
+// WithErrorHandler receives encoding problems such as unserializable object
+// fields and write errors. By default they are written to stderr.
+func WithErrorHandler(handler func(error)) Option {
+	return func(l *Logger) {
+		if handler != nil {
+			l.onError = handler
+		}
+	}
+}
+
+// WithMaxObjectDepth limits how many levels of nested maps, slices and
+// structs are written for object fields (default safejson.DefaultMaxDepth).
+func WithMaxObjectDepth(depth int) Option {
+	return func(l *Logger) {
+		l.objectOpts = append(l.objectOpts, safejson.WithMaxDepth(depth))
+	}
+}
+
+// WithTimeFormat sets the layout of the time field, like
+// zerolog.TimeFieldFormat. Default time.RFC3339.
+func WithTimeFormat(layout string) Option {
+	return func(l *Logger) {
+		if layout != "" {
+			l.timeFormat = layout
+		}
+	}
+}
+
+func NewZerologLogger(appID, env string, opts ...Option) *Logger {
+	return NewZerologLoggerWithWriter(appID, env, os.Stdout, opts...)
+}
+
+// NewZerologLoggerWithWriter creates a Logger that writes to w, e.g.
+// zerolog.ConsoleWriter{Out: os.Stdout}. Each record is one Write call.
+func NewZerologLoggerWithWriter(appID, env string, w io.Writer, opts ...Option) *Logger {
+	l := &Logger{
+		w:          w,
+		appID:      appID,
+		env:        env,
+		timeFormat: time.RFC3339,
+		onError:    func(err error) { fmt.Fprintln(os.Stderr, "glogger/zerolog:", err) },
+		exit:       os.Exit,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// SendMsg writes one line. Like zerolog's Panic and Fatal, a PanicLevel
+// record panics and a FatalLevel record exits after it is written.
+func (l *Logger) SendMsg(logData *models.LogData) {
+	ctx := logData.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	appID, ok := ctx.Value(models.AppID).(string)
+	if !ok || appID == "" {
+		appID = l.appID
+	}
+	env, ok := ctx.Value(models.EnvName).(string)
+	if !ok || env == "" {
+		env = l.env
+	}
+
+	level := logData.Level
+	if level < models.DebugLevel || level > models.FatalLevel {
+		level = models.InfoLevel
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`{"level":`)
+	appendString(&buf, levelName(level))
+	buf.WriteString(`,"service_name":`)
+	appendString(&buf, appID)
+	buf.WriteString(`,"env":`)
+	appendString(&buf, env)
+	for _, f := range logData.Fields {
+		if f == nil {
+			continue
+		}
+		buf.WriteByte(',')
+		appendString(&buf, keys.FlatFieldKey(f.Key))
+		buf.WriteByte(':')
+		l.appendValue(&buf, f)
+	}
+	buf.WriteString(`,"time":`)
+	appendString(&buf, logData.TimeOr(time.Now()).Format(l.timeFormat))
+	buf.WriteString(`,"message":`)
+	appendString(&buf, logData.Msg)
+	buf.WriteString("}\n")
+
+	l.mu.Lock()
+	_, err := l.w.Write(buf.Bytes())
+	l.mu.Unlock()
+	if err != nil {
+		l.onError(fmt.Errorf("write: %w", err))
+	}
+
+	switch level {
+	case models.PanicLevel:
+		panic(logData.Msg)
+	case models.FatalLevel:
+		l.exit(1)
+	}
+}
+
+// levelName maps glogger levels to zerolog's names. zerolog has no DPanic,
+// so it is written as "error".
+func levelName(level models.LogLevel) string {
+	if level == models.DPanicLevel {
+		return "error"
+	}
+	return level.String()
+}
+
+func (l *Logger) appendValue(buf *bytes.Buffer, f *models.LogField) {
+	switch f.Type {
+	case models.FieldTypeString:
+		appendString(buf, f.String)
+	case models.FieldTypeInt:
+		buf.WriteString(strconv.Itoa(f.Integer))
+	case models.FieldTypeFloat:
+		// zerolog writes non-finite floats as strings.
+		if math.IsNaN(f.Float) || math.IsInf(f.Float, 0) {
+			appendString(buf, strconv.FormatFloat(f.Float, 'f', -1, 64))
+			return
+		}
+		buf.WriteString(strconv.FormatFloat(f.Float, 'f', -1, 64))
+	case models.FieldTypeBool:
+		buf.WriteString(strconv.FormatBool(f.Bool))
+	case models.FieldTypeInt64:
+		buf.WriteString(strconv.FormatInt(f.Int64, 10))
+	case models.FieldTypeUint64:
+		buf.WriteString(strconv.FormatUint(f.Uint64, 10))
+	case models.FieldTypeBinary:
+		// zerolog's Bytes writes the bytes as a string; base64 keeps the line
+		// valid JSON for any content.
+		appendString(buf, base64.StdEncoding.EncodeToString(f.Binary))
+	case models.FieldTypeDuration:
+		// zerolog's Dur writes milliseconds as a number by default.
+		buf.WriteString(strconv.FormatFloat(float64(f.Duration)/float64(time.Millisecond), 'f', -1, 64))
+	case models.FieldTypeTime:
+		appendString(buf, f.Time.Format(l.timeFormat))
+	default:
+		b, err := safejson.Marshal(f.Object, l.objectOpts...)
+		if err != nil {
+			l.onError(fmt.Errorf("field %q: %w", f.Key, err))
+		}
+		buf.Write(b)
+	}
+}
+
+func appendString(buf *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s)
+	buf.Write(b)
+}
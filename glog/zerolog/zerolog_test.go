@@ -0,0 +1,105 @@
+package zerolog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/alexnobleburn/glogger/glog/glogtest"
+	"github.com/alexnobleburn/glogger/glog/interfaces"
+	"github.com/alexnobleburn/glogger/glog/models"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogger_ZerologFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewZerologLoggerWithWriter("test-app", "test", &buf)
+
+	ctx := context.WithValue(context.Background(), models.EnvName, "prod")
+	logger.SendMsg(&models.LogData{
+		Ctx:   ctx,
+		Msg:   "payment failed",
+		Level: models.ErrorLevel,
+		Time:  time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Fields: []*models.LogField{
+			{Key: models.FieldComponentKey, Type: models.FieldTypeString, String: "billing"},
+			{Key: "amount", Type: models.FieldTypeFloat, Float: 9.5},
+			{Key: "retries", Type: models.FieldTypeInt, Integer: 2},
+			{Key: "ratio", Type: models.FieldTypeFloat, Float: math.Inf(1)},
+			{Key: "order", Type: models.FieldTypeObject, Object: map[string]int{"id": 7}},
+			{Key: "message", Type: models.FieldTypeString, String: "collides"},
+		},
+	})
+
+	line := buf.String()
+	if !strings.HasPrefix(line, `{"level":"error","service_name":"test-app","env":"prod",`) {
+		t.Errorf("expected zerolog key order, got %s", line)
+	}
+	if !strings.HasSuffix(line, `"time":"2024-05-01T12:00:00Z","message":"payment failed"}`+"\n") {
+		t.Errorf("expected time and message last, got %s", line)
+	}
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(line), &rec); err != nil {
+		t.Fatalf("invalid JSON %q: %v", line, err)
+	}
+	want := map[string]any{
+		"component":      "billing",
+		"amount":         9.5,
+		"retries":        float64(2),
+		"ratio":          "+Inf",
+		"fields.message": "collides",
+	}
+	for k, v := range want {
+		if rec[k] != v {
+			t.Errorf("%s = %#v, want %#v", k, rec[k], v)
+		}
+	}
+	if order, _ := rec["order"].(map[string]any); order["id"] != float64(7) {
+		t.Errorf("expected the object field as JSON, got %v", rec["order"])
+	}
+}
+
+func TestLogger_Levels(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewZerologLoggerWithWriter("app", "env", &buf)
+	for _, level := range []models.LogLevel{models.DebugLevel, models.WarnLevel, models.DPanicLevel, models.LogLevel(42)} {
+		logger.SendMsg(&models.LogData{Ctx: context.Background(), Level: level})
+	}
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec struct{ Level string }
+		_ = json.Unmarshal([]byte(line), &rec)
+		got = append(got, rec.Level)
+	}
+	if strings.Join(got, ",") != "debug,warn,error,info" {
+		t.Errorf("expected debug,warn,error,info, got %v", got)
+	}
+}
+
+func TestLogger_PanicAndFatal(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewZerologLoggerWithWriter("app", "env", &buf)
+	code := -1
+	logger.exit = func(c int) { code = c }
+
+	logger.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "fatal", Level: models.FatalLevel})
+	if code != 1 || !strings.Contains(buf.String(), `"level":"fatal"`) {
+		t.Errorf("expected the record written and exit(1), got code %d and %q", code, buf.String())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected PanicLevel to panic")
+		}
+	}()
+	logger.SendMsg(&models.LogData{Ctx: context.Background(), Msg: "panic", Level: models.PanicLevel})
+}
+
+func TestLogger_Conformance(t *testing.T) {
+	glogtest.RunPublisherConformance(t, func(t testing.TB) interfaces.LogPublisher {
+		return NewZerologLoggerWithWriter("test-app", "test", io.Discard)
+	})
+}